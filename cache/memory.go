@@ -0,0 +1,119 @@
+// Package cache provides the storage the report responses are cached in.
+// Any fiber.Storage can be used instead, like the ones in github.com/gofiber/storage, as long as it isn't shared with anything else since invalidating the reports resets it.
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultMaxEntries number of entries the in-memory storage keeps when no limit is given
+const DefaultMaxEntries = 1000
+
+var _ fiber.Storage = (*Memory)(nil)
+
+type entry struct {
+	value   []byte
+	expires time.Time
+}
+
+// Memory an in-memory fiber.Storage for a single instance.
+// Expired entries are dropped when they are read or when room is needed, once it's full the entry closest to expiring makes room for the new one.
+type Memory struct {
+	mu         sync.Mutex
+	entries    map[string]entry
+	maxEntries int
+}
+
+// NewMemory returns an empty in-memory storage holding up to maxEntries entries, DefaultMaxEntries when it isn't positive
+func NewMemory(maxEntries int) *Memory {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Memory{
+		entries:    make(map[string]entry),
+		maxEntries: maxEntries,
+	}
+}
+
+// Get implements fiber.Storage
+func (m *Memory) Get(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if e.expired(time.Now()) {
+		delete(m.entries, key)
+		return nil, nil
+	}
+	return e.value, nil
+}
+
+// Set implements fiber.Storage
+func (m *Memory) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= m.maxEntries {
+		m.evict(now)
+	}
+	e := entry{value: val}
+	if exp > 0 {
+		e.expires = now.Add(exp)
+	}
+	m.entries[key] = e
+	return nil
+}
+
+// Delete implements fiber.Storage
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// Reset implements fiber.Storage
+func (m *Memory) Reset() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[string]entry)
+	return nil
+}
+
+// Close implements fiber.Storage, the entries are kept
+func (m *Memory) Close() error {
+	return nil
+}
+
+// evict drops the expired entries, or the one closest to expiring if none has expired
+func (m *Memory) evict(now time.Time) {
+	var (
+		oldest  string
+		expires time.Time
+	)
+	for k, v := range m.entries {
+		if v.expired(now) {
+			delete(m.entries, k)
+			continue
+		}
+		// entries without expiration are only evicted when every entry is like them
+		if oldest == "" || (!v.expires.IsZero() && (expires.IsZero() || v.expires.Before(expires))) {
+			oldest, expires = k, v.expires
+		}
+	}
+	if len(m.entries) >= m.maxEntries {
+		delete(m.entries, oldest)
+	}
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
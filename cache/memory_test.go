@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory(0)
+	v, err := m.Get("missing")
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	require.NoError(t, m.Set("a", []byte("1"), 0))
+	require.NoError(t, m.Set("b", []byte("2"), time.Millisecond))
+	// empty keys and values are ignored
+	require.NoError(t, m.Set("", []byte("3"), 0))
+	require.NoError(t, m.Set("c", nil, 0))
+	v, err = m.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), v)
+
+	time.Sleep(2 * time.Millisecond)
+	v, err = m.Get("b")
+	require.NoError(t, err)
+	assert.Nil(t, v, "expired")
+
+	require.NoError(t, m.Delete("a"))
+	v, err = m.Get("a")
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	require.NoError(t, m.Set("a", []byte("1"), 0))
+	require.NoError(t, m.Reset())
+	v, err = m.Get("a")
+	require.NoError(t, err)
+	assert.Nil(t, v)
+	require.NoError(t, m.Close())
+}
+
+func TestMemoryEviction(t *testing.T) {
+	m := NewMemory(2)
+	require.NoError(t, m.Set("forever", []byte("1"), 0))
+	require.NoError(t, m.Set("soon", []byte("2"), time.Minute))
+	require.NoError(t, m.Set("later", []byte("3"), time.Hour))
+
+	// the entry closest to expiring made room
+	v, _ := m.Get("soon")
+	assert.Nil(t, v)
+	v, _ = m.Get("forever")
+	assert.Equal(t, []byte("1"), v)
+	v, _ = m.Get("later")
+	assert.Equal(t, []byte("3"), v)
+
+	// overwriting a key doesn't evict anything
+	require.NoError(t, m.Set("later", []byte("4"), time.Hour))
+	v, _ = m.Get("forever")
+	assert.NotNil(t, v)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"zssn/domains/core"
+	iusr "zssn/domains/users/store"
+
+	"gorm.io/gorm"
+)
+
+const adminsUsage = `usage: zssn admins <command> <email>
+
+commands:
+  grant       give the survivor admin rights
+  revoke      take the admin rights away again
+  token       print an admin token for the survivor, they have to be an admin`
+
+// runAdmins handles the admins subcommand. Admin rights and tokens are only handed out here,
+// anyone who can reach the API can ask for a token with just an email.
+func runAdmins(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("admins", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Println(adminsUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("expected a command and an email")
+	}
+
+	storage, err := iusr.New(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	usr, err := storage.FindByEmail(ctx, fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("finding %s: %w", fs.Arg(1), err)
+	}
+
+	switch fs.Arg(0) {
+	case "grant":
+		if err := storage.UpdateRole(ctx, usr.ID, iusr.RoleAdmin); err != nil {
+			return err
+		}
+		fmt.Printf("%s is now an admin\n", usr.Email)
+	case "revoke":
+		if err := storage.UpdateRole(ctx, usr.ID, iusr.RoleSurvivor); err != nil {
+			return err
+		}
+		fmt.Printf("%s is no longer an admin\n", usr.Email)
+	case "token":
+		if usr.Role != iusr.RoleAdmin {
+			return fmt.Errorf("%s is not an admin", usr.Email)
+		}
+		td := &core.TokenData{UserID: usr.ID, Email: usr.Email, Admin: true}
+		token, err := td.Generate()
+		if err != nil {
+			return err
+		}
+		fmt.Println(token)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown admins command %q", fs.Arg(0))
+	}
+	return nil
+}
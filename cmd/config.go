@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"zssn/config"
+)
+
+const configUsage = `usage: zssn config <command>
+
+commands:
+  print       print the effective configuration with secrets redacted`
+
+// runConfig handles the config subcommand
+func runConfig(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Println(configUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "print":
+		b, err := cfg.Redacted().YAML()
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(b))
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown config command %q", fs.Arg(0))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"zssn/config"
+	"zssn/domains/export"
+	"zssn/domains/geo"
+	itr "zssn/domains/trade/store"
+	iusr "zssn/domains/users/store"
+
+	"gorm.io/gorm"
+)
+
+const exportUsage = `usage: zssn export [flags] <layer>
+
+layers:
+  survivors   clean survivors as points, their location is fuzzed
+  infected    infected and quarantined survivors, identified by their ID
+  trades      trades as lines between the partners
+  zones       zones as polygons
+
+flags:
+  -format     geojson or kml (default geojson)
+  -from       only data from this RFC 3339 time on
+  -to         only data up to this RFC 3339 time
+  -bbox       only data inside west,south,east,north, matched against the fuzzed locations
+  -o          file to write to (default stdout)`
+
+// runExport handles the export subcommand
+func runExport(db *gorm.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Println(exportUsage) }
+	formatName := fs.String("format", "geojson", "")
+	fromValue := fs.String("from", "", "")
+	toValue := fs.String("to", "", "")
+	bbox := fs.String("bbox", "", "")
+	output := fs.String("o", "", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	layer, err := export.ParseLayer(fs.Arg(0))
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	var filter export.Filter
+	for name, v := range map[string]struct {
+		value string
+		dst   *time.Time
+	}{"from": {*fromValue, &filter.From}, "to": {*toValue, &filter.To}} {
+		if v.value == "" {
+			continue
+		}
+		if *v.dst, err = time.Parse(time.RFC3339, v.value); err != nil {
+			return fmt.Errorf("invalid %s, expected an RFC 3339 time", name)
+		}
+	}
+	if strings.TrimSpace(*bbox) != "" {
+		box, err := geo.ParseBox(*bbox)
+		if err != nil {
+			return err
+		}
+		filter.Box = &box
+	}
+
+	usrStore, err := iusr.New(db)
+	if err != nil {
+		return err
+	}
+	trStore, err := itr.New(db)
+	if err != nil {
+		return err
+	}
+	// the CLI exports anonymously, every survivor's visibility applies
+	svc := export.New(usrStore, trStore, export.WithApproximatePrecision(cfg.Privacy.ApproximatePrecision))
+	res, err := svc.Export(context.Background(), "", layer, filter)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return export.Write(os.Stdout, format, "zssn "+string(layer), res)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(f, format, "zssn "+string(layer), res); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d %s feature(s) to %s\n", len(res.Features), layer, *output)
+	return nil
+}
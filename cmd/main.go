@@ -1,49 +1,133 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"io/fs"
 	"log"
 	"os"
 
+	"zssn/cache"
+	"zssn/config"
+	"zssn/database"
+	"zssn/domains/core"
+	"zssn/domains/export"
+	"zssn/domains/reports"
+	"zssn/domains/users"
 	"zssn/servers"
 
 	"github.com/joho/godotenv"
-	"gorm.io/driver/mysql"
-	"gorm.io/gorm"
 )
 
 func main() {
 	env := os.Getenv("ENVIRONMENT")
 	if env == "" || env == "development" {
-		if err := godotenv.Load(".envs/.env"); err != nil {
-			panic(err)
+		// the env file is a convenience for local development, every value can be provided some other way
+		if err := godotenv.Load(".envs/.env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Fatal(err)
 		}
 	}
-	db, err := setupDB()
+	cfg, args, err := config.Load(os.Args[1:])
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	server, err := servers.New(db)
-	if err != nil {
-		panic(err)
+	core.SigningSecret = cfg.Auth.SigningSecret
+
+	if len(args) > 0 && args[0] == "config" {
+		if err := runConfig(cfg, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	// server.Router.Use(requestid.New())
-	// server.Router.Use(cors.New())
-	// server.Router.Use(logger.New())
-	if err := server.Router.Listen(":8080"); err != nil {
+	db, err := database.Open(cfg.Database.Options())
+	if err != nil {
 		log.Fatal(err)
 	}
-}
-
-func setupDB() (*gorm.DB, error) {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	dbName := os.Getenv("DB_NAME")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		user, password, host, port, dbName)
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(db, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(args) > 0 && args[0] == "admins" {
+		if err := runAdmins(db, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(args) > 0 && args[0] == "export" {
+		if err := runExport(db, cfg, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	policy, err := users.NewInfectionPolicy(users.PolicyConfig{
+		Name:             cfg.Infection.Policy,
+		Threshold:        cfg.Infection.Threshold,
+		NearbyRadiusKm:   cfg.Infection.NearbyRadiusKm,
+		NearbyPercentage: cfg.Infection.NearbyPercentage,
+		NearbyMinFlags:   cfg.Infection.NearbyMinFlags,
+		MinReputation:    cfg.Infection.MinReputation,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	server, err := servers.New(db,
+		servers.WithCORSOrigins(cfg.CORS.AllowedOrigins...),
+		servers.WithInfectionPolicy(policy),
+		servers.WithInventoryRestoredOnRecovery(cfg.Recovery.RestoreInventory),
+		servers.WithStatusEffectsRetry(cfg.Status.EffectsRetryInterval),
+		servers.WithCollusionDetection(cfg.Collusion.Interval),
+		servers.WithLocationPruning(cfg.Locations.PruneInterval),
+		servers.WithReportSnapshots(cfg.Reports.SnapshotInterval),
+		servers.WithReportCache(cache.NewMemory(cfg.Reports.CacheSize), cfg.Reports.CacheTTL),
+		servers.WithContactTracing(cfg.Tracing.Enabled),
+		servers.WithSafeZoneTrades(cfg.Zones.SafeTradesOnly),
+		servers.WithReportOptions(
+			reports.WithForecastRules(reports.ForecastRules{
+				Consumption: map[core.Item]float64{
+					core.ItemWater:      cfg.Forecast.WaterPerDay,
+					core.ItemFood:       cfg.Forecast.FoodPerDay,
+					core.ItemMedication: cfg.Forecast.MedicationPerDay,
+					core.ItemAmmunition: cfg.Forecast.AmmunitionPerDay,
+				},
+				Window:       cfg.Forecast.Window,
+				ShortageDays: cfg.Forecast.ShortageDays,
+			}),
+			reports.WithTraderPrivacy(reports.TraderPrivacy(cfg.Reports.TraderPrivacy)),
+		),
+		servers.WithExportOptions(export.WithApproximatePrecision(cfg.Privacy.ApproximatePrecision)),
+		servers.WithUserOptions(
+			users.WithApproximatePrecision(cfg.Privacy.ApproximatePrecision),
+			users.WithAppealQuorum(cfg.Appeals.Quorum),
+			users.WithCollusionRules(users.CollusionRules{
+				Window:        cfg.Collusion.Window,
+				SignupWindow:  cfg.Collusion.SignupWindow,
+				ClusterSize:   cfg.Collusion.ClusterSize,
+				SharedTargets: cfg.Collusion.SharedTargets,
+				MinSignals:    cfg.Collusion.MinSignals,
+				Discount:      cfg.Collusion.DiscountFlags,
+			}),
+			users.WithLocationRules(users.LocationRules{
+				Retention:          cfg.Locations.Retention,
+				DownsampleAfter:    cfg.Locations.DownsampleAfter,
+				DownsampleInterval: cfg.Locations.DownsampleInterval,
+			}),
+			users.WithTracingRules(users.TracingRules{
+				Window:        cfg.Tracing.Window,
+				RadiusKm:      cfg.Tracing.RadiusKm,
+				ContactWindow: cfg.Tracing.ContactWindow,
+				MarkSuspected: cfg.Tracing.MarkSuspected,
+			}),
+		),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server.StartJobs(context.Background())
 
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err := server.Router.Listen(cfg.Server.ListenAddr); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"zssn/database/migrations"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `usage: zssn migrate <command>
+
+commands:
+  up          apply every pending migration
+  down [n]    revert the last n migrations (default 1)
+  version     print the current and latest schema versions`
+
+// runMigrate handles the migrate subcommand
+func runMigrate(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Println(migrateUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch fs.Arg(0) {
+	case "up":
+		applied, err := migrations.Up(ctx, db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			n, err := strconv.Atoi(fs.Arg(1))
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", fs.Arg(1))
+			}
+			steps = n
+		}
+		reverted, err := migrations.Down(ctx, db, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migration(s)\n", reverted)
+	case "version":
+		version, err := migrations.Version(ctx, db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("current: %d\nlatest: %d\n", version, migrations.Latest())
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migrate command %q", fs.Arg(0))
+	}
+	return nil
+}
@@ -0,0 +1,619 @@
+// Package config loads the service configuration.
+// Values are resolved in order from the defaults, an optional YAML file, the environment and finally the command line flags,
+// every source overriding the ones before it.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"zssn/database"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "*****"
+
+// Config the effective service configuration
+type Config struct {
+	Environment string    `yaml:"environment"`
+	Server      Server    `yaml:"server"`
+	Database    Database  `yaml:"database"`
+	Auth        Auth      `yaml:"auth"`
+	Infection   Infection `yaml:"infection"`
+	Appeals     Appeals   `yaml:"appeals"`
+	Recovery    Recovery  `yaml:"recovery"`
+	Status      Status    `yaml:"status"`
+	Collusion   Collusion `yaml:"collusion"`
+	Locations   Locations `yaml:"locations"`
+	Tracing     Tracing   `yaml:"tracing"`
+	Zones       Zones     `yaml:"zones"`
+	Privacy     Privacy   `yaml:"privacy"`
+	Reports     Reports   `yaml:"reports"`
+	Forecast    Forecast  `yaml:"forecast"`
+	CORS        CORS      `yaml:"cors"`
+}
+
+// Server http server settings
+type Server struct {
+	ListenAddr string `yaml:"listen_addr"`
+}
+
+// Database connection and pool settings.
+// DSN takes precedence over the individual connection fields.
+type Database struct {
+	Driver          string        `yaml:"driver"`
+	DSN             string        `yaml:"dsn"`
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	Name            string        `yaml:"name"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+}
+
+// Auth token settings, admins are granted with the admins command rather than configured
+type Auth struct {
+	SigningSecret string `yaml:"signing_secret"`
+}
+
+// Infection settings for deciding when a survivor is infected.
+// Policy is one of threshold, nearby or reputation, the threshold is also used by the reputation policy.
+type Infection struct {
+	Policy           string  `yaml:"policy"`
+	Threshold        int     `yaml:"threshold"`
+	NearbyRadiusKm   float64 `yaml:"nearby_radius_km"`
+	NearbyPercentage float64 `yaml:"nearby_percentage"`
+	NearbyMinFlags   int     `yaml:"nearby_min_flags"`
+	MinReputation    float64 `yaml:"min_reputation"`
+}
+
+// Appeals settings, quorum is the number of matching votes needed to resolve an appeal
+type Appeals struct {
+	Quorum int `yaml:"quorum"`
+}
+
+// Recovery settings, restore_inventory gives recovered survivors their blocked inventory back
+type Recovery struct {
+	RestoreInventory bool `yaml:"restore_inventory"`
+}
+
+// Status status change settings. The inventories are blocked and the contacts warned once the status of a survivor changes,
+// the changes those effects failed for are retried every effects_retry_interval and a zero interval disables it.
+type Status struct {
+	EffectsRetryInterval time.Duration `yaml:"effects_retry_interval"`
+}
+
+// Collusion detection settings, the detection runs every interval and a zero interval disables it.
+// Flaggers with at least min_signals signals are queued for review, discount_flags leaves their flags out of the infection policy.
+type Collusion struct {
+	Interval      time.Duration `yaml:"interval"`
+	Window        time.Duration `yaml:"window"`
+	SignupWindow  time.Duration `yaml:"signup_window"`
+	ClusterSize   int           `yaml:"cluster_size"`
+	SharedTargets int           `yaml:"shared_targets"`
+	MinSignals    int           `yaml:"min_signals"`
+	DiscountFlags bool          `yaml:"discount_flags"`
+}
+
+// Locations history settings, the pruning runs every prune_interval and a zero interval disables it.
+// Points older than retention are deleted, a zero retention keeps them forever.
+// Points older than downsample_after are thinned out to one every downsample_interval, a zero downsample_after disables downsampling.
+type Locations struct {
+	PruneInterval      time.Duration `yaml:"prune_interval"`
+	Retention          time.Duration `yaml:"retention"`
+	DownsampleAfter    time.Duration `yaml:"downsample_after"`
+	DownsampleInterval time.Duration `yaml:"downsample_interval"`
+}
+
+// Tracing contact tracing settings. Once a survivor is found infected, clean survivors who traded with them within the window
+// or reported a location within radius_km and contact_window of theirs are warned, mark_suspected also makes them suspected.
+type Tracing struct {
+	Enabled       bool          `yaml:"enabled"`
+	Window        time.Duration `yaml:"window"`
+	RadiusKm      float64       `yaml:"radius_km"`
+	ContactWindow time.Duration `yaml:"contact_window"`
+	MarkSuspected bool          `yaml:"mark_suspected"`
+}
+
+// Zones zone settings, safe_trades_only only lets survivors trade while both of them are inside a safe zone
+type Zones struct {
+	SafeTradesOnly bool `yaml:"safe_trades_only"`
+}
+
+// Privacy location privacy settings, approximate locations are snapped to the middle of their geohash cell of approximate_precision characters
+type Privacy struct {
+	ApproximatePrecision int `yaml:"approximate_precision"`
+}
+
+// Reports settings, the reports are recorded for the history series every snapshot_interval and a zero interval disables it.
+// Up to cache_size report responses are cached for cache_ttl, a zero ttl disables the cache.
+// TraderPrivacy is one of public, anonymous or hidden and decides how the trade report lists the most active traders.
+type Reports struct {
+	SnapshotInterval time.Duration `yaml:"snapshot_interval"`
+	CacheTTL         time.Duration `yaml:"cache_ttl"`
+	CacheSize        int           `yaml:"cache_size"`
+	TraderPrivacy    string        `yaml:"trader_privacy"`
+}
+
+// Forecast supply forecast settings, every clean survivor uses the per_day amount of each item a day.
+// The supplies used up over the last window are the observed consumption, items lasting fewer than shortage_days are flagged.
+type Forecast struct {
+	WaterPerDay      float64       `yaml:"water_per_day"`
+	FoodPerDay       float64       `yaml:"food_per_day"`
+	MedicationPerDay float64       `yaml:"medication_per_day"`
+	AmmunitionPerDay float64       `yaml:"ammunition_per_day"`
+	Window           time.Duration `yaml:"window"`
+	ShortageDays     float64       `yaml:"shortage_days"`
+}
+
+// CORS allowed origins, an empty list allows every origin
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// Default returns the configuration used when nothing else is provided
+func Default() *Config {
+	return &Config{
+		Environment: "development",
+		Server: Server{
+			ListenAddr: ":8080",
+		},
+		Database: Database{
+			Driver:          database.DriverMySQL,
+			Host:            "127.0.0.1",
+			Port:            "3306",
+			Name:            "zssn",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		Infection: Infection{
+			Policy:           "threshold",
+			Threshold:        3,
+			NearbyRadiusKm:   5,
+			NearbyPercentage: 50,
+			NearbyMinFlags:   2,
+		},
+		Appeals: Appeals{
+			Quorum: 3,
+		},
+		Recovery: Recovery{
+			RestoreInventory: true,
+		},
+		Status: Status{
+			EffectsRetryInterval: time.Minute,
+		},
+		Collusion: Collusion{
+			Interval:      time.Hour,
+			Window:        7 * 24 * time.Hour,
+			SignupWindow:  time.Hour,
+			ClusterSize:   3,
+			SharedTargets: 2,
+			MinSignals:    2,
+		},
+		Locations: Locations{
+			PruneInterval:      time.Hour,
+			Retention:          30 * 24 * time.Hour,
+			DownsampleInterval: time.Hour,
+		},
+		Tracing: Tracing{
+			Window:        14 * 24 * time.Hour,
+			RadiusKm:      0.1,
+			ContactWindow: 30 * time.Minute,
+		},
+		Privacy: Privacy{
+			ApproximatePrecision: 6,
+		},
+		Reports: Reports{
+			SnapshotInterval: time.Hour,
+			CacheTTL:         time.Minute,
+			CacheSize:        1000,
+			TraderPrivacy:    "anonymous",
+		},
+		Forecast: Forecast{
+			WaterPerDay:      1,
+			FoodPerDay:       1,
+			MedicationPerDay: 0.1,
+			Window:           7 * 24 * time.Hour,
+			ShortageDays:     7,
+		},
+	}
+}
+
+// Load builds the configuration from every source and validates it.
+// It returns the arguments left after the flags, which is the command to run.
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("zssn", flag.ContinueOnError)
+	file := fs.String("config", "", "path to a YAML config file (env CONFIG_FILE)")
+	flags := cfg.flags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	path := *file
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, nil, err
+	}
+	// only the flags that were explicitly set override the other sources
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if apply, ok := flags[f.Name]; ok && err == nil {
+			err = apply(f.Value.String())
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// Validate makes sure the configuration can be used to start the service
+func (c *Config) Validate() error {
+	var errs []string
+	switch c.Database.Driver {
+	case database.DriverMySQL, database.DriverPostgres:
+		if c.Database.DSN == "" && (c.Database.Host == "" || c.Database.Name == "") {
+			errs = append(errs, "database host and name or a dsn are required")
+		}
+	case database.DriverSQLite:
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported database driver %q", c.Database.Driver))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		errs = append(errs, "database pool sizes cannot be negative")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, "database max idle connections cannot exceed max open connections")
+	}
+	if c.Server.ListenAddr == "" {
+		errs = append(errs, "listen address is required")
+	}
+	if c.Auth.SigningSecret == "" {
+		errs = append(errs, "auth signing secret is required")
+	}
+	switch c.Infection.Policy {
+	case "threshold", "nearby", "reputation":
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported infection policy %q", c.Infection.Policy))
+	}
+	if c.Infection.Threshold < 1 {
+		errs = append(errs, "infection threshold must be at least 1")
+	}
+	if c.Infection.NearbyRadiusKm <= 0 {
+		errs = append(errs, "infection nearby radius must be positive")
+	}
+	if c.Infection.NearbyPercentage <= 0 || c.Infection.NearbyPercentage > 100 {
+		errs = append(errs, "infection nearby percentage must be between 0 and 100")
+	}
+	if c.Appeals.Quorum < 1 {
+		errs = append(errs, "appeal quorum must be at least 1")
+	}
+	if c.Status.EffectsRetryInterval < 0 {
+		errs = append(errs, "status effects retry interval cannot be negative")
+	}
+	if c.Collusion.Interval < 0 {
+		errs = append(errs, "collusion interval cannot be negative")
+	}
+	if c.Collusion.Window <= 0 || c.Collusion.SignupWindow <= 0 {
+		errs = append(errs, "collusion windows must be positive")
+	}
+	if c.Collusion.ClusterSize < 2 {
+		errs = append(errs, "collusion cluster size must be at least 2")
+	}
+	if c.Collusion.SharedTargets < 1 {
+		errs = append(errs, "collusion shared targets must be at least 1")
+	}
+	if c.Collusion.MinSignals < 1 || c.Collusion.MinSignals > 3 {
+		errs = append(errs, "collusion min signals must be between 1 and 3")
+	}
+	if c.Locations.PruneInterval < 0 || c.Locations.Retention < 0 || c.Locations.DownsampleAfter < 0 {
+		errs = append(errs, "location durations cannot be negative")
+	}
+	if c.Locations.DownsampleAfter > 0 && c.Locations.DownsampleInterval <= 0 {
+		errs = append(errs, "location downsample interval must be positive when downsampling")
+	}
+	if c.Tracing.Window <= 0 || c.Tracing.ContactWindow <= 0 || c.Tracing.RadiusKm <= 0 {
+		errs = append(errs, "tracing window, contact window and radius must be positive")
+	}
+	if c.Privacy.ApproximatePrecision < 1 || c.Privacy.ApproximatePrecision > 7 {
+		errs = append(errs, "privacy approximate precision must be between 1 and 7")
+	}
+	if c.Reports.SnapshotInterval < 0 {
+		errs = append(errs, "report snapshot interval cannot be negative")
+	}
+	if c.Reports.CacheTTL < 0 || c.Reports.CacheSize < 1 {
+		errs = append(errs, "report cache ttl cannot be negative and cache size must be at least 1")
+	}
+	switch c.Reports.TraderPrivacy {
+	case "public", "anonymous", "hidden":
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported report trader privacy %q", c.Reports.TraderPrivacy))
+	}
+	f := c.Forecast
+	if f.WaterPerDay < 0 || f.FoodPerDay < 0 || f.MedicationPerDay < 0 || f.AmmunitionPerDay < 0 {
+		errs = append(errs, "forecast consumption cannot be negative")
+	}
+	if f.Window <= 0 || f.ShortageDays <= 0 {
+		errs = append(errs, "forecast window and shortage days must be positive")
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid config: " + strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// ConnectionString returns the DSN, building it from the individual fields when it isn't set
+func (d Database) ConnectionString() string {
+	if d.DSN != "" {
+		return d.DSN
+	}
+	switch d.Driver {
+	case database.DriverMySQL:
+		return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			d.User, d.Password, d.Host, d.Port, d.Name)
+	case database.DriverPostgres:
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			d.Host, d.Port, d.User, d.Password, d.Name)
+	default:
+		return d.Name
+	}
+}
+
+// Options returns the settings needed to open the database connection
+func (d Database) Options() database.Config {
+	return database.Config{
+		Driver:          d.Driver,
+		DSN:             d.ConnectionString(),
+		MaxOpenConns:    d.MaxOpenConns,
+		MaxIdleConns:    d.MaxIdleConns,
+		ConnMaxLifetime: d.ConnMaxLifetime,
+	}
+}
+
+// Redacted returns a copy of the configuration that is safe to print
+func (c *Config) Redacted() *Config {
+	r := *c
+	if r.Database.Password != "" {
+		r.Database.Password = redacted
+	}
+	if r.Auth.SigningSecret != "" {
+		r.Auth.SigningSecret = redacted
+	}
+	r.Database.DSN = redactDSN(r.Database.DSN)
+	return &r
+}
+
+// YAML returns the configuration in the same format as the config file
+func (c *Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+func (c *Config) loadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	for name, apply := range c.envs() {
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			continue
+		}
+		if err := apply(v); err != nil {
+			return fmt.Errorf("env %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+type setter func(string) error
+
+func (c *Config) envs() map[string]setter {
+	return map[string]setter{
+		"ENVIRONMENT":                   setString(&c.Environment),
+		"LISTEN_ADDR":                   setString(&c.Server.ListenAddr),
+		"DB_DRIVER":                     setString(&c.Database.Driver),
+		"DB_DSN":                        setString(&c.Database.DSN),
+		"DB_HOST":                       setString(&c.Database.Host),
+		"DB_PORT":                       setString(&c.Database.Port),
+		"DB_NAME":                       setString(&c.Database.Name),
+		"DB_USER":                       setString(&c.Database.User),
+		"DB_PASSWORD":                   setString(&c.Database.Password),
+		"DB_MAX_OPEN_CONNS":             setInt(&c.Database.MaxOpenConns),
+		"DB_MAX_IDLE_CONNS":             setInt(&c.Database.MaxIdleConns),
+		"DB_CONN_MAX_LIFETIME":          setDuration(&c.Database.ConnMaxLifetime),
+		"SIGNING_SECRET":                setString(&c.Auth.SigningSecret),
+		"INFECTION_POLICY":              setString(&c.Infection.Policy),
+		"INFECTION_THRESHOLD":           setInt(&c.Infection.Threshold),
+		"INFECTION_NEARBY_RADIUS_KM":    setFloat(&c.Infection.NearbyRadiusKm),
+		"INFECTION_NEARBY_PERCENTAGE":   setFloat(&c.Infection.NearbyPercentage),
+		"INFECTION_NEARBY_MIN_FLAGS":    setInt(&c.Infection.NearbyMinFlags),
+		"INFECTION_MIN_REPUTATION":      setFloat(&c.Infection.MinReputation),
+		"APPEAL_QUORUM":                 setInt(&c.Appeals.Quorum),
+		"RECOVERY_RESTORE_INVENTORY":    setBool(&c.Recovery.RestoreInventory),
+		"STATUS_EFFECTS_RETRY_INTERVAL": setDuration(&c.Status.EffectsRetryInterval),
+		"COLLUSION_INTERVAL":            setDuration(&c.Collusion.Interval),
+		"COLLUSION_WINDOW":              setDuration(&c.Collusion.Window),
+		"COLLUSION_SIGNUP_WINDOW":       setDuration(&c.Collusion.SignupWindow),
+		"COLLUSION_CLUSTER_SIZE":        setInt(&c.Collusion.ClusterSize),
+		"COLLUSION_SHARED_TARGETS":      setInt(&c.Collusion.SharedTargets),
+		"COLLUSION_MIN_SIGNALS":         setInt(&c.Collusion.MinSignals),
+		"COLLUSION_DISCOUNT_FLAGS":      setBool(&c.Collusion.DiscountFlags),
+		"LOCATION_PRUNE_INTERVAL":       setDuration(&c.Locations.PruneInterval),
+		"LOCATION_RETENTION":            setDuration(&c.Locations.Retention),
+		"LOCATION_DOWNSAMPLE_AFTER":     setDuration(&c.Locations.DownsampleAfter),
+		"LOCATION_DOWNSAMPLE_INTERVAL":  setDuration(&c.Locations.DownsampleInterval),
+		"TRACING_ENABLED":               setBool(&c.Tracing.Enabled),
+		"TRACING_WINDOW":                setDuration(&c.Tracing.Window),
+		"TRACING_RADIUS_KM":             setFloat(&c.Tracing.RadiusKm),
+		"TRACING_CONTACT_WINDOW":        setDuration(&c.Tracing.ContactWindow),
+		"TRACING_MARK_SUSPECTED":        setBool(&c.Tracing.MarkSuspected),
+		"ZONES_SAFE_TRADES_ONLY":        setBool(&c.Zones.SafeTradesOnly),
+		"PRIVACY_APPROXIMATE_PRECISION": setInt(&c.Privacy.ApproximatePrecision),
+		"REPORTS_SNAPSHOT_INTERVAL":     setDuration(&c.Reports.SnapshotInterval),
+		"REPORTS_CACHE_TTL":             setDuration(&c.Reports.CacheTTL),
+		"REPORTS_CACHE_SIZE":            setInt(&c.Reports.CacheSize),
+		"REPORTS_TRADER_PRIVACY":        setString(&c.Reports.TraderPrivacy),
+		"FORECAST_WATER_PER_DAY":        setFloat(&c.Forecast.WaterPerDay),
+		"FORECAST_FOOD_PER_DAY":         setFloat(&c.Forecast.FoodPerDay),
+		"FORECAST_MEDICATION_PER_DAY":   setFloat(&c.Forecast.MedicationPerDay),
+		"FORECAST_AMMUNITION_PER_DAY":   setFloat(&c.Forecast.AmmunitionPerDay),
+		"FORECAST_WINDOW":               setDuration(&c.Forecast.Window),
+		"FORECAST_SHORTAGE_DAYS":        setFloat(&c.Forecast.ShortageDays),
+		"CORS_ALLOWED_ORIGINS":          setList(&c.CORS.AllowedOrigins),
+	}
+}
+
+// flags registers the command line flags and returns how each of them is applied
+func (c *Config) flags(fs *flag.FlagSet) map[string]setter {
+	fs.String("env", "", "environment name")
+	fs.String("listen", "", "address the http server listens on")
+	fs.String("db-driver", "", "database driver: mysql, postgres or sqlite")
+	fs.String("db-dsn", "", "database connection string")
+	fs.Int("db-max-open-conns", 0, "maximum number of open database connections")
+	fs.Int("db-max-idle-conns", 0, "maximum number of idle database connections")
+	fs.Duration("db-conn-max-lifetime", 0, "maximum lifetime of a database connection")
+	fs.String("infection-policy", "", "infection policy: threshold, nearby or reputation")
+	fs.Int("infection-threshold", 0, "number of flags needed to mark a survivor infected")
+	fs.String("cors-origins", "", "comma separated list of allowed CORS origins")
+
+	return map[string]setter{
+		"env":                  setString(&c.Environment),
+		"listen":               setString(&c.Server.ListenAddr),
+		"db-driver":            setString(&c.Database.Driver),
+		"db-dsn":               setString(&c.Database.DSN),
+		"db-max-open-conns":    setInt(&c.Database.MaxOpenConns),
+		"db-max-idle-conns":    setInt(&c.Database.MaxIdleConns),
+		"db-conn-max-lifetime": setDuration(&c.Database.ConnMaxLifetime),
+		"infection-policy":     setString(&c.Infection.Policy),
+		"infection-threshold":  setInt(&c.Infection.Threshold),
+		"cors-origins":         setList(&c.CORS.AllowedOrigins),
+	}
+}
+
+func setString(dst *string) setter {
+	return func(v string) error {
+		*dst = v
+		return nil
+	}
+}
+
+func setInt(dst *int) setter {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func setFloat(dst *float64) setter {
+	return func(v string) error {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func setBool(dst *bool) setter {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*dst = b
+		return nil
+	}
+}
+
+func setDuration(dst *time.Duration) setter {
+	return func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*dst = d
+		return nil
+	}
+}
+
+func setList(dst *[]string) setter {
+	return func(v string) error {
+		var res []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				res = append(res, s)
+			}
+		}
+		*dst = res
+		return nil
+	}
+}
+
+var keyValuePassword = regexp.MustCompile(`(password=)\S+`)
+
+// redactDSN hides the password from url, key=value and mysql style connection strings
+func redactDSN(dsn string) string {
+	if dsn == "" {
+		return dsn
+	}
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			return strings.Replace(u.String(), url.QueryEscape(redacted), redacted, 1)
+		}
+		return dsn
+	}
+	if keyValuePassword.MatchString(dsn) {
+		return keyValuePassword.ReplaceAllString(dsn, "${1}"+redacted)
+	}
+	// mysql: user:password@protocol(address)/dbname, the password can contain both ':' and '@'
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return dsn
+	}
+	at := strings.LastIndex(dsn[:slash], "@")
+	if at < 0 {
+		return dsn
+	}
+	colon := strings.Index(dsn[:at], ":")
+	if colon < 0 {
+		return dsn
+	}
+	return dsn[:colon+1] + redacted + dsn[at:]
+}
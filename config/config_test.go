@@ -0,0 +1,254 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("SIGNING_SECRET", "hello world")
+
+	cfg, args, err := Load(nil)
+	require.NoError(t, err)
+	assert.Empty(t, args)
+	assert.Equal(t, ":8080", cfg.Server.ListenAddr)
+	assert.Equal(t, "mysql", cfg.Database.Driver)
+	assert.Equal(t, 3, cfg.Infection.Threshold)
+	assert.Equal(t, 5*time.Minute, cfg.Database.ConnMaxLifetime)
+	assert.Empty(t, cfg.CORS.AllowedOrigins)
+	assert.Equal(t, 3, cfg.Appeals.Quorum)
+	assert.True(t, cfg.Recovery.RestoreInventory)
+	assert.Equal(t, time.Hour, cfg.Collusion.Interval)
+	assert.Equal(t, 3, cfg.Collusion.ClusterSize)
+	assert.Equal(t, 2, cfg.Collusion.SharedTargets)
+	assert.False(t, cfg.Collusion.DiscountFlags)
+	assert.Equal(t, 30*24*time.Hour, cfg.Locations.Retention)
+	assert.Zero(t, cfg.Locations.DownsampleAfter)
+	assert.False(t, cfg.Tracing.Enabled)
+	assert.Equal(t, 0.1, cfg.Tracing.RadiusKm)
+	assert.False(t, cfg.Zones.SafeTradesOnly)
+	assert.Equal(t, 6, cfg.Privacy.ApproximatePrecision)
+	assert.Equal(t, time.Hour, cfg.Reports.SnapshotInterval)
+	assert.Equal(t, time.Minute, cfg.Reports.CacheTTL)
+	assert.Equal(t, 1000, cfg.Reports.CacheSize)
+	assert.Equal(t, "anonymous", cfg.Reports.TraderPrivacy)
+	assert.Equal(t, 0.1, cfg.Forecast.MedicationPerDay)
+	assert.Equal(t, 7*24*time.Hour, cfg.Forecast.Window)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeConfig(t, `
+server:
+  listen_addr: ":9000"
+database:
+  driver: postgres
+  dsn: "host=db user=zssn password=secret dbname=zssn"
+  max_open_conns: 10
+  conn_max_lifetime: 1m
+auth:
+  signing_secret: from-file
+infection:
+  threshold: 4
+appeals:
+  quorum: 2
+collusion:
+  interval: 10m
+  window: 48h
+cors:
+  allowed_origins: ["https://zssn.io"]
+`)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("INFECTION_THRESHOLD", "5")
+	t.Setenv("INFECTION_POLICY", "nearby")
+	t.Setenv("INFECTION_NEARBY_PERCENTAGE", "30.5")
+	t.Setenv("DB_MAX_OPEN_CONNS", "20")
+	t.Setenv("APPEAL_QUORUM", "4")
+	t.Setenv("RECOVERY_RESTORE_INVENTORY", "false")
+	t.Setenv("COLLUSION_WINDOW", "24h")
+	t.Setenv("COLLUSION_DISCOUNT_FLAGS", "true")
+	t.Setenv("LOCATION_DOWNSAMPLE_AFTER", "72h")
+	t.Setenv("TRACING_ENABLED", "true")
+	t.Setenv("ZONES_SAFE_TRADES_ONLY", "true")
+	t.Setenv("PRIVACY_APPROXIMATE_PRECISION", "5")
+	t.Setenv("REPORTS_SNAPSHOT_INTERVAL", "24h")
+	t.Setenv("REPORTS_CACHE_TTL", "0")
+	t.Setenv("REPORTS_TRADER_PRIVACY", "hidden")
+	t.Setenv("FORECAST_WATER_PER_DAY", "2.5")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://a.zssn.io, https://b.zssn.io")
+
+	cfg, args, err := Load([]string{"-infection-threshold", "6", "migrate", "up"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"migrate", "up"}, args)
+
+	// file values override the defaults
+	assert.Equal(t, ":9000", cfg.Server.ListenAddr)
+	assert.Equal(t, "postgres", cfg.Database.Driver)
+	assert.Equal(t, time.Minute, cfg.Database.ConnMaxLifetime)
+	assert.Equal(t, "from-file", cfg.Auth.SigningSecret)
+	assert.Equal(t, 5, cfg.Database.MaxIdleConns)
+	assert.Equal(t, 10*time.Minute, cfg.Collusion.Interval)
+
+	// env values override the file
+	assert.Equal(t, 20, cfg.Database.MaxOpenConns)
+	assert.Equal(t, []string{"https://a.zssn.io", "https://b.zssn.io"}, cfg.CORS.AllowedOrigins)
+	assert.Equal(t, "nearby", cfg.Infection.Policy)
+	assert.Equal(t, 30.5, cfg.Infection.NearbyPercentage)
+	assert.Equal(t, 4, cfg.Appeals.Quorum)
+	assert.False(t, cfg.Recovery.RestoreInventory)
+	assert.Equal(t, 24*time.Hour, cfg.Collusion.Window)
+	assert.True(t, cfg.Collusion.DiscountFlags)
+	assert.Equal(t, 72*time.Hour, cfg.Locations.DownsampleAfter)
+	assert.True(t, cfg.Tracing.Enabled)
+	assert.True(t, cfg.Zones.SafeTradesOnly)
+	assert.Equal(t, 5, cfg.Privacy.ApproximatePrecision)
+	assert.Equal(t, 24*time.Hour, cfg.Reports.SnapshotInterval)
+	assert.Zero(t, cfg.Reports.CacheTTL)
+	assert.Equal(t, "hidden", cfg.Reports.TraderPrivacy)
+	assert.Equal(t, 2.5, cfg.Forecast.WaterPerDay)
+
+	// flags override everything
+	assert.Equal(t, 6, cfg.Infection.Threshold)
+}
+
+func TestLoadConfigFlag(t *testing.T) {
+	file := writeConfig(t, `
+auth:
+  signing_secret: from-file
+database:
+  driver: sqlite
+  name: ":memory:"
+`)
+	cfg, _, err := Load([]string{"-config", file})
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", cfg.Database.Driver)
+	assert.Equal(t, ":memory:", cfg.Database.ConnectionString())
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Setenv("SIGNING_SECRET", "hello world")
+
+	_, _, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")})
+	require.Error(t, err)
+
+	_, _, err = Load([]string{"-unknown"})
+	require.Error(t, err)
+
+	_, _, err = Load([]string{"-db-conn-max-lifetime", "forever"})
+	require.Error(t, err)
+
+	t.Setenv("DB_MAX_IDLE_CONNS", "many")
+	_, _, err = Load(nil)
+	require.EqualError(t, err, `env DB_MAX_IDLE_CONNS: invalid number "many"`)
+
+	t.Setenv("DB_MAX_IDLE_CONNS", "")
+	t.Setenv("RECOVERY_RESTORE_INVENTORY", "maybe")
+	_, _, err = Load(nil)
+	require.EqualError(t, err, `env RECOVERY_RESTORE_INVENTORY: invalid boolean "maybe"`)
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.SigningSecret = "hello world"
+	require.NoError(t, cfg.Validate())
+
+	cfg.Database.Driver = "oracle"
+	cfg.Infection.Policy = "coin-toss"
+	cfg.Infection.Threshold = 0
+	cfg.Appeals.Quorum = 0
+	cfg.Collusion.ClusterSize = 1
+	cfg.Collusion.SharedTargets = 0
+	cfg.Collusion.MinSignals = 4
+	cfg.Locations.DownsampleAfter = time.Hour
+	cfg.Locations.DownsampleInterval = 0
+	cfg.Tracing.RadiusKm = 0
+	cfg.Privacy.ApproximatePrecision = 8
+	cfg.Reports.SnapshotInterval = -time.Hour
+	cfg.Reports.CacheSize = 0
+	cfg.Reports.TraderPrivacy = "famous"
+	cfg.Forecast.FoodPerDay = -1
+	cfg.Forecast.ShortageDays = 0
+	cfg.Auth.SigningSecret = ""
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unsupported database driver "oracle"`)
+	assert.Contains(t, err.Error(), `unsupported infection policy "coin-toss"`)
+	assert.Contains(t, err.Error(), "infection threshold must be at least 1")
+	assert.Contains(t, err.Error(), "appeal quorum must be at least 1")
+	assert.Contains(t, err.Error(), "collusion shared targets must be at least 1")
+	assert.Contains(t, err.Error(), "collusion cluster size must be at least 2")
+	assert.Contains(t, err.Error(), "collusion min signals must be between 1 and 3")
+	assert.Contains(t, err.Error(), "location downsample interval must be positive when downsampling")
+	assert.Contains(t, err.Error(), "tracing window, contact window and radius must be positive")
+	assert.Contains(t, err.Error(), "privacy approximate precision must be between 1 and 7")
+	assert.Contains(t, err.Error(), "report snapshot interval cannot be negative")
+	assert.Contains(t, err.Error(), "report cache ttl cannot be negative and cache size must be at least 1")
+	assert.Contains(t, err.Error(), `unsupported report trader privacy "famous"`)
+	assert.Contains(t, err.Error(), "forecast consumption cannot be negative")
+	assert.Contains(t, err.Error(), "forecast window and shortage days must be positive")
+	assert.Contains(t, err.Error(), "auth signing secret is required")
+
+	cfg = Default()
+	cfg.Auth.SigningSecret = "hello world"
+	cfg.Database.MaxOpenConns = 2
+	cfg.Database.MaxIdleConns = 5
+	require.EqualError(t, cfg.Validate(), "invalid config: database max idle connections cannot exceed max open connections")
+}
+
+func TestConnectionString(t *testing.T) {
+	db := Default().Database
+	db.User, db.Password = "zssn_user", "p@azzword"
+	assert.Equal(t, "zssn_user:p@azzword@tcp(127.0.0.1:3306)/zssn?charset=utf8mb4&parseTime=True&loc=Local", db.ConnectionString())
+
+	db.Driver = "postgres"
+	assert.Equal(t, "host=127.0.0.1 port=3306 user=zssn_user password=p@azzword dbname=zssn sslmode=disable", db.ConnectionString())
+
+	db.DSN = "postgres://zssn@db/zssn"
+	assert.Equal(t, db.DSN, db.ConnectionString())
+}
+
+func TestRedacted(t *testing.T) {
+	table := []struct {
+		name, dsn, expected string
+	}{
+		{"Empty", "", ""},
+		{"MySQL", "zssn_user:p@azzword@tcp(db:3306)/zssn?parseTime=True", "zssn_user:*****@tcp(db:3306)/zssn?parseTime=True"},
+		{"KeyValue", "host=db user=zssn password=secret dbname=zssn", "host=db user=zssn password=***** dbname=zssn"},
+		{"URL", "postgres://zssn:secret@db:5432/zssn?sslmode=disable", "postgres://zssn:*****@db:5432/zssn?sslmode=disable"},
+		{"URLWithoutPassword", "postgres://zssn@db:5432/zssn", "postgres://zssn@db:5432/zssn"},
+		{"SQLite", "file:zssn.db?cache=shared", "file:zssn.db?cache=shared"},
+	}
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Database.DSN = tt.dsn
+			cfg.Database.Password = "p@azzword"
+			cfg.Auth.SigningSecret = "hello world"
+
+			r := cfg.Redacted()
+			assert.Equal(t, tt.expected, r.Database.DSN)
+			assert.Equal(t, "*****", r.Database.Password)
+			assert.Equal(t, "*****", r.Auth.SigningSecret)
+
+			// the original config is left untouched
+			assert.Equal(t, tt.dsn, cfg.Database.DSN)
+			assert.Equal(t, "hello world", cfg.Auth.SigningSecret)
+
+			b, err := r.YAML()
+			require.NoError(t, err)
+			assert.NotContains(t, string(b), "hello world")
+			assert.NotContains(t, string(b), "p@azzword")
+		})
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+	return file
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sqlitedriver "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	// DriverMySQL mysql storage driver
+	DriverMySQL = "mysql"
+	// DriverPostgres postgres storage driver
+	DriverPostgres = "postgres"
+	// DriverSQLite sqlite storage driver, supports both file and in-memory databases
+	DriverSQLite = "sqlite"
+
+	// MemoryDSN can be used with the sqlite driver to get a private in-memory database
+	MemoryDSN = ":memory:"
+)
+
+// Config contains the details needed to open a database connection.
+// Zero pool settings keep the database/sql defaults.
+type Config struct {
+	Driver          string
+	DSN             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// Open opens a new connection using the driver specified in the config
+func Open(cfg Config) (*gorm.DB, error) {
+	dialector, err := dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Driver == DriverSQLite {
+		// sqlite allows a single writer at a time and every connection to an in-memory
+		// database gets its own copy, so we keep everything on one connection.
+		sqlDB.SetMaxOpenConns(1)
+		return db, nil
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	return db, nil
+}
+
+// IsSQLite returns true if the connection is backed by sqlite
+func IsSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == DriverSQLite
+}
+
+// IsUniqueViolation returns true if the write was rejected for breaking a unique index, whichever driver rejected it
+func IsUniqueViolation(err error) bool {
+	var (
+		pgErr     *pgconn.PgError
+		mysqlErr  *mysqldriver.MySQLError
+		sqliteErr *sqlitedriver.Error
+	)
+	switch {
+	case errors.As(err, &pgErr):
+		// unique_violation
+		return pgErr.Code == "23505"
+	case errors.As(err, &mysqlErr):
+		// ER_DUP_ENTRY
+		return mysqlErr.Number == 1062
+	case errors.As(err, &sqliteErr):
+		// SQLITE_CONSTRAINT_PRIMARYKEY and SQLITE_CONSTRAINT_UNIQUE
+		return sqliteErr.Code() == 1555 || sqliteErr.Code() == 2067
+	}
+	return false
+}
+
+func dialector(cfg Config) (gorm.Dialector, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case DriverMySQL:
+		return mysql.Open(cfg.DSN), nil
+	case DriverPostgres:
+		return postgres.Open(cfg.DSN), nil
+	case DriverSQLite:
+		return sqlite.Open(sqliteDSN(cfg.DSN)), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+// sqliteDSN makes sure foreign keys are enforced the same way they are in the other databases
+func sqliteDSN(dsn string) string {
+	if dsn == "" || dsn == MemoryDSN {
+		dsn = "file:" + uuid.NewString() + "?mode=memory"
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenInMemorySQLite(t *testing.T) {
+	db, err := Open(Config{Driver: DriverSQLite, DSN: MemoryDSN})
+	require.NoError(t, err)
+	require.NotNil(t, db)
+	assert.True(t, IsSQLite(db))
+
+	var fk int
+	require.NoError(t, db.Raw("PRAGMA foreign_keys").Scan(&fk).Error)
+	assert.Equal(t, 1, fk)
+}
+
+func TestInMemoryDatabasesAreIsolated(t *testing.T) {
+	type record struct {
+		ID string
+	}
+	first, err := Open(Config{Driver: DriverSQLite})
+	require.NoError(t, err)
+	require.NoError(t, first.AutoMigrate(&record{}))
+
+	second, err := Open(Config{Driver: DriverSQLite})
+	require.NoError(t, err)
+	assert.False(t, second.Migrator().HasTable(&record{}))
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	type record struct {
+		ID    string
+		Email string `gorm:"uniqueIndex"`
+	}
+	db, err := Open(Config{Driver: DriverSQLite})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&record{}))
+	require.NoError(t, db.Create(&record{ID: "a", Email: "a@zssn.io"}).Error)
+
+	err = db.Create(&record{ID: "b", Email: "a@zssn.io"}).Error
+	require.Error(t, err)
+	assert.True(t, IsUniqueViolation(err))
+	err = db.Create(&record{ID: "a", Email: "b@zssn.io"}).Error
+	require.Error(t, err)
+	assert.True(t, IsUniqueViolation(err))
+
+	assert.False(t, IsUniqueViolation(db.Exec("SELECT * FROM missing").Error))
+	assert.False(t, IsUniqueViolation(nil))
+}
+
+func TestOpenUnsupportedDriver(t *testing.T) {
+	db, err := Open(Config{Driver: "oracle"})
+	require.EqualError(t, err, `unsupported database driver "oracle"`)
+	assert.Nil(t, db)
+}
@@ -0,0 +1,223 @@
+// Package migrations contains the versioned schema migrations for every store.
+// Migrations are applied in order and the applied versions are recorded in the schema_version table.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	lockName = "zssn_schema_migrations"
+	// lockKey is the postgres advisory lock key, postgres only accepts integer keys
+	lockKey = 7_261_042_019
+	// lockTimeout number of seconds to wait for another instance to finish migrating
+	lockTimeout = 60
+)
+
+var (
+	// ErrSchemaOutdated is returned when the database is behind the migrations this binary knows about
+	ErrSchemaOutdated = errors.New("database schema is outdated, run `zssn migrate up`")
+
+	errLockTimeout = errors.New("timed out waiting for the migration lock")
+)
+
+// Migration a single versioned schema change.
+// Migrations must not reference the store models since those keep changing, they work on their own snapshot of the schema.
+type Migration struct {
+	Version     uint
+	Description string
+	Up          func(tx *gorm.DB) error
+	Down        func(tx *gorm.DB) error
+}
+
+// schemaVersion records every applied migration
+type schemaVersion struct {
+	Version     uint   `gorm:"primaryKey;autoIncrement:false"`
+	Description string `gorm:"size:255"`
+	AppliedAt   time.Time
+}
+
+// TableName overrides the default table name
+func (schemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// all contains every migration, new migrations are appended at the end
+var all = []Migration{
+	baseline,
+	infectionAudits,
+	appeals,
+	survivorStatus,
+	flagEvidence,
+	collusionReviews,
+	rejectedFlags,
+	locationHistory,
+	notifications,
+	zones,
+	locationPrivacy,
+	reportSnapshots,
+	userRoles,
+	transitionEffects,
+}
+
+// Migrations returns all the known migrations ordered by version
+func Migrations() []Migration {
+	res := make([]Migration, len(all))
+	copy(res, all)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Version < res[j].Version
+	})
+	return res
+}
+
+// Latest returns the version of the newest migration
+func Latest() uint {
+	ms := Migrations()
+	if len(ms) == 0 {
+		return 0
+	}
+	return ms[len(ms)-1].Version
+}
+
+// Version returns the current schema version of the database, 0 means no migration has been applied
+func Version(ctx context.Context, db *gorm.DB) (uint, error) {
+	db = db.WithContext(ctx)
+	if !db.Migrator().HasTable(&schemaVersion{}) {
+		return 0, nil
+	}
+	var version sql.NullInt64
+	if err := db.Model(&schemaVersion{}).Select("MAX(version)").Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	return uint(version.Int64), nil
+}
+
+// Verify makes sure every known migration has been applied to the database
+func Verify(ctx context.Context, db *gorm.DB) error {
+	version, err := Version(ctx, db)
+	if err != nil {
+		return err
+	}
+	if version < Latest() {
+		return fmt.Errorf("%w: at version %d, expected %d", ErrSchemaOutdated, version, Latest())
+	}
+	return nil
+}
+
+// Up applies every pending migration and returns the number of migrations applied
+func Up(ctx context.Context, db *gorm.DB) (int, error) {
+	var applied int
+	err := withLock(ctx, db, func(conn *gorm.DB) error {
+		current, err := Version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range Migrations() {
+			if m.Version <= current {
+				continue
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := m.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&schemaVersion{
+					Version:     m.Version,
+					Description: m.Description,
+					AppliedAt:   time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the given number of migrations, starting from the newest applied one
+func Down(ctx context.Context, db *gorm.DB, steps int) (int, error) {
+	var reverted int
+	err := withLock(ctx, db, func(conn *gorm.DB) error {
+		current, err := Version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		ms := Migrations()
+		for i := len(ms) - 1; i >= 0 && reverted < steps; i-- {
+			m := ms[i]
+			if m.Version > current {
+				continue
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+				return tx.Where("version = ?", m.Version).Delete(&schemaVersion{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %d (%s) failed: %w", m.Version, m.Description, err)
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// withLock runs fn on a single connection while holding a database wide advisory lock,
+// so that instances starting at the same time don't apply the same migration twice.
+func withLock(ctx context.Context, db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.WithContext(ctx).Connection(func(conn *gorm.DB) (err error) {
+		unlock, err := lock(conn)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if uerr := unlock(); err == nil {
+				err = uerr
+			}
+		}()
+
+		if !conn.Migrator().HasTable(&schemaVersion{}) {
+			if err := conn.Migrator().CreateTable(&schemaVersion{}); err != nil {
+				return err
+			}
+		}
+		return fn(conn)
+	})
+}
+
+func lock(conn *gorm.DB) (func() error, error) {
+	switch conn.Dialector.Name() {
+	case "mysql":
+		var acquired sql.NullInt64
+		if err := conn.Raw("SELECT GET_LOCK(?, ?)", lockName, lockTimeout).Scan(&acquired).Error; err != nil {
+			return nil, err
+		}
+		if acquired.Int64 != 1 {
+			return nil, errLockTimeout
+		}
+		return func() error {
+			return conn.Exec("SELECT RELEASE_LOCK(?)", lockName).Error
+		}, nil
+	case "postgres":
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+			return nil, err
+		}
+		return func() error {
+			return conn.Exec("SELECT pg_advisory_unlock(?)", lockKey).Error
+		}, nil
+	default:
+		// sqlite locks the whole database on write, and we only ever open a single connection to it
+		return func() error { return nil }, nil
+	}
+}
@@ -0,0 +1,149 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestUpAppliesEveryMigration(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+
+	applied, err := Up(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, len(Migrations()), applied)
+
+	version, err := Version(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, Latest(), version)
+	require.NoError(t, Verify(ctx, db))
+
+	for _, table := range []string{"users", "flag_monitors", "inventories", "transactions"} {
+		assert.True(t, db.Migrator().HasTable(table), table)
+	}
+
+	// running it again is a no-op
+	applied, err = Up(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, 0, applied)
+}
+
+func TestVerifyUnmigratedDatabase(t *testing.T) {
+	err := Verify(context.Background(), newDB(t))
+	require.True(t, errors.Is(err, ErrSchemaOutdated))
+}
+
+func TestDownRevertsMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	_, err := Up(ctx, db)
+	require.NoError(t, err)
+
+	reverted, err := Down(ctx, db, len(Migrations())+1)
+	require.NoError(t, err)
+	assert.Equal(t, len(Migrations()), reverted)
+
+	version, err := Version(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), version)
+	assert.False(t, db.Migrator().HasTable("users"))
+	require.True(t, errors.Is(Verify(ctx, db), ErrSchemaOutdated))
+}
+
+func TestBaselineKeepsExistingTables(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	// databases created before migrations existed already contain the tables and their data
+	require.NoError(t, db.Migrator().CreateTable(&v1User{}))
+	require.NoError(t, db.Create(&v1User{ID: uuid.NewString(), Email: "legacy@zssn.io"}).Error)
+
+	_, err := Up(ctx, db)
+	require.NoError(t, err)
+
+	var count int64
+	require.NoError(t, db.Table("users").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestSurvivorStatusBackfill(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	_, err := Up(ctx, db)
+	require.NoError(t, err)
+	// revert everything up to and including the status migration
+	_, err = Down(ctx, db, int(Latest()-survivorStatus.Version+1))
+	require.NoError(t, err)
+	assert.False(t, db.Migrator().HasColumn("users", "status"))
+
+	require.NoError(t, db.Create(&v1User{ID: "infected", Email: "infected@zssn.io", Infected: true}).Error)
+	require.NoError(t, db.Create(&v1User{ID: "clean", Email: "clean@zssn.io"}).Error)
+	_, err = Up(ctx, db)
+	require.NoError(t, err)
+
+	var statuses []v4User
+	require.NoError(t, db.Order("id").Find(&statuses).Error)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "healthy", statuses[0].Status)
+	assert.Equal(t, "infected", statuses[1].Status)
+}
+
+func TestTransitionEffectsBackfill(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	_, err := Up(ctx, db)
+	require.NoError(t, err)
+	_, err = Down(ctx, db, int(Latest()-transitionEffects.Version+1))
+	require.NoError(t, err)
+
+	require.NoError(t, db.Create(&v4StatusTransition{ID: "existing", UserID: "infected", ToStatus: "infected", CreatedAt: time.Now()}).Error)
+	_, err = Up(ctx, db)
+	require.NoError(t, err)
+
+	// changes made before the effects were tracked aren't applied again
+	var res v14StatusTransition
+	require.NoError(t, db.First(&res, "id = ?", "existing").Error)
+	assert.NotNil(t, res.AppliedAt)
+}
+
+func TestConcurrentUp(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applied, err := Up(ctx, db)
+			assert.NoError(t, err)
+			mu.Lock()
+			total += applied
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, len(Migrations()), total)
+}
+
+func newDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+uuid.NewString()+"?mode=memory&_pragma=foreign_keys(1)"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
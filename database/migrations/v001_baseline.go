@@ -0,0 +1,82 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// baseline creates the schema the stores used to AutoMigrate.
+// Databases that were created before migrations existed already have these tables, so they are left untouched.
+var baseline = Migration{
+	Version:     1,
+	Description: "baseline schema",
+	Up: func(tx *gorm.DB) error {
+		for _, table := range []interface{}{&v1User{}, &v1FlagMonitor{}, &v1Inventory{}, &v1Transaction{}} {
+			if tx.Migrator().HasTable(table) {
+				continue
+			}
+			if err := tx.Migrator().CreateTable(table); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&v1Transaction{}, &v1Inventory{}, &v1FlagMonitor{}, &v1User{})
+	},
+}
+
+type v1User struct {
+	ID          string `gorm:"primaryKey"`
+	Email       string `gorm:"size:50;uniqueIndex"`
+	Name        string
+	Age         uint32
+	Gender      int
+	Latitude    float64
+	Longitude   float64
+	FlagMonitor []v1FlagMonitor `gorm:"foreignKey:InfectedUserID"`
+	Infected    bool
+	gorm.Model
+}
+
+func (v1User) TableName() string {
+	return "users"
+}
+
+type v1FlagMonitor struct {
+	ID             string  `gorm:"primaryKey"`
+	UserID         string  `gorm:"size:50; index:idx_flagged,unique; "`
+	User           *v1User `gorm:"foreignKey:UserID"`
+	InfectedUserID string  `gorm:"size:50; index:idx_flagged,unique"`
+	InfectedUser   *v1User `gorm:"foreignKey:InfectedUserID"`
+	gorm.Model
+}
+
+func (v1FlagMonitor) TableName() string {
+	return "flag_monitors"
+}
+
+type v1Inventory struct {
+	ID         string `gorm:"primaryKey"`
+	UserID     string `gorm:"size:50;index:idx_user_item,unique"`
+	Item       int    `gorm:"index:idx_user_item,unique"`
+	Quantity   uint32
+	Balance    uint32
+	Accessible bool `gorm:"column:is_accessible"`
+	gorm.Model
+}
+
+func (v1Inventory) TableName() string {
+	return "inventories"
+}
+
+type v1Transaction struct {
+	ID        string `gorm:"primaryKey"`
+	Reference string
+	SellerID  string
+	BuyerID   string
+	Item      int
+	Quantity  uint32
+	gorm.Model
+}
+
+func (v1Transaction) TableName() string {
+	return "transactions"
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// infectionAudits records the decisions of the infection policies
+var infectionAudits = Migration{
+	Version:     2,
+	Description: "infection audits",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&v2InfectionAudit{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&v2InfectionAudit{})
+	},
+}
+
+type v2InfectionAudit struct {
+	ID        string `gorm:"primaryKey"`
+	UserID    string `gorm:"size:50;index"`
+	ActorID   string `gorm:"size:50"`
+	Action    string `gorm:"size:50"`
+	Policy    string `gorm:"size:50"`
+	Infected  bool
+	Score     float64
+	Required  float64
+	Inputs    string
+	CreatedAt time.Time
+}
+
+func (v2InfectionAudit) TableName() string {
+	return "infection_audits"
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// appeals adds the infection appeals and the votes of the survivors on them
+var appeals = Migration{
+	Version:     3,
+	Description: "infection appeals",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&v3Appeal{}, &v3AppealVote{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&v3AppealVote{}, &v3Appeal{})
+	},
+}
+
+type v3Appeal struct {
+	ID         string `gorm:"primaryKey"`
+	UserID     string `gorm:"size:50;index"`
+	Reason     string
+	Status     string `gorm:"size:20"`
+	ResolvedBy string `gorm:"size:50"`
+	ResolvedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (v3Appeal) TableName() string {
+	return "appeals"
+}
+
+type v3AppealVote struct {
+	ID        string `gorm:"primaryKey"`
+	AppealID  string `gorm:"size:50;index:idx_appeal_voter,unique"`
+	VoterID   string `gorm:"size:50;index:idx_appeal_voter,unique"`
+	Approve   bool
+	CreatedAt time.Time
+}
+
+func (v3AppealVote) TableName() string {
+	return "appeal_votes"
+}
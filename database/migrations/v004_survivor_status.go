@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// survivorStatus replaces the infected flag with a status and records every status transition.
+// The infected column is kept in sync with the status, existing infected survivors start out as infected.
+var survivorStatus = Migration{
+	Version:     4,
+	Description: "survivor status and transitions",
+	Up: func(tx *gorm.DB) error {
+		m := tx.Migrator()
+		if err := m.AddColumn(&v4User{}, "Status"); err != nil {
+			return err
+		}
+		if err := m.CreateIndex(&v4User{}, "Status"); err != nil {
+			return err
+		}
+		if err := tx.Model(&v4User{}).Where("infected = ?", true).Update("status", "infected").Error; err != nil {
+			return err
+		}
+		return m.CreateTable(&v4StatusTransition{})
+	},
+	Down: func(tx *gorm.DB) error {
+		m := tx.Migrator()
+		if err := m.DropTable(&v4StatusTransition{}); err != nil {
+			return err
+		}
+		if err := m.DropIndex(&v4User{}, "Status"); err != nil {
+			return err
+		}
+		return m.DropColumn(&v4User{}, "Status")
+	},
+}
+
+type v4User struct {
+	ID       string `gorm:"primaryKey"`
+	Infected bool
+	Status   string `gorm:"size:20;not null;default:healthy;index"`
+}
+
+func (v4User) TableName() string {
+	return "users"
+}
+
+type v4StatusTransition struct {
+	ID         string `gorm:"primaryKey"`
+	UserID     string `gorm:"size:50;index"`
+	ActorID    string `gorm:"size:50"`
+	FromStatus string `gorm:"size:20"`
+	ToStatus   string `gorm:"size:20"`
+	Reason     string
+	CreatedAt  time.Time
+}
+
+func (v4StatusTransition) TableName() string {
+	return "status_transitions"
+}
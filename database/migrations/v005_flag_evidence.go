@@ -0,0 +1,39 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// flagEvidence lets flaggers add a note, their location and a severity to a flag
+var flagEvidence = Migration{
+	Version:     5,
+	Description: "flag evidence",
+	Up: func(tx *gorm.DB) error {
+		m := tx.Migrator()
+		for _, field := range []string{"Note", "Latitude", "Longitude", "Severity"} {
+			if err := m.AddColumn(&v5FlagMonitor{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		m := tx.Migrator()
+		for _, field := range []string{"Severity", "Longitude", "Latitude", "Note"} {
+			if err := m.DropColumn(&v5FlagMonitor{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+type v5FlagMonitor struct {
+	ID        string `gorm:"primaryKey"`
+	Note      string
+	Latitude  *float64
+	Longitude *float64
+	Severity  string `gorm:"size:10;not null;default:medium"`
+}
+
+func (v5FlagMonitor) TableName() string {
+	return "flag_monitors"
+}
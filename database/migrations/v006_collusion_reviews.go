@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// collusionReviews adds the queue of suspicious flaggers found by the collusion detection
+var collusionReviews = Migration{
+	Version:     6,
+	Description: "collusion reviews",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&v6CollusionReview{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&v6CollusionReview{})
+	},
+}
+
+type v6CollusionReview struct {
+	ID         string `gorm:"primaryKey"`
+	UserID     string `gorm:"size:50;uniqueIndex"`
+	Signals    string
+	Targets    string
+	Score      float64
+	Status     string `gorm:"size:20;index"`
+	ReviewedBy string `gorm:"size:50"`
+	ReviewedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (v6CollusionReview) TableName() string {
+	return "collusion_reviews"
+}
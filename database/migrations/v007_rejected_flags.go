@@ -0,0 +1,29 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// rejectedFlags counts the flags every survivor raised that were overturned, the reputation score is based on it
+var rejectedFlags = Migration{
+	Version:     7,
+	Description: "rejected flags",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().AddColumn(&v7User{}, "RejectedFlags")
+	},
+	Down: func(tx *gorm.DB) error {
+		// the sqlite migrator drops columns by recreating the table, which loses every index on users.
+		// sqlite can drop unindexed columns on its own.
+		if tx.Dialector.Name() == "sqlite" {
+			return tx.Exec("ALTER TABLE users DROP COLUMN rejected_flags").Error
+		}
+		return tx.Migrator().DropColumn(&v7User{}, "RejectedFlags")
+	},
+}
+
+type v7User struct {
+	ID            string `gorm:"primaryKey"`
+	RejectedFlags int    `gorm:"not null;default:0"`
+}
+
+func (v7User) TableName() string {
+	return "users"
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// locationHistory adds the trail of every location update
+var locationHistory = Migration{
+	Version:     8,
+	Description: "location history",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&v8LocationPoint{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&v8LocationPoint{})
+	},
+}
+
+type v8LocationPoint struct {
+	ID        string `gorm:"primaryKey"`
+	UserID    string `gorm:"size:50;index:idx_location_history_user_time,priority:1"`
+	Latitude  float64
+	Longitude float64
+	CreatedAt time.Time `gorm:"index:idx_location_history_user_time,priority:2;index"`
+}
+
+func (v8LocationPoint) TableName() string {
+	return "location_history"
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// notifications adds the notifications sent to survivors, like exposure warnings from the contact tracing
+var notifications = Migration{
+	Version:     9,
+	Description: "notifications",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&v9Notification{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&v9Notification{})
+	},
+}
+
+type v9Notification struct {
+	ID        string `gorm:"primaryKey"`
+	UserID    string `gorm:"size:50;uniqueIndex:idx_notifications_source,priority:1"`
+	Kind      string `gorm:"size:30;uniqueIndex:idx_notifications_source,priority:2"`
+	SourceID  string `gorm:"size:50;uniqueIndex:idx_notifications_source,priority:3"`
+	Message   string
+	ReadAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (v9Notification) TableName() string {
+	return "notifications"
+}
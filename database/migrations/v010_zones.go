@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// zones adds the zones defined by the admins, the survivors currently inside them and the log of survivors entering and leaving them
+var zones = Migration{
+	Version:     10,
+	Description: "zones",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&v10Zone{}, &v10ZoneMember{}, &v10ZoneEvent{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&v10ZoneEvent{}, &v10ZoneMember{}, &v10Zone{})
+	},
+}
+
+type v10Zone struct {
+	ID           string `gorm:"primaryKey"`
+	Name         string `gorm:"size:100"`
+	Kind         string `gorm:"size:20;index"`
+	Latitude     float64
+	Longitude    float64
+	RadiusKm     float64
+	Vertices     string
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+	CreatedBy    string `gorm:"size:50"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (v10Zone) TableName() string {
+	return "zones"
+}
+
+type v10ZoneMember struct {
+	UserID    string `gorm:"primaryKey;size:50"`
+	ZoneID    string `gorm:"primaryKey;size:50;index"`
+	CreatedAt time.Time
+}
+
+func (v10ZoneMember) TableName() string {
+	return "zone_members"
+}
+
+type v10ZoneEvent struct {
+	ID        string `gorm:"primaryKey"`
+	ZoneID    string `gorm:"size:50;index:idx_zone_events_zone,priority:1"`
+	UserID    string `gorm:"size:50;index"`
+	Kind      string `gorm:"size:10"`
+	Latitude  float64
+	Longitude float64
+	CreatedAt time.Time `gorm:"index:idx_zone_events_zone,priority:2"`
+}
+
+func (v10ZoneEvent) TableName() string {
+	return "zone_events"
+}
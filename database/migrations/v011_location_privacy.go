@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// locationPrivacy lets every survivor choose how precisely others see their location and logs every time an admin sees it exactly.
+// Existing survivors start out approximate.
+var locationPrivacy = Migration{
+	Version:     11,
+	Description: "location privacy",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&v11User{}, "Visibility"); err != nil {
+			return err
+		}
+		return tx.Migrator().CreateTable(&v11LocationAudit{})
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropTable(&v11LocationAudit{}); err != nil {
+			return err
+		}
+		// like the rejected flags, the column is unindexed so sqlite can drop it without recreating the table
+		if tx.Dialector.Name() == "sqlite" {
+			return tx.Exec("ALTER TABLE users DROP COLUMN visibility").Error
+		}
+		return tx.Migrator().DropColumn(&v11User{}, "Visibility")
+	},
+}
+
+type v11User struct {
+	ID         string `gorm:"primaryKey"`
+	Visibility string `gorm:"size:20;not null;default:approximate"`
+}
+
+func (v11User) TableName() string {
+	return "users"
+}
+
+type v11LocationAudit struct {
+	ID        string    `gorm:"primaryKey"`
+	AdminID   string    `gorm:"size:50;index"`
+	UserID    string    `gorm:"size:50;index:idx_location_audits_user,priority:1"`
+	Resource  string    `gorm:"size:50"`
+	CreatedAt time.Time `gorm:"index:idx_location_audits_user,priority:2"`
+}
+
+func (v11LocationAudit) TableName() string {
+	return "location_audits"
+}
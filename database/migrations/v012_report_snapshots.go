@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// reportSnapshots adds the hourly rollups of the reports, so the outbreak and the supplies can be charted over time
+var reportSnapshots = Migration{
+	Version:     12,
+	Description: "report snapshots",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&v12ReportSnapshot{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&v12ReportSnapshot{})
+	},
+}
+
+type v12ReportSnapshot struct {
+	ID         string    `gorm:"primaryKey"`
+	Hour       time.Time `gorm:"uniqueIndex"`
+	Total      uint32
+	Clean      uint32
+	Infected   uint32
+	LostPoints uint32
+	Resources  string
+	CreatedAt  time.Time
+}
+
+func (v12ReportSnapshot) TableName() string {
+	return "report_snapshots"
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// userRoles keeps admin rights on the user row, so they can't be claimed by anyone who knows an admin's email.
+// Everyone starts out a survivor, admins have to be granted again from the command line.
+var userRoles = Migration{
+	Version:     13,
+	Description: "user roles",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().AddColumn(&v13User{}, "Role")
+	},
+	Down: func(tx *gorm.DB) error {
+		// unindexed like the visibility, so sqlite can drop it in place
+		if tx.Dialector.Name() == "sqlite" {
+			return tx.Exec("ALTER TABLE users DROP COLUMN role").Error
+		}
+		return tx.Migrator().DropColumn(&v13User{}, "Role")
+	},
+}
+
+type v13User struct {
+	ID   string `gorm:"primaryKey"`
+	Role string `gorm:"size:20;not null;default:survivor"`
+}
+
+func (v13User) TableName() string {
+	return "users"
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// transitionEffects records when the effects of a status change, like blocking the inventory, have been applied,
+// so the changes whose effects failed can be retried. The effects of the existing changes were applied when they were made.
+var transitionEffects = Migration{
+	Version:     14,
+	Description: "status transition effects",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&v14StatusTransition{}, "AppliedAt"); err != nil {
+			return err
+		}
+		return tx.Model(&v14StatusTransition{}).Where("applied_at IS NULL").Update("applied_at", gorm.Expr("created_at")).Error
+	},
+	Down: func(tx *gorm.DB) error {
+		// unindexed like the roles, so sqlite can drop it in place
+		if tx.Dialector.Name() == "sqlite" {
+			return tx.Exec("ALTER TABLE status_transitions DROP COLUMN applied_at").Error
+		}
+		return tx.Migrator().DropColumn(&v14StatusTransition{}, "AppliedAt")
+	},
+}
+
+type v14StatusTransition struct {
+	ID        string `gorm:"primaryKey"`
+	AppliedAt *time.Time
+}
+
+func (v14StatusTransition) TableName() string {
+	return "status_transitions"
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"os"
+
+	"zssn/database/migrations"
+
+	"gorm.io/gorm"
+)
+
+// TestConfig returns the database the test suites should run against.
+// TEST_DB_DRIVER and TEST_DB_DSN select the backend, a private in-memory sqlite database is used by default.
+func TestConfig() Config {
+	driver := os.Getenv("TEST_DB_DRIVER")
+	if driver == "" {
+		return Config{
+			Driver: DriverSQLite,
+			DSN:    MemoryDSN,
+		}
+	}
+	return Config{
+		Driver: driver,
+		DSN:    os.Getenv("TEST_DB_DSN"),
+	}
+}
+
+// OpenTest opens the test database and migrates it to the latest schema version
+func OpenTest() (*gorm.DB, error) {
+	db, err := Open(TestConfig())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := migrations.Up(context.Background(), db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
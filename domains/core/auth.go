@@ -12,6 +12,8 @@ var SigningSecret string
 
 type TokenData struct {
 	UserID, Email string
+	// Admin is only set on the tokens issued from the command line, the API never hands out admin tokens
+	Admin bool
 }
 
 // AuthClaims extra claims struct for using standard claims
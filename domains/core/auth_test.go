@@ -30,6 +30,7 @@ func TestDecodeToken(t *testing.T) {
 	td := &TokenData{
 		UserID: userID,
 		Email:  email,
+		Admin:  true,
 	}
 	token, err := td.Generate()
 	require.NoError(t, err)
@@ -40,4 +41,5 @@ func TestDecodeToken(t *testing.T) {
 	require.NotNil(t, tk)
 	assert.Equal(t, td.UserID, tk.UserID)
 	assert.Equal(t, td.Email, tk.Email)
+	assert.True(t, tk.Admin)
 }
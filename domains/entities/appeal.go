@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"time"
+
+	"zssn/domains/users/store"
+)
+
+// Appeal service entity for an infection appeal
+type Appeal struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	Approvals  int        `json:"approvals"`
+	Rejections int        `json:"rejections"`
+	ResolvedBy string     `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Transition the status change resolving the appeal caused, nil if there was none
+	Transition *StatusTransition `json:"-"`
+}
+
+// FromAppealDBEntity returns a service entity from the db entity
+func FromAppealDBEntity(m *store.Appeal) *Appeal {
+	if m == nil {
+		return nil
+	}
+	a := &Appeal{
+		ID:         m.ID,
+		UserID:     m.UserID,
+		Reason:     m.Reason,
+		Status:     string(m.Status),
+		ResolvedBy: m.ResolvedBy,
+		ResolvedAt: m.ResolvedAt,
+		CreatedAt:  m.CreatedAt,
+	}
+	for _, v := range m.Votes {
+		if v.Approve {
+			a.Approvals++
+		} else {
+			a.Rejections++
+		}
+	}
+	return a
+}
@@ -0,0 +1,48 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"zssn/domains/users/store"
+)
+
+// CollusionReview service entity for a flagger waiting for or cleared by an admin review
+type CollusionReview struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Signals    []string   `json:"signals"`
+	Targets    []string   `json:"targets"`
+	Score      float64    `json:"score"`
+	Status     string     `json:"status"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// FromCollusionReviewDBEntity returns a service entity from the db entity
+func FromCollusionReviewDBEntity(m *store.CollusionReview) *CollusionReview {
+	if m == nil {
+		return nil
+	}
+	return &CollusionReview{
+		ID:         m.ID,
+		UserID:     m.UserID,
+		Signals:    splitList(m.Signals),
+		Targets:    splitList(m.Targets),
+		Score:      m.Score,
+		Status:     string(m.Status),
+		ReviewedBy: m.ReviewedBy,
+		ReviewedAt: m.ReviewedAt,
+		CreatedAt:  m.CreatedAt,
+		UpdatedAt:  m.UpdatedAt,
+	}
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"zssn/domains/users/store"
+)
+
+// FlagEvidence the optional context a flagger gives with a flag.
+// The flagger's last known location is used when no location is given.
+type FlagEvidence struct {
+	Note      string
+	Latitude  *float64
+	Longitude *float64
+	Severity  string
+}
+
+// Flag service entity for a flag and its evidence, FlaggerID is empty when the flagger is hidden
+type Flag struct {
+	ID             string    `json:"id"`
+	FlaggerID      string    `json:"flagger_id,omitempty"`
+	InfectedUserID string    `json:"infected_user_id"`
+	Note           string    `json:"note,omitempty"`
+	Latitude       *float64  `json:"latitude,omitempty"`
+	Longitude      *float64  `json:"longitude,omitempty"`
+	Severity       string    `json:"severity"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// FromFlagDBEntity returns a service entity from the db entity
+func FromFlagDBEntity(m *store.FlagMonitor) *Flag {
+	if m == nil {
+		return nil
+	}
+	return &Flag{
+		ID:             m.ID,
+		FlaggerID:      m.UserID,
+		InfectedUserID: m.InfectedUserID,
+		Note:           m.Note,
+		Latitude:       m.Latitude,
+		Longitude:      m.Longitude,
+		Severity:       string(m.Severity),
+		CreatedAt:      m.CreatedAt,
+	}
+}
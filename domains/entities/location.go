@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"zssn/domains/users/store"
+)
+
+// LocationPoint service entity for a location in a survivor's trail
+type LocationPoint struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FromLocationPointDBEntity returns a service entity from the db entity
+func FromLocationPointDBEntity(m *store.LocationPoint) *LocationPoint {
+	if m == nil {
+		return nil
+	}
+	return &LocationPoint{
+		Latitude:  m.Latitude,
+		Longitude: m.Longitude,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// LocationAudit service entity for an admin seeing the exact location of a survivor
+type LocationAudit struct {
+	AdminID   string    `json:"admin_id"`
+	Resource  string    `json:"resource"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FromLocationAuditDBEntity returns a service entity from the db entity
+func FromLocationAuditDBEntity(m *store.LocationAudit) *LocationAudit {
+	if m == nil {
+		return nil
+	}
+	return &LocationAudit{
+		AdminID:   m.AdminID,
+		Resource:  m.Resource,
+		CreatedAt: m.CreatedAt,
+	}
+}
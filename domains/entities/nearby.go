@@ -0,0 +1,9 @@
+package entities
+
+// NearbySurvivor a survivor close to the requester. The distance is coarse and the exact location is left out
+type NearbySurvivor struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	DistanceKm float64 `json:"distance_km"`
+}
@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"time"
+
+	"zssn/domains/users/store"
+)
+
+// Notification service entity for a message sent to a survivor
+type Notification struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	SourceID  string     `json:"source_id"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// FromNotificationDBEntity returns a service entity from the db entity
+func FromNotificationDBEntity(m *store.Notification) *Notification {
+	if m == nil {
+		return nil
+	}
+	return &Notification{
+		ID:        m.ID,
+		Kind:      m.Kind,
+		SourceID:  m.SourceID,
+		Message:   m.Message,
+		ReadAt:    m.ReadAt,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// Exposure a survivor who traded with or stood close to an infected survivor
+type Exposure struct {
+	UserID string `json:"user_id"`
+	// Traded the survivor traded with the infected survivor
+	Traded bool `json:"traded"`
+	// LastCoLocated the last time the survivor was close to the infected survivor, nil if they never were
+	LastCoLocated *time.Time `json:"last_co_located,omitempty"`
+	// Suspected the survivor was marked suspected because of the exposure
+	Suspected bool `json:"suspected"`
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+
+	"zssn/domains/users/store"
+)
+
+// Profile public view of a survivor, it leaves out the email and the location
+type Profile struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Age         uint32    `json:"age"`
+	Gender      string    `json:"gender"`
+	Status      string    `json:"status"`
+	Reputation  float64   `json:"reputation"`
+	Trades      int       `json:"trades"`
+	MemberSince time.Time `json:"member_since"`
+}
+
+// FromUserDBEntityProfile returns the public profile of the db entity with the given reputation and number of trades
+func FromUserDBEntityProfile(m *store.User, reputation float64, trades int) *Profile {
+	if m == nil {
+		return nil
+	}
+	return &Profile{
+		ID:          m.ID,
+		Name:        m.Name,
+		Age:         m.Age,
+		Gender:      m.Gender.String(),
+		Status:      string(m.Status),
+		Reputation:  reputation,
+		Trades:      trades,
+		MemberSince: m.CreatedAt,
+	}
+}
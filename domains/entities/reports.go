@@ -1,6 +1,11 @@
 package entities
 
-import "zssn/domains/core"
+import (
+	"time"
+
+	"zssn/domains/core"
+	"zssn/domains/geo"
+)
 
 // Survivor struct for survivors rate
 type Survivor struct {
@@ -35,3 +40,129 @@ type ResourceSharing struct {
 	Balance     uint32 `json:"balance" gorm:"balance"`
 	PerSurvivor uint32 `json:"per_survivor"`
 }
+
+// ZoneReport the survivors currently inside a zone. The statuses include deceased survivors, the totals leave them out like the other reports
+type ZoneReport struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Kind     string            `json:"kind"`
+	Total    uint32            `json:"total_survivors"`
+	Infected uint32            `json:"infected_survivors"`
+	Statuses map[string]uint32 `json:"statuses"`
+}
+
+// ZoneSupplies the clean survivors inside a zone and the balances of their accessible inventories
+type ZoneSupplies struct {
+	ID        string
+	Name      string
+	Kind      string
+	Survivors uint32
+	Resources map[core.Item]uint32
+}
+
+// SurvivorLocation the last known location of a survivor with the balance of every item they can still trade
+type SurvivorLocation struct {
+	UserID    string
+	Latitude  float64
+	Longitude float64
+	Status    string
+	Resources map[core.Item]uint32
+}
+
+// HeatmapCell the survivors and resources inside one cell of the heatmap grid
+type HeatmapCell struct {
+	Cell      string            `json:"cell"`
+	Bounds    geo.Box           `json:"bounds"`
+	Total     uint32            `json:"total_survivors"`
+	Clean     uint32            `json:"clean"`
+	Infected  uint32            `json:"infected"`
+	Resources map[string]uint32 `json:"resources"`
+}
+
+// ReportSnapshot the reports at one point of a history series. Resources are keyed by the lower case item name
+type ReportSnapshot struct {
+	Time       time.Time         `json:"time"`
+	Total      uint32            `json:"total_survivors"`
+	Clean      uint32            `json:"clean"`
+	Infected   uint32            `json:"infected"`
+	LostPoints uint32            `json:"lost_points"`
+	Resources  map[string]uint32 `json:"resources"`
+}
+
+// Forecast how long the supplies last across the network and inside every zone
+type Forecast struct {
+	Network *SupplyForecast `json:"network"`
+	Zones   []*ZoneForecast `json:"zones"`
+}
+
+// ZoneForecast the supply forecast of the clean survivors inside a zone
+type ZoneForecast struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	SupplyForecast
+}
+
+// SupplyForecast how long the supplies of a group of clean survivors last
+type SupplyForecast struct {
+	Survivors uint32          `json:"survivors"`
+	Items     []*ItemForecast `json:"items"`
+	// RunsOutFirst the items with the fewest days left, empty when nothing is consumed
+	RunsOutFirst []string `json:"runs_out_first"`
+}
+
+// ItemForecast how long the balance of an item lasts.
+// The item is used up at the planned or the observed consumption, whichever is faster, DaysLeft is nil when nobody consumes it.
+type ItemForecast struct {
+	Item          string  `json:"item"`
+	Balance       uint32  `json:"balance"`
+	PlannedPerDay float64 `json:"planned_per_day"`
+	// ObservedPerDay how much of the balance was used up a day over the forecast window, nil until enough history was recorded
+	ObservedPerDay *float64 `json:"observed_per_day,omitempty"`
+	DaysLeft       *float64 `json:"days_left"`
+	Shortage       bool     `json:"shortage"`
+}
+
+// TradeReport the trading activity of a time range, volumes are in points and count both sides of a trade
+type TradeReport struct {
+	Trades         uint32           `json:"trades"`
+	Volume         uint32           `json:"volume"`
+	Traders        uint32           `json:"traders"`
+	Series         []*TradePoint    `json:"series"`
+	TopItems       []*TradedItem    `json:"top_items"`
+	ExchangeRatios []*ExchangeRatio `json:"exchange_ratios"`
+	// TopTraders the most active traders, left out when the traders are hidden
+	TopTraders []*Trader `json:"top_traders,omitempty"`
+}
+
+// TradePoint the trades of an interval, placed at its start
+type TradePoint struct {
+	Time   time.Time `json:"time"`
+	Trades uint32    `json:"trades"`
+	Volume uint32    `json:"volume"`
+}
+
+// TradedItem how much of an item changed hands and in how many trades
+type TradedItem struct {
+	Item     string `json:"item"`
+	Quantity uint32 `json:"quantity"`
+	Trades   uint32 `json:"trades"`
+	Volume   uint32 `json:"volume"`
+}
+
+// ExchangeRatio how many of the received item one of the given item got in trades of one item for another
+type ExchangeRatio struct {
+	Given    string  `json:"given"`
+	Received string  `json:"received"`
+	Ratio    float64 `json:"ratio"`
+	Trades   uint32  `json:"trades"`
+}
+
+// Trader a survivor ranked by the trades they took part in, the ID and name are left out when the traders are anonymous
+type Trader struct {
+	Rank   int    `json:"rank"`
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Trades uint32 `json:"trades"`
+	Volume uint32 `json:"volume"`
+}
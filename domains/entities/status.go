@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"time"
+
+	"zssn/domains/users/store"
+)
+
+// StatusTransition service entity for a change of a survivor's status
+type StatusTransition struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	ActorID   string    `json:"actor_id,omitempty"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FromStatusTransitionDBEntity returns a service entity from the db entity
+func FromStatusTransitionDBEntity(m *store.StatusTransition) *StatusTransition {
+	if m == nil {
+		return nil
+	}
+	return &StatusTransition{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		ActorID:   m.ActorID,
+		From:      string(m.FromStatus),
+		To:        string(m.ToStatus),
+		Reason:    m.Reason,
+		CreatedAt: m.CreatedAt,
+	}
+}
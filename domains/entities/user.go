@@ -12,6 +12,8 @@ type User struct {
 	Latitude    float64       `json:"latitude"`
 	Longitude   float64       `json:"longitude"`
 	Infected    bool          `json:"infected"`
+	Status      string        `json:"status"`
+	Visibility  string        `json:"visibility"`
 	FlagMonitor []FlagMonitor `json:"flag_monitor"`
 }
 
@@ -23,6 +25,15 @@ type FlagMonitor struct {
 	InfectedUser   User   `json:"infected_user"`
 }
 
+// Clean reports whether the survivor can trade and move around.
+// Users without a status fall back to the infected flag.
+func (u *User) Clean() bool {
+	if u.Status == "" {
+		return !u.Infected
+	}
+	return store.Status(u.Status).Clean()
+}
+
 // ToUserDBEntity converts service entity to DB entity
 func (u *User) ToUserDBEntity() *store.User {
 	return &store.User{
@@ -42,14 +53,16 @@ func FromUserDBEntity(m *store.User) *User {
 		return nil
 	}
 	u := &User{
-		ID:        m.ID,
-		Email:     m.Email,
-		Name:      m.Name,
-		Age:       m.Age,
-		Gender:    m.Gender.String(),
-		Latitude:  m.Latitude,
-		Longitude: m.Longitude,
-		Infected:  m.Infected,
+		ID:         m.ID,
+		Email:      m.Email,
+		Name:       m.Name,
+		Age:        m.Age,
+		Gender:     m.Gender.String(),
+		Latitude:   m.Latitude,
+		Longitude:  m.Longitude,
+		Infected:   m.Infected,
+		Status:     string(m.Status),
+		Visibility: string(m.Visibility),
 	}
 	for _, v := range m.FlagMonitor {
 		u.FlagMonitor = append(u.FlagMonitor, FlagMonitor{
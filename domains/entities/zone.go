@@ -0,0 +1,81 @@
+package entities
+
+import (
+	"time"
+
+	"zssn/domains/geo"
+	"zssn/domains/users/store"
+)
+
+// Zone service entity for an area defined by an admin, either a circle around the center or a polygon
+type Zone struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Kind      string      `json:"kind"`
+	Center    *geo.Point  `json:"center,omitempty"`
+	RadiusKm  float64     `json:"radius_km,omitempty"`
+	Polygon   []geo.Point `json:"polygon,omitempty"`
+	CreatedBy string      `json:"created_by,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ToZoneDBEntity returns the db entity of the zone
+func (z *Zone) ToZoneDBEntity() *store.Zone {
+	res := &store.Zone{
+		Name:      z.Name,
+		Kind:      store.ZoneKind(z.Kind),
+		RadiusKm:  z.RadiusKm,
+		Vertices:  z.Polygon,
+		CreatedBy: z.CreatedBy,
+	}
+	if z.Center != nil {
+		res.Latitude, res.Longitude = z.Center.Latitude, z.Center.Longitude
+	}
+	return res
+}
+
+// FromZoneDBEntity returns a service entity from the db entity
+func FromZoneDBEntity(m *store.Zone) *Zone {
+	if m == nil {
+		return nil
+	}
+	res := &Zone{
+		ID:        m.ID,
+		Name:      m.Name,
+		Kind:      string(m.Kind),
+		CreatedBy: m.CreatedBy,
+		CreatedAt: m.CreatedAt,
+	}
+	if len(m.Vertices) > 0 {
+		res.Polygon = m.Vertices
+	} else {
+		res.Center = &geo.Point{Latitude: m.Latitude, Longitude: m.Longitude}
+		res.RadiusKm = m.RadiusKm
+	}
+	return res
+}
+
+// ZoneEvent service entity for a survivor entering or leaving a zone
+type ZoneEvent struct {
+	ZoneID    string    `json:"zone_id"`
+	UserID    string    `json:"user_id"`
+	Kind      string    `json:"kind"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FromZoneEventDBEntity returns a service entity from the db entity
+func FromZoneEventDBEntity(m *store.ZoneEvent) *ZoneEvent {
+	if m == nil {
+		return nil
+	}
+	return &ZoneEvent{
+		ZoneID:    m.ZoneID,
+		UserID:    m.UserID,
+		Kind:      string(m.Kind),
+		Latitude:  m.Latitude,
+		Longitude: m.Longitude,
+		CreatedAt: m.CreatedAt,
+	}
+}
@@ -0,0 +1,361 @@
+// Package export turns survivors, zones and trades into GeoJSON or KML documents that mapping tools can import.
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"zssn/domains/geo"
+	trStore "zssn/domains/trade/store"
+	"zssn/domains/users"
+	usrStore "zssn/domains/users/store"
+)
+
+// circleSides number of vertices circular zones are drawn with
+const circleSides = 64
+
+var (
+	// ErrInvalidTimeRange is returned when the end of the time range is before its start
+	ErrInvalidTimeRange = errors.New("the end of the time range cannot be before its start")
+	// ErrInvalidBox is returned when the bounding box is upside down or off the map
+	ErrInvalidBox = errors.New("bounding box must be west,south,east,north within -180,-90,180,90")
+)
+
+// Layer what an export contains
+type Layer string
+
+const (
+	// LayerSurvivors clean survivors as points, anonymous
+	LayerSurvivors Layer = "survivors"
+	// LayerInfected infected and quarantined survivors as points, identified
+	LayerInfected Layer = "infected"
+	// LayerTrades trades as lines between the partners
+	LayerTrades Layer = "trades"
+	// LayerZones zones as polygons, circles are approximated
+	LayerZones Layer = "zones"
+)
+
+// Layers every known layer
+var Layers = []Layer{LayerSurvivors, LayerInfected, LayerTrades, LayerZones}
+
+// ParseLayer returns the layer with the given name
+func ParseLayer(s string) (Layer, error) {
+	for _, v := range Layers {
+		if string(v) == s {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unknown export layer %q", s)
+}
+
+// Format the file format of an export
+type Format string
+
+const (
+	// FormatGeoJSON RFC 7946 GeoJSON
+	FormatGeoJSON Format = "geojson"
+	// FormatKML KML 2.2 as read by Google Earth
+	FormatKML Format = "kml"
+)
+
+// ParseFormat returns the format with the given name, an empty name is GeoJSON
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case "", FormatGeoJSON:
+		return FormatGeoJSON, nil
+	case FormatKML:
+		return FormatKML, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q", s)
+	}
+}
+
+// ContentType returns the media type of the format
+func (f Format) ContentType() string {
+	if f == FormatKML {
+		return "application/vnd.google-earth.kml+xml"
+	}
+	return "application/geo+json"
+}
+
+// Extension returns the file extension of the format
+func (f Format) Extension() string {
+	if f == FormatKML {
+		return ".kml"
+	}
+	return ".geojson"
+}
+
+// Filter narrows an export down.
+// Survivors are placed at the last location they reported in the time range, trades are the ones made in it.
+// Zones are always exported as they are now, only the box applies to them.
+type Filter struct {
+	// From and To bound the time range, zero times leave it open
+	From time.Time
+	To   time.Time
+	// Box only features inside the box are exported, nil exports everything
+	Box *geo.Box
+}
+
+// Validate checks the time range and the box
+func (f Filter) Validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return ErrInvalidTimeRange
+	}
+	if f.Box != nil && !f.Box.Valid() {
+		return ErrInvalidBox
+	}
+	return nil
+}
+
+func (f Filter) timed() bool {
+	return !f.From.IsZero() || !f.To.IsZero()
+}
+
+func (f Filter) contains(p geo.Point) bool {
+	return f.Box == nil || f.Box.Contains(p)
+}
+
+var _ IExportService = (*ExportService)(nil)
+
+// ExportService builds the exports on top of the user and trade storages
+type ExportService struct {
+	Users  usrStore.IUserStorage
+	Trades trStore.ITradeStorage
+
+	isAdmin              AdminFunc
+	approximatePrecision int
+}
+
+// AdminFunc reports whether the survivor is an admin, admins export exact locations
+type AdminFunc func(ctx context.Context, id string) (bool, error)
+
+// Option configures the export service
+type Option func(*ExportService)
+
+// WithAdmins sets how admins are recognized, without it nobody exports exact locations of others
+func WithAdmins(fn AdminFunc) Option {
+	return func(e *ExportService) {
+		e.isAdmin = fn
+	}
+}
+
+// WithApproximatePrecision sets the geohash length approximate locations are snapped to, values out of range are ignored
+func WithApproximatePrecision(precision int) Option {
+	return func(e *ExportService) {
+		if precision >= 1 && precision <= users.MaxApproximatePrecision {
+			e.approximatePrecision = precision
+		}
+	}
+}
+
+// New returns a new service implementation
+func New(userStorage usrStore.IUserStorage, trades trStore.ITradeStorage, opts ...Option) IExportService {
+	e := &ExportService{
+		Users:                userStorage,
+		Trades:               trades,
+		approximatePrecision: users.DefaultApproximatePrecision,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export implements IExportService
+func (e *ExportService) Export(ctx context.Context, requesterID string, layer Layer, filter Filter) (*geo.FeatureCollection, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if layer == LayerZones {
+		return e.zones(ctx, filter)
+	}
+
+	viewer, err := e.viewer(ctx, requesterID, layer)
+	if err != nil {
+		return nil, err
+	}
+	var fc *geo.FeatureCollection
+	switch layer {
+	case LayerSurvivors:
+		fc, err = e.survivors(ctx, viewer, filter, false)
+	case LayerInfected:
+		fc, err = e.survivors(ctx, viewer, filter, true)
+	case LayerTrades:
+		fc, err = e.trades(ctx, viewer, filter)
+	default:
+		return nil, fmt.Errorf("unknown export layer %q", layer)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := viewer.Save(ctx, e.Users); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+// viewer returns the location viewer of the requester, an empty requester is an anonymous export like the CLI one
+func (e *ExportService) viewer(ctx context.Context, requesterID string, layer Layer) (*users.LocationViewer, error) {
+	admin := false
+	if requesterID != "" && e.isAdmin != nil {
+		var err error
+		if admin, err = e.isAdmin(ctx, requesterID); err != nil {
+			return nil, err
+		}
+	}
+	return users.NewLocationViewer(requesterID, admin, "exports/"+string(layer), e.approximatePrecision), nil
+}
+
+// survivors exports the clean or the infected survivors where the requester is allowed to see them, hidden ones are left out.
+// Clean survivors aren't identified, infected survivors carry their ID so field teams can report on them.
+func (e *ExportService) survivors(ctx context.Context, viewer *users.LocationViewer, filter Filter, infected bool) (*geo.FeatureCollection, error) {
+	statuses := usrStore.CleanStatuses()
+	if infected {
+		statuses = []usrStore.Status{usrStore.StatusInfected, usrStore.StatusQuarantined}
+	}
+	users, err := e.Users.FindByStatus(ctx, statuses...)
+	if err != nil {
+		return nil, err
+	}
+	var points map[string]*usrStore.LocationPoint
+	if filter.timed() {
+		if points, err = e.Users.FindLastLocationPoints(ctx, filter.From, filter.To); err != nil {
+			return nil, err
+		}
+	}
+
+	var features []*geo.Feature
+	for _, u := range users {
+		p, ok := geo.Point{Latitude: u.Latitude, Longitude: u.Longitude}, true
+		props := map[string]interface{}{"status": string(u.Status)}
+		if filter.timed() {
+			var point *usrStore.LocationPoint
+			if point, ok = points[u.ID]; !ok {
+				continue
+			}
+			p = geo.Point{Latitude: point.Latitude, Longitude: point.Longitude}
+			props["reported_at"] = point.CreatedAt.UTC().Format(time.RFC3339)
+		}
+		// the box is matched against the location the requester gets to see, so it can't narrow down a fuzzed one
+		if p, ok = viewer.Location(u.ID, u.Visibility, p); !ok || !filter.contains(p) {
+			continue
+		}
+		id := u.ID
+		if !infected {
+			id = ""
+		}
+		features = append(features, geo.NewFeature(id, geo.PointGeometry(p), props))
+	}
+	return geo.NewFeatureCollection(features...), nil
+}
+
+// trades exports every trade as a line between the last known locations of the partners as the requester sees them.
+// A trade is kept if either partner is inside the box, and left out if either of them hides their location.
+func (e *ExportService) trades(ctx context.Context, viewer *users.LocationViewer, filter Filter) (*geo.FeatureCollection, error) {
+	trans, err := e.Trades.FindTransactions(ctx, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		refs   []string
+		byRef  = make(map[string][]*trStore.Transaction)
+		ids    []string
+		seenID = make(map[string]bool)
+	)
+	for _, v := range trans {
+		if _, ok := byRef[v.Reference]; !ok {
+			refs = append(refs, v.Reference)
+		}
+		byRef[v.Reference] = append(byRef[v.Reference], v)
+		for _, id := range []string{v.SellerID, v.BuyerID} {
+			if !seenID[id] {
+				seenID[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return geo.NewFeatureCollection(), nil
+	}
+	partners, err := e.Users.FindUsers(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	var features []*geo.Feature
+	for _, ref := range refs {
+		rows := byRef[ref]
+		// both sides of a trade are written at the same time, so the line runs between the partners in ID order to stay the same between exports
+		fromID, toID := rows[0].SellerID, rows[0].BuyerID
+		if toID < fromID {
+			fromID, toID = toID, fromID
+		}
+		from, to := partners[fromID], partners[toID]
+		if from == nil || to == nil {
+			continue
+		}
+		a := geo.Point{Latitude: from.Latitude, Longitude: from.Longitude}
+		b := geo.Point{Latitude: to.Latitude, Longitude: to.Longitude}
+		a, okA := viewer.Location(from.ID, from.Visibility, a)
+		b, okB := viewer.Location(to.ID, to.Visibility, b)
+		if !okA || !okB {
+			continue
+		}
+		if !filter.contains(a) && !filter.contains(b) {
+			continue
+		}
+		sent, received := map[string]uint32{}, map[string]uint32{}
+		for _, v := range rows {
+			item := strings.ToLower(v.Item.String())
+			if v.SellerID == from.ID {
+				sent[item] += v.Quantity
+			} else {
+				received[item] += v.Quantity
+			}
+		}
+		features = append(features, geo.NewFeature(ref, geo.LineGeometry(a, b), map[string]interface{}{
+			"traded_at": rows[0].CreatedAt.UTC().Format(time.RFC3339),
+			"sent":      sent,
+			"received":  received,
+		}))
+	}
+	return geo.NewFeatureCollection(features...), nil
+}
+
+// zones exports every zone touching the box as a polygon
+func (e *ExportService) zones(ctx context.Context, filter Filter) (*geo.FeatureCollection, error) {
+	zones, err := e.Users.FindZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var features []*geo.Feature
+	for _, z := range zones {
+		if filter.Box != nil && !filter.Box.Intersects(z.Box()) {
+			continue
+		}
+		props := map[string]interface{}{"name": z.Name, "kind": string(z.Kind)}
+		vertices := z.Vertices
+		if len(vertices) == 0 {
+			vertices = geo.Circle(geo.Point{Latitude: z.Latitude, Longitude: z.Longitude}, z.RadiusKm, circleSides)
+			props["radius_km"] = z.RadiusKm
+		}
+		features = append(features, geo.NewFeature(z.ID, geo.PolygonGeometry(vertices), props))
+	}
+	return geo.NewFeatureCollection(features...), nil
+}
+
+// Write encodes the features in the format, name is the title of KML documents
+func Write(w io.Writer, format Format, name string, fc *geo.FeatureCollection) error {
+	if format == FormatKML {
+		return geo.WriteKML(w, name, fc)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fc)
+}
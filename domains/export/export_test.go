@@ -0,0 +1,262 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"zssn/database"
+	"zssn/domains/core"
+	"zssn/domains/export"
+	"zssn/domains/geo"
+	trStore "zssn/domains/trade/store"
+	usrStore "zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	svc                        export.IExportService
+	users                      usrStore.IUserStorage
+	healthy, recovered, zombie *usrStore.User
+	trade                      string
+}
+
+func newFixture(t *testing.T, opts ...export.Option) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	users, err := usrStore.New(db)
+	require.NoError(t, err)
+	trades, err := trStore.New(db)
+	require.NoError(t, err)
+
+	f := &fixture{svc: export.New(users, trades, opts...), users: users}
+	locations := []geo.Point{{Latitude: 52.52, Longitude: 13.405}, {Latitude: 48.8566, Longitude: 2.3522}, {Latitude: 52.5, Longitude: 13.4}}
+	for i, u := range []**usrStore.User{&f.healthy, &f.recovered, &f.zombie} {
+		*u = storetest.NewUser(t)
+		(*u).Latitude, (*u).Longitude = locations[i].Latitude, locations[i].Longitude
+		require.NoError(t, users.Create(ctx, *u))
+	}
+	require.NoError(t, users.UpdateStatus(ctx, f.recovered.ID, usrStore.StatusRecovered))
+	require.NoError(t, users.UpdateStatus(ctx, f.zombie.ID, usrStore.StatusInfected))
+	f.recovered.Status, f.zombie.Status = usrStore.StatusRecovered, usrStore.StatusInfected
+	require.NoError(t, users.UpdateVisibility(ctx, f.zombie.ID, usrStore.VisibilityExact))
+
+	seller := &trStore.TradeItems{UserID: f.healthy.ID, Items: []trStore.TradeItem{{Item: core.ItemWater, Quantity: 2}}}
+	buyer := &trStore.TradeItems{UserID: f.recovered.ID, Items: []trStore.TradeItem{{Item: core.ItemFood, Quantity: 1}}}
+	require.NoError(t, trades.Execute(ctx, seller, buyer))
+	f.trade = seller.Reference
+
+	require.NoError(t, users.CreateZone(ctx, &usrStore.Zone{Name: "camp", Kind: usrStore.ZoneSafe, Latitude: 52.52, Longitude: 13.405, RadiusKm: 1}))
+	return f
+}
+
+func point(t *testing.T, f *geo.Feature) geo.Point {
+	t.Helper()
+	require.Equal(t, "Point", f.Geometry.Type)
+	pos := f.Geometry.Coordinates.([2]float64)
+	return geo.Point{Latitude: pos[1], Longitude: pos[0]}
+}
+
+func TestExportSurvivors(t *testing.T) {
+	f := newFixture(t)
+	res, err := f.svc.Export(context.Background(), "", export.LayerSurvivors, export.Filter{})
+	require.NoError(t, err)
+	require.Len(t, res.Features, 2)
+
+	for i, u := range []*usrStore.User{f.healthy, f.recovered} {
+		feature := res.Features[i]
+		// clean survivors are anonymous, approximate locations are moved to the middle of their cell, at most a few hundred metres away
+		assert.Empty(t, feature.ID)
+		exact := geo.Point{Latitude: u.Latitude, Longitude: u.Longitude}
+		p := point(t, feature)
+		assert.NotEqual(t, exact, p)
+		assert.Less(t, geo.Distance(exact, p), 1.0)
+		assert.Equal(t, string(u.Status), feature.Properties["status"])
+	}
+
+	res, err = f.svc.Export(context.Background(), "", export.LayerInfected, export.Filter{})
+	require.NoError(t, err)
+	require.Len(t, res.Features, 1)
+	assert.Equal(t, f.zombie.ID, res.Features[0].ID)
+	assert.Equal(t, geo.Point{Latitude: 52.5, Longitude: 13.4}, point(t, res.Features[0]))
+
+	// a coarser precision moves survivors further
+	coarse := newFixture(t, export.WithApproximatePrecision(3))
+	res, err = coarse.svc.Export(context.Background(), "", export.LayerSurvivors, export.Filter{})
+	require.NoError(t, err)
+	require.Len(t, res.Features, 2)
+	assert.Greater(t, geo.Distance(geo.Point{Latitude: 52.52, Longitude: 13.405}, point(t, res.Features[0])), 1.0)
+}
+
+func TestExportFilter(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	berlin := &geo.Box{MinLongitude: 13, MinLatitude: 52, MaxLongitude: 14, MaxLatitude: 53}
+
+	res, err := f.svc.Export(ctx, "", export.LayerSurvivors, export.Filter{Box: berlin})
+	require.NoError(t, err)
+	require.Len(t, res.Features, 1)
+	assert.Equal(t, "healthy", res.Features[0].Properties["status"])
+
+	// the box is matched against the fuzzed location, a box around the exact one can't single anybody out
+	revealed := point(t, res.Features[0])
+	exact := &geo.Box{MinLongitude: 13.4049, MinLatitude: 52.5199, MaxLongitude: 13.4051, MaxLatitude: 52.5201}
+	require.NotEqual(t, geo.Point{Latitude: 52.52, Longitude: 13.405}, revealed)
+	res, err = f.svc.Export(ctx, "", export.LayerSurvivors, export.Filter{Box: exact})
+	require.NoError(t, err)
+	assert.Empty(t, res.Features)
+	around := &geo.Box{MinLongitude: revealed.Longitude - 0.0001, MinLatitude: revealed.Latitude - 0.0001, MaxLongitude: revealed.Longitude + 0.0001, MaxLatitude: revealed.Latitude + 0.0001}
+	res, err = f.svc.Export(ctx, "", export.LayerSurvivors, export.Filter{Box: around})
+	require.NoError(t, err)
+	require.Len(t, res.Features, 1)
+	assert.Equal(t, revealed, point(t, res.Features[0]))
+
+	// without location history nobody reported a location in the time range
+	now := time.Now()
+	res, err = f.svc.Export(ctx, "", export.LayerSurvivors, export.Filter{From: now.Add(-time.Hour), To: now})
+	require.NoError(t, err)
+	assert.Empty(t, res.Features)
+
+	// survivors are placed where they were at the end of the time range
+	require.NoError(t, f.users.CreateLocationPoint(ctx, &usrStore.LocationPoint{UserID: f.zombie.ID, Latitude: 10, Longitude: 20, CreatedAt: now.Add(-30 * time.Minute)}))
+	require.NoError(t, f.users.CreateLocationPoint(ctx, &usrStore.LocationPoint{UserID: f.zombie.ID, Latitude: 11, Longitude: 21, CreatedAt: now.Add(time.Hour)}))
+	res, err = f.svc.Export(ctx, "", export.LayerInfected, export.Filter{From: now.Add(-time.Hour), To: now})
+	require.NoError(t, err)
+	require.Len(t, res.Features, 1)
+	assert.Equal(t, geo.Point{Latitude: 10, Longitude: 20}, point(t, res.Features[0]))
+	assert.NotEmpty(t, res.Features[0].Properties["reported_at"])
+
+	res, err = f.svc.Export(ctx, "", export.LayerTrades, export.Filter{From: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, res.Features)
+	res, err = f.svc.Export(ctx, "", export.LayerZones, export.Filter{Box: &geo.Box{MinLongitude: 0, MinLatitude: 0, MaxLongitude: 1, MaxLatitude: 1}})
+	require.NoError(t, err)
+	assert.Empty(t, res.Features)
+
+	_, err = f.svc.Export(ctx, "", export.LayerSurvivors, export.Filter{From: now, To: now.Add(-time.Hour)})
+	assert.True(t, errors.Is(err, export.ErrInvalidTimeRange))
+	_, err = f.svc.Export(ctx, "", export.LayerSurvivors, export.Filter{Box: &geo.Box{MinLongitude: 14, MaxLongitude: 13}})
+	assert.True(t, errors.Is(err, export.ErrInvalidBox))
+	_, err = f.svc.Export(ctx, "", export.Layer("bunkers"), export.Filter{})
+	assert.EqualError(t, err, `unknown export layer "bunkers"`)
+}
+
+func TestExportTradesAndZones(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	res, err := f.svc.Export(ctx, "", export.LayerTrades, export.Filter{})
+	require.NoError(t, err)
+	require.Len(t, res.Features, 1)
+	trade := res.Features[0]
+	assert.Equal(t, f.trade, trade.ID)
+	assert.Equal(t, "LineString", trade.Geometry.Type)
+	line := trade.Geometry.Coordinates.([][2]float64)
+	require.Len(t, line, 2)
+	// the line starts at the partner with the lower ID, what they sent is what the other one received
+	lng, sent, received := []float64{13.405, 2.3522}, map[string]uint32{"water": 2}, map[string]uint32{"food": 1}
+	if f.recovered.ID < f.healthy.ID {
+		lng[0], lng[1], sent, received = lng[1], lng[0], received, sent
+	}
+	assert.InDelta(t, lng[0], line[0][0], 0.01)
+	assert.InDelta(t, lng[1], line[1][0], 0.01)
+	assert.Equal(t, sent, trade.Properties["sent"])
+	assert.Equal(t, received, trade.Properties["received"])
+
+	// a trade is kept as long as one of the partners is inside the box
+	res, err = f.svc.Export(ctx, "", export.LayerTrades, export.Filter{Box: &geo.Box{MinLongitude: 2, MinLatitude: 48, MaxLongitude: 3, MaxLatitude: 49}})
+	require.NoError(t, err)
+	assert.Len(t, res.Features, 1)
+
+	res, err = f.svc.Export(ctx, "", export.LayerZones, export.Filter{})
+	require.NoError(t, err)
+	require.Len(t, res.Features, 1)
+	zone := res.Features[0]
+	assert.Equal(t, "Polygon", zone.Geometry.Type)
+	assert.Equal(t, "camp", zone.Properties["name"])
+	assert.Equal(t, 1.0, zone.Properties["radius_km"])
+	rings := zone.Geometry.Coordinates.([][][2]float64)
+	assert.Len(t, rings[0], 65)
+}
+
+func TestExportVisibility(t *testing.T) {
+	admin := ""
+	f := newFixture(t, export.WithAdmins(func(ctx context.Context, id string) (bool, error) {
+		return id == admin, nil
+	}))
+	ctx := context.Background()
+	require.NoError(t, f.users.UpdateVisibility(ctx, f.recovered.ID, usrStore.VisibilityHidden))
+
+	// hidden survivors and their trades are left out
+	res, err := f.svc.Export(ctx, f.healthy.ID, export.LayerSurvivors, export.Filter{})
+	require.NoError(t, err)
+	require.Len(t, res.Features, 1)
+	assert.Equal(t, "healthy", res.Features[0].Properties["status"])
+	res, err = f.svc.Export(ctx, f.healthy.ID, export.LayerTrades, export.Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, res.Features)
+
+	// admins see everyone where they are, and the survivors can see that they did
+	admin = f.zombie.ID
+	res, err = f.svc.Export(ctx, admin, export.LayerSurvivors, export.Filter{})
+	require.NoError(t, err)
+	require.Len(t, res.Features, 2)
+	assert.Equal(t, geo.Point{Latitude: 52.52, Longitude: 13.405}, point(t, res.Features[0]))
+	assert.Equal(t, geo.Point{Latitude: 48.8566, Longitude: 2.3522}, point(t, res.Features[1]))
+	res, err = f.svc.Export(ctx, admin, export.LayerTrades, export.Filter{})
+	require.NoError(t, err)
+	assert.Len(t, res.Features, 1)
+
+	audits, err := f.users.FindLocationAudits(ctx, f.recovered.ID)
+	require.NoError(t, err)
+	require.Len(t, audits, 2)
+	assert.Equal(t, admin, audits[0].AdminID)
+	assert.ElementsMatch(t, []string{"exports/survivors", "exports/trades"}, []string{audits[0].Resource, audits[1].Resource})
+	audits, err = f.users.FindLocationAudits(ctx, f.zombie.ID)
+	require.NoError(t, err)
+	assert.Empty(t, audits)
+}
+
+func TestWrite(t *testing.T) {
+	f := newFixture(t)
+	res, err := f.svc.Export(context.Background(), "", export.LayerInfected, export.Filter{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, export.Write(&buf, export.FormatGeoJSON, "infected", res))
+	var fc geo.FeatureCollection
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &fc))
+	assert.Equal(t, "FeatureCollection", fc.Type)
+	assert.Len(t, fc.Features, 1)
+
+	buf.Reset()
+	require.NoError(t, export.Write(&buf, export.FormatKML, "infected", res))
+	assert.Contains(t, buf.String(), "<coordinates>13.4,52.5</coordinates>")
+	assert.Contains(t, buf.String(), `<Placemark id="`+f.zombie.ID+`">`)
+}
+
+func TestParse(t *testing.T) {
+	layer, err := export.ParseLayer("trades")
+	require.NoError(t, err)
+	assert.Equal(t, export.LayerTrades, layer)
+	_, err = export.ParseLayer("")
+	assert.Error(t, err)
+
+	for in, want := range map[string]export.Format{"": export.FormatGeoJSON, "GeoJSON": export.FormatGeoJSON, "kml": export.FormatKML} {
+		format, err := export.ParseFormat(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, format)
+	}
+	_, err = export.ParseFormat("shp")
+	assert.EqualError(t, err, `unknown export format "shp"`)
+	assert.Equal(t, "application/vnd.google-earth.kml+xml", export.FormatKML.ContentType())
+	assert.Equal(t, ".geojson", export.FormatGeoJSON.Extension())
+}
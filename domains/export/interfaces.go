@@ -0,0 +1,13 @@
+package export
+
+import (
+	"context"
+
+	"zssn/domains/geo"
+)
+
+// IExportService defines the expectations between the export and service
+type IExportService interface {
+	// Export returns the features of the layer matching the filter, with locations as the requester is allowed to see them
+	Export(ctx context.Context, requesterID string, layer Layer, filter Filter) (*geo.FeatureCollection, error)
+}
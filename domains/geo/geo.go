@@ -0,0 +1,192 @@
+// Package geo contains the distance helpers used for location based features.
+// Distances are in kilometres and coordinates in decimal degrees.
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EarthRadiusKm mean radius of the earth
+const EarthRadiusKm = 6371.0
+
+// Point a location on the earth
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Box a bounding box that can be used to pre-filter locations in the database before calculating the exact distance
+type Box struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+// Distance returns the great-circle distance between both points using the haversine formula
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLong := radians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLong/2)*math.Sin(dLong/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Within returns true if b is at most radiusKm away from a
+func Within(a, b Point, radiusKm float64) bool {
+	return Distance(a, b) <= radiusKm
+}
+
+// Coarse rounds the distance up to the next multiple of stepKm, so the exact location of a survivor can't be worked out from it.
+// Distances are returned as they are for non-positive steps.
+func Coarse(distanceKm, stepKm float64) float64 {
+	if stepKm <= 0 {
+		return distanceKm
+	}
+	return math.Max(1, math.Ceil(distanceKm/stepKm)) * stepKm
+}
+
+// BoundingBox returns the smallest box containing every point within radiusKm of the center.
+// Close to the poles or the antimeridian the box spans every longitude.
+func BoundingBox(center Point, radiusKm float64) Box {
+	dLat := degrees(radiusKm / EarthRadiusKm)
+	box := Box{
+		MinLatitude:  math.Max(-90, center.Latitude-dLat),
+		MaxLatitude:  math.Min(90, center.Latitude+dLat),
+		MinLongitude: -180,
+		MaxLongitude: 180,
+	}
+	if box.MinLatitude == -90 || box.MaxLatitude == 90 {
+		return box
+	}
+
+	dLong := degrees(math.Asin(math.Sin(radiusKm/EarthRadiusKm) / math.Cos(radians(center.Latitude))))
+	if center.Longitude-dLong < -180 || center.Longitude+dLong > 180 {
+		return box
+	}
+	box.MinLongitude = center.Longitude - dLong
+	box.MaxLongitude = center.Longitude + dLong
+	return box
+}
+
+// Center returns the point in the middle of the box
+func (b Box) Center() Point {
+	return Point{Latitude: (b.MinLatitude + b.MaxLatitude) / 2, Longitude: (b.MinLongitude + b.MaxLongitude) / 2}
+}
+
+// Contains returns true if the point is inside the box
+func (b Box) Contains(p Point) bool {
+	return p.Latitude >= b.MinLatitude && p.Latitude <= b.MaxLatitude &&
+		p.Longitude >= b.MinLongitude && p.Longitude <= b.MaxLongitude
+}
+
+// Valid returns true if the box isn't upside down and lies within -180,-90,180,90
+func (b Box) Valid() bool {
+	return b.MinLatitude <= b.MaxLatitude && b.MinLongitude <= b.MaxLongitude &&
+		b.MinLatitude >= -90 && b.MaxLatitude <= 90 && b.MinLongitude >= -180 && b.MaxLongitude <= 180
+}
+
+// Intersects returns true if both boxes share at least one point
+func (b Box) Intersects(o Box) bool {
+	return b.MinLatitude <= o.MaxLatitude && o.MinLatitude <= b.MaxLatitude &&
+		b.MinLongitude <= o.MaxLongitude && o.MinLongitude <= b.MaxLongitude
+}
+
+// ParseBox parses a box given as west,south,east,north like the GeoJSON bbox member, the box isn't validated
+func ParseBox(s string) (Box, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return Box{}, fmt.Errorf("invalid bbox %q, expected west,south,east,north", s)
+	}
+	var coords [4]float64
+	for i, v := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return Box{}, fmt.Errorf("invalid bbox %q, expected west,south,east,north", s)
+		}
+		coords[i] = f
+	}
+	return Box{MinLongitude: coords[0], MinLatitude: coords[1], MaxLongitude: coords[2], MaxLatitude: coords[3]}, nil
+}
+
+// Circle returns the vertices of a regular polygon with the given number of sides approximating the circle, for formats without circles
+func Circle(center Point, radiusKm float64, sides int) []Point {
+	if sides < 3 {
+		sides = 3
+	}
+	lat, long := radians(center.Latitude), radians(center.Longitude)
+	d := radiusKm / EarthRadiusKm
+	res := make([]Point, 0, sides)
+	for i := 0; i < sides; i++ {
+		bearing := 2 * math.Pi * float64(i) / float64(sides)
+		vLat := math.Asin(math.Sin(lat)*math.Cos(d) + math.Cos(lat)*math.Sin(d)*math.Cos(bearing))
+		vLong := long + math.Atan2(math.Sin(bearing)*math.Sin(d)*math.Cos(lat), math.Cos(d)-math.Sin(lat)*math.Sin(vLat))
+		res = append(res, Point{
+			Latitude:  degrees(vLat),
+			Longitude: math.Mod(degrees(vLong)+540, 360) - 180,
+		})
+	}
+	return res
+}
+
+// InPolygon returns true if the point is inside the polygon or on its edge, the vertices are in order and the polygon is closed implicitly.
+// The edges are treated as straight lines on a flat map, which is close enough for areas of a few kilometres.
+func InPolygon(p Point, vertices []Point) bool {
+	if len(vertices) < 3 {
+		return false
+	}
+	inside := false
+	for i, j := 0, len(vertices)-1; i < len(vertices); j, i = i, i+1 {
+		a, b := vertices[i], vertices[j]
+		if onSegment(p, a, b) {
+			return true
+		}
+		if (a.Latitude > p.Latitude) != (b.Latitude > p.Latitude) &&
+			p.Longitude < (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// PolygonBox returns the smallest box containing every vertex
+func PolygonBox(vertices []Point) Box {
+	if len(vertices) == 0 {
+		return Box{}
+	}
+	box := Box{
+		MinLatitude:  vertices[0].Latitude,
+		MaxLatitude:  vertices[0].Latitude,
+		MinLongitude: vertices[0].Longitude,
+		MaxLongitude: vertices[0].Longitude,
+	}
+	for _, v := range vertices[1:] {
+		box.MinLatitude = math.Min(box.MinLatitude, v.Latitude)
+		box.MaxLatitude = math.Max(box.MaxLatitude, v.Latitude)
+		box.MinLongitude = math.Min(box.MinLongitude, v.Longitude)
+		box.MaxLongitude = math.Max(box.MaxLongitude, v.Longitude)
+	}
+	return box
+}
+
+// onSegment returns true if p lies on the segment between a and b
+func onSegment(p, a, b Point) bool {
+	cross := (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude) - (b.Latitude-a.Latitude)*(p.Longitude-a.Longitude)
+	if math.Abs(cross) > 1e-12 {
+		return false
+	}
+	return p.Latitude >= math.Min(a.Latitude, b.Latitude) && p.Latitude <= math.Max(a.Latitude, b.Latitude) &&
+		p.Longitude >= math.Min(a.Longitude, b.Longitude) && p.Longitude <= math.Max(a.Longitude, b.Longitude)
+}
+
+func radians(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+func degrees(r float64) float64 {
+	return r * 180 / math.Pi
+}
@@ -0,0 +1,126 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	lagos  = Point{Latitude: 6.5244, Longitude: 3.3792}
+	ibadan = Point{Latitude: 7.3775, Longitude: 3.9470}
+	london = Point{Latitude: 51.5074, Longitude: -0.1278}
+)
+
+func TestDistance(t *testing.T) {
+	assert.InDelta(t, 113, Distance(lagos, ibadan), 2)
+	assert.InDelta(t, 5000, Distance(lagos, london), 20)
+	assert.InDelta(t, Distance(lagos, london), Distance(london, lagos), 1e-9)
+	assert.Zero(t, Distance(lagos, lagos))
+}
+
+func TestWithin(t *testing.T) {
+	assert.True(t, Within(lagos, ibadan, 120))
+	assert.False(t, Within(lagos, ibadan, 100))
+}
+
+func TestBoundingBox(t *testing.T) {
+	box := BoundingBox(lagos, 120)
+	assert.True(t, box.Contains(lagos))
+	assert.True(t, box.Contains(ibadan))
+	assert.False(t, box.Contains(london))
+
+	// every point within the radius has to be inside the box
+	for _, p := range []Point{
+		{Latitude: lagos.Latitude + 1.07, Longitude: lagos.Longitude},
+		{Latitude: lagos.Latitude, Longitude: lagos.Longitude - 1.07},
+	} {
+		assert.True(t, Within(lagos, p, 120))
+		assert.True(t, box.Contains(p))
+	}
+}
+
+func TestBoundingBoxEdges(t *testing.T) {
+	box := BoundingBox(Point{Latitude: 89.9, Longitude: 10}, 50)
+	assert.Equal(t, 90.0, box.MaxLatitude)
+	assert.Equal(t, -180.0, box.MinLongitude)
+	assert.Equal(t, 180.0, box.MaxLongitude)
+
+	box = BoundingBox(Point{Latitude: 0, Longitude: 179.9}, 50)
+	assert.Equal(t, -180.0, box.MinLongitude)
+	assert.True(t, box.Contains(Point{Latitude: 0, Longitude: -179.9}))
+}
+
+func TestCoarse(t *testing.T) {
+	assert.Equal(t, float64(1), Coarse(0, 1))
+	assert.Equal(t, float64(1), Coarse(0.2, 1))
+	assert.Equal(t, float64(2), Coarse(1.01, 1))
+	assert.Equal(t, float64(115), Coarse(Distance(lagos, ibadan), 5))
+	assert.Equal(t, 0.2, Coarse(0.2, 0))
+}
+
+func TestInPolygon(t *testing.T) {
+	// a concave polygon shaped like a U around lagos
+	u := []Point{
+		{Latitude: 6, Longitude: 3},
+		{Latitude: 6, Longitude: 4},
+		{Latitude: 7, Longitude: 4},
+		{Latitude: 7, Longitude: 3.6},
+		{Latitude: 6.4, Longitude: 3.6},
+		{Latitude: 6.4, Longitude: 3.4},
+		{Latitude: 7, Longitude: 3.4},
+		{Latitude: 7, Longitude: 3},
+	}
+	assert.True(t, InPolygon(Point{Latitude: 6.2, Longitude: 3.5}, u))
+	assert.True(t, InPolygon(Point{Latitude: 6.8, Longitude: 3.2}, u))
+	assert.False(t, InPolygon(Point{Latitude: 6.8, Longitude: 3.5}, u))
+	assert.False(t, InPolygon(london, u))
+	// the edges and vertices belong to the polygon
+	assert.True(t, InPolygon(Point{Latitude: 6, Longitude: 3.5}, u))
+	assert.True(t, InPolygon(Point{Latitude: 7, Longitude: 4}, u))
+	assert.False(t, InPolygon(lagos, u[:2]))
+}
+
+func TestPolygonBox(t *testing.T) {
+	box := PolygonBox([]Point{lagos, ibadan, {Latitude: 7, Longitude: 3}})
+	assert.Equal(t, Box{MinLatitude: 6.5244, MaxLatitude: 7.3775, MinLongitude: 3, MaxLongitude: 3.9470}, box)
+	assert.Equal(t, Box{}, PolygonBox(nil))
+}
+
+func TestParseBox(t *testing.T) {
+	box, err := ParseBox("3, 6.4,4,7.5")
+	assert.NoError(t, err)
+	assert.Equal(t, Box{MinLongitude: 3, MinLatitude: 6.4, MaxLongitude: 4, MaxLatitude: 7.5}, box)
+	assert.True(t, box.Valid())
+	assert.True(t, box.Contains(lagos))
+
+	for _, v := range []string{"", "1,2,3", "1,2,3,x", "1,2,3,4,5"} {
+		_, err := ParseBox(v)
+		assert.Error(t, err, v)
+	}
+	assert.False(t, Box{MinLongitude: 4, MaxLongitude: 3}.Valid())
+	assert.False(t, Box{MinLatitude: -91, MaxLatitude: 0}.Valid())
+	assert.False(t, Box{MaxLongitude: 181}.Valid())
+}
+
+func TestBoxIntersects(t *testing.T) {
+	box := BoundingBox(lagos, 10)
+	assert.True(t, box.Intersects(BoundingBox(lagos, 1)))
+	assert.True(t, box.Intersects(BoundingBox(ibadan, 120)))
+	assert.False(t, box.Intersects(BoundingBox(ibadan, 10)))
+}
+
+func TestCircle(t *testing.T) {
+	vertices := Circle(lagos, 5, 16)
+	assert.Len(t, vertices, 16)
+	for _, v := range vertices {
+		assert.InDelta(t, 5, Distance(lagos, v), 0.01)
+	}
+	assert.True(t, InPolygon(lagos, vertices))
+	assert.Len(t, Circle(lagos, 5, 1), 3)
+
+	// vertices across the antimeridian wrap around
+	for _, v := range Circle(Point{Longitude: 179.99}, 10, 8) {
+		assert.True(t, v.Longitude >= -180 && v.Longitude <= 180)
+	}
+}
@@ -0,0 +1,83 @@
+package geo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// geohashAlphabet the base32 alphabet of geohashes, it leaves out a, i, l and o
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxGeohashPrecision longest geohash that is encoded, 12 characters are already below a millimetre
+const MaxGeohashPrecision = 12
+
+// Geohash encodes the point as a geohash with the given number of characters, the precision is clamped to 1..MaxGeohashPrecision.
+// Points sharing a geohash are inside the same cell, every extra character splits a cell into 32 smaller ones.
+func Geohash(p Point, precision int) string {
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > MaxGeohashPrecision {
+		precision = MaxGeohashPrecision
+	}
+	lat, long := [2]float64{-90, 90}, [2]float64{-180, 180}
+	var (
+		sb   strings.Builder
+		even = true
+		bit  int
+		ch   int
+	)
+	for sb.Len() < precision {
+		if even {
+			ch = ch<<1 | split(&long, p.Longitude)
+		} else {
+			ch = ch<<1 | split(&lat, p.Latitude)
+		}
+		even = !even
+		if bit++; bit == 5 {
+			sb.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// GeohashBox returns the cell the geohash stands for
+func GeohashBox(hash string) (Box, error) {
+	if hash == "" {
+		return Box{}, fmt.Errorf("empty geohash")
+	}
+	lat, long := [2]float64{-90, 90}, [2]float64{-180, 180}
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		idx := strings.IndexRune(geohashAlphabet, c)
+		if idx < 0 {
+			return Box{}, fmt.Errorf("invalid geohash %q", hash)
+		}
+		for i := 4; i >= 0; i-- {
+			r := &lat
+			if even {
+				r = &long
+			}
+			mid := (r[0] + r[1]) / 2
+			if idx>>i&1 == 1 {
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+	}
+	return Box{MinLatitude: lat[0], MaxLatitude: lat[1], MinLongitude: long[0], MaxLongitude: long[1]}, nil
+}
+
+// split halves the range and keeps the half containing v, it returns 1 for the upper half
+func split(r *[2]float64, v float64) int {
+	mid := (r[0] + r[1]) / 2
+	if v >= mid {
+		r[0] = mid
+		return 1
+	}
+	r[1] = mid
+	return 0
+}
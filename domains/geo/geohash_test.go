@@ -0,0 +1,37 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeohash(t *testing.T) {
+	p := Point{Latitude: 57.64911, Longitude: 10.40744}
+	assert.Equal(t, "u4pruydqqvj", Geohash(p, 11))
+	assert.Equal(t, "u4pru", Geohash(p, 5))
+	assert.Equal(t, "u", Geohash(p, 0))
+	assert.Len(t, Geohash(p, 20), MaxGeohashPrecision)
+	assert.Equal(t, "7zzzzz", Geohash(Point{Latitude: -0.000001, Longitude: -0.000001}, 6))
+	assert.Equal(t, "s00000", Geohash(Point{}, 6))
+}
+
+func TestGeohashBox(t *testing.T) {
+	box, err := GeohashBox("u4pru")
+	require.NoError(t, err)
+	assert.True(t, box.Contains(Point{Latitude: 57.64911, Longitude: 10.40744}))
+	assert.InDelta(t, 0.0439, box.MaxLatitude-box.MinLatitude, 1e-4)
+	assert.InDelta(t, 0.0439, box.MaxLongitude-box.MinLongitude, 1e-4)
+
+	// the center of a cell encodes back to the same cell
+	assert.Equal(t, "u4pru", Geohash(box.Center(), 5))
+	upper, err := GeohashBox("U4PRU")
+	require.NoError(t, err)
+	assert.Equal(t, box, upper)
+
+	_, err = GeohashBox("u4pra")
+	assert.EqualError(t, err, `invalid geohash "u4pra"`)
+	_, err = GeohashBox("")
+	assert.Error(t, err)
+}
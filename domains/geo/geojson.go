@@ -0,0 +1,79 @@
+package geo
+
+// GeoJSON types as defined by RFC 7946. Positions are [longitude, latitude], the other way around than Point.
+
+// FeatureCollection a GeoJSON document made of features
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// Feature a geometry with arbitrary properties
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry a GeoJSON geometry, Coordinates holds a position, a list of positions or a list of rings depending on the type
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// NewFeatureCollection returns a collection of the features, it's never nil so it encodes as an empty list
+func NewFeatureCollection(features ...*Feature) *FeatureCollection {
+	if features == nil {
+		features = []*Feature{}
+	}
+	return &FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// NewFeature returns a feature with the geometry and properties
+func NewFeature(id string, geometry *Geometry, properties map[string]interface{}) *Feature {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return &Feature{Type: "Feature", ID: id, Geometry: geometry, Properties: properties}
+}
+
+// PointGeometry returns the GeoJSON point of p
+func PointGeometry(p Point) *Geometry {
+	return &Geometry{Type: "Point", Coordinates: position(p)}
+}
+
+// LineGeometry returns the GeoJSON line string through the points
+func LineGeometry(points ...Point) *Geometry {
+	coords := make([][2]float64, 0, len(points))
+	for _, v := range points {
+		coords = append(coords, position(v))
+	}
+	return &Geometry{Type: "LineString", Coordinates: coords}
+}
+
+// PolygonGeometry returns the GeoJSON polygon with the vertices as its outer ring, the ring is closed if it isn't already
+func PolygonGeometry(vertices []Point) *Geometry {
+	ring := make([][2]float64, 0, len(vertices)+1)
+	for _, v := range vertices {
+		ring = append(ring, position(v))
+	}
+	if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+		ring = append(ring, ring[0])
+	}
+	return &Geometry{Type: "Polygon", Coordinates: [][][2]float64{ring}}
+}
+
+// BoxGeometry returns the GeoJSON polygon of the box, counterclockwise as RFC 7946 recommends
+func BoxGeometry(b Box) *Geometry {
+	return PolygonGeometry([]Point{
+		{Latitude: b.MinLatitude, Longitude: b.MinLongitude},
+		{Latitude: b.MinLatitude, Longitude: b.MaxLongitude},
+		{Latitude: b.MaxLatitude, Longitude: b.MaxLongitude},
+		{Latitude: b.MaxLatitude, Longitude: b.MinLongitude},
+	})
+}
+
+func position(p Point) [2]float64 {
+	return [2]float64{p.Longitude, p.Latitude}
+}
@@ -0,0 +1,31 @@
+package geo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureCollection(t *testing.T) {
+	fc := NewFeatureCollection(
+		NewFeature("lagos", PointGeometry(lagos), map[string]interface{}{"name": "Lagos"}),
+		NewFeature("", LineGeometry(lagos, ibadan), nil),
+		NewFeature("", BoxGeometry(Box{MinLatitude: 1, MaxLatitude: 2, MinLongitude: 3, MaxLongitude: 4}), nil),
+	)
+	b, err := json.Marshal(fc)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "id": "lagos", "geometry": {"type": "Point", "coordinates": [3.3792, 6.5244]}, "properties": {"name": "Lagos"}},
+			{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[3.3792, 6.5244], [3.947, 7.3775]]}, "properties": {}},
+			{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[3, 1], [4, 1], [4, 2], [3, 2], [3, 1]]]}, "properties": {}}
+		]
+	}`, string(b))
+
+	b, err = json.Marshal(NewFeatureCollection())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "FeatureCollection", "features": []}`, string(b))
+}
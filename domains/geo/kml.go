@@ -0,0 +1,156 @@
+package geo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KMLNamespace namespace of KML 2.2 documents
+const KMLNamespace = "http://www.opengis.net/kml/2.2"
+
+type kmlRoot struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name       string          `xml:"name"`
+	Placemarks []*kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	ID           string          `xml:"id,attr,omitempty"`
+	Name         string          `xml:"name,omitempty"`
+	ExtendedData *kmlData        `xml:"ExtendedData,omitempty"`
+	Point        *kmlCoordinates `xml:"Point,omitempty"`
+	LineString   *kmlLineString  `xml:"LineString,omitempty"`
+	Polygon      *kmlPolygon     `xml:"Polygon,omitempty"`
+}
+
+type kmlData struct {
+	Data []kmlValue `xml:"Data"`
+}
+
+type kmlValue struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlCoordinates struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Outer kmlCoordinates   `xml:"outerBoundaryIs>LinearRing"`
+	Inner []kmlInnerBounds `xml:"innerBoundaryIs"`
+}
+
+type kmlInnerBounds struct {
+	Ring kmlCoordinates `xml:"LinearRing"`
+}
+
+// WriteKML writes the features as a KML document with the given name for tools that don't read GeoJSON.
+// Every feature becomes a placemark named after its name property or its ID, the properties become its extended data.
+func WriteKML(w io.Writer, name string, fc *FeatureCollection) error {
+	doc := kmlRoot{Xmlns: KMLNamespace, Document: kmlDocument{Name: name}}
+	for _, f := range fc.Features {
+		pm, err := kmlPlacemarkOf(f)
+		if err != nil {
+			return err
+		}
+		doc.Document.Placemarks = append(doc.Document.Placemarks, pm)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func kmlPlacemarkOf(f *Feature) (*kmlPlacemark, error) {
+	pm := &kmlPlacemark{ID: f.ID, Name: f.ID}
+	if v, ok := f.Properties["name"].(string); ok && v != "" {
+		pm.Name = v
+	}
+	if len(f.Properties) > 0 {
+		keys := make([]string, 0, len(f.Properties))
+		for k := range f.Properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pm.ExtendedData = &kmlData{}
+		for _, k := range keys {
+			v, err := kmlValueOf(f.Properties[k])
+			if err != nil {
+				return nil, err
+			}
+			pm.ExtendedData.Data = append(pm.ExtendedData.Data, kmlValue{Name: k, Value: v})
+		}
+	}
+	if f.Geometry == nil {
+		return pm, nil
+	}
+
+	switch coords := f.Geometry.Coordinates.(type) {
+	case [2]float64:
+		pm.Point = &kmlCoordinates{Coordinates: kmlPositions(coords)}
+	case [][2]float64:
+		pm.LineString = &kmlLineString{Tessellate: 1, Coordinates: kmlPositions(coords...)}
+	case [][][2]float64:
+		if len(coords) == 0 {
+			return nil, fmt.Errorf("polygon %q has no rings", f.ID)
+		}
+		pm.Polygon = &kmlPolygon{Outer: kmlCoordinates{Coordinates: kmlPositions(coords[0]...)}}
+		for _, ring := range coords[1:] {
+			pm.Polygon.Inner = append(pm.Polygon.Inner, kmlInnerBounds{Ring: kmlCoordinates{Coordinates: kmlPositions(ring...)}})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported %s geometry in KML", f.Geometry.Type)
+	}
+	return pm, nil
+}
+
+// kmlPositions formats the positions as KML coordinate tuples, which are longitude first like in GeoJSON
+func kmlPositions(positions ...[2]float64) string {
+	tuples := make([]string, 0, len(positions))
+	for _, v := range positions {
+		tuples = append(tuples, strconv.FormatFloat(v[0], 'f', -1, 64)+","+strconv.FormatFloat(v[1], 'f', -1, 64))
+	}
+	return strings.Join(tuples, " ")
+}
+
+// kmlValueOf formats a property, values that aren't scalars are written as JSON
+func kmlValueOf(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case bool, int, int32, int64, uint, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
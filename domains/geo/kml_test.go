@@ -0,0 +1,43 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteKML(t *testing.T) {
+	fc := NewFeatureCollection(
+		NewFeature("a", PointGeometry(lagos), map[string]interface{}{"name": "Lagos", "survivors": 3, "items": map[string]int{"water": 2}}),
+		NewFeature("b", LineGeometry(lagos, ibadan), nil),
+		NewFeature("c", BoxGeometry(Box{MinLatitude: 6, MaxLatitude: 7, MinLongitude: 3, MaxLongitude: 4}), nil),
+	)
+	var buf bytes.Buffer
+	require.NoError(t, WriteKML(&buf, "survivors", fc))
+	out := buf.String()
+	assert.Contains(t, out, `<kml xmlns="http://www.opengis.net/kml/2.2">`)
+	assert.Contains(t, out, "<name>survivors</name>")
+	assert.Contains(t, out, "<coordinates>3.3792,6.5244</coordinates>")
+	assert.Contains(t, out, "<coordinates>3.3792,6.5244 3.947,7.3775</coordinates>")
+	assert.Contains(t, out, "<outerBoundaryIs>")
+	assert.Contains(t, out, `<Data name="items">`)
+	assert.Contains(t, out, `<value>{&#34;water&#34;:2}</value>`)
+
+	// the document has to be well formed for the mapping tools to import it
+	var doc struct {
+		Placemarks []struct {
+			ID   string `xml:"id,attr"`
+			Name string `xml:"name"`
+		} `xml:"Document>Placemark"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Len(t, doc.Placemarks, 3)
+	assert.Equal(t, "Lagos", doc.Placemarks[0].Name)
+	assert.Equal(t, "b", doc.Placemarks[1].Name)
+
+	err := WriteKML(&buf, "", NewFeatureCollection(NewFeature("x", &Geometry{Type: "MultiPoint", Coordinates: []float64{1}}, nil)))
+	assert.EqualError(t, err, "unsupported MultiPoint geometry in KML")
+}
@@ -81,3 +81,8 @@ func (iv *InventoryService) UpdateMultipleBalance(ctx context.Context, userID st
 func (iv *InventoryService) BlockUserInventory(ctx context.Context, userID string) error {
 	return iv.store.UpdateUserInventoryAccessibility(ctx, userID)
 }
+
+// UnblockUserInventory implements IInventoryService
+func (iv *InventoryService) UnblockUserInventory(ctx context.Context, userID string) error {
+	return iv.store.RestoreUserInventoryAccessibility(ctx, userID)
+}
@@ -183,6 +183,22 @@ func TestBlockUserAccessWithEmptyMock(t *testing.T) {
 	require.EqualError(t, err, errMockNotInitialized.Error())
 }
 
+func TestUnblockUserInventory(t *testing.T) {
+	ctx := context.Background()
+
+	userID := uuid.NewString()
+	require.NoError(t, service.Create(ctx, newInventory(t, userID)))
+	require.NoError(t, service.BlockUserInventory(ctx, userID))
+	require.NoError(t, service.UnblockUserInventory(ctx, userID))
+
+	res, err := service.FindUserInventory(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, res, 4)
+	for _, v := range res {
+		assert.True(t, v.Accessible)
+	}
+}
+
 func newInventory(t *testing.T, userID string) []*entities.Inventory {
 	t.Helper()
 	return []*entities.Inventory{
@@ -0,0 +1,14 @@
+package inventory
+
+import (
+	"testing"
+
+	"zssn/domains/inventory/store"
+	"zssn/domains/inventory/store/storetest"
+)
+
+func TestMockStorageConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.IInventoryStorage {
+		return NewMockStore()
+	})
+}
@@ -17,37 +17,51 @@ var (
 	mockStore = make(map[string]store.Response)
 
 	errMockNotInitialized = errors.New("mock not initialized")
+	errDuplicateItem      = errors.New("item already exists in user's inventory")
 )
 
 // MockInventoryStore inventory store mock
 type MockInventoryStore struct {
-	CreateFunc                           func(ctx context.Context, items []*store.Inventory) error
-	FindUserInventoryFunc                func(ctx context.Context, userID string) (store.Response, error)
-	FindUsersInventoryFunc               func(ctx context.Context, userIDs ...string) (map[string]store.Response, error)
-	UpdateBalanceFunc                    func(ctx context.Context, userID string, item core.Item, newBalance uint32) error
-	UpdateUserInventoryAccessibilityFunc func(ctx context.Context, userID string) error
-	ReduceBalanceFunc                    func(ctx context.Context, userID string, item core.Item, qty uint32) error
-	UpdateMultipleBalanceFunc            func(ctx context.Context, userID string, items map[core.Item]uint32) error
+	CreateFunc                            func(ctx context.Context, items []*store.Inventory) error
+	FindUserInventoryFunc                 func(ctx context.Context, userID string) (store.Response, error)
+	FindUsersInventoryFunc                func(ctx context.Context, userIDs ...string) (map[string]store.Response, error)
+	UpdateBalanceFunc                     func(ctx context.Context, userID string, item core.Item, newBalance uint32) error
+	UpdateUserInventoryAccessibilityFunc  func(ctx context.Context, userID string) error
+	RestoreUserInventoryAccessibilityFunc func(ctx context.Context, userID string) error
+	ReduceBalanceFunc                     func(ctx context.Context, userID string, item core.Item, qty uint32) error
+	UpdateMultipleBalanceFunc             func(ctx context.Context, userID string, items map[core.Item]uint32) error
 }
 
 // NewMockStore return a new mock store with prefilled functions using mockStore
 func NewMockStore() *MockInventoryStore {
 	return &MockInventoryStore{
 		CreateFunc: func(ctx context.Context, items []*store.Inventory) error {
-			var res = make(store.Response)
+			if len(items) == 0 {
+				return gorm.ErrEmptySlice
+			}
+			// the whole batch is rejected if any of the items already exist, the same way the unique index does
+			for _, v := range items {
+				if _, ok := mockStore[v.UserID][v.Item]; ok {
+					return errDuplicateItem
+				}
+			}
 			for _, v := range items {
 				v.ID = uuid.NewString()
 				v.Accessible = true
 				v.Balance = v.Quantity
+				res, ok := mockStore[v.UserID]
+				if !ok {
+					res = make(store.Response)
+				}
 				res[v.Item] = v
+				mockStore[v.UserID] = res
 			}
-			mockStore[items[0].UserID] = res
 			return nil
 		},
 		FindUserInventoryFunc: func(ctx context.Context, userID string) (store.Response, error) {
 			res, ok := mockStore[userID]
 			if !ok {
-				return nil, gorm.ErrRecordNotFound
+				return make(store.Response), nil
 			}
 			return res, nil
 		},
@@ -64,23 +78,23 @@ func NewMockStore() *MockInventoryStore {
 			return result, nil
 		},
 		UpdateBalanceFunc: func(ctx context.Context, userID string, item core.Item, newBalance uint32) error {
-			data, ok := mockStore[userID]
+			inv, ok := mockStore[userID][item]
 			if !ok {
 				return nil
 			}
-			data[item].Balance = newBalance
-			mockStore[userID] = data
+			inv.Balance = newBalance
 			return nil
 		},
 		UpdateUserInventoryAccessibilityFunc: func(ctx context.Context, userID string) error {
-			data, ok := mockStore[userID]
-			if !ok {
-				return gorm.ErrRecordNotFound
-			}
-			for _, v := range data {
+			for _, v := range mockStore[userID] {
 				v.Accessible = false
 			}
-			mockStore[userID] = data
+			return nil
+		},
+		RestoreUserInventoryAccessibilityFunc: func(ctx context.Context, userID string) error {
+			for _, v := range mockStore[userID] {
+				v.Accessible = true
+			}
 			return nil
 		},
 	}
@@ -143,3 +157,11 @@ func (m *MockInventoryStore) UpdateUserInventoryAccessibility(ctx context.Contex
 	}
 	return m.UpdateUserInventoryAccessibilityFunc(ctx, userID)
 }
+
+// RestoreUserInventoryAccessibility implements store.IInventoryStorage
+func (m *MockInventoryStore) RestoreUserInventoryAccessibility(ctx context.Context, userID string) error {
+	if m.RestoreUserInventoryAccessibilityFunc == nil {
+		return errMockNotInitialized
+	}
+	return m.RestoreUserInventoryAccessibilityFunc(ctx, userID)
+}
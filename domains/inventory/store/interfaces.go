@@ -14,4 +14,5 @@ type IInventoryStorage interface {
 	UpdateBalance(ctx context.Context, userID string, item core.Item, newBalance uint32) error
 	UpdateMultipleBalance(ctx context.Context, userID string, items map[core.Item]uint32) error
 	UpdateUserInventoryAccessibility(ctx context.Context, userID string) error
+	RestoreUserInventoryAccessibility(ctx context.Context, userID string) error
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"zssn/database/migrations"
 	"zssn/domains/core"
 
 	"github.com/google/uuid"
@@ -16,11 +17,12 @@ type InventoryStore struct {
 }
 
 // New creates a new instance of IInventoryStorage
+// The database has to be migrated to the latest schema version beforehand.
 func New(db *gorm.DB) (IInventoryStorage, error) {
 	if db == nil {
 		return nil, fmt.Errorf("invalid db provided")
 	}
-	if err := db.AutoMigrate(&Inventory{}); err != nil {
+	if err := migrations.Verify(context.Background(), db); err != nil {
 		return nil, err
 	}
 	return &InventoryStore{
@@ -91,3 +93,8 @@ func (*InventoryStore) UpdateMultipleBalance(ctx context.Context, userID string,
 func (inv *InventoryStore) UpdateUserInventoryAccessibility(ctx context.Context, userID string) error {
 	return inv.DB.Model(&Inventory{}).Where("user_id = ?", userID).Update("is_accessible", false).Error
 }
+
+// RestoreUserInventoryAccessibility implements IInventoryStore
+func (inv *InventoryStore) RestoreUserInventoryAccessibility(ctx context.Context, userID string) error {
+	return inv.DB.Model(&Inventory{}).Where("user_id = ?", userID).Update("is_accessible", true).Error
+}
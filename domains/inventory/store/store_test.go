@@ -5,12 +5,12 @@ import (
 	"os"
 	"testing"
 
+	"zssn/database"
 	"zssn/domains/core"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
@@ -239,12 +239,7 @@ func newInventory(t *testing.T, userID string) []*Inventory {
 }
 
 func setupTestDB() (*gorm.DB, error) {
-	env := os.Getenv("ENVIRONMENT")
-	dsn := "root:@tcp(127.0.0.1:3306)/zssn?charset=utf8mb4&parseTime=True&loc=Local"
-	if env == "cicd" {
-		dsn = "zssn_user:password@tcp(127.0.0.1:33306)/zssn?charset=utf8mb4&parseTime=True&loc=Local"
-	}
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	return database.OpenTest()
 }
 
 func cleanup() {
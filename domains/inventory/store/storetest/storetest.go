@@ -0,0 +1,187 @@
+// Package storetest contains the behavioural contract every IInventoryStorage implementation has to satisfy.
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"zssn/domains/core"
+	"zssn/domains/inventory/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns the storage implementation under test
+type Factory func(t *testing.T) store.IInventoryStorage
+
+// Run runs the conformance suite against the storage returned by newStorage
+func Run(t *testing.T, newStorage Factory) {
+	t.Run("Create", func(t *testing.T) { testCreate(t, newStorage(t)) })
+	t.Run("CreateEmpty", func(t *testing.T) { testCreateEmpty(t, newStorage(t)) })
+	t.Run("CreateDuplicateItem", func(t *testing.T) { testCreateDuplicateItem(t, newStorage(t)) })
+	t.Run("CreateAdditionalItem", func(t *testing.T) { testCreateAdditionalItem(t, newStorage(t)) })
+	t.Run("FindUserInventory", func(t *testing.T) { testFindUserInventory(t, newStorage(t)) })
+	t.Run("FindUnknownUserInventory", func(t *testing.T) { testFindUnknownUserInventory(t, newStorage(t)) })
+	t.Run("FindUsersInventory", func(t *testing.T) { testFindUsersInventory(t, newStorage(t)) })
+	t.Run("UpdateBalance", func(t *testing.T) { testUpdateBalance(t, newStorage(t)) })
+	t.Run("UpdateUserInventoryAccessibility", func(t *testing.T) { testUpdateAccessibility(t, newStorage(t)) })
+}
+
+func testCreate(t *testing.T, storage store.IInventoryStorage) {
+	items := NewInventory(t, uuid.NewString())
+	require.NoError(t, storage.Create(context.Background(), items))
+	for _, v := range items {
+		assert.NotEmpty(t, v.ID)
+		assert.Equal(t, v.Quantity, v.Balance)
+		assert.True(t, v.Accessible)
+	}
+}
+
+func testCreateEmpty(t *testing.T, storage store.IInventoryStorage) {
+	require.Error(t, storage.Create(context.Background(), []*store.Inventory{}))
+}
+
+func testCreateDuplicateItem(t *testing.T, storage store.IInventoryStorage) {
+	ctx := context.Background()
+	userID := uuid.NewString()
+	require.NoError(t, storage.Create(ctx, NewInventory(t, userID)))
+
+	duplicate := []*store.Inventory{
+		{
+			UserID:   userID,
+			Item:     core.ItemWater,
+			Quantity: 15,
+		},
+	}
+	require.Error(t, storage.Create(ctx, duplicate))
+
+	res, err := storage.FindUserInventory(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(20), res[core.ItemWater].Quantity)
+}
+
+func testCreateAdditionalItem(t *testing.T, storage store.IInventoryStorage) {
+	ctx := context.Background()
+	userID := uuid.NewString()
+	items := NewInventory(t, userID)
+	require.NoError(t, storage.Create(ctx, items[:2]))
+	require.NoError(t, storage.Create(ctx, items[2:]))
+
+	res, err := storage.FindUserInventory(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, res, len(items))
+}
+
+func testFindUserInventory(t *testing.T, storage store.IInventoryStorage) {
+	ctx := context.Background()
+	userID := uuid.NewString()
+	items := NewInventory(t, userID)
+	require.NoError(t, storage.Create(ctx, items))
+	require.NoError(t, storage.Create(ctx, NewInventory(t, uuid.NewString())))
+
+	res, err := storage.FindUserInventory(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, res, len(items))
+	for _, v := range items {
+		inv, ok := res[v.Item]
+		require.True(t, ok)
+		assert.Equal(t, v.ID, inv.ID)
+		assert.Equal(t, userID, inv.UserID)
+		assert.Equal(t, v.Quantity, inv.Quantity)
+		assert.Equal(t, v.Balance, inv.Balance)
+		assert.True(t, inv.Accessible)
+	}
+}
+
+func testFindUnknownUserInventory(t *testing.T, storage store.IInventoryStorage) {
+	res, err := storage.FindUserInventory(context.Background(), uuid.NewString())
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testFindUsersInventory(t *testing.T, storage store.IInventoryStorage) {
+	ctx := context.Background()
+	userID, anotherUserID := uuid.NewString(), uuid.NewString()
+	require.NoError(t, storage.Create(ctx, NewInventory(t, userID)))
+	require.NoError(t, storage.Create(ctx, NewInventory(t, anotherUserID)))
+
+	res, err := storage.FindUsersInventory(ctx, userID, anotherUserID, uuid.NewString())
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Len(t, res[userID], 4)
+	assert.Len(t, res[anotherUserID], 4)
+	for _, v := range res[anotherUserID] {
+		assert.Equal(t, anotherUserID, v.UserID)
+	}
+}
+
+func testUpdateBalance(t *testing.T, storage store.IInventoryStorage) {
+	ctx := context.Background()
+	userID := uuid.NewString()
+	require.NoError(t, storage.Create(ctx, NewInventory(t, userID)))
+
+	require.NoError(t, storage.UpdateBalance(ctx, userID, core.ItemWater, 50))
+	res, err := storage.FindUserInventory(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(50), res[core.ItemWater].Balance)
+	assert.Equal(t, uint32(20), res[core.ItemWater].Quantity)
+	assert.Equal(t, uint32(20), res[core.ItemFood].Balance)
+}
+
+func testUpdateAccessibility(t *testing.T, storage store.IInventoryStorage) {
+	ctx := context.Background()
+	userID, anotherUserID := uuid.NewString(), uuid.NewString()
+	require.NoError(t, storage.Create(ctx, NewInventory(t, userID)))
+	require.NoError(t, storage.Create(ctx, NewInventory(t, anotherUserID)))
+
+	require.NoError(t, storage.UpdateUserInventoryAccessibility(ctx, userID))
+
+	res, err := storage.FindUsersInventory(ctx, userID, anotherUserID)
+	require.NoError(t, err)
+	for _, v := range res[userID] {
+		assert.False(t, v.Accessible)
+	}
+	for _, v := range res[anotherUserID] {
+		assert.True(t, v.Accessible)
+	}
+
+	// users without inventory have nothing to block
+	require.NoError(t, storage.UpdateUserInventoryAccessibility(ctx, uuid.NewString()))
+
+	require.NoError(t, storage.RestoreUserInventoryAccessibility(ctx, userID))
+	res, err = storage.FindUsersInventory(ctx, userID, anotherUserID)
+	require.NoError(t, err)
+	for _, v := range res[userID] {
+		assert.True(t, v.Accessible)
+	}
+	require.NoError(t, storage.RestoreUserInventoryAccessibility(ctx, uuid.NewString()))
+}
+
+// NewInventory returns one record for every item for the given user
+func NewInventory(t *testing.T, userID string) []*store.Inventory {
+	t.Helper()
+	return []*store.Inventory{
+		{
+			UserID:   userID,
+			Item:     core.ItemWater,
+			Quantity: 20,
+		},
+		{
+			UserID:   userID,
+			Item:     core.ItemFood,
+			Quantity: 20,
+		},
+		{
+			UserID:   userID,
+			Item:     core.ItemMedication,
+			Quantity: 30,
+		},
+		{
+			UserID:   userID,
+			Item:     core.ItemAmmunition,
+			Quantity: 50,
+		},
+	}
+}
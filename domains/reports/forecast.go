@@ -0,0 +1,171 @@
+package reports
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"zssn/domains/core"
+	"zssn/domains/entities"
+)
+
+// ForecastRules decides how fast the supplies are used up
+type ForecastRules struct {
+	// Consumption what every clean survivor uses of an item a day, items left out aren't consumed
+	Consumption map[core.Item]float64
+	// Window the drop in the clean balance since the oldest report snapshot in the window is the observed consumption
+	Window time.Duration
+	// ShortageDays items lasting fewer days are flagged as a shortage
+	ShortageDays float64
+}
+
+// DefaultForecastRules returns the rules used when none are configured
+func DefaultForecastRules() ForecastRules {
+	return ForecastRules{
+		Consumption: map[core.Item]float64{
+			core.ItemWater:      1,
+			core.ItemFood:       1,
+			core.ItemMedication: 0.1,
+		},
+		Window:       7 * 24 * time.Hour,
+		ShortageDays: 7,
+	}
+}
+
+// WithForecastRules sets how fast the supplies are used up, defaults are used for a nil consumption and non-positive values
+func WithForecastRules(r ForecastRules) Option {
+	return func(rs *ReportService) {
+		d := DefaultForecastRules()
+		if r.Consumption == nil {
+			r.Consumption = d.Consumption
+		}
+		if r.Window <= 0 {
+			r.Window = d.Window
+		}
+		if r.ShortageDays <= 0 {
+			r.ShortageDays = d.ShortageDays
+		}
+		rs.forecast = r
+	}
+}
+
+// Forecast implements IReportService.
+// Every item runs out at the planned or the observed consumption, whichever is faster. Zones don't have snapshots of their own,
+// their survivors are expected to use up as much as the clean survivors of the network do on average.
+func (rs *ReportService) Forecast(ctx context.Context) (*entities.Forecast, error) {
+	survivors, err := rs.Repository.Survivors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := rs.Repository.Resources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	zones, err := rs.Repository.ZoneSupplies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[core.Item]uint32, len(resources))
+	for k, v := range resources {
+		balances[k] = v.Balance
+	}
+	observed, err := rs.observedConsumption(ctx, balances)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entities.Forecast{
+		Network: rs.supplyForecast(survivors.Clean, balances, observed),
+		Zones:   make([]*entities.ZoneForecast, 0, len(zones)),
+	}
+	for _, z := range zones {
+		var zoneObserved map[core.Item]float64
+		if observed != nil && survivors.Clean > 0 {
+			zoneObserved = make(map[core.Item]float64, len(observed))
+			for k, v := range observed {
+				zoneObserved[k] = v / float64(survivors.Clean) * float64(z.Survivors)
+			}
+		}
+		result.Zones = append(result.Zones, &entities.ZoneForecast{
+			ID:             z.ID,
+			Name:           z.Name,
+			Kind:           z.Kind,
+			SupplyForecast: *rs.supplyForecast(z.Survivors, z.Resources, zoneObserved),
+		})
+	}
+	return result, nil
+}
+
+// observedConsumption returns how much of every item the clean survivors used up a day over the window, from the oldest snapshot in it to the current balances.
+// Items that didn't drop weren't used up. It returns nil until the oldest snapshot is at least an hour old.
+func (rs *ReportService) observedConsumption(ctx context.Context, balances map[core.Item]uint32) (map[core.Item]float64, error) {
+	now := time.Now()
+	snapshots, err := rs.Repository.FindSnapshots(ctx, now.Add(-rs.forecast.Window), now)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	oldest := snapshots[0]
+	elapsed := now.Sub(oldest.Hour)
+	if elapsed < time.Hour {
+		return nil, nil
+	}
+	days := elapsed.Hours() / 24
+	result := make(map[core.Item]float64)
+	for _, item := range forecastItems() {
+		if before, current := oldest.Resources[strings.ToLower(item.String())], balances[item]; before > current {
+			result[item] = float64(before-current) / days
+		}
+	}
+	return result, nil
+}
+
+// supplyForecast returns how long the balances last for the given number of clean survivors, observed is nil when the consumption wasn't observed
+func (rs *ReportService) supplyForecast(survivors uint32, balances map[core.Item]uint32, observed map[core.Item]float64) *entities.SupplyForecast {
+	res := &entities.SupplyForecast{
+		Survivors:    survivors,
+		RunsOutFirst: []string{},
+	}
+	first := math.Inf(1)
+	for _, item := range forecastItems() {
+		f := &entities.ItemForecast{
+			Item:          strings.ToLower(item.String()),
+			Balance:       balances[item],
+			PlannedPerDay: rs.forecast.Consumption[item] * float64(survivors),
+		}
+		perDay := f.PlannedPerDay
+		if observed != nil {
+			v := observed[item]
+			f.ObservedPerDay = &v
+			perDay = math.Max(perDay, v)
+		}
+		if perDay > 0 {
+			days := float64(f.Balance) / perDay
+			f.DaysLeft = &days
+			f.Shortage = days < rs.forecast.ShortageDays
+			switch {
+			case days < first:
+				first = days
+				res.RunsOutFirst = []string{f.Item}
+			case days == first:
+				res.RunsOutFirst = append(res.RunsOutFirst, f.Item)
+			}
+		}
+		res.Items = append(res.Items, f)
+	}
+	return res
+}
+
+// forecastItems returns every item in the order they are declared
+func forecastItems() []core.Item {
+	items := make([]core.Item, 0, len(core.ItemPoints))
+	for k := range core.ItemPoints {
+		items = append(items, k)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i] < items[j] })
+	return items
+}
@@ -0,0 +1,108 @@
+package reports
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"zssn/domains/core"
+	"zssn/domains/entities"
+	"zssn/domains/reports/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForecast(t *testing.T) {
+	var from, to time.Time
+	mock := &MockReportRepository{
+		SurvivorsFunc: func(ctx context.Context) (*entities.Survivor, error) {
+			return &entities.Survivor{Total: 12, Clean: 10}, nil
+		},
+		ResourcesFunc: func(ctx context.Context) (map[core.Item]*entities.Resource, error) {
+			return map[core.Item]*entities.Resource{
+				core.ItemWater:      {Item: core.ItemWater, Balance: 100},
+				core.ItemFood:       {Item: core.ItemFood, Balance: 300},
+				core.ItemMedication: {Item: core.ItemMedication, Balance: 40},
+			}, nil
+		},
+		ZoneSuppliesFunc: func(ctx context.Context) ([]*entities.ZoneSupplies, error) {
+			return []*entities.ZoneSupplies{
+				{ID: "camp", Name: "camp", Kind: "safe", Survivors: 2, Resources: map[core.Item]uint32{core.ItemWater: 10, core.ItemFood: 10}},
+				{ID: "empty", Name: "empty", Kind: "danger", Resources: map[core.Item]uint32{}},
+			}, nil
+		},
+		FindSnapshotsFunc: func(ctx context.Context, f, tt time.Time) ([]*repo.Snapshot, error) {
+			from, to = f, tt
+			// two days ago there was less water, more food and as much medication
+			return []*repo.Snapshot{
+				{Hour: time.Now().Add(-48 * time.Hour), Resources: map[string]uint32{"water": 80, "food": 360, "medication": 40}},
+				{Hour: time.Now().Add(-time.Hour), Resources: map[string]uint32{"food": 1000}},
+			}, nil
+		},
+	}
+	svc := New(mock, WithForecastRules(ForecastRules{
+		Consumption:  map[core.Item]float64{core.ItemWater: 2, core.ItemFood: 1, core.ItemMedication: 0.5},
+		Window:       72 * time.Hour,
+		ShortageDays: 6,
+	}))
+
+	res, err := svc.Forecast(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, 72, to.Sub(from).Hours(), 0.01)
+
+	network := res.Network
+	assert.Equal(t, uint32(10), network.Survivors)
+	require.Len(t, network.Items, 4)
+	water, food, medication, ammunition := network.Items[0], network.Items[1], network.Items[2], network.Items[3]
+	assert.Equal(t, "water", water.Item)
+	assert.Equal(t, float64(20), water.PlannedPerDay)
+	// water went up, so none was used up and it lasts as planned
+	require.NotNil(t, water.ObservedPerDay)
+	assert.Zero(t, *water.ObservedPerDay)
+	require.NotNil(t, water.DaysLeft)
+	assert.Equal(t, float64(5), *water.DaysLeft)
+	assert.True(t, water.Shortage)
+	// food dropped 60 in two days, faster than planned, so it runs out sooner
+	require.NotNil(t, food.ObservedPerDay)
+	assert.InDelta(t, 30, *food.ObservedPerDay, 0.01)
+	require.NotNil(t, food.DaysLeft)
+	assert.InDelta(t, 10, *food.DaysLeft, 0.01)
+	assert.False(t, food.Shortage)
+	require.NotNil(t, medication.DaysLeft)
+	assert.Equal(t, float64(8), *medication.DaysLeft)
+	assert.Nil(t, ammunition.DaysLeft)
+	assert.Equal(t, []string{"water"}, network.RunsOutFirst)
+
+	require.Len(t, res.Zones, 2)
+	camp := res.Zones[0]
+	assert.Equal(t, "safe", camp.Kind)
+	// zones use up as much per survivor as the network, 3 food a day for each of the two survivors
+	require.NotNil(t, camp.Items[1].ObservedPerDay)
+	assert.InDelta(t, 6, *camp.Items[1].ObservedPerDay, 0.01)
+	require.NotNil(t, camp.Items[1].DaysLeft)
+	assert.InDelta(t, 10.0/6, *camp.Items[1].DaysLeft, 0.01)
+	require.NotNil(t, camp.Items[0].DaysLeft)
+	assert.Equal(t, 2.5, *camp.Items[0].DaysLeft)
+	assert.Equal(t, float64(0), *camp.Items[2].DaysLeft)
+	assert.Equal(t, []string{"medication"}, camp.RunsOutFirst)
+	// nobody is inside, nothing runs out
+	for _, v := range res.Zones[1].Items {
+		assert.Nil(t, v.DaysLeft)
+	}
+	assert.Empty(t, res.Zones[1].RunsOutFirst)
+
+	// without snapshots the supplies only last as planned
+	mock.FindSnapshotsFunc = func(ctx context.Context, f, tt time.Time) ([]*repo.Snapshot, error) {
+		return nil, nil
+	}
+	res, err = New(mock).Forecast(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, res.Network.Items[1].ObservedPerDay)
+	assert.Equal(t, float64(10), res.Network.Items[1].PlannedPerDay)
+	assert.Equal(t, float64(30), *res.Network.Items[1].DaysLeft)
+	assert.Equal(t, []string{"water"}, res.Network.RunsOutFirst)
+
+	_, err = New(&MockReportRepository{}).Forecast(context.Background())
+	assert.ErrorIs(t, err, errMockNotInitialized)
+}
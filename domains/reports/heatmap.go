@@ -0,0 +1,168 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+	usrStore "zssn/domains/users/store"
+)
+
+const (
+	// DefaultHeatmapPrecision geohash length used when neither a precision nor a cell size is given, cells are about 5km wide
+	DefaultHeatmapPrecision = 5
+	// MaxHeatmapPrecision longest geohash the heatmap accepts, longer ones would make the cells small enough to single survivors out
+	MaxHeatmapPrecision = 6
+	// MinHeatmapCellSize smallest cell size in degrees, about 1km at the equator
+	MinHeatmapCellSize = 0.01
+	// MaxHeatmapCellSize largest cell size in degrees
+	MaxHeatmapCellSize = 45
+)
+
+var (
+	// ErrInvalidPrecision is returned when the geohash precision is out of range
+	ErrInvalidPrecision = fmt.Errorf("precision must be between 1 and %d", MaxHeatmapPrecision)
+	// ErrInvalidCellSize is returned when the cell size is out of range
+	ErrInvalidCellSize = fmt.Errorf("cell size must be between %v and %v degrees", MinHeatmapCellSize, MaxHeatmapCellSize)
+	// ErrPrecisionAndCellSize is returned when both a geohash precision and a cell size are given
+	ErrPrecisionAndCellSize = errors.New("use either a precision or a cell size")
+	// ErrInvalidBox is returned when the bounding box is upside down or off the map
+	ErrInvalidBox = errors.New("bounding box must be west,south,east,north within -180,-90,180,90")
+)
+
+// HeatmapOptions decides how the survivors are bucketed.
+// The cells are geohashes of the given precision, or a grid of CellSize degrees when it's set.
+type HeatmapOptions struct {
+	Precision int
+	CellSize  float64
+	// Box only survivors inside the box are counted, nil counts everyone
+	Box *geo.Box
+}
+
+// Validate checks the options and fills in the default precision
+func (o *HeatmapOptions) Validate() error {
+	switch {
+	case o.Precision != 0 && o.CellSize != 0:
+		return ErrPrecisionAndCellSize
+	case o.CellSize != 0 && (o.CellSize < MinHeatmapCellSize || o.CellSize > MaxHeatmapCellSize):
+		return ErrInvalidCellSize
+	case o.Precision < 0 || o.Precision > MaxHeatmapPrecision:
+		return ErrInvalidPrecision
+	}
+	if o.Box != nil && !o.Box.Valid() {
+		return ErrInvalidBox
+	}
+	if o.CellSize == 0 && o.Precision == 0 {
+		o.Precision = DefaultHeatmapPrecision
+	}
+	return nil
+}
+
+// cell returns the key and bounds of the cell containing the point
+func (o *HeatmapOptions) cell(p geo.Point) (string, geo.Box) {
+	if o.CellSize == 0 {
+		hash := geo.Geohash(p, o.Precision)
+		box, _ := geo.GeohashBox(hash)
+		return hash, box
+	}
+	// the top row and the last column would only contain the poles and the antimeridian, they are folded into their neighbours
+	rows, cols := int(math.Ceil(180/o.CellSize)), int(math.Ceil(360/o.CellSize))
+	row := int(math.Min(math.Floor((p.Latitude+90)/o.CellSize), float64(rows-1)))
+	col := int(math.Min(math.Floor((p.Longitude+180)/o.CellSize), float64(cols-1)))
+	return fmt.Sprintf("%d:%d", row, col), geo.Box{
+		MinLatitude:  float64(row)*o.CellSize - 90,
+		MaxLatitude:  math.Min(float64(row+1)*o.CellSize-90, 90),
+		MinLongitude: float64(col)*o.CellSize - 180,
+		MaxLongitude: math.Min(float64(col+1)*o.CellSize-180, 180),
+	}
+}
+
+// snap grows the box to the edges of the cells its corners are in.
+// Whether a survivor is counted then only depends on their cell, moving the box around within a cell can't single them out.
+func (o *HeatmapOptions) snap(box geo.Box) geo.Box {
+	_, sw := o.cell(geo.Point{Latitude: box.MinLatitude, Longitude: box.MinLongitude})
+	_, ne := o.cell(geo.Point{Latitude: box.MaxLatitude, Longitude: box.MaxLongitude})
+	return geo.Box{
+		MinLatitude:  sw.MinLatitude,
+		MaxLatitude:  ne.MaxLatitude,
+		MinLongitude: sw.MinLongitude,
+		MaxLongitude: ne.MaxLongitude,
+	}
+}
+
+// Heatmap implements IReportService.
+// Every cell with at least one survivor is returned, the cells with the most infected survivors first.
+// The box is snapped to the cells, so only whole cells are counted.
+func (rs *ReportService) Heatmap(ctx context.Context, opts HeatmapOptions) ([]*entities.HeatmapCell, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	box := opts.Box
+	if box != nil {
+		snapped := opts.snap(*box)
+		box = &snapped
+	}
+	locations, err := rs.Repository.Locations(ctx, box)
+	if err != nil {
+		return nil, err
+	}
+
+	cells := make(map[string]*entities.HeatmapCell)
+	for _, v := range locations {
+		key, bounds := opts.cell(geo.Point{Latitude: v.Latitude, Longitude: v.Longitude})
+		// survivors right on the edge of the box belong to the cell beyond it
+		if box != nil && !box.Contains(bounds.Center()) {
+			continue
+		}
+		c, ok := cells[key]
+		if !ok {
+			c = &entities.HeatmapCell{Cell: key, Bounds: bounds, Resources: make(map[string]uint32)}
+			cells[key] = c
+		}
+		c.Total++
+		switch status := usrStore.Status(v.Status); {
+		case status.Clean():
+			c.Clean++
+		case status.Infected():
+			c.Infected++
+		}
+		for item, balance := range v.Resources {
+			c.Resources[strings.ToLower(item.String())] += balance
+		}
+	}
+
+	result := make([]*entities.HeatmapCell, 0, len(cells))
+	for _, v := range cells {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Infected != b.Infected {
+			return a.Infected > b.Infected
+		}
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Cell < b.Cell
+	})
+	return result, nil
+}
+
+// HeatmapGeoJSON returns the cells as a GeoJSON feature collection, every cell is a rectangle with the counts as its properties
+func HeatmapGeoJSON(cells []*entities.HeatmapCell) *geo.FeatureCollection {
+	features := make([]*geo.Feature, 0, len(cells))
+	for _, v := range cells {
+		features = append(features, geo.NewFeature(v.Cell, geo.BoxGeometry(v.Bounds), map[string]interface{}{
+			"total_survivors": v.Total,
+			"clean":           v.Clean,
+			"infected":        v.Infected,
+			"resources":       v.Resources,
+		}))
+	}
+	return geo.NewFeatureCollection(features...)
+}
@@ -0,0 +1,129 @@
+package reports
+
+import (
+	"context"
+	"testing"
+
+	"zssn/domains/core"
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func heatmapRepo(box **geo.Box) *MockReportRepository {
+	return &MockReportRepository{
+		LocationsFunc: func(ctx context.Context, b *geo.Box) ([]*entities.SurvivorLocation, error) {
+			*box = b
+			return []*entities.SurvivorLocation{
+				{UserID: "a", Latitude: 57.649, Longitude: 10.407, Status: "healthy", Resources: map[core.Item]uint32{core.ItemWater: 3}},
+				{UserID: "b", Latitude: 57.650, Longitude: 10.408, Status: "infected"},
+				{UserID: "c", Latitude: 57.651, Longitude: 10.409, Status: "quarantined", Resources: map[core.Item]uint32{core.ItemWater: 1, core.ItemFood: 2}},
+				{UserID: "d", Latitude: -33.9, Longitude: 18.4, Status: "recovered"},
+				{UserID: "e", Latitude: -33.9, Longitude: 18.4, Status: "suspected"},
+			}, nil
+		},
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	var box *geo.Box
+	svc := New(heatmapRepo(&box))
+	res, err := svc.Heatmap(context.Background(), HeatmapOptions{})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Nil(t, box)
+
+	// the outbreak comes first
+	assert.Equal(t, "u4pru", res[0].Cell)
+	assert.Equal(t, uint32(3), res[0].Total)
+	assert.Equal(t, uint32(1), res[0].Clean)
+	assert.Equal(t, uint32(2), res[0].Infected)
+	assert.Equal(t, map[string]uint32{"water": 4, "food": 2}, res[0].Resources)
+	assert.True(t, res[0].Bounds.Contains(geo.Point{Latitude: 57.649, Longitude: 10.407}))
+	assert.Equal(t, uint32(2), res[1].Clean)
+	assert.Zero(t, res[1].Infected)
+
+	res, err = svc.Heatmap(context.Background(), HeatmapOptions{Precision: 1})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "u", res[0].Cell)
+}
+
+func TestHeatmapCellSize(t *testing.T) {
+	var box *geo.Box
+	svc := New(heatmapRepo(&box))
+	filter := &geo.Box{MinLatitude: -40, MaxLatitude: 60, MinLongitude: 0, MaxLongitude: 20}
+	res, err := svc.Heatmap(context.Background(), HeatmapOptions{CellSize: 45, Box: filter})
+	require.NoError(t, err)
+	// the box is grown to the edges of the cells
+	assert.Equal(t, &geo.Box{MinLatitude: -45, MaxLatitude: 90, MinLongitude: 0, MaxLongitude: 45}, box)
+	require.Len(t, res, 2)
+	assert.Equal(t, "3:4", res[0].Cell)
+	assert.Equal(t, geo.Box{MinLatitude: 45, MaxLatitude: 90, MinLongitude: 0, MaxLongitude: 45}, res[0].Bounds)
+	assert.Equal(t, "1:4", res[1].Cell)
+	assert.Equal(t, geo.Box{MinLatitude: -45, MaxLatitude: 0, MinLongitude: 0, MaxLongitude: 45}, res[1].Bounds)
+
+	// the poles and the antimeridian belong to the last row and column
+	o := HeatmapOptions{CellSize: 45}
+	key, _ := o.cell(geo.Point{Latitude: 90, Longitude: 180})
+	assert.Equal(t, "3:7", key)
+}
+
+func TestHeatmapBoxSnapsToCells(t *testing.T) {
+	var box *geo.Box
+	svc := New(heatmapRepo(&box))
+
+	// boxes that only differ within a cell ask for the same survivors, so they can't tell where someone is inside it
+	var queried []geo.Box
+	for _, filter := range []geo.Box{
+		{MinLatitude: 57.6495, MaxLatitude: 57.6505, MinLongitude: 10.4075, MaxLongitude: 10.4085},
+		{MinLatitude: 57.6501, MaxLatitude: 57.6502, MinLongitude: 10.4081, MaxLongitude: 10.4082},
+	} {
+		filter := filter
+		res, err := svc.Heatmap(context.Background(), HeatmapOptions{Box: &filter})
+		require.NoError(t, err)
+		require.NotNil(t, box)
+		queried = append(queried, *box)
+
+		// the whole cell is counted, the survivors elsewhere are left out even if the repository returned them
+		require.Len(t, res, 1)
+		assert.Equal(t, "u4pru", res[0].Cell)
+		assert.Equal(t, uint32(3), res[0].Total)
+		assert.Equal(t, res[0].Bounds, *box)
+	}
+	assert.Equal(t, queried[0], queried[1])
+}
+
+func TestHeatmapOptions(t *testing.T) {
+	svc := New(&MockReportRepository{})
+	for name, opts := range map[string]HeatmapOptions{
+		ErrInvalidPrecision.Error():     {Precision: MaxHeatmapPrecision + 1},
+		ErrInvalidCellSize.Error():      {CellSize: 0.001},
+		ErrPrecisionAndCellSize.Error(): {Precision: 2, CellSize: 1},
+		ErrInvalidBox.Error():           {Box: &geo.Box{MinLatitude: 10, MaxLatitude: 0}},
+	} {
+		_, err := svc.Heatmap(context.Background(), opts)
+		assert.EqualError(t, err, name)
+	}
+	_, err := svc.Heatmap(context.Background(), HeatmapOptions{})
+	require.Error(t, err)
+}
+
+func TestHeatmapGeoJSON(t *testing.T) {
+	fc := HeatmapGeoJSON([]*entities.HeatmapCell{{
+		Cell:      "s0",
+		Bounds:    geo.Box{MaxLatitude: 5.625, MaxLongitude: 11.25},
+		Total:     2,
+		Clean:     1,
+		Infected:  1,
+		Resources: map[string]uint32{"water": 1},
+	}})
+	require.Len(t, fc.Features, 1)
+	assert.Equal(t, "FeatureCollection", fc.Type)
+	assert.Equal(t, "s0", fc.Features[0].ID)
+	assert.Equal(t, "Polygon", fc.Features[0].Geometry.Type)
+	assert.Equal(t, uint32(1), fc.Features[0].Properties["infected"])
+	assert.Empty(t, HeatmapGeoJSON(nil).Features)
+}
@@ -0,0 +1,143 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"zssn/domains/core"
+	"zssn/domains/entities"
+	"zssn/domains/reports/repo"
+)
+
+var (
+	// ErrInvalidInterval is returned when the history interval isn't hour or day
+	ErrInvalidInterval = errors.New("interval must be hour or day")
+	// ErrInvalidTimeRange is returned when the end of the time range is before its start
+	ErrInvalidTimeRange = errors.New("the end of the time range cannot be before its start")
+)
+
+// Interval how far apart the points of a history series are
+type Interval string
+
+const (
+	// IntervalHour one point for every hour a snapshot was taken in
+	IntervalHour Interval = "hour"
+	// IntervalDay one point for every day a snapshot was taken on, the default
+	IntervalDay Interval = "day"
+)
+
+// ParseInterval returns the interval with the given name, an empty name is a day
+func ParseInterval(s string) (Interval, error) {
+	switch Interval(strings.ToLower(s)) {
+	case "", IntervalDay:
+		return IntervalDay, nil
+	case IntervalHour:
+		return IntervalHour, nil
+	default:
+		return "", fmt.Errorf("%w, got %q", ErrInvalidInterval, s)
+	}
+}
+
+// truncate returns the start of the interval t is in, in UTC
+func (i Interval) truncate(t time.Time) time.Time {
+	t = t.UTC()
+	if i == IntervalHour {
+		return t.Truncate(time.Hour)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// duration returns the length of the interval, days are counted in UTC so they are always 24 hours long
+func (i Interval) duration() time.Duration {
+	if i == IntervalHour {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Snapshot implements IReportService.
+// The snapshot belongs to the current hour, taking another one in the same hour replaces it.
+func (rs *ReportService) Snapshot(ctx context.Context) (*entities.ReportSnapshot, error) {
+	survivors, err := rs.Repository.Survivors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	infected, err := rs.Repository.Infected(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lost, err := rs.LostPoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := rs.Repository.Resources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &repo.Snapshot{
+		Hour:       IntervalHour.truncate(time.Now()),
+		Total:      survivors.Total,
+		Clean:      survivors.Clean,
+		Infected:   infected.Infected,
+		LostPoints: lost,
+		Resources:  make(map[string]uint32, len(core.ItemPoints)),
+	}
+	// every item is recorded, so series don't have gaps for items nobody holds anymore
+	for item := range core.ItemPoints {
+		s.Resources[strings.ToLower(item.String())] = 0
+	}
+	for k, v := range resources {
+		s.Resources[strings.ToLower(k.String())] = v.Balance
+	}
+	if err := rs.Repository.SaveSnapshot(ctx, s); err != nil {
+		return nil, err
+	}
+	return fromSnapshot(s, s.Hour), nil
+}
+
+// History implements IReportService.
+// Every point is the last snapshot taken in its interval and is placed at the start of the interval, intervals without snapshots are left out.
+func (rs *ReportService) History(ctx context.Context, from, to time.Time, interval Interval) ([]*entities.ReportSnapshot, error) {
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, ErrInvalidTimeRange
+	}
+	if interval != IntervalHour && interval != IntervalDay {
+		return nil, ErrInvalidInterval
+	}
+	snapshots, err := rs.Repository.FindSnapshots(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*entities.ReportSnapshot
+	for _, v := range snapshots {
+		at := interval.truncate(v.Hour)
+		// the snapshots are ordered, so a later one in the same interval replaces the point
+		if n := len(result); n > 0 && result[n-1].Time.Equal(at) {
+			result[n-1] = fromSnapshot(v, at)
+			continue
+		}
+		result = append(result, fromSnapshot(v, at))
+	}
+	return result, nil
+}
+
+// fromSnapshot returns the point of a history series for the snapshot
+func fromSnapshot(s *repo.Snapshot, at time.Time) *entities.ReportSnapshot {
+	resources := make(map[string]uint32, len(s.Resources))
+	for k, v := range s.Resources {
+		resources[k] = v
+	}
+	return &entities.ReportSnapshot{
+		Time:       at,
+		Total:      s.Total,
+		Clean:      s.Clean,
+		Infected:   s.Infected,
+		LostPoints: s.LostPoints,
+		Resources:  resources,
+	}
+}
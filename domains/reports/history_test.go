@@ -0,0 +1,96 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"zssn/domains/core"
+	"zssn/domains/entities"
+	"zssn/domains/reports/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	var saved *repo.Snapshot
+	svc := New(&MockReportRepository{
+		SurvivorsFunc: func(ctx context.Context) (*entities.Survivor, error) {
+			return &entities.Survivor{Total: 10, Clean: 7}, nil
+		},
+		InfectedFunc: func(ctx context.Context) (*entities.Infected, error) {
+			return &entities.Infected{Total: 10, Infected: 3}, nil
+		},
+		PointsFunc: func(ctx context.Context) (map[core.Item]*entities.Resource, error) {
+			return map[core.Item]*entities.Resource{core.ItemWater: {Item: core.ItemWater, Balance: 2}}, nil
+		},
+		ResourcesFunc: func(ctx context.Context) (map[core.Item]*entities.Resource, error) {
+			return map[core.Item]*entities.Resource{core.ItemFood: {Item: core.ItemFood, Balance: 12}}, nil
+		},
+		SaveSnapshotFunc: func(ctx context.Context, s *repo.Snapshot) error {
+			saved = s
+			return nil
+		},
+	})
+
+	res, err := svc.Snapshot(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, time.Now().UTC().Truncate(time.Hour), saved.Hour)
+	assert.Equal(t, saved.Hour, res.Time)
+	assert.Equal(t, uint32(10), res.Total)
+	assert.Equal(t, uint32(7), res.Clean)
+	assert.Equal(t, uint32(3), res.Infected)
+	assert.Equal(t, core.ItemPoints[core.ItemWater]*2, res.LostPoints)
+	// items nobody holds are recorded as well
+	assert.Equal(t, map[string]uint32{"water": 0, "food": 12, "medication": 0, "ammunition": 0}, res.Resources)
+}
+
+func TestHistory(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var from, to time.Time
+	svc := New(&MockReportRepository{
+		FindSnapshotsFunc: func(ctx context.Context, f, t time.Time) ([]*repo.Snapshot, error) {
+			from, to = f, t
+			return []*repo.Snapshot{
+				{Hour: day.Add(9 * time.Hour), Total: 10, Infected: 1},
+				{Hour: day.Add(10 * time.Hour), Total: 10, Infected: 2},
+				{Hour: day.Add(34 * time.Hour), Total: 9, Infected: 4, Resources: map[string]uint32{"water": 5}},
+			}, nil
+		},
+	})
+	ctx := context.Background()
+
+	res, err := svc.History(ctx, day, day.AddDate(0, 0, 2), IntervalHour)
+	require.NoError(t, err)
+	assert.Equal(t, day, from)
+	assert.Equal(t, day.AddDate(0, 0, 2), to)
+	require.Len(t, res, 3)
+	assert.Equal(t, day.Add(10*time.Hour), res[1].Time)
+
+	// a day is the last snapshot taken on it
+	res, err = svc.History(ctx, time.Time{}, time.Time{}, IntervalDay)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, day, res[0].Time)
+	assert.Equal(t, uint32(2), res[0].Infected)
+	assert.Equal(t, day.AddDate(0, 0, 1), res[1].Time)
+	assert.Equal(t, uint32(5), res[1].Resources["water"])
+
+	_, err = svc.History(ctx, day, day.Add(-time.Hour), IntervalDay)
+	assert.True(t, errors.Is(err, ErrInvalidTimeRange))
+	_, err = svc.History(ctx, time.Time{}, time.Time{}, Interval("week"))
+	assert.True(t, errors.Is(err, ErrInvalidInterval))
+}
+
+func TestParseInterval(t *testing.T) {
+	for in, want := range map[string]Interval{"": IntervalDay, "day": IntervalDay, "Hour": IntervalHour} {
+		got, err := ParseInterval(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseInterval("minute")
+	assert.True(t, errors.Is(err, ErrInvalidInterval))
+}
@@ -2,6 +2,7 @@ package reports
 
 import (
 	"context"
+	"time"
 
 	"zssn/domains/entities"
 )
@@ -12,4 +13,15 @@ type IReportService interface {
 	NonInfectedSurvivors(ctx context.Context) (*entities.Survivor, error)
 	ResourceSharing(ctx context.Context) (map[string]*entities.ResourceSharing, error)
 	LostPoints(ctx context.Context) (uint32, error)
+	Statuses(ctx context.Context) (map[string]uint32, error)
+	Zones(ctx context.Context) ([]*entities.ZoneReport, error)
+	Heatmap(ctx context.Context, opts HeatmapOptions) ([]*entities.HeatmapCell, error)
+	// Forecast estimates how many days the supplies last across the network and inside every zone
+	Forecast(ctx context.Context) (*entities.Forecast, error)
+	// Trades returns the trading activity within the range of the options
+	Trades(ctx context.Context, opts TradeReportOptions) (*entities.TradeReport, error)
+	// Snapshot records the reports as they are now for the history series
+	Snapshot(ctx context.Context) (*entities.ReportSnapshot, error)
+	// History returns the recorded reports between from and to, one point per interval, oldest first. Zero times leave the range open
+	History(ctx context.Context, from, to time.Time, interval Interval) ([]*entities.ReportSnapshot, error)
 }
@@ -3,9 +3,11 @@ package reports
 import (
 	"context"
 	"errors"
+	"time"
 
 	"zssn/domains/core"
 	"zssn/domains/entities"
+	"zssn/domains/geo"
 	"zssn/domains/reports/repo"
 )
 
@@ -16,11 +18,19 @@ var (
 )
 
 type MockReportRepository struct {
-	InfectedFunc  func(ctx context.Context) (*entities.Infected, error)
-	PointsFunc    func(ctx context.Context) (map[core.Item]*entities.Resource, error)
-	ResourcesFunc func(ctx context.Context) (map[core.Item]*entities.Resource, error)
-	SurvivorsFunc func(ctx context.Context) (*entities.Survivor, error)
-	TotalFunc     func(ctx context.Context) (uint32, error)
+	InfectedFunc     func(ctx context.Context) (*entities.Infected, error)
+	PointsFunc       func(ctx context.Context) (map[core.Item]*entities.Resource, error)
+	ResourcesFunc    func(ctx context.Context) (map[core.Item]*entities.Resource, error)
+	SurvivorsFunc    func(ctx context.Context) (*entities.Survivor, error)
+	TotalFunc        func(ctx context.Context) (uint32, error)
+	StatusesFunc     func(ctx context.Context) (map[string]uint32, error)
+	ZonesFunc        func(ctx context.Context) ([]*entities.ZoneReport, error)
+	LocationsFunc    func(ctx context.Context, box *geo.Box) ([]*entities.SurvivorLocation, error)
+	ZoneSuppliesFunc func(ctx context.Context) ([]*entities.ZoneSupplies, error)
+
+	TradeActivityFunc func(ctx context.Context, from, to time.Time, bucket time.Duration, topTraders int) (*repo.TradeActivity, error)
+	SaveSnapshotFunc  func(ctx context.Context, s *repo.Snapshot) error
+	FindSnapshotsFunc func(ctx context.Context, from, to time.Time) ([]*repo.Snapshot, error)
 }
 
 // Infected implements repo.IReportRepository
@@ -63,3 +73,59 @@ func (m *MockReportRepository) Total(ctx context.Context) (uint32, error) {
 
 	return m.TotalFunc(ctx)
 }
+
+// Statuses implements repo.IReportRepository
+func (m *MockReportRepository) Statuses(ctx context.Context) (map[string]uint32, error) {
+	if m.StatusesFunc == nil {
+		return nil, errMockNotInitialized
+	}
+	return m.StatusesFunc(ctx)
+}
+
+// Zones implements repo.IReportRepository
+func (m *MockReportRepository) Zones(ctx context.Context) ([]*entities.ZoneReport, error) {
+	if m.ZonesFunc == nil {
+		return nil, errMockNotInitialized
+	}
+	return m.ZonesFunc(ctx)
+}
+
+// Locations implements repo.IReportRepository
+func (m *MockReportRepository) Locations(ctx context.Context, box *geo.Box) ([]*entities.SurvivorLocation, error) {
+	if m.LocationsFunc == nil {
+		return nil, errMockNotInitialized
+	}
+	return m.LocationsFunc(ctx, box)
+}
+
+// ZoneSupplies implements repo.IReportRepository
+func (m *MockReportRepository) ZoneSupplies(ctx context.Context) ([]*entities.ZoneSupplies, error) {
+	if m.ZoneSuppliesFunc == nil {
+		return nil, errMockNotInitialized
+	}
+	return m.ZoneSuppliesFunc(ctx)
+}
+
+// TradeActivity implements repo.IReportRepository
+func (m *MockReportRepository) TradeActivity(ctx context.Context, from, to time.Time, bucket time.Duration, topTraders int) (*repo.TradeActivity, error) {
+	if m.TradeActivityFunc == nil {
+		return nil, errMockNotInitialized
+	}
+	return m.TradeActivityFunc(ctx, from, to, bucket, topTraders)
+}
+
+// SaveSnapshot implements repo.IReportRepository
+func (m *MockReportRepository) SaveSnapshot(ctx context.Context, s *repo.Snapshot) error {
+	if m.SaveSnapshotFunc == nil {
+		return errMockNotInitialized
+	}
+	return m.SaveSnapshotFunc(ctx, s)
+}
+
+// FindSnapshots implements repo.IReportRepository
+func (m *MockReportRepository) FindSnapshots(ctx context.Context, from, to time.Time) ([]*repo.Snapshot, error) {
+	if m.FindSnapshotsFunc == nil {
+		return nil, errMockNotInitialized
+	}
+	return m.FindSnapshotsFunc(ctx, from, to)
+}
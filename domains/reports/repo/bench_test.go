@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"zssn/domains/core"
+	"zssn/domains/entities"
+	invStore "zssn/domains/inventory/store"
+	usrStore "zssn/domains/users/store"
+
+	"github.com/google/uuid"
+)
+
+// benchmarkSizes number of survivors seeded for every benchmark, each of them holds all four items
+var benchmarkSizes = []int{1000, 10000}
+
+// seedSurvivors inserts n survivors spread over every status with their inventories, every fifth inventory is blocked.
+// Everything is deleted again once the benchmark is done.
+func seedSurvivors(b *testing.B, n int) {
+	b.Helper()
+	users := make([]*usrStore.User, 0, n)
+	inventories := make([]*invStore.Inventory, 0, n*len(core.ItemPoints))
+	for i := 0; i < n; i++ {
+		u := &usrStore.User{
+			ID:     uuid.NewString(),
+			Email:  fmt.Sprintf("bench-%d-%d@zssn.io", n, i),
+			Name:   "bench",
+			Status: usrStore.Statuses[i%len(usrStore.Statuses)],
+		}
+		users = append(users, u)
+		for item := range core.ItemPoints {
+			inventories = append(inventories, &invStore.Inventory{
+				ID:         uuid.NewString(),
+				UserID:     u.ID,
+				Item:       item,
+				Quantity:   uint32(i % 50),
+				Balance:    uint32(i % 50),
+				Accessible: i%5 != 0,
+			})
+		}
+	}
+	if err := db.CreateInBatches(users, 500).Error; err != nil {
+		b.Fatal(err)
+	}
+	if err := db.CreateInBatches(inventories, 500).Error; err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		db.Exec("DELETE FROM inventories WHERE user_id IN (SELECT id FROM users WHERE name = ?)", "bench")
+		db.Exec("DELETE FROM users WHERE name = ?", "bench")
+	})
+}
+
+// sumRows is how the resources used to be computed, every inventory row is loaded and summed in Go
+func sumRows(ctx context.Context, accessible bool) (map[core.Item]*entities.Resource, error) {
+	var rows []*entities.Resource
+	if err := db.WithContext(ctx).Table("inventories").Where("is_accessible = ?", accessible).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[core.Item]*entities.Resource)
+	for _, v := range rows {
+		if data, ok := result[v.Item]; ok {
+			data.Balance += v.Balance
+			continue
+		}
+		result[v.Item] = v
+	}
+	return result, nil
+}
+
+// countSeparately is how the survivor counts used to be computed, with a COUNT query for every group
+func countSeparately(ctx context.Context) (total, clean, infected int64, err error) {
+	if err = db.WithContext(ctx).Table("users").Where("status <> ?", usrStore.StatusDeceased).Count(&total).Error; err != nil {
+		return
+	}
+	if err = db.WithContext(ctx).Table("users").Where("status IN ?", usrStore.CleanStatuses()).Count(&clean).Error; err != nil {
+		return
+	}
+	err = db.WithContext(ctx).Table("users").Where("status IN ?", usrStore.InfectedStatuses()).Count(&infected).Error
+	return
+}
+
+func BenchmarkResources(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("survivors=%d", n), func(b *testing.B) {
+			seedSurvivors(b, n)
+			b.Run("group-by", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := repo.Resources(ctx); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("load-rows", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := sumRows(ctx, true); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkPoints(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("survivors=%d", n), func(b *testing.B) {
+			seedSurvivors(b, n)
+			b.Run("group-by", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := repo.Points(ctx); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("load-rows", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := sumRows(ctx, false); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkSurvivorCounts(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("survivors=%d", n), func(b *testing.B) {
+			seedSurvivors(b, n)
+			b.Run("single-query", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := repo.Infected(ctx); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("three-counts", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, _, _, err := countSeparately(ctx); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
@@ -2,8 +2,10 @@ package repo
 
 import (
 	"context"
+	"time"
 	"zssn/domains/core"
 	"zssn/domains/entities"
+	"zssn/domains/geo"
 )
 
 // IIReportRepository interface to define the report contracts
@@ -13,4 +15,17 @@ type IReportRepository interface {
 	Infected(ctx context.Context) (*entities.Infected, error)
 	Resources(ctx context.Context) (map[core.Item]*entities.Resource, error)
 	Points(ctx context.Context) (map[core.Item]*entities.Resource, error)
+	Statuses(ctx context.Context) (map[string]uint32, error)
+	Zones(ctx context.Context) ([]*entities.ZoneReport, error)
+	// ZoneSupplies returns the clean survivors inside every zone and the balance of every item they can still use, ordered by zone name
+	ZoneSupplies(ctx context.Context) ([]*entities.ZoneSupplies, error)
+	// Locations returns the last known location of every survivor who isn't deceased, only the ones inside the box if one is given
+	Locations(ctx context.Context, box *geo.Box) ([]*entities.SurvivorLocation, error)
+	// TradeActivity returns the trades between from and to, summed up in buckets of the given length, with up to topTraders of the most active traders.
+	// Zero times leave the range open
+	TradeActivity(ctx context.Context, from, to time.Time, bucket time.Duration, topTraders int) (*TradeActivity, error)
+	// SaveSnapshot stores the snapshot of an hour, replacing the one taken earlier in the same hour
+	SaveSnapshot(ctx context.Context, s *Snapshot) error
+	// FindSnapshots returns the snapshots of the hours between from and to, oldest first. Zero times leave the range open
+	FindSnapshots(ctx context.Context, from, to time.Time) ([]*Snapshot, error)
 }
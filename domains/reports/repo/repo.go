@@ -4,6 +4,9 @@ import (
 	"context"
 	"zssn/domains/core"
 	"zssn/domains/entities"
+	"zssn/domains/geo"
+	invStore "zssn/domains/inventory/store"
+	usrStore "zssn/domains/users/store"
 
 	"gorm.io/gorm"
 )
@@ -24,12 +27,12 @@ func New(db *gorm.DB) IReportRepository {
 	}
 }
 
-// Total gets the total number of users in the system.
+// Total gets the total number of survivors in the system, deceased and deleted survivors are left out.
 // This will help us given the db does cache some query results
 func (rr *ReportRepository) Total(ctx context.Context) (uint32, error) {
 	var total int64
 
-	err := rr.DB.Table("users").Count(&total).Error
+	err := rr.DB.WithContext(ctx).Model(&usrStore.User{}).Where("status <> ?", usrStore.StatusDeceased).Count(&total).Error
 	if err != nil {
 		return 0, err
 	}
@@ -39,104 +42,294 @@ func (rr *ReportRepository) Total(ctx context.Context) (uint32, error) {
 
 // Infected implements IIReportRepository
 func (rr *ReportRepository) Infected(ctx context.Context) (*entities.Infected, error) {
-	var (
-		infected   int64
-		percentage float64
-	)
+	var percentage float64
+	c, err := rr.counts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Total != 0 && c.Infected != 0 {
+		percentage = float64(c.Infected) / float64(c.Total) * 100.0
+	}
+
+	return &entities.Infected{
+		Total:      uint32(c.Total),
+		Infected:   uint32(c.Infected),
+		Percentage: percentage,
+	}, nil
+}
+
+// Points returns the accumulated points for each given item
+func (rr *ReportRepository) Points(ctx context.Context) (map[core.Item]*entities.Resource, error) {
+	return rr.balances(ctx, false)
+}
 
-	total, err := rr.Total(ctx)
+// Resources calculates the total amount of resources available for each
+func (rr *ReportRepository) Resources(ctx context.Context) (map[core.Item]*entities.Resource, error) {
+	return rr.balances(ctx, true)
+}
+
+// balances sums the balance of every item in the database, over the accessible inventories or the blocked ones
+func (rr *ReportRepository) balances(ctx context.Context, accessible bool) (map[core.Item]*entities.Resource, error) {
+	var rows []*entities.Resource
+	err := rr.DB.WithContext(ctx).Table("inventories").
+		Select("item, SUM(balance) AS balance").
+		Where("is_accessible = ?", accessible).
+		Group("item").
+		Scan(&rows).Error
 	if err != nil {
 		return nil, err
 	}
 
-	err = rr.DB.Table("users").Where("infected = ?", true).Count(&infected).Error
+	result := make(map[core.Item]*entities.Resource, len(rows))
+	for _, v := range rows {
+		result[v.Item] = v
+	}
+	return result, nil
+}
+
+// Survivors returns the rate of survivors
+func (rr *ReportRepository) Survivors(ctx context.Context) (*entities.Survivor, error) {
+	var percentage float64
+	c, err := rr.counts(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	if total != 0 && infected != 0 {
-		percentage = float64(infected) / float64(total) * 100.0
+	if c.Total > 0 && c.Clean > 0 {
+		percentage = float64(c.Clean) / float64(c.Total) * 100.0
 	}
 
-	return &entities.Infected{
-		Total:      uint32(total),
-		Infected:   uint32(infected),
+	return &entities.Survivor{
+		Total:      uint32(c.Total),
+		Clean:      uint32(c.Clean),
 		Percentage: percentage,
 	}, nil
 }
 
-// Points returns the accumulated points for each given item
-func (rr *ReportRepository) Points(ctx context.Context) (map[core.Item]*entities.Resource, error) {
-	var (
-		dbResult []*entities.Resource
-		result   = make(map[core.Item]*entities.Resource)
-	)
-	err := rr.DB.Table("inventories").Where("is_accessible = ?", false).Find(&dbResult).Error
+// survivorCounts the number of survivors in the groups the reports are made of
+type survivorCounts struct {
+	// Total every survivor who isn't deceased
+	Total    int64
+	Clean    int64
+	Infected int64
+}
+
+// counts returns the survivor counts of the users who haven't been deleted with a single query.
+// The users are counted per status, which the status index answers on its own, and the statuses are grouped here.
+func (rr *ReportRepository) counts(ctx context.Context) (*survivorCounts, error) {
+	var rows []struct {
+		Status usrStore.Status
+		Total  int64
+	}
+	err := rr.DB.WithContext(ctx).Model(&usrStore.User{}).Select("status, COUNT(*) AS total").Group("status").Scan(&rows).Error
 	if err != nil {
 		return nil, err
 	}
 
-	for _, v := range dbResult {
-		data, ok := result[v.Item]
-		if !ok {
-			result[v.Item] = v
-			continue
+	var c survivorCounts
+	groups := make(map[usrStore.Status]*int64)
+	for _, v := range usrStore.CleanStatuses() {
+		groups[v] = &c.Clean
+	}
+	for _, v := range usrStore.InfectedStatuses() {
+		groups[v] = &c.Infected
+	}
+	for _, v := range rows {
+		if v.Status != usrStore.StatusDeceased {
+			c.Total += v.Total
+		}
+		if g, ok := groups[v.Status]; ok {
+			*g += v.Total
 		}
-		data.Balance += v.Balance
-		result[v.Item] = data
+	}
+	return &c, nil
+}
+
+// Statuses returns the number of users who haven't been deleted in each status, statuses without users are included with 0
+func (rr *ReportRepository) Statuses(ctx context.Context) (map[string]uint32, error) {
+	var rows []struct {
+		Status string
+		Total  int64
+	}
+	err := rr.DB.WithContext(ctx).Model(&usrStore.User{}).Select("status, COUNT(*) AS total").Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
 	}
 
+	result := make(map[string]uint32, len(usrStore.Statuses))
+	for _, v := range usrStore.Statuses {
+		result[string(v)] = 0
+	}
+	for _, v := range rows {
+		result[v.Status] = uint32(v.Total)
+	}
 	return result, nil
 }
 
-// Resources calculates the total amount of resources available for each
-func (rr *ReportRepository) Resources(ctx context.Context) (map[core.Item]*entities.Resource, error) {
-	var (
-		dbResult []*entities.Resource
-		result   = make(map[core.Item]*entities.Resource)
-	)
-	err := rr.DB.Debug().Table("inventories").Where("is_accessible = ?", true).Find(&dbResult).Error
+// Zones returns the number of survivors in each status for every zone ordered by name, zones nobody is in are included
+func (rr *ReportRepository) Zones(ctx context.Context) ([]*entities.ZoneReport, error) {
+	var rows []struct {
+		ID     string
+		Name   string
+		Kind   string
+		Status *string
+		Total  int64
+	}
+	err := rr.DB.WithContext(ctx).Table("zones").
+		Select("zones.id, zones.name, zones.kind, users.status, COUNT(users.id) AS total").
+		Joins("LEFT JOIN zone_members ON zone_members.zone_id = zones.id").
+		Joins("LEFT JOIN users ON users.id = zone_members.user_id AND users.deleted_at IS NULL").
+		Group("zones.id, zones.name, zones.kind, users.status").
+		Order("zones.name, zones.id").
+		Scan(&rows).Error
 	if err != nil {
 		return nil, err
 	}
 
-	for _, v := range dbResult {
-		data, ok := result[v.Item]
-		if !ok {
-			result[v.Item] = v
+	infected := make(map[string]bool)
+	for _, v := range usrStore.InfectedStatuses() {
+		infected[string(v)] = true
+	}
+	var (
+		result []*entities.ZoneReport
+		last   *entities.ZoneReport
+	)
+	for _, v := range rows {
+		if last == nil || last.ID != v.ID {
+			last = &entities.ZoneReport{
+				ID:       v.ID,
+				Name:     v.Name,
+				Kind:     v.Kind,
+				Statuses: make(map[string]uint32, len(usrStore.Statuses)),
+			}
+			for _, s := range usrStore.Statuses {
+				last.Statuses[string(s)] = 0
+			}
+			result = append(result, last)
+		}
+		// zones without members have a single row without a status
+		if v.Status == nil {
 			continue
 		}
-		data.Balance += v.Balance
-		result[v.Item] = data
+		last.Statuses[*v.Status] = uint32(v.Total)
+		if *v.Status != string(usrStore.StatusDeceased) {
+			last.Total += uint32(v.Total)
+		}
+		if infected[*v.Status] {
+			last.Infected += uint32(v.Total)
+		}
+	}
+	return result, nil
+}
+
+// ZoneSupplies implements IReportRepository.
+// Only clean survivors are counted, the inventories of everyone else are blocked.
+func (rr *ReportRepository) ZoneSupplies(ctx context.Context) ([]*entities.ZoneSupplies, error) {
+	clean := usrStore.CleanStatuses()
+	var zones []struct {
+		ID        string
+		Name      string
+		Kind      string
+		Survivors int64
+	}
+	err := rr.DB.WithContext(ctx).Table("zones").
+		Select("zones.id, zones.name, zones.kind, COUNT(users.id) AS survivors").
+		Joins("LEFT JOIN zone_members ON zone_members.zone_id = zones.id").
+		Joins("LEFT JOIN users ON users.id = zone_members.user_id AND users.deleted_at IS NULL AND users.status IN ?", clean).
+		Group("zones.id, zones.name, zones.kind").
+		Order("zones.name, zones.id").
+		Scan(&zones).Error
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ZoneID  string
+		Item    core.Item
+		Balance uint32
+	}
+	err = rr.DB.WithContext(ctx).Table("zone_members").
+		Select("zone_members.zone_id, inventories.item, SUM(inventories.balance) AS balance").
+		Joins("JOIN users ON users.id = zone_members.user_id AND users.deleted_at IS NULL").
+		Joins("JOIN inventories ON inventories.user_id = users.id").
+		Where("users.status IN ? AND inventories.is_accessible = ?", clean, true).
+		Group("zone_members.zone_id, inventories.item").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
 	}
 
+	result := make([]*entities.ZoneSupplies, 0, len(zones))
+	byID := make(map[string]*entities.ZoneSupplies, len(zones))
+	for _, v := range zones {
+		z := &entities.ZoneSupplies{
+			ID:        v.ID,
+			Name:      v.Name,
+			Kind:      v.Kind,
+			Survivors: uint32(v.Survivors),
+			Resources: make(map[core.Item]uint32),
+		}
+		byID[v.ID] = z
+		result = append(result, z)
+	}
+	for _, v := range rows {
+		if z, ok := byID[v.ZoneID]; ok {
+			z.Resources[v.Item] = v.Balance
+		}
+	}
 	return result, nil
 }
 
-// Survivors returns the rate of survivors
-func (rr *ReportRepository) Survivors(ctx context.Context) (*entities.Survivor, error) {
-	var (
-		clean      int64
-		percentage float64
-	)
+// Locations implements IReportRepository.
+// The resources are summed per survivor in the database, blocked inventories are left out like in the resources report.
+// Survivors who hide their location are left out as well.
+func (rr *ReportRepository) Locations(ctx context.Context, box *geo.Box) ([]*entities.SurvivorLocation, error) {
+	survivors := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("users.status <> ? AND users.visibility <> ?", usrStore.StatusDeceased, usrStore.VisibilityHidden)
+		if box != nil {
+			db = db.Where("users.latitude BETWEEN ? AND ?", box.MinLatitude, box.MaxLatitude).
+				Where("users.longitude BETWEEN ? AND ?", box.MinLongitude, box.MaxLongitude)
+		}
+		return db
+	}
 
-	total, err := rr.Total(ctx)
+	var users []*usrStore.User
+	err := rr.DB.WithContext(ctx).Select("id, latitude, longitude, status").Scopes(survivors).Order("id").Find(&users).Error
 	if err != nil {
 		return nil, err
 	}
-
-	err = rr.DB.Table("users").Where("infected = false").Count(&clean).Error
+	var rows []struct {
+		UserID  string
+		Item    core.Item
+		Balance uint32
+	}
+	err = rr.DB.WithContext(ctx).Model(&invStore.Inventory{}).
+		Select("inventories.user_id, inventories.item, SUM(inventories.balance) AS balance").
+		Joins("JOIN users ON users.id = inventories.user_id AND users.deleted_at IS NULL").
+		Scopes(survivors).
+		Where("inventories.is_accessible = ?", true).
+		Group("inventories.user_id, inventories.item").
+		Scan(&rows).Error
 	if err != nil {
 		return nil, err
 	}
 
-	if total > 0 && clean > 0 {
-		percentage = float64(clean) / float64(total) * 100.0
+	resources := make(map[string]map[core.Item]uint32)
+	for _, v := range rows {
+		if resources[v.UserID] == nil {
+			resources[v.UserID] = make(map[core.Item]uint32)
+		}
+		resources[v.UserID][v.Item] = v.Balance
 	}
-
-	return &entities.Survivor{
-		Total:      uint32(total),
-		Clean:      uint32(clean),
-		Percentage: percentage,
-	}, nil
+	result := make([]*entities.SurvivorLocation, 0, len(users))
+	for _, v := range users {
+		result = append(result, &entities.SurvivorLocation{
+			UserID:    v.ID,
+			Latitude:  v.Latitude,
+			Longitude: v.Longitude,
+			Status:    string(v.Status),
+			Resources: resources[v.ID],
+		})
+	}
+	return result, nil
 }
@@ -4,14 +4,18 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
+	"zssn/database"
 	"zssn/domains/core"
+	"zssn/domains/entities"
+	"zssn/domains/geo"
 	invStore "zssn/domains/inventory/store"
+	trStore "zssn/domains/trade/store"
 	usrStore "zssn/domains/users/store"
 
 	"github.com/brianvoe/gofakeit"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
@@ -120,6 +124,166 @@ func TestGetSurvivorRateNoUsers(t *testing.T) {
 	assert.Equal(t, float64(0.0), inf.Percentage)
 }
 
+func TestStatuses(t *testing.T) {
+	ids := createSomeInfectedUser(t, 10, 2)
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM users WHERE id IN ?", ids)
+	})
+	ctx := context.Background()
+	require.NoError(t, userStorage.UpdateStatus(ctx, ids[0], usrStore.StatusSuspected))
+	require.NoError(t, userStorage.UpdateStatus(ctx, ids[1], usrStore.StatusQuarantined))
+	require.NoError(t, userStorage.UpdateStatus(ctx, ids[2], usrStore.StatusDeceased))
+
+	res, err := repo.Statuses(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint32{
+		"healthy":     3,
+		"suspected":   1,
+		"infected":    4,
+		"quarantined": 1,
+		"recovered":   0,
+		"deceased":    1,
+	}, res)
+
+	// deceased survivors are left out, quarantined ones count as infected
+	inf, err := repo.Infected(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(9), inf.Total)
+	assert.Equal(t, uint32(5), inf.Infected)
+
+	sur, err := repo.Survivors(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(4), sur.Clean)
+
+	// deleted users aren't counted anywhere
+	require.NoError(t, db.Where("id = ?", ids[0]).Delete(&usrStore.User{}).Error)
+	res, err = repo.Statuses(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), res["suspected"])
+	total, err := repo.Total(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(8), total)
+	inf, err = repo.Infected(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(8), inf.Total)
+	sur, err = repo.Survivors(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), sur.Clean)
+}
+
+func TestZones(t *testing.T) {
+	ids := createSomeInfectedUser(t, 4, 2)
+	ctx := context.Background()
+	camp := &usrStore.Zone{Name: "camp", Kind: usrStore.ZoneSafe, Latitude: 1, Longitude: 1, RadiusKm: 1}
+	empty := &usrStore.Zone{Name: "empty", Kind: usrStore.ZoneDanger, Latitude: 2, Longitude: 2, RadiusKm: 1}
+	require.NoError(t, userStorage.CreateZone(ctx, camp))
+	require.NoError(t, userStorage.CreateZone(ctx, empty))
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM zone_members WHERE zone_id IN ?", []string{camp.ID, empty.ID})
+		db.Exec("DELETE FROM zones WHERE id IN ?", []string{camp.ID, empty.ID})
+		db.Exec("DELETE FROM users WHERE id IN ?", ids)
+	})
+	require.NoError(t, userStorage.UpdateStatus(ctx, ids[2], usrStore.StatusDeceased))
+	for _, id := range ids[:3] {
+		require.NoError(t, userStorage.AddZoneMember(ctx, &usrStore.ZoneMember{UserID: id, ZoneID: camp.ID}))
+	}
+
+	res, err := repo.Zones(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, camp.ID, res[0].ID)
+	assert.Equal(t, "safe", res[0].Kind)
+	// the deceased survivor is only counted in the statuses
+	assert.Equal(t, uint32(2), res[0].Total)
+	assert.Equal(t, uint32(1), res[0].Infected)
+	assert.Equal(t, uint32(1), res[0].Statuses["healthy"])
+	assert.Equal(t, uint32(1), res[0].Statuses["infected"])
+	assert.Equal(t, uint32(1), res[0].Statuses["deceased"])
+	assert.Equal(t, "empty", res[1].Name)
+	assert.Zero(t, res[1].Total)
+	assert.Len(t, res[1].Statuses, len(usrStore.Statuses))
+}
+
+func TestZoneSupplies(t *testing.T) {
+	ids, userIDs := createInaccessibleInventory(t, 3, 2)
+	ctx := context.Background()
+	camp := &usrStore.Zone{Name: "camp", Kind: usrStore.ZoneSafe, Latitude: 1, Longitude: 1, RadiusKm: 1}
+	empty := &usrStore.Zone{Name: "empty", Kind: usrStore.ZoneDanger, Latitude: 2, Longitude: 2, RadiusKm: 1}
+	require.NoError(t, userStorage.CreateZone(ctx, camp))
+	require.NoError(t, userStorage.CreateZone(ctx, empty))
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM zone_members WHERE zone_id IN ?", []string{camp.ID, empty.ID})
+		db.Exec("DELETE FROM zones WHERE id IN ?", []string{camp.ID, empty.ID})
+		db.Exec("DELETE FROM inventories WHERE id IN ?", ids)
+		db.Exec("DELETE FROM users WHERE id IN ?", userIDs)
+	})
+	// the second survivor is infected with a blocked inventory, the third one still has access to theirs
+	require.NoError(t, userStorage.UpdateStatus(ctx, userIDs[1], usrStore.StatusInfected))
+	require.NoError(t, userStorage.UpdateStatus(ctx, userIDs[2], usrStore.StatusInfected))
+	for _, id := range userIDs {
+		require.NoError(t, userStorage.AddZoneMember(ctx, &usrStore.ZoneMember{UserID: id, ZoneID: camp.ID}))
+	}
+
+	res, err := repo.ZoneSupplies(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, camp.ID, res[0].ID)
+	assert.Equal(t, "safe", res[0].Kind)
+	assert.Equal(t, uint32(1), res[0].Survivors)
+	assert.Equal(t, uint32(20), res[0].Resources[core.ItemWater])
+	assert.Equal(t, uint32(30), res[0].Resources[core.ItemMedication])
+	assert.Equal(t, "empty", res[1].Name)
+	assert.Zero(t, res[1].Survivors)
+	assert.Empty(t, res[1].Resources)
+}
+
+func TestLocations(t *testing.T) {
+	ids, userIDs := createInaccessibleInventory(t, 2, 2)
+	deceased := createSomeInfectedUser(t, 1, 2)
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM inventories WHERE id IN ?", ids)
+		db.Exec("DELETE FROM users WHERE id IN ?", append(userIDs, deceased...))
+	})
+	ctx := context.Background()
+	require.NoError(t, userStorage.UpdateLocation(ctx, userIDs[0], 12.3, 45.6))
+	require.NoError(t, userStorage.UpdateLocation(ctx, userIDs[1], 12.31, 45.61))
+	require.NoError(t, userStorage.UpdateLocation(ctx, deceased[0], 12.3, 45.6))
+	require.NoError(t, userStorage.UpdateStatus(ctx, deceased[0], usrStore.StatusDeceased))
+
+	res, err := repo.Locations(ctx, &geo.Box{MinLatitude: 12, MaxLatitude: 12.5, MinLongitude: 45.5, MaxLongitude: 46})
+	require.NoError(t, err)
+	found := make(map[string]*entities.SurvivorLocation)
+	for _, v := range res {
+		found[v.UserID] = v
+	}
+	require.Contains(t, found, userIDs[0])
+	require.Contains(t, found, userIDs[1])
+	assert.NotContains(t, found, deceased[0])
+	assert.Equal(t, 12.3, found[userIDs[0]].Latitude)
+	assert.Equal(t, "healthy", found[userIDs[0]].Status)
+	assert.Equal(t, uint32(20), found[userIDs[0]].Resources[core.ItemWater])
+	assert.Equal(t, uint32(50), found[userIDs[0]].Resources[core.ItemAmmunition])
+	// blocked inventories are left out
+	assert.Empty(t, found[userIDs[1]].Resources)
+
+	res, err = repo.Locations(ctx, &geo.Box{MinLatitude: 12.305, MaxLatitude: 12.5, MinLongitude: 45.5, MaxLongitude: 46})
+	require.NoError(t, err)
+	for _, v := range res {
+		assert.NotEqual(t, userIDs[0], v.UserID)
+	}
+	res, err = repo.Locations(ctx, nil)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(res), 2)
+
+	// hidden survivors are left out
+	require.NoError(t, userStorage.UpdateVisibility(ctx, userIDs[0], usrStore.VisibilityHidden))
+	res, err = repo.Locations(ctx, nil)
+	require.NoError(t, err)
+	for _, v := range res {
+		assert.NotEqual(t, userIDs[0], v.UserID)
+	}
+}
+
 func TestResources(t *testing.T) {
 	ids, userIDs := createInaccessibleInventory(t, 10, 2)
 	t.Cleanup(func() {
@@ -241,12 +405,7 @@ func newInventory(t *testing.T, userID string) []*invStore.Inventory {
 }
 
 func setupTestDB() (*gorm.DB, error) {
-	env := os.Getenv("ENVIRONMENT")
-	dsn := "root:@tcp(127.0.0.1:3306)/zssn?charset=utf8mb4&parseTime=True&loc=Local"
-	if env == "cicd" {
-		dsn = "zssn_user:password@tcp(127.0.0.1:33306)/zssn?charset=utf8mb4&parseTime=True&loc=Local"
-	}
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	return database.OpenTest()
 }
 
 func cleanup() {
@@ -255,3 +414,113 @@ func cleanup() {
 	db.Exec("DELETE FROM flag_monitors")
 	db.Exec("DELETE FROM users")
 }
+
+func TestSnapshots(t *testing.T) {
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM report_snapshots")
+	})
+	ctx := context.Background()
+	hour := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, v := range []*Snapshot{
+		{Hour: hour, Total: 10, Infected: 1},
+		{Hour: hour.Add(time.Hour), Total: 10, Infected: 2, Resources: map[string]uint32{"water": 4}},
+		// taken again in the same hour
+		{Hour: hour.Add(time.Hour), Total: 10, Infected: 3, Resources: map[string]uint32{"water": 5}},
+		{Hour: hour.Add(2 * time.Hour), Total: 9, Infected: 4},
+	} {
+		require.NoError(t, repo.SaveSnapshot(ctx, v), i)
+		assert.NotEmpty(t, v.ID)
+	}
+
+	res, err := repo.FindSnapshots(ctx, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	assert.True(t, hour.Equal(res[0].Hour))
+	assert.Equal(t, uint32(3), res[1].Infected)
+	assert.Equal(t, map[string]uint32{"water": 5}, res[1].Resources)
+
+	res, err = repo.FindSnapshots(ctx, hour.Add(time.Hour), hour.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, uint32(3), res[0].Infected)
+}
+
+func TestTradeActivity(t *testing.T) {
+	ctx := context.Background()
+	trades, err := trStore.New(db)
+	require.NoError(t, err)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		u := newUser(t)
+		require.NoError(t, userStorage.Create(ctx, u))
+		ids = append(ids, u.ID)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM transactions WHERE seller_id IN ?", ids)
+		db.Exec("DELETE FROM users WHERE id IN ?", ids)
+	})
+	trade := func(seller, buyer string, sold, bought []trStore.TradeItem) {
+		require.NoError(t, trades.Execute(ctx, &trStore.TradeItems{UserID: seller, Items: sold}, &trStore.TradeItems{UserID: buyer, Items: bought}))
+	}
+	water := func(q uint32) trStore.TradeItem { return trStore.TradeItem{Item: core.ItemWater, Quantity: q} }
+	food := func(q uint32) trStore.TradeItem { return trStore.TradeItem{Item: core.ItemFood, Quantity: q} }
+	ammo := func(q uint32) trStore.TradeItem { return trStore.TradeItem{Item: core.ItemAmmunition, Quantity: q} }
+	// 3 water for 4 food twice, then 1 water and 1 ammunition for 5 ammunition
+	trade(ids[0], ids[1], []trStore.TradeItem{water(3)}, []trStore.TradeItem{food(4)})
+	trade(ids[1], ids[0], []trStore.TradeItem{food(8)}, []trStore.TradeItem{water(6)})
+	trade(ids[0], ids[2], []trStore.TradeItem{water(1), ammo(1)}, []trStore.TradeItem{ammo(5)})
+
+	// the trades are summed up per bucket in the database, buckets start on the hour in UTC
+	res, err := repo.TradeActivity(ctx, time.Time{}, time.Time{}, time.Hour, 2)
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Series)
+	var total, volume uint32
+	for _, v := range res.Series {
+		assert.Equal(t, time.UTC, v.Start.Location())
+		assert.True(t, v.Start.Equal(v.Start.Truncate(time.Hour)), v.Start)
+		total += v.Trades
+		volume += v.Volume
+	}
+	assert.Equal(t, uint32(3), total)
+	assert.Equal(t, uint32(82), volume)
+	last := res.Series[len(res.Series)-1]
+	assert.WithinDuration(t, time.Now().Truncate(time.Hour), last.Start, time.Hour)
+
+	require.Len(t, res.Items, 3)
+	assert.Equal(t, core.ItemFood, res.Items[0].Item)
+	assert.Equal(t, uint32(12), res.Items[0].Quantity)
+	assert.Equal(t, uint32(2), res.Items[0].Trades)
+	assert.Equal(t, uint32(36), res.Items[0].Volume)
+	assert.Equal(t, core.ItemWater, res.Items[1].Item)
+	assert.Equal(t, uint32(10), res.Items[1].Quantity)
+	assert.Equal(t, uint32(3), res.Items[1].Trades)
+
+	// the trade with two items on one side isn't a swap
+	require.Len(t, res.Swaps, 2)
+	assert.Equal(t, &Swap{Given: core.ItemWater, Received: core.ItemFood, GivenQuantity: 9, ReceivedQuantity: 12, Trades: 2}, res.Swaps[0])
+	assert.Equal(t, &Swap{Given: core.ItemFood, Received: core.ItemWater, GivenQuantity: 12, ReceivedQuantity: 9, Trades: 2}, res.Swaps[1])
+
+	assert.Equal(t, uint32(3), res.Traders)
+	require.Len(t, res.TopTraders, 2)
+	assert.Equal(t, ids[0], res.TopTraders[0].ID)
+	assert.NotEmpty(t, res.TopTraders[0].Name)
+	assert.Equal(t, uint32(3), res.TopTraders[0].Trades)
+	assert.Equal(t, uint32(41), res.TopTraders[0].Volume)
+	assert.Equal(t, ids[1], res.TopTraders[1].ID)
+
+	// nothing was traded before the range
+	res, err = repo.TradeActivity(ctx, time.Time{}, time.Now().Add(-time.Hour), 24*time.Hour, 2)
+	require.NoError(t, err)
+	assert.Empty(t, res.Series)
+	assert.Empty(t, res.Items)
+	assert.Zero(t, res.Traders)
+	res, err = repo.TradeActivity(ctx, time.Now().Add(-time.Hour), time.Time{}, 24*time.Hour, 0)
+	require.NoError(t, err)
+	require.Len(t, res.Series, 1)
+	assert.Equal(t, uint32(3), res.Series[0].Trades)
+	assert.True(t, time.Now().UTC().Truncate(24*time.Hour).Equal(res.Series[0].Start))
+	assert.Empty(t, res.TopTraders)
+
+	_, err = repo.TradeActivity(ctx, time.Time{}, time.Time{}, 0, 0)
+	assert.Error(t, err)
+}
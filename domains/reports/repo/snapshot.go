@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Snapshot the reports as they were at the end of an hour
+type Snapshot struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	Hour       time.Time `json:"hour" gorm:"uniqueIndex"`
+	Total      uint32    `json:"total"`
+	Clean      uint32    `json:"clean"`
+	Infected   uint32    `json:"infected"`
+	LostPoints uint32    `json:"lost_points"`
+	// Resources the balance of every item survivors can still trade, by item name
+	Resources map[string]uint32 `json:"resources" gorm:"serializer:json"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// TableName overrides the default table name
+func (Snapshot) TableName() string {
+	return "report_snapshots"
+}
+
+// SaveSnapshot implements IReportRepository, a snapshot replaces the one already taken in the same hour
+func (rr *ReportRepository) SaveSnapshot(ctx context.Context, s *Snapshot) error {
+	return rr.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("hour = ?", s.Hour).Delete(&Snapshot{}).Error; err != nil {
+			return err
+		}
+		s.ID = uuid.NewString()
+		return tx.Create(s).Error
+	})
+}
+
+// FindSnapshots implements IReportRepository
+func (rr *ReportRepository) FindSnapshots(ctx context.Context, from, to time.Time) ([]*Snapshot, error) {
+	var result []*Snapshot
+	query := rr.DB.WithContext(ctx)
+	if !from.IsZero() {
+		query = query.Where("hour >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("hour <= ?", to)
+	}
+	err := query.Order("hour").Find(&result).Error
+	return result, err
+}
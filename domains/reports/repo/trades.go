@@ -0,0 +1,176 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"zssn/domains/core"
+	trStore "zssn/domains/trade/store"
+
+	"gorm.io/gorm"
+)
+
+// TradeActivity the trades of a time range aggregated for the trade report, volumes are in points
+type TradeActivity struct {
+	// Series the trades of every bucket something was traded in, oldest first
+	Series []*TradeBucket
+	// Items every traded item, most traded first
+	Items []*TradedItem
+	// Swaps the trades of a single item for another, once for each side of the trade
+	Swaps []*Swap
+	// Traders the number of survivors who traded
+	Traders uint32
+	// TopTraders the survivors who took part in the most trades
+	TopTraders []*Trader
+}
+
+// TradeBucket the trades made in a bucket of time and the points they moved, counting both sides
+type TradeBucket struct {
+	Start  time.Time
+	Trades uint32
+	Volume uint32
+}
+
+// TradedItem how much of an item changed hands
+type TradedItem struct {
+	Item     core.Item
+	Quantity uint32
+	Trades   uint32
+	Volume   uint32
+}
+
+// Swap the quantity given of an item and the quantity received of another in trades of one item for another
+type Swap struct {
+	Given            core.Item
+	Received         core.Item
+	GivenQuantity    uint32
+	ReceivedQuantity uint32
+	Trades           uint32
+}
+
+// Trader the trades a survivor took part in and the points they gave away in them
+type Trader struct {
+	ID     string
+	Name   string
+	Trades uint32
+	Volume uint32
+}
+
+// TradeActivity implements IReportRepository.
+// Every side of a trade is a transaction of each item it gave, so the survivors who gave something are the ones who traded.
+func (rr *ReportRepository) TradeActivity(ctx context.Context, from, to time.Time, bucket time.Duration, topTraders int) (*TradeActivity, error) {
+	if bucket < time.Second {
+		return nil, fmt.Errorf("bucket must be at least a second, got %s", bucket)
+	}
+	between := func(db *gorm.DB) *gorm.DB {
+		if !from.IsZero() {
+			db = db.Where("transactions.created_at >= ?", from)
+		}
+		if !to.IsZero() {
+			db = db.Where("transactions.created_at <= ?", to)
+		}
+		return db
+	}
+	transactions := func() *gorm.DB {
+		return rr.DB.WithContext(ctx).Model(&trStore.Transaction{}).Scopes(between)
+	}
+	points := pointsExpr("transactions.item")
+
+	var res TradeActivity
+	// the transactions of a trade are created together, so a trade never spans two buckets
+	var buckets []struct {
+		Bucket int64
+		Trades uint32
+		Volume uint32
+	}
+	start := rr.bucketExpr("transactions.created_at", bucket)
+	err := transactions().
+		Select(start + " AS bucket, COUNT(DISTINCT transactions.reference) AS trades, SUM(transactions.quantity * " + points + ") AS volume").
+		Group(start).
+		Order("bucket").
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range buckets {
+		res.Series = append(res.Series, &TradeBucket{Start: time.Unix(v.Bucket, 0).UTC(), Trades: v.Trades, Volume: v.Volume})
+	}
+	err = transactions().
+		Select("transactions.item, SUM(transactions.quantity) AS quantity, COUNT(DISTINCT transactions.reference) AS trades, " +
+			"SUM(transactions.quantity * " + points + ") AS volume").
+		Group("transactions.item").
+		Order("quantity DESC, transactions.item").
+		Scan(&res.Items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// trades of one item for another are the ones with a transaction on each side
+	swaps := transactions().Select("transactions.reference").Group("transactions.reference").Having("COUNT(*) = 2")
+	err = rr.DB.WithContext(ctx).Table("transactions AS given").
+		Select("given.item AS given, received.item AS received, SUM(given.quantity) AS given_quantity, "+
+			"SUM(received.quantity) AS received_quantity, COUNT(*) AS trades").
+		Joins("JOIN transactions AS received ON received.reference = given.reference AND received.seller_id <> given.seller_id AND received.deleted_at IS NULL").
+		Where("given.deleted_at IS NULL AND given.reference IN (?)", swaps).
+		Group("given.item, received.item").
+		Order("given.item, received.item").
+		Scan(&res.Swaps).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var traders int64
+	if err := transactions().Distinct("transactions.seller_id").Count(&traders).Error; err != nil {
+		return nil, err
+	}
+	res.Traders = uint32(traders)
+	if topTraders <= 0 {
+		return &res, nil
+	}
+	err = transactions().
+		Select("transactions.seller_id AS id, users.name, COUNT(DISTINCT transactions.reference) AS trades, " +
+			"SUM(transactions.quantity * " + points + ") AS volume").
+		Joins("LEFT JOIN users ON users.id = transactions.seller_id").
+		Group("transactions.seller_id, users.name").
+		Order("trades DESC, volume DESC, transactions.seller_id").
+		Limit(topTraders).
+		Scan(&res.TopTraders).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// bucketExpr returns the SQL expression of the start of the bucket the time in column is in, as seconds since the epoch.
+// Buckets are counted from the epoch, so hours and days start on the hour and at midnight UTC.
+func (rr *ReportRepository) bucketExpr(column string, bucket time.Duration) string {
+	size := int64(bucket / time.Second)
+	switch rr.DB.Dialector.Name() {
+	case "postgres":
+		return fmt.Sprintf("CAST(FLOOR(EXTRACT(EPOCH FROM %s) / %d) AS BIGINT) * %d", column, size, size)
+	case "mysql":
+		return fmt.Sprintf("UNIX_TIMESTAMP(%s) DIV %d * %d", column, size, size)
+	default:
+		return fmt.Sprintf("CAST(strftime('%%s', %s) AS INTEGER) / %d * %d", column, size, size)
+	}
+}
+
+// pointsExpr returns the SQL expression of the points of the item in column
+func pointsExpr(column string) string {
+	items := make([]core.Item, 0, len(core.ItemPoints))
+	for k := range core.ItemPoints {
+		items = append(items, k)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i] < items[j] })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CASE %s", column)
+	for _, v := range items {
+		fmt.Fprintf(&b, " WHEN %d THEN %d", v, core.ItemPoints[v])
+	}
+	b.WriteString(" ELSE 0 END")
+	return b.String()
+}
@@ -13,13 +13,25 @@ var _ IReportService = (*ReportService)(nil)
 // ReportService returns the implementation of report service
 type ReportService struct {
 	Repository repo.IReportRepository
+
+	forecast      ForecastRules
+	traderPrivacy TraderPrivacy
 }
 
+// Option configures the report service
+type Option func(*ReportService)
+
 // New returns a new service implementation
-func New(repo repo.IReportRepository) IReportService {
-	return &ReportService{
-		Repository: repo,
+func New(repo repo.IReportRepository, opts ...Option) IReportService {
+	rs := &ReportService{
+		Repository:    repo,
+		forecast:      DefaultForecastRules(),
+		traderPrivacy: TradersAnonymous,
 	}
+	for _, opt := range opts {
+		opt(rs)
+	}
+	return rs
 }
 
 // InfectedSurvivors implements IReportService
@@ -48,6 +60,16 @@ func (rs *ReportService) NonInfectedSurvivors(ctx context.Context) (*entities.Su
 	return rs.Repository.Survivors(ctx)
 }
 
+// Statuses implements IReportService
+func (rs *ReportService) Statuses(ctx context.Context) (map[string]uint32, error) {
+	return rs.Repository.Statuses(ctx)
+}
+
+// Zones implements IReportService
+func (rs *ReportService) Zones(ctx context.Context) ([]*entities.ZoneReport, error) {
+	return rs.Repository.Zones(ctx)
+}
+
 // ResourceSharing implements IReportService
 func (rs *ReportService) ResourceSharing(ctx context.Context) (map[string]*entities.ResourceSharing, error) {
 	surviors, err := rs.Repository.Survivors(ctx)
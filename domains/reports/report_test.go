@@ -236,3 +236,32 @@ func TestResourceSharingResourcesRepoError(t *testing.T) {
 	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
 	require.Nil(t, res)
 }
+
+func TestStatuses(t *testing.T) {
+	repo := &MockReportRepository{
+		StatusesFunc: func(ctx context.Context) (map[string]uint32, error) {
+			return map[string]uint32{"healthy": 3, "infected": 1}, nil
+		},
+	}
+	res, err := New(repo).Statuses(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), res["healthy"])
+
+	_, err = New(&MockReportRepository{}).Statuses(context.Background())
+	require.Error(t, err)
+}
+
+func TestZones(t *testing.T) {
+	repo := &MockReportRepository{
+		ZonesFunc: func(ctx context.Context) ([]*entities.ZoneReport, error) {
+			return []*entities.ZoneReport{{ID: "camp", Total: 2}}, nil
+		},
+	}
+	res, err := New(repo).Zones(context.Background())
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, uint32(2), res[0].Total)
+
+	_, err = New(&MockReportRepository{}).Zones(context.Background())
+	require.Error(t, err)
+}
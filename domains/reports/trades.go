@@ -0,0 +1,137 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"zssn/domains/entities"
+)
+
+const (
+	// DefaultTopTraders number of traders in the trade report when no limit is given
+	DefaultTopTraders = 10
+	// MaxTopTraders most traders the trade report lists
+	MaxTopTraders = 100
+)
+
+var (
+	// ErrInvalidLimit is returned when the number of top traders is out of range
+	ErrInvalidLimit = fmt.Errorf("limit must be between 1 and %d", MaxTopTraders)
+	// ErrUnknownTraderPrivacy is returned for a trader privacy that doesn't exist
+	ErrUnknownTraderPrivacy = errors.New("unknown trader privacy")
+)
+
+// TraderPrivacy how much the trade report tells about the most active traders
+type TraderPrivacy string
+
+const (
+	// TradersPublic the traders are listed with their ID and name
+	TradersPublic TraderPrivacy = "public"
+	// TradersAnonymous the traders are listed by rank only, the default
+	TradersAnonymous TraderPrivacy = "anonymous"
+	// TradersHidden the traders aren't listed
+	TradersHidden TraderPrivacy = "hidden"
+)
+
+// ParseTraderPrivacy returns the trader privacy with the given name
+func ParseTraderPrivacy(s string) (TraderPrivacy, error) {
+	switch TraderPrivacy(s) {
+	case TradersPublic, TradersAnonymous, TradersHidden:
+		return TraderPrivacy(s), nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnknownTraderPrivacy, s)
+	}
+}
+
+// WithTraderPrivacy sets how much the trade report tells about the most active traders, unknown values are ignored
+func WithTraderPrivacy(p TraderPrivacy) Option {
+	return func(rs *ReportService) {
+		if _, err := ParseTraderPrivacy(string(p)); err == nil {
+			rs.traderPrivacy = p
+		}
+	}
+}
+
+// TradeReportOptions the trades the report covers, zero times leave the range open.
+// Interval is how far apart the points of the series are and Limit the number of top traders, DefaultTopTraders when it's 0.
+type TradeReportOptions struct {
+	From     time.Time
+	To       time.Time
+	Interval Interval
+	Limit    int
+}
+
+// Trades implements IReportService.
+// Only trades of a single item for another imply an exchange ratio.
+func (rs *ReportService) Trades(ctx context.Context, opts TradeReportOptions) (*entities.TradeReport, error) {
+	if !opts.From.IsZero() && !opts.To.IsZero() && opts.To.Before(opts.From) {
+		return nil, ErrInvalidTimeRange
+	}
+	if opts.Interval != IntervalHour && opts.Interval != IntervalDay {
+		return nil, ErrInvalidInterval
+	}
+	if opts.Limit == 0 {
+		opts.Limit = DefaultTopTraders
+	}
+	if opts.Limit < 0 || opts.Limit > MaxTopTraders {
+		return nil, ErrInvalidLimit
+	}
+	limit := opts.Limit
+	if rs.traderPrivacy == TradersHidden {
+		limit = 0
+	}
+	activity, err := rs.Repository.TradeActivity(ctx, opts.From, opts.To, opts.Interval.duration(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &entities.TradeReport{
+		Traders:        activity.Traders,
+		Series:         make([]*entities.TradePoint, 0, len(activity.Series)),
+		TopItems:       make([]*entities.TradedItem, 0, len(activity.Items)),
+		ExchangeRatios: make([]*entities.ExchangeRatio, 0, len(activity.Swaps)),
+	}
+	for _, v := range activity.Series {
+		res.Trades += v.Trades
+		res.Volume += v.Volume
+		res.Series = append(res.Series, &entities.TradePoint{Time: v.Start, Trades: v.Trades, Volume: v.Volume})
+	}
+	for _, v := range activity.Items {
+		res.TopItems = append(res.TopItems, &entities.TradedItem{
+			Item:     strings.ToLower(v.Item.String()),
+			Quantity: v.Quantity,
+			Trades:   v.Trades,
+			Volume:   v.Volume,
+		})
+	}
+	for _, v := range activity.Swaps {
+		if v.GivenQuantity == 0 {
+			continue
+		}
+		res.ExchangeRatios = append(res.ExchangeRatios, &entities.ExchangeRatio{
+			Given:    strings.ToLower(v.Given.String()),
+			Received: strings.ToLower(v.Received.String()),
+			Ratio:    float64(v.ReceivedQuantity) / float64(v.GivenQuantity),
+			Trades:   v.Trades,
+		})
+	}
+	if rs.traderPrivacy == TradersHidden {
+		return res, nil
+	}
+	res.TopTraders = make([]*entities.Trader, 0, len(activity.TopTraders))
+	for i, v := range activity.TopTraders {
+		t := &entities.Trader{
+			Rank:   i + 1,
+			Trades: v.Trades,
+			Volume: v.Volume,
+		}
+		if rs.traderPrivacy == TradersPublic {
+			t.ID, t.Name = v.ID, v.Name
+		}
+		res.TopTraders = append(res.TopTraders, t)
+	}
+	return res, nil
+}
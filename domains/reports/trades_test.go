@@ -0,0 +1,101 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"zssn/domains/core"
+	"zssn/domains/reports/repo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrades(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var (
+		limit  int
+		bucket time.Duration
+	)
+	mock := &MockReportRepository{
+		TradeActivityFunc: func(ctx context.Context, from, to time.Time, b time.Duration, topTraders int) (*repo.TradeActivity, error) {
+			limit, bucket = topTraders, b
+			return &repo.TradeActivity{
+				Series: []*repo.TradeBucket{
+					{Start: day, Trades: 2, Volume: 72},
+					{Start: day.AddDate(0, 0, 1), Trades: 1, Volume: 10},
+				},
+				Items: []*repo.TradedItem{
+					{Item: core.ItemFood, Quantity: 12, Trades: 2, Volume: 36},
+					{Item: core.ItemWater, Quantity: 10, Trades: 3, Volume: 40},
+				},
+				Swaps: []*repo.Swap{
+					{Given: core.ItemWater, Received: core.ItemFood, GivenQuantity: 9, ReceivedQuantity: 12, Trades: 2},
+					{Given: core.ItemFood, Received: core.ItemWater, GivenQuantity: 12, ReceivedQuantity: 9, Trades: 2},
+				},
+				Traders:    3,
+				TopTraders: []*repo.Trader{{ID: "ada", Name: "Ada", Trades: 3, Volume: 41}, {ID: "bob", Name: "Bob", Trades: 2, Volume: 36}},
+			}, nil
+		},
+	}
+
+	res, err := New(mock).Trades(context.Background(), TradeReportOptions{Interval: IntervalDay})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTopTraders, limit)
+	assert.Equal(t, 24*time.Hour, bucket)
+	assert.Equal(t, uint32(3), res.Trades)
+	assert.Equal(t, uint32(82), res.Volume)
+	assert.Equal(t, uint32(3), res.Traders)
+	require.Len(t, res.Series, 2)
+	assert.True(t, day.Equal(res.Series[0].Time))
+	assert.Equal(t, uint32(2), res.Series[0].Trades)
+	assert.Equal(t, uint32(72), res.Series[0].Volume)
+	assert.Equal(t, uint32(1), res.Series[1].Trades)
+	assert.Equal(t, "food", res.TopItems[0].Item)
+	require.Len(t, res.ExchangeRatios, 2)
+	assert.Equal(t, "water", res.ExchangeRatios[0].Given)
+	assert.InDelta(t, 1.333, res.ExchangeRatios[0].Ratio, 0.001)
+	assert.Equal(t, 0.75, res.ExchangeRatios[1].Ratio)
+	// the traders are anonymous by default
+	require.Len(t, res.TopTraders, 2)
+	assert.Equal(t, 1, res.TopTraders[0].Rank)
+	assert.Equal(t, uint32(3), res.TopTraders[0].Trades)
+	assert.Empty(t, res.TopTraders[0].ID)
+	assert.Empty(t, res.TopTraders[0].Name)
+
+	res, err = New(mock, WithTraderPrivacy(TradersPublic)).Trades(context.Background(), TradeReportOptions{Interval: IntervalHour, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, limit)
+	assert.Equal(t, time.Hour, bucket)
+	assert.Len(t, res.Series, 2)
+	assert.Equal(t, "ada", res.TopTraders[0].ID)
+	assert.Equal(t, "Bob", res.TopTraders[1].Name)
+
+	res, err = New(mock, WithTraderPrivacy(TradersHidden)).Trades(context.Background(), TradeReportOptions{Interval: IntervalDay})
+	require.NoError(t, err)
+	assert.Zero(t, limit)
+	assert.Nil(t, res.TopTraders)
+	assert.Equal(t, uint32(3), res.Traders)
+
+	for _, opts := range []TradeReportOptions{
+		{Interval: "week"},
+		{Interval: IntervalDay, Limit: MaxTopTraders + 1},
+		{Interval: IntervalDay, Limit: -1},
+		{Interval: IntervalDay, From: day, To: day.Add(-time.Hour)},
+	} {
+		_, err = New(mock).Trades(context.Background(), opts)
+		assert.True(t, errors.Is(err, ErrInvalidInterval) || errors.Is(err, ErrInvalidLimit) || errors.Is(err, ErrInvalidTimeRange), opts)
+	}
+	_, err = New(&MockReportRepository{}).Trades(context.Background(), TradeReportOptions{Interval: IntervalDay})
+	assert.ErrorIs(t, err, errMockNotInitialized)
+}
+
+func TestParseTraderPrivacy(t *testing.T) {
+	p, err := ParseTraderPrivacy("public")
+	require.NoError(t, err)
+	assert.Equal(t, TradersPublic, p)
+	_, err = ParseTraderPrivacy("secret")
+	assert.ErrorIs(t, err, ErrUnknownTraderPrivacy)
+}
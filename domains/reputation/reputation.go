@@ -0,0 +1,88 @@
+// Package reputation scores how trustworthy a survivor is from their trades, their flags and the age of their account.
+package reputation
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// MinScore the lowest possible score
+	MinScore = 0
+	// MaxScore the highest possible score
+	MaxScore = 100
+)
+
+// Inputs everything the score is computed from
+type Inputs struct {
+	// Trades number of completed trades
+	Trades int
+	// AccurateFlags flags the survivor raised against survivors who turned out to be infected
+	AccurateFlags int
+	// RejectedFlags flags the survivor raised that were overturned by an appeal
+	RejectedFlags int
+	// FlagsReceived flags currently raised against the survivor
+	FlagsReceived int
+	// AccountAge time since the survivor signed up
+	AccountAge time.Duration
+}
+
+// Func computes the score of a survivor, scores are between MinScore and MaxScore
+type Func func(in Inputs) float64
+
+// Weights of a linear score. Every input adds its weight to the base score for every occurrence,
+// capped where a cap is given so old or busy accounts can't outweigh everything else.
+type Weights struct {
+	Base          float64
+	Trade         float64
+	MaxTrades     int
+	AccurateFlag  float64
+	RejectedFlag  float64
+	FlagReceived  float64
+	AccountDay    float64
+	MaxAccountAge time.Duration
+}
+
+// DefaultWeights new survivors start at 50. Trades and accurate flags earn trust, rejected and received flags cost more than they earn.
+func DefaultWeights() Weights {
+	return Weights{
+		Base:          50,
+		Trade:         2,
+		MaxTrades:     10,
+		AccurateFlag:  5,
+		RejectedFlag:  -10,
+		FlagReceived:  -8,
+		AccountDay:    0.5,
+		MaxAccountAge: 30 * 24 * time.Hour,
+	}
+}
+
+// Score implements Func
+func (w Weights) Score(in Inputs) float64 {
+	trades := in.Trades
+	if w.MaxTrades > 0 && trades > w.MaxTrades {
+		trades = w.MaxTrades
+	}
+	age := in.AccountAge
+	if age < 0 {
+		age = 0
+	}
+	if w.MaxAccountAge > 0 && age > w.MaxAccountAge {
+		age = w.MaxAccountAge
+	}
+
+	score := w.Base +
+		w.Trade*float64(trades) +
+		w.AccurateFlag*float64(in.AccurateFlags) +
+		w.RejectedFlag*float64(in.RejectedFlags) +
+		w.FlagReceived*float64(in.FlagsReceived) +
+		w.AccountDay*age.Hours()/24
+	// keep the score readable, the exact decimals of the account age don't matter
+	score = math.Round(score*100) / 100
+	return math.Max(MinScore, math.Min(MaxScore, score))
+}
+
+// Default scores survivors with the default weights
+func Default(in Inputs) float64 {
+	return DefaultWeights().Score(in)
+}
@@ -0,0 +1,46 @@
+package reputation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const day = 24 * time.Hour
+
+func TestDefaultWeights(t *testing.T) {
+	table := []struct {
+		name     string
+		in       Inputs
+		expected float64
+	}{
+		{"new survivor", Inputs{}, 50},
+		{"trades", Inputs{Trades: 3}, 56},
+		{"trades are capped", Inputs{Trades: 50}, 70},
+		{"accurate flags", Inputs{AccurateFlags: 2}, 60},
+		{"rejected flag", Inputs{RejectedFlags: 1}, 40},
+		{"flags received", Inputs{FlagsReceived: 2}, 34},
+		{"account age", Inputs{AccountAge: 10 * day}, 55},
+		{"account age is capped", Inputs{AccountAge: 400 * day}, 65},
+		{"partial days", Inputs{AccountAge: 36 * time.Hour}, 50.75},
+		{"negative age", Inputs{AccountAge: -day}, 50},
+		{"floor", Inputs{RejectedFlags: 4, FlagsReceived: 3}, MinScore},
+		{"ceiling", Inputs{Trades: 10, AccurateFlags: 10, AccountAge: 30 * day}, MaxScore},
+		{"mixed", Inputs{Trades: 4, AccurateFlags: 1, RejectedFlags: 1, FlagsReceived: 1, AccountAge: 2 * day}, 46},
+	}
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Default(tt.in))
+		})
+	}
+}
+
+func TestCustomWeights(t *testing.T) {
+	// without caps every trade and day counts
+	w := Weights{Base: 10, Trade: 1, AccountDay: 1}
+	assert.Equal(t, float64(70), w.Score(Inputs{Trades: 40, AccountAge: 20 * day}))
+
+	var fn Func = Weights{Base: 20, FlagReceived: -1}.Score
+	assert.Equal(t, float64(15), fn(Inputs{FlagsReceived: 5}))
+}
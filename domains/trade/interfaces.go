@@ -11,8 +11,10 @@ import (
 type ITradeService interface {
 	Execute(ctx context.Context, seller, buyer *entities.TradeItems) error
 	History(ctx context.Context, id string, startDate, endDate time.Time) ([]*entities.Transaction, error)
+	TradeCounts(ctx context.Context, ids ...string) (map[string]int, error)
 	IsTransactionAmountEqual(sellerItem, buyerItem *entities.TradeItems) error
 	AnyParticipantInfected(users ...*entities.User) error
+	InSafeZone(ctx context.Context, users ...*entities.User) error
 	EnoughStock(stock entities.Stock, items *entities.TradeItems) error
 	VerifyTransaction(ctx context.Context, balances entities.UserStock, sellerItem, buyerItem *entities.TradeItems) error
 }
@@ -13,6 +13,7 @@ var _ inventory.IInventoryService = (*MockInventoryService)(nil)
 // MockInventoryService mock for IInventoryService
 type MockInventoryService struct {
 	BlockUserInventoryFunc    func(ctx context.Context, userID string) error
+	UnblockUserInventoryFunc  func(ctx context.Context, userID string) error
 	CreateFunc                func(ctx context.Context, item []*entities.Inventory) error
 	FindMultipleInventoryFunc func(ctx context.Context, userIDs ...string) (entities.UserStock, error)
 	FindUserInventoryFunc     func(ctx context.Context, userID string) (map[string]*entities.Inventory, error)
@@ -33,6 +34,14 @@ func (m *MockInventoryService) BlockUserInventory(ctx context.Context, userID st
 	return m.BlockUserInventoryFunc(ctx, userID)
 }
 
+// UnblockUserInventory implements inventory.IInventoryService
+func (m *MockInventoryService) UnblockUserInventory(ctx context.Context, userID string) error {
+	if m.UnblockUserInventoryFunc == nil {
+		return errMockNotDefined
+	}
+	return m.UnblockUserInventoryFunc(ctx, userID)
+}
+
 // Create implements inventory.IInventoryService
 func (m *MockInventoryService) Create(ctx context.Context, item []*entities.Inventory) error {
 	if m.CreateFunc == nil {
@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 	"errors"
+	"sort"
 	"time"
 
 	"zssn/domains/trade/store"
@@ -23,6 +24,9 @@ type MockTradeStore struct {
 	DetailsFunc func(ctx context.Context, ref string) ([]*store.Transaction, error)
 	ExecuteFunc func(ctx context.Context, seller, buyer *store.TradeItems) error
 	HistoryFunc func(ctx context.Context, userID string, start time.Time, endDate time.Time) ([]*store.Transaction, error)
+
+	CountTradesFunc      func(ctx context.Context, userIDs ...string) (map[string]int, error)
+	FindTransactionsFunc func(ctx context.Context, from, to time.Time) ([]*store.Transaction, error)
 }
 
 // NewStoreMock returns a new mock for storage trade
@@ -30,6 +34,7 @@ func NewStoreMock() store.ITradeStorage {
 	return &MockTradeStore{
 		ExecuteFunc: func(ctx context.Context, seller, buyer *store.TradeItems) error {
 			ref := uuid.NewString()
+			now := time.Now()
 			var trans []*store.Transaction
 			for _, v := range seller.Items {
 				// create a transaction record for every item
@@ -40,6 +45,7 @@ func NewStoreMock() store.ITradeStorage {
 					BuyerID:   buyer.UserID,
 					Item:      v.Item,
 					Quantity:  v.Quantity,
+					Model:     gorm.Model{CreatedAt: now, UpdatedAt: now},
 				})
 			}
 			for _, v := range buyer.Items {
@@ -51,6 +57,7 @@ func NewStoreMock() store.ITradeStorage {
 					BuyerID:   seller.UserID,
 					Item:      v.Item,
 					Quantity:  v.Quantity,
+					Model:     gorm.Model{CreatedAt: now, UpdatedAt: now},
 				})
 			}
 			seller.Reference = ref
@@ -59,16 +66,17 @@ func NewStoreMock() store.ITradeStorage {
 			return nil
 		},
 		DetailsFunc: func(ctx context.Context, ref string) ([]*store.Transaction, error) {
-			v, ok := mockDB[ref]
-			if !ok {
-				return nil, gorm.ErrRecordNotFound
-			}
-			return v, nil
+			return mockDB[ref], nil
 		},
 		HistoryFunc: func(ctx context.Context, userID string, start, endDate time.Time) ([]*store.Transaction, error) {
 			var result []*store.Transaction
+			from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+			to := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, endDate.Location()).AddDate(0, 0, 1)
 			for _, trans := range mockDB {
 				for _, v := range trans {
+					if v.CreatedAt.Before(from) || !v.CreatedAt.Before(to) {
+						continue
+					}
 					if v.BuyerID == userID || v.SellerID == userID {
 						result = append(result, v)
 					}
@@ -77,6 +85,43 @@ func NewStoreMock() store.ITradeStorage {
 
 			return result, nil
 		},
+		CountTradesFunc: func(ctx context.Context, userIDs ...string) (map[string]int, error) {
+			result := make(map[string]int)
+			for _, trans := range mockDB {
+				counted := make(map[string]bool)
+				for _, v := range trans {
+					for _, id := range userIDs {
+						if !counted[id] && (v.BuyerID == id || v.SellerID == id) {
+							counted[id] = true
+							result[id]++
+						}
+					}
+				}
+			}
+			return result, nil
+		},
+		FindTransactionsFunc: func(ctx context.Context, from, to time.Time) ([]*store.Transaction, error) {
+			var result []*store.Transaction
+			for _, trans := range mockDB {
+				for _, v := range trans {
+					if (!from.IsZero() && v.CreatedAt.Before(from)) || (!to.IsZero() && v.CreatedAt.After(to)) {
+						continue
+					}
+					result = append(result, v)
+				}
+			}
+			sort.Slice(result, func(i, j int) bool {
+				a, b := result[i], result[j]
+				if !a.CreatedAt.Equal(b.CreatedAt) {
+					return a.CreatedAt.Before(b.CreatedAt)
+				}
+				if a.Reference != b.Reference {
+					return a.Reference < b.Reference
+				}
+				return a.ID < b.ID
+			})
+			return result, nil
+		},
 	}
 }
 
@@ -103,3 +148,19 @@ func (m *MockTradeStore) History(ctx context.Context, userID string, start time.
 	}
 	return m.HistoryFunc(ctx, userID, start, endDate)
 }
+
+// CountTrades implements store.ITradeStorage
+func (m *MockTradeStore) CountTrades(ctx context.Context, userIDs ...string) (map[string]int, error) {
+	if m.CountTradesFunc == nil {
+		return nil, errMockNotInitialized
+	}
+	return m.CountTradesFunc(ctx, userIDs...)
+}
+
+// FindTransactions implements store.ITradeStorage
+func (m *MockTradeStore) FindTransactions(ctx context.Context, from, to time.Time) ([]*store.Transaction, error) {
+	if m.FindTransactionsFunc == nil {
+		return nil, errMockNotInitialized
+	}
+	return m.FindTransactionsFunc(ctx, from, to)
+}
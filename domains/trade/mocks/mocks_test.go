@@ -0,0 +1,14 @@
+package mocks
+
+import (
+	"testing"
+
+	"zssn/domains/trade/store"
+	"zssn/domains/trade/store/storetest"
+)
+
+func TestMockStorageConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.ITradeStorage {
+		return NewStoreMock()
+	})
+}
@@ -3,9 +3,11 @@ package mocks
 import (
 	"context"
 	"errors"
+	"time"
 
 	"zssn/domains/entities"
 	"zssn/domains/users"
+	"zssn/domains/users/store"
 )
 
 var (
@@ -20,9 +22,38 @@ type MockUserService struct {
 	FindFunc           func(ctx context.Context, id string) (*entities.User, error)
 	FindByEmailFunc    func(ctx context.Context, email string) (*entities.User, error)
 	FindUsersFunc      func(ctx context.Context, ids ...string) (map[string]*entities.User, error)
-	FlagUserFunc       func(ctx context.Context, id string, infectedUser string) error
+	FlagUserFunc       func(ctx context.Context, id string, infectedUser string, evidence *entities.FlagEvidence) (*entities.StatusTransition, error)
+	FindFlagsFunc      func(ctx context.Context, requesterID, userID string) ([]*entities.Flag, error)
 	IsInfectedFunc     func(ctx context.Context, id string) (bool, error)
 	UpdateLocationFunc func(ctx context.Context, id string, lat float64, long float64) error
+	RetractFlagFunc    func(ctx context.Context, id, infectedUser string) (*entities.StatusTransition, error)
+	SubmitAppealFunc   func(ctx context.Context, userID, reason string) (*entities.Appeal, error)
+	FindAppealFunc     func(ctx context.Context, id string) (*entities.Appeal, error)
+	VoteAppealFunc     func(ctx context.Context, voterID, appealID string, approve bool) (*entities.Appeal, error)
+	ResolveAppealFunc  func(ctx context.Context, adminID, appealID string, approve bool) (*entities.Appeal, error)
+	UpdateStatusFunc   func(ctx context.Context, adminID, userID string, status store.Status, reason string) (*entities.StatusTransition, error)
+	StatusHistoryFunc  func(ctx context.Context, requesterID, userID string) ([]*entities.StatusTransition, error)
+
+	ApplyPendingTransitionsFunc func(ctx context.Context) (int, error)
+	DetectCollusionFunc         func(ctx context.Context) ([]*entities.CollusionReview, error)
+	CollusionReviewsFunc        func(ctx context.Context, adminID, status string) ([]*entities.CollusionReview, error)
+	ResolveCollusionReviewFunc  func(ctx context.Context, adminID, id string, confirm bool) (*entities.CollusionReview, error)
+	ProfileFunc                 func(ctx context.Context, id string) (*entities.Profile, error)
+	FindPartnersFunc            func(ctx context.Context, requesterID string, minReputation float64, limit int) ([]*entities.Profile, error)
+	NearbyFunc                  func(ctx context.Context, id string, radiusKm float64) ([]*entities.NearbySurvivor, error)
+	LocationHistoryFunc         func(ctx context.Context, id string, from, to time.Time) ([]*entities.LocationPoint, error)
+	PruneLocationsFunc          func(ctx context.Context) (int64, error)
+	TraceContactsFunc           func(ctx context.Context, id string) ([]*entities.Exposure, error)
+	NotificationsFunc           func(ctx context.Context, id string, unreadOnly bool) ([]*entities.Notification, error)
+	ReadNotificationFunc        func(ctx context.Context, id, notificationID string) error
+	CreateZoneFunc              func(ctx context.Context, adminID string, zone *entities.Zone) (*entities.Zone, error)
+	ZonesFunc                   func(ctx context.Context) ([]*entities.Zone, error)
+	DeleteZoneFunc              func(ctx context.Context, adminID, id string) error
+	ZoneEventsFunc              func(ctx context.Context, adminID, zoneID string, from, to time.Time) ([]*entities.ZoneEvent, error)
+	UserZonesFunc               func(ctx context.Context, ids ...string) (map[string][]*entities.Zone, error)
+	IsAdminFunc                 func(ctx context.Context, id string) (bool, error)
+	UpdateVisibilityFunc        func(ctx context.Context, id, visibility string) error
+	LocationAuditsFunc          func(ctx context.Context, id string) ([]*entities.LocationAudit, error)
 }
 
 // NewUserMock returns a legit user service using mocked db
@@ -64,11 +95,19 @@ func (m *MockUserService) FindUsers(ctx context.Context, ids ...string) (map[str
 }
 
 // FlagUser implements users.IUserService
-func (m *MockUserService) FlagUser(ctx context.Context, id string, infectedUser string) error {
+func (m *MockUserService) FlagUser(ctx context.Context, id string, infectedUser string, evidence *entities.FlagEvidence) (*entities.StatusTransition, error) {
 	if m.FlagUserFunc == nil {
-		return errMockNotDefined
+		return nil, errMockNotDefined
+	}
+	return m.FlagUserFunc(ctx, id, infectedUser, evidence)
+}
+
+// FindFlags implements users.IUserService
+func (m *MockUserService) FindFlags(ctx context.Context, requesterID, userID string) ([]*entities.Flag, error) {
+	if m.FindFlagsFunc == nil {
+		return nil, errMockNotDefined
 	}
-	return m.FlagUserFunc(ctx, id, infectedUser)
+	return m.FindFlagsFunc(ctx, requesterID, userID)
 }
 
 // IsInfected implements users.IUserService
@@ -86,3 +125,219 @@ func (m *MockUserService) UpdateLocation(ctx context.Context, id string, lat flo
 	}
 	return m.UpdateLocationFunc(ctx, id, lat, long)
 }
+
+// RetractFlag implements users.IUserService
+func (m *MockUserService) RetractFlag(ctx context.Context, id, infectedUser string) (*entities.StatusTransition, error) {
+	if m.RetractFlagFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.RetractFlagFunc(ctx, id, infectedUser)
+}
+
+// SubmitAppeal implements users.IUserService
+func (m *MockUserService) SubmitAppeal(ctx context.Context, userID, reason string) (*entities.Appeal, error) {
+	if m.SubmitAppealFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.SubmitAppealFunc(ctx, userID, reason)
+}
+
+// FindAppeal implements users.IUserService
+func (m *MockUserService) FindAppeal(ctx context.Context, id string) (*entities.Appeal, error) {
+	if m.FindAppealFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindAppealFunc(ctx, id)
+}
+
+// VoteAppeal implements users.IUserService
+func (m *MockUserService) VoteAppeal(ctx context.Context, voterID, appealID string, approve bool) (*entities.Appeal, error) {
+	if m.VoteAppealFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.VoteAppealFunc(ctx, voterID, appealID, approve)
+}
+
+// ResolveAppeal implements users.IUserService
+func (m *MockUserService) ResolveAppeal(ctx context.Context, adminID, appealID string, approve bool) (*entities.Appeal, error) {
+	if m.ResolveAppealFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.ResolveAppealFunc(ctx, adminID, appealID, approve)
+}
+
+// UpdateStatus implements users.IUserService
+func (m *MockUserService) UpdateStatus(ctx context.Context, adminID, userID string, status store.Status, reason string) (*entities.StatusTransition, error) {
+	if m.UpdateStatusFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.UpdateStatusFunc(ctx, adminID, userID, status, reason)
+}
+
+// StatusHistory implements users.IUserService
+func (m *MockUserService) StatusHistory(ctx context.Context, requesterID, userID string) ([]*entities.StatusTransition, error) {
+	if m.StatusHistoryFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.StatusHistoryFunc(ctx, requesterID, userID)
+}
+
+// ApplyPendingTransitions implements users.IUserService
+func (m *MockUserService) ApplyPendingTransitions(ctx context.Context) (int, error) {
+	if m.ApplyPendingTransitionsFunc == nil {
+		return 0, errMockNotDefined
+	}
+	return m.ApplyPendingTransitionsFunc(ctx)
+}
+
+// DetectCollusion implements users.IUserService
+func (m *MockUserService) DetectCollusion(ctx context.Context) ([]*entities.CollusionReview, error) {
+	if m.DetectCollusionFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.DetectCollusionFunc(ctx)
+}
+
+// CollusionReviews implements users.IUserService
+func (m *MockUserService) CollusionReviews(ctx context.Context, adminID, status string) ([]*entities.CollusionReview, error) {
+	if m.CollusionReviewsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.CollusionReviewsFunc(ctx, adminID, status)
+}
+
+// ResolveCollusionReview implements users.IUserService
+func (m *MockUserService) ResolveCollusionReview(ctx context.Context, adminID, id string, confirm bool) (*entities.CollusionReview, error) {
+	if m.ResolveCollusionReviewFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.ResolveCollusionReviewFunc(ctx, adminID, id, confirm)
+}
+
+// Profile implements users.IUserService
+func (m *MockUserService) Profile(ctx context.Context, id string) (*entities.Profile, error) {
+	if m.ProfileFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.ProfileFunc(ctx, id)
+}
+
+// FindPartners implements users.IUserService
+func (m *MockUserService) FindPartners(ctx context.Context, requesterID string, minReputation float64, limit int) ([]*entities.Profile, error) {
+	if m.FindPartnersFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindPartnersFunc(ctx, requesterID, minReputation, limit)
+}
+
+// Nearby implements users.IUserService
+func (m *MockUserService) Nearby(ctx context.Context, id string, radiusKm float64) ([]*entities.NearbySurvivor, error) {
+	if m.NearbyFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.NearbyFunc(ctx, id, radiusKm)
+}
+
+// LocationHistory implements users.IUserService
+func (m *MockUserService) LocationHistory(ctx context.Context, id string, from, to time.Time) ([]*entities.LocationPoint, error) {
+	if m.LocationHistoryFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.LocationHistoryFunc(ctx, id, from, to)
+}
+
+// PruneLocations implements users.IUserService
+func (m *MockUserService) PruneLocations(ctx context.Context) (int64, error) {
+	if m.PruneLocationsFunc == nil {
+		return 0, errMockNotDefined
+	}
+	return m.PruneLocationsFunc(ctx)
+}
+
+// TraceContacts implements users.IUserService
+func (m *MockUserService) TraceContacts(ctx context.Context, id string) ([]*entities.Exposure, error) {
+	if m.TraceContactsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.TraceContactsFunc(ctx, id)
+}
+
+// Notifications implements users.IUserService
+func (m *MockUserService) Notifications(ctx context.Context, id string, unreadOnly bool) ([]*entities.Notification, error) {
+	if m.NotificationsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.NotificationsFunc(ctx, id, unreadOnly)
+}
+
+// ReadNotification implements users.IUserService
+func (m *MockUserService) ReadNotification(ctx context.Context, id, notificationID string) error {
+	if m.ReadNotificationFunc == nil {
+		return errMockNotDefined
+	}
+	return m.ReadNotificationFunc(ctx, id, notificationID)
+}
+
+// CreateZone implements users.IUserService
+func (m *MockUserService) CreateZone(ctx context.Context, adminID string, zone *entities.Zone) (*entities.Zone, error) {
+	if m.CreateZoneFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.CreateZoneFunc(ctx, adminID, zone)
+}
+
+// Zones implements users.IUserService
+func (m *MockUserService) Zones(ctx context.Context) ([]*entities.Zone, error) {
+	if m.ZonesFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.ZonesFunc(ctx)
+}
+
+// DeleteZone implements users.IUserService
+func (m *MockUserService) DeleteZone(ctx context.Context, adminID, id string) error {
+	if m.DeleteZoneFunc == nil {
+		return errMockNotDefined
+	}
+	return m.DeleteZoneFunc(ctx, adminID, id)
+}
+
+// ZoneEvents implements users.IUserService
+func (m *MockUserService) ZoneEvents(ctx context.Context, adminID, zoneID string, from, to time.Time) ([]*entities.ZoneEvent, error) {
+	if m.ZoneEventsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.ZoneEventsFunc(ctx, adminID, zoneID, from, to)
+}
+
+// UserZones implements users.IUserService
+func (m *MockUserService) UserZones(ctx context.Context, ids ...string) (map[string][]*entities.Zone, error) {
+	if m.UserZonesFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.UserZonesFunc(ctx, ids...)
+}
+
+// IsAdmin implements users.IUserService
+func (m *MockUserService) IsAdmin(ctx context.Context, id string) (bool, error) {
+	if m.IsAdminFunc == nil {
+		return false, errMockNotDefined
+	}
+	return m.IsAdminFunc(ctx, id)
+}
+
+// UpdateVisibility implements users.IUserService
+func (m *MockUserService) UpdateVisibility(ctx context.Context, id, visibility string) error {
+	if m.UpdateVisibilityFunc == nil {
+		return errMockNotDefined
+	}
+	return m.UpdateVisibilityFunc(ctx, id, visibility)
+}
+
+// LocationAudits implements users.IUserService
+func (m *MockUserService) LocationAudits(ctx context.Context, id string) ([]*entities.LocationAudit, error) {
+	if m.LocationAuditsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.LocationAuditsFunc(ctx, id)
+}
@@ -10,4 +10,8 @@ type ITradeStorage interface {
 	Execute(ctx context.Context, seller, buyer *TradeItems) error
 	Details(ctx context.Context, ref string) ([]*Transaction, error)
 	History(ctx context.Context, userID string, start, endDate time.Time) ([]*Transaction, error)
+	// CountTrades returns the number of trades every given user took part in, users without trades are left out
+	CountTrades(ctx context.Context, userIDs ...string) (map[string]int, error)
+	// FindTransactions returns every transaction between from and to ordered by time and reference, zero times leave the range open
+	FindTransactions(ctx context.Context, from, to time.Time) ([]*Transaction, error)
 }
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"zssn/database/migrations"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -15,11 +17,12 @@ type TradeStorage struct {
 }
 
 // New returns a new implementation of trade storage
+// The database has to be migrated to the latest schema version beforehand.
 func New(db *gorm.DB) (ITradeStorage, error) {
 	if db == nil {
 		return nil, fmt.Errorf("invalid connection passed")
 	}
-	if err := db.AutoMigrate(&Transaction{}); err != nil {
+	if err := migrations.Verify(context.Background(), db); err != nil {
 		return nil, err
 	}
 	return &TradeStorage{
@@ -62,7 +65,10 @@ func (ts *TradeStorage) Execute(ctx context.Context, seller, buyer *TradeItems)
 // History returns the trade history for a particular user within a timeframe, we still want accountability even in an apocalypse :)
 func (ts *TradeStorage) History(ctx context.Context, userID string, start time.Time, endDate time.Time) ([]*Transaction, error) {
 	var result []*Transaction
-	err := ts.DB.Debug().Where("(seller_id = ? OR buyer_id = ?) AND DATE(created_at) BETWEEN DATE(?) AND DATE(?)", userID, userID, start, endDate).Find(&result).Error
+	// the range covers whole days, so we compare against the start of the first day and the start of the day after the last one
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	to := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, endDate.Location()).AddDate(0, 0, 1)
+	err := ts.DB.Where("(seller_id = ? OR buyer_id = ?) AND created_at >= ? AND created_at < ?", userID, userID, from, to).Find(&result).Error
 
 	return result, err
 }
@@ -74,3 +80,43 @@ func (ts *TradeStorage) Details(ctx context.Context, ref string) ([]*Transaction
 
 	return result, err
 }
+
+// CountTrades implements ITradeStorage, a trade is every transaction sharing the same reference
+func (ts *TradeStorage) CountTrades(ctx context.Context, userIDs ...string) (map[string]int, error) {
+	result := make(map[string]int)
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+	db := ts.DB.WithContext(ctx)
+	sellers := db.Model(&Transaction{}).Select("seller_id AS participant, reference").Where("seller_id IN ?", userIDs)
+	buyers := db.Model(&Transaction{}).Select("buyer_id AS participant, reference").Where("buyer_id IN ?", userIDs)
+	var rows []struct {
+		Participant string
+		Total       int
+	}
+	err := db.Table("(?) AS participants", db.Raw("? UNION ALL ?", sellers, buyers)).
+		Select("participant, COUNT(DISTINCT reference) AS total").
+		Group("participant").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range rows {
+		result[v.Participant] = v.Total
+	}
+	return result, nil
+}
+
+// FindTransactions implements ITradeStorage
+func (ts *TradeStorage) FindTransactions(ctx context.Context, from, to time.Time) ([]*Transaction, error) {
+	var result []*Transaction
+	query := ts.DB.WithContext(ctx)
+	if !from.IsZero() {
+		query = query.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("created_at <= ?", to)
+	}
+	err := query.Order("created_at, reference, id").Find(&result).Error
+	return result, err
+}
@@ -6,12 +6,12 @@ import (
 	"testing"
 	"time"
 
+	"zssn/database"
 	"zssn/domains/core"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
@@ -87,6 +87,12 @@ func TestTradeHistory(t *testing.T) {
 	res, err := store.History(ctx, sellerID, start, end)
 	require.NoError(t, err)
 	require.NotNil(t, res)
+	assert.Len(t, res, 12)
+
+	// trades from today should not show up when the range ends yesterday
+	res, err = store.History(ctx, sellerID, start.Add(-24*time.Hour), start)
+	require.NoError(t, err)
+	assert.Empty(t, res)
 }
 
 func TestTradeDetails(t *testing.T) {
@@ -132,12 +138,7 @@ func newTradeItems(t *testing.T, userID string) *TradeItems {
 }
 
 func setupTestDB() (*gorm.DB, error) {
-	env := os.Getenv("ENVIRONMENT")
-	dsn := "root:@tcp(127.0.0.1:3306)/zssn?charset=utf8mb4&parseTime=True&loc=Local"
-	if env == "cicd" {
-		dsn = "zssn_user:password@tcp(127.0.0.1:33306)/zssn?charset=utf8mb4&parseTime=True&loc=Local"
-	}
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	return database.OpenTest()
 }
 
 func cleanup() {
@@ -0,0 +1,175 @@
+// Package storetest contains the behavioural contract every ITradeStorage implementation has to satisfy.
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"zssn/domains/core"
+	"zssn/domains/trade/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns the storage implementation under test
+type Factory func(t *testing.T) store.ITradeStorage
+
+// Run runs the conformance suite against the storage returned by newStorage
+func Run(t *testing.T, newStorage Factory) {
+	t.Run("Execute", func(t *testing.T) { testExecute(t, newStorage(t)) })
+	t.Run("DetailsUnknownReference", func(t *testing.T) { testDetailsUnknownReference(t, newStorage(t)) })
+	t.Run("History", func(t *testing.T) { testHistory(t, newStorage(t)) })
+	t.Run("CountTrades", func(t *testing.T) { testCountTrades(t, newStorage(t)) })
+	t.Run("FindTransactions", func(t *testing.T) { testFindTransactions(t, newStorage(t)) })
+}
+
+func testExecute(t *testing.T, storage store.ITradeStorage) {
+	ctx := context.Background()
+	seller, buyer := NewTradeItems(t, uuid.NewString()), NewTradeItems(t, uuid.NewString())
+	buyer.Items = buyer.Items[:1]
+
+	require.NoError(t, storage.Execute(ctx, seller, buyer))
+	require.NotEmpty(t, seller.Reference)
+	assert.Equal(t, seller.Reference, buyer.Reference)
+
+	res, err := storage.Details(ctx, seller.Reference)
+	require.NoError(t, err)
+	require.Len(t, res, len(seller.Items)+len(buyer.Items))
+
+	var sold, bought int
+	for _, v := range res {
+		assert.NotEmpty(t, v.ID)
+		assert.Equal(t, seller.Reference, v.Reference)
+		switch v.SellerID {
+		case seller.UserID:
+			assert.Equal(t, buyer.UserID, v.BuyerID)
+			sold++
+		case buyer.UserID:
+			assert.Equal(t, seller.UserID, v.BuyerID)
+			bought++
+		default:
+			t.Fatalf("unexpected seller %s", v.SellerID)
+		}
+	}
+	assert.Equal(t, len(seller.Items), sold)
+	assert.Equal(t, len(buyer.Items), bought)
+}
+
+func testDetailsUnknownReference(t *testing.T, storage store.ITradeStorage) {
+	res, err := storage.Details(context.Background(), uuid.NewString())
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testHistory(t *testing.T, storage store.ITradeStorage) {
+	ctx := context.Background()
+	userID, partnerID, strangerID := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	require.NoError(t, storage.Execute(ctx, NewTradeItems(t, userID), NewTradeItems(t, partnerID)))
+	require.NoError(t, storage.Execute(ctx, NewTradeItems(t, partnerID), NewTradeItems(t, userID)))
+	require.NoError(t, storage.Execute(ctx, NewTradeItems(t, partnerID), NewTradeItems(t, strangerID)))
+
+	now := time.Now()
+	res, err := storage.History(ctx, userID, now.Add(-24*time.Hour), now)
+	require.NoError(t, err)
+	assert.Len(t, res, 12)
+	for _, v := range res {
+		assert.True(t, v.SellerID == userID || v.BuyerID == userID)
+	}
+
+	// the range is inclusive of whole days
+	res, err = storage.History(ctx, userID, now, now)
+	require.NoError(t, err)
+	assert.Len(t, res, 12)
+
+	res, err = storage.History(ctx, userID, now.AddDate(0, 0, -3), now.AddDate(0, 0, -1))
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testCountTrades(t *testing.T, storage store.ITradeStorage) {
+	ctx := context.Background()
+	userID, partnerID, strangerID := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	require.NoError(t, storage.Execute(ctx, NewTradeItems(t, userID), NewTradeItems(t, partnerID)))
+	require.NoError(t, storage.Execute(ctx, NewTradeItems(t, partnerID), NewTradeItems(t, userID)))
+	// a trade where the buyer gives nothing back still counts for both
+	require.NoError(t, storage.Execute(ctx, NewTradeItems(t, partnerID), &store.TradeItems{UserID: strangerID}))
+
+	res, err := storage.CountTrades(ctx, userID, partnerID, strangerID, uuid.NewString())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{userID: 2, partnerID: 3, strangerID: 1}, res)
+
+	res, err = storage.CountTrades(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testFindTransactions(t *testing.T, storage store.ITradeStorage) {
+	ctx := context.Background()
+	userID, partnerID := uuid.NewString(), uuid.NewString()
+	first, second := NewTradeItems(t, userID), NewTradeItems(t, partnerID)
+	require.NoError(t, storage.Execute(ctx, first, NewTradeItems(t, partnerID)))
+	require.NoError(t, storage.Execute(ctx, second, &store.TradeItems{UserID: userID}))
+
+	// other tests share the storage, only the transactions of these users are looked at
+	own := func(trans []*store.Transaction) []*store.Transaction {
+		var res []*store.Transaction
+		for _, v := range trans {
+			if v.SellerID == userID || v.SellerID == partnerID {
+				res = append(res, v)
+			}
+		}
+		return res
+	}
+	res, err := storage.FindTransactions(ctx, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	res = own(res)
+	require.Len(t, res, 9)
+	for i, v := range res {
+		if i > 0 {
+			assert.False(t, v.CreatedAt.Before(res[i-1].CreatedAt))
+		}
+	}
+	refs := map[string]int{}
+	for _, v := range res {
+		refs[v.Reference]++
+	}
+	assert.Equal(t, map[string]int{first.Reference: 6, second.Reference: 3}, refs)
+
+	now := time.Now()
+	res, err = storage.FindTransactions(ctx, now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, own(res), 9)
+	res, err = storage.FindTransactions(ctx, now.Add(time.Hour), time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, own(res))
+	res, err = storage.FindTransactions(ctx, time.Time{}, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, own(res))
+}
+
+// NewTradeItems returns a trade offer of three items for the given user
+func NewTradeItems(t *testing.T, userID string) *store.TradeItems {
+	t.Helper()
+	return &store.TradeItems{
+		UserID: userID,
+		Items: []store.TradeItem{
+			{
+				Item:     core.ItemWater,
+				Quantity: 10,
+			},
+			{
+				Item:     core.ItemAmmunition,
+				Quantity: 20,
+			},
+			{
+				Item:     core.ItemMedication,
+				Quantity: 30,
+			},
+		},
+	}
+}
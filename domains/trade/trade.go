@@ -2,6 +2,7 @@ package trade
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,22 +10,42 @@ import (
 	"zssn/domains/inventory"
 	"zssn/domains/trade/store"
 	"zssn/domains/users"
+	usrStore "zssn/domains/users/store"
 )
 
+// ErrNotInSafeZone is returned when trades are restricted to safe zones and a participant is outside every safe zone
+var ErrNotInSafeZone = errors.New("survivors can only trade inside a safe zone")
+
 // TradeService to implement ITradeService
 type TradeService struct {
 	Storage          store.ITradeStorage
 	UserService      users.IUserService
 	InventoryService inventory.IInventoryService
+
+	safeZonesOnly bool
+}
+
+// Option configures the trade service
+type Option func(*TradeService)
+
+// WithSafeZonesOnly only lets survivors trade while both of them are inside a safe zone
+func WithSafeZonesOnly(enabled bool) Option {
+	return func(ts *TradeService) {
+		ts.safeZonesOnly = enabled
+	}
 }
 
 // New returns an implementation of ITradeService
-func New(storage store.ITradeStorage, usr users.IUserService, inv inventory.IInventoryService) ITradeService {
-	return &TradeService{
+func New(storage store.ITradeStorage, usr users.IUserService, inv inventory.IInventoryService, opts ...Option) ITradeService {
+	ts := &TradeService{
 		Storage:          storage,
 		UserService:      usr,
 		InventoryService: inv,
 	}
+	for _, opt := range opts {
+		opt(ts)
+	}
+	return ts
 }
 
 // Execute implements ITradeService
@@ -98,6 +119,11 @@ func (ts *TradeService) History(ctx context.Context, id string, startDate time.T
 	return result, nil
 }
 
+// TradeCounts returns the number of trades every given survivor took part in, survivors who never traded are left out
+func (ts *TradeService) TradeCounts(ctx context.Context, ids ...string) (map[string]int, error) {
+	return ts.Storage.CountTrades(ctx, ids...)
+}
+
 func (ts *TradeService) IsTransactionAmountEqual(sellerItem *entities.TradeItems, buyerItem *entities.TradeItems) error {
 	if sellerItem.Calculate() != buyerItem.Calculate() {
 		return fmt.Errorf("value of the trade doesn't match")
@@ -117,6 +143,31 @@ func (ts *TradeService) AnyParticipantInfected(users ...*entities.User) error {
 		if v.Infected {
 			return fmt.Errorf("participant %s is infected, cannot proceed with transaction", v.Name)
 		}
+		if !v.Clean() {
+			return fmt.Errorf("participant %s is %s, cannot proceed with transaction", v.Name, v.Status)
+		}
+	}
+	return nil
+}
+
+// InSafeZone confirms that every participant is currently inside a safe zone
+func (ts *TradeService) InSafeZone(ctx context.Context, users ...*entities.User) error {
+	ids := make([]string, 0, len(users))
+	for _, v := range users {
+		ids = append(ids, v.ID)
+	}
+	zones, err := ts.UserService.UserZones(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	for _, v := range users {
+		safe := false
+		for _, z := range zones[v.ID] {
+			safe = safe || z.Kind == string(usrStore.ZoneSafe)
+		}
+		if !safe {
+			return fmt.Errorf("participant %s is outside every safe zone: %w", v.Name, ErrNotInSafeZone)
+		}
 	}
 	return nil
 }
@@ -163,6 +214,12 @@ func (ts *TradeService) VerifyTransaction(ctx context.Context, balances entities
 		return err
 	}
 
+	if ts.safeZonesOnly {
+		if err := ts.InSafeZone(ctx, users[sellerItem.UserID], users[buyerItem.UserID]); err != nil {
+			return err
+		}
+	}
+
 	if err := ts.EnoughStock(balances[sellerItem.UserID], sellerItem); err != nil {
 		return err
 	}
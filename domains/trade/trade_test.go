@@ -9,6 +9,7 @@ import (
 
 	"zssn/domains/core"
 	"zssn/domains/entities"
+	"zssn/domains/geo"
 	"zssn/domains/inventory"
 	invStore "zssn/domains/inventory/store"
 	"zssn/domains/trade/mocks"
@@ -77,6 +78,10 @@ func TestVerifyTransaction_NotEqualItem(t *testing.T) {
 func TestAnyInfectedParticipant(t *testing.T) {
 	infectedUser := newUser(t)
 	infectedUser.Infected = true
+	deceasedUser := newUser(t)
+	deceasedUser.Status = "deceased"
+	recoveredUser := newUser(t)
+	recoveredUser.Status = "recovered"
 	table := []struct {
 		name      string
 		users     []*entities.User
@@ -99,6 +104,17 @@ func TestAnyInfectedParticipant(t *testing.T) {
 			expectErr: true,
 			errMsg:    fmt.Sprintf("participant %s is infected, cannot proceed with transaction", infectedUser.Name),
 		},
+		{
+			name:      "one deceased user",
+			users:     []*entities.User{newUser(t), deceasedUser},
+			expectErr: true,
+			errMsg:    fmt.Sprintf("participant %s is deceased, cannot proceed with transaction", deceasedUser.Name),
+		},
+		{
+			name:      "recovered user",
+			users:     []*entities.User{newUser(t), recoveredUser},
+			expectErr: false,
+		},
 	}
 
 	for _, tt := range table {
@@ -605,3 +621,45 @@ func newTradeItems(t *testing.T, userID string) *entities.TradeItems {
 		},
 	}
 }
+
+func TestVerifyTransaction_SafeZonesOnly(t *testing.T) {
+	ctx := context.Background()
+	admin := newUser(t)
+	require.NoError(t, userService.Create(ctx, admin))
+	require.NoError(t, users.NewMockStore().UpdateRole(ctx, admin.ID, usrStore.RoleAdmin))
+	zoneService, err := users.New(users.NewMockStore())
+	require.NoError(t, err)
+	ts := New(storage, zoneService, inventoryService, WithSafeZonesOnly(true))
+
+	fUser := setupUser(t)
+	sUser := setupUser(t)
+	fut := &entities.TradeItems{
+		UserID: fUser.user.ID,
+		Items:  []entities.TradeItem{{Item: core.ItemWater, Quantity: 1}},
+	}
+	sut := &entities.TradeItems{
+		UserID: sUser.user.ID,
+		Items:  []entities.TradeItem{{Item: core.ItemMedication, Quantity: 2}},
+	}
+	balances, err := inventoryService.FindMultipleInventory(ctx, fut.UserID, sut.UserID)
+	require.NoError(t, err)
+
+	_, err = zoneService.CreateZone(ctx, admin.ID, &entities.Zone{
+		Name:     "market",
+		Kind:     string(usrStore.ZoneSafe),
+		Center:   &geo.Point{Latitude: -89, Longitude: 0},
+		RadiusKm: 10,
+	})
+	require.NoError(t, err)
+	require.NoError(t, zoneService.UpdateLocation(ctx, fUser.user.ID, -89, 0))
+	require.NoError(t, zoneService.UpdateLocation(ctx, sUser.user.ID, -88, 0))
+	err = ts.VerifyTransaction(ctx, balances, fut, sut)
+	assert.ErrorIs(t, err, ErrNotInSafeZone)
+	assert.Contains(t, err.Error(), sUser.user.Name)
+
+	require.NoError(t, zoneService.UpdateLocation(ctx, sUser.user.ID, -89.01, 0))
+	require.NoError(t, ts.VerifyTransaction(ctx, balances, fut, sut))
+	// the restriction is off by default
+	require.NoError(t, zoneService.UpdateLocation(ctx, sUser.user.ID, -88, 0))
+	require.NoError(t, tradeService.VerifyTransaction(ctx, balances, fut, sut))
+}
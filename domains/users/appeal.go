@@ -0,0 +1,373 @@
+package users
+
+import (
+	"context"
+	"errors"
+
+	"zssn/domains/entities"
+	"zssn/domains/users/store"
+
+	"gorm.io/gorm"
+)
+
+// DefaultAppealQuorum number of matching votes from clean survivors needed to resolve an appeal
+const DefaultAppealQuorum = 3
+
+// audit actions recorded in the infection audit trail
+const (
+	// AuditActionFlag a flag was evaluated by the infection policy
+	AuditActionFlag = "flag"
+	// AuditActionRetract a flag was retracted and the infection policy evaluated the remaining flags
+	AuditActionRetract = "retract"
+	// AuditActionAppeal an infected survivor appealed
+	AuditActionAppeal = "appeal"
+	// AuditActionVote a clean survivor voted on an appeal
+	AuditActionVote = "appeal_vote"
+	// AuditActionAppealApproved an appeal was approved and the survivor cleared
+	AuditActionAppealApproved = "appeal_approved"
+	// AuditActionAppealRejected an appeal was rejected
+	AuditActionAppealRejected = "appeal_rejected"
+)
+
+var (
+	// ErrNotInfected is returned when a clean survivor appeals
+	ErrNotInfected = errors.New("only infected survivors can appeal")
+	// ErrAppealPending is returned when a survivor appeals while another appeal is still pending
+	ErrAppealPending = errors.New("survivor already has a pending appeal")
+	// ErrAppealResolved is returned when voting on or resolving an appeal that has already been resolved
+	ErrAppealResolved = errors.New("appeal has already been resolved")
+	// ErrNotEligibleVoter is returned when an infected survivor or the appellant votes on an appeal
+	ErrNotEligibleVoter = errors.New("only clean survivors can vote on someone else's appeal")
+	// ErrNotAdmin is returned when someone other than an admin tries to resolve an appeal or change a status
+	ErrNotAdmin = errors.New("only admins can perform this operation")
+)
+
+// WithAppealQuorum sets the number of matching votes needed to resolve an appeal
+func WithAppealQuorum(n int) Option {
+	return func(u *UserService) {
+		if n > 0 {
+			u.appealQuorum = n
+		}
+	}
+}
+
+// RetractFlag removes the flag the survivor raised and lets the infection policy evaluate the remaining flags.
+// Survivors the flags made suspected or infected who no longer meet the policy are cleared, a status an admin
+// or anything else set is left alone. The status change is returned, nil if there was none.
+func (u *UserService) RetractFlag(ctx context.Context, id, infectedUserID string) (*entities.StatusTransition, error) {
+	var res *store.StatusTransition
+	err := u.Storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		if err := tx.LockUser(ctx, infectedUserID); err != nil {
+			return err
+		}
+		if err := tx.DeleteFlag(ctx, id, infectedUserID); err != nil {
+			return err
+		}
+
+		usr, err := tx.Find(ctx, infectedUserID)
+		if err != nil {
+			return err
+		}
+		target, ignored, err := withoutInfectedFlaggers(ctx, tx, usr)
+		if err != nil {
+			return err
+		}
+		target, discounted, err := u.withoutSuspiciousFlaggers(ctx, tx, target)
+		if err != nil {
+			return err
+		}
+		decision, err := u.policy.Evaluate(ctx, tx, target)
+		if err != nil {
+			return err
+		}
+		if decision.Inputs == nil {
+			decision.Inputs = make(map[string]interface{})
+		}
+		wasInfected := usr.Infected
+		decision.Inputs["ignored_flags"] = ignored
+		decision.Inputs["was_infected"] = wasInfected
+		if u.collusion.Discount {
+			decision.Inputs["discounted_flags"] = discounted
+		}
+		byFlags, err := causedByFlags(ctx, tx, usr)
+		if err != nil {
+			return err
+		}
+		if byFlags && (usr.Status == store.StatusSuspected || usr.Status == store.StatusInfected && !decision.Infected) {
+			next := store.StatusSuspected
+			if len(target.FlagMonitor) == 0 {
+				next = store.StatusHealthy
+			}
+			if res, err = u.transition(ctx, tx, usr, next, id, "flag retracted"); err != nil {
+				return err
+			}
+		}
+		// survivors only get infected by new flags, a retraction never infects anyone
+		decision.Infected = wasInfected && decision.Infected
+		return u.auditDecision(ctx, tx, AuditActionRetract, id, infectedUserID, decision)
+	})
+	if err != nil || res == nil {
+		return nil, err
+	}
+	if err := u.settle(ctx, res); err != nil {
+		return nil, err
+	}
+	return entities.FromStatusTransitionDBEntity(res), nil
+}
+
+// causedByFlags reports whether the flags put the survivor in their current status: the last status change was made
+// by a survivor who isn't an admin while flagging them or retracting a flag, and the infection policy agreed when it was an infection.
+func causedByFlags(ctx context.Context, tx store.IUserStorage, usr *store.User) (bool, error) {
+	transitions, err := tx.FindStatusTransitions(ctx, usr.ID)
+	if err != nil || len(transitions) == 0 {
+		return false, err
+	}
+	last := transitions[len(transitions)-1]
+	if last.ToStatus != usr.Status {
+		return false, nil
+	}
+	actor, err := tx.Find(ctx, last.ActorID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil:
+		return false, err
+	case actor.Role == store.RoleAdmin:
+		return false, nil
+	}
+	audits, err := tx.FindInfectionAudits(ctx, usr.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range audits {
+		flags := v.Action == AuditActionFlag || v.Action == AuditActionRetract
+		if flags && v.ActorID == last.ActorID && v.Infected == usr.Status.Infected() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SubmitAppeal creates a new appeal for an infected survivor
+func (u *UserService) SubmitAppeal(ctx context.Context, userID, reason string) (*entities.Appeal, error) {
+	var appeal *store.Appeal
+	err := u.Storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		if err := tx.LockUser(ctx, userID); err != nil {
+			return err
+		}
+		usr, err := tx.Find(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !usr.Infected {
+			return ErrNotInfected
+		}
+		appeals, err := tx.FindUserAppeals(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, v := range appeals {
+			if v.Status == store.AppealPending {
+				return ErrAppealPending
+			}
+		}
+
+		appeal = &store.Appeal{
+			UserID: userID,
+			Reason: reason,
+			Status: store.AppealPending,
+		}
+		if err := tx.CreateAppeal(ctx, appeal); err != nil {
+			return err
+		}
+		return u.audit(ctx, tx, &store.InfectionAudit{
+			UserID:   userID,
+			ActorID:  userID,
+			Action:   AuditActionAppeal,
+			Infected: true,
+		}, map[string]interface{}{
+			"appeal_id": appeal.ID,
+			"flags":     len(usr.FlagMonitor),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entities.FromAppealDBEntity(appeal), nil
+}
+
+// FindAppeal returns the appeal with the current vote counts
+func (u *UserService) FindAppeal(ctx context.Context, id string) (*entities.Appeal, error) {
+	res, err := u.Storage.FindAppeal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return entities.FromAppealDBEntity(res), nil
+}
+
+// VoteAppeal records the vote of a clean survivor.
+// The appeal is resolved as soon as either side reaches the quorum.
+func (u *UserService) VoteAppeal(ctx context.Context, voterID, appealID string, approve bool) (*entities.Appeal, error) {
+	var (
+		appeal     *store.Appeal
+		transition *store.StatusTransition
+	)
+	err := u.Storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		a, err := u.lockAppeal(ctx, tx, appealID)
+		if err != nil {
+			return err
+		}
+		voter, err := tx.Find(ctx, voterID)
+		if err != nil {
+			return err
+		}
+		if !voter.Status.Clean() || voter.ID == a.UserID {
+			return ErrNotEligibleVoter
+		}
+		if err := tx.CreateAppealVote(ctx, &store.AppealVote{AppealID: a.ID, VoterID: voterID, Approve: approve}); err != nil {
+			return err
+		}
+		if appeal, err = tx.FindAppeal(ctx, appealID); err != nil {
+			return err
+		}
+
+		res := entities.FromAppealDBEntity(appeal)
+		err = u.audit(ctx, tx, &store.InfectionAudit{
+			UserID:   appeal.UserID,
+			ActorID:  voterID,
+			Action:   AuditActionVote,
+			Infected: true,
+		}, map[string]interface{}{
+			"appeal_id":  appeal.ID,
+			"approve":    approve,
+			"approvals":  res.Approvals,
+			"rejections": res.Rejections,
+			"quorum":     u.appealQuorum,
+		})
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case res.Approvals >= u.appealQuorum:
+			transition, err = u.resolve(ctx, tx, appeal, true, voterID, "")
+		case res.Rejections >= u.appealQuorum:
+			transition, err = u.resolve(ctx, tx, appeal, false, voterID, "")
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.settle(ctx, transition); err != nil {
+		return nil, err
+	}
+	res := entities.FromAppealDBEntity(appeal)
+	res.Transition = entities.FromStatusTransitionDBEntity(transition)
+	return res, nil
+}
+
+// ResolveAppeal lets an admin approve or reject an appeal regardless of the votes
+func (u *UserService) ResolveAppeal(ctx context.Context, adminID, appealID string, approve bool) (*entities.Appeal, error) {
+	if err := u.requireAdmin(ctx, adminID); err != nil {
+		return nil, err
+	}
+
+	var (
+		appeal     *store.Appeal
+		transition *store.StatusTransition
+	)
+	err := u.Storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		var err error
+		if appeal, err = u.lockAppeal(ctx, tx, appealID); err != nil {
+			return err
+		}
+		transition, err = u.resolve(ctx, tx, appeal, approve, adminID, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.settle(ctx, transition); err != nil {
+		return nil, err
+	}
+	res := entities.FromAppealDBEntity(appeal)
+	res.Transition = entities.FromStatusTransitionDBEntity(transition)
+	return res, nil
+}
+
+// lockAppeal locks the appellant so votes and resolutions of the same appeal are applied one after the other
+func (u *UserService) lockAppeal(ctx context.Context, tx store.IUserStorage, appealID string) (*store.Appeal, error) {
+	appeal, err := tx.FindAppeal(ctx, appealID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.LockUser(ctx, appeal.UserID); err != nil {
+		return nil, err
+	}
+	// the appeal might have been resolved while we were waiting for the lock
+	if appeal, err = tx.FindAppeal(ctx, appealID); err != nil {
+		return nil, err
+	}
+	if appeal.Status != store.AppealPending {
+		return nil, ErrAppealResolved
+	}
+	return appeal, nil
+}
+
+// resolve records the outcome of the appeal. Approved appeals clear the survivor and overturn every flag against them.
+// resolvedBy is empty when the appeal was resolved by the quorum. Returns the status change, nil if there was none.
+func (u *UserService) resolve(ctx context.Context, tx store.IUserStorage, appeal *store.Appeal, approve bool, actorID, resolvedBy string) (*store.StatusTransition, error) {
+	status, action := store.AppealRejected, AuditActionAppealRejected
+	if approve {
+		status, action = store.AppealApproved, AuditActionAppealApproved
+	}
+	if err := tx.ResolveAppeal(ctx, appeal.ID, status, resolvedBy); err != nil {
+		return nil, err
+	}
+	var transition *store.StatusTransition
+
+	inputs := map[string]interface{}{
+		"appeal_id":   appeal.ID,
+		"resolved_by": resolvedBy,
+	}
+	if approve {
+		usr, err := tx.Find(ctx, appeal.UserID)
+		if err != nil {
+			return nil, err
+		}
+		// the survivor might have been cured or died while the appeal was pending
+		if usr.Status.Infected() {
+			if transition, err = u.transition(ctx, tx, usr, store.StatusHealthy, actorID, "appeal approved"); err != nil {
+				return nil, err
+			}
+		}
+		// the flags are deleted, the flaggers keep a count of them for their reputation
+		flaggers := make([]string, 0, len(usr.FlagMonitor))
+		for _, v := range usr.FlagMonitor {
+			flaggers = append(flaggers, v.UserID)
+		}
+		if err := tx.AddRejectedFlags(ctx, flaggers...); err != nil {
+			return nil, err
+		}
+		overturned, err := tx.DeleteFlags(ctx, appeal.UserID)
+		if err != nil {
+			return nil, err
+		}
+		inputs["overturned_flags"] = overturned
+	}
+	if err := u.audit(ctx, tx, &store.InfectionAudit{
+		UserID:   appeal.UserID,
+		ActorID:  actorID,
+		Action:   action,
+		Infected: !approve,
+	}, inputs); err != nil {
+		return nil, err
+	}
+
+	res, err := tx.FindAppeal(ctx, appeal.ID)
+	if err != nil {
+		return nil, err
+	}
+	*appeal = *res
+	return transition, nil
+}
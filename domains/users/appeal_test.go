@@ -0,0 +1,190 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"zssn/domains/entities"
+	"zssn/domains/users/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRetractFlag(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage, WithInfectionThreshold(2))
+	require.NoError(t, err)
+
+	target, flagger, anotherFlagger := createUsers(t, svc, 3)
+	_, err = svc.FlagUser(ctx, flagger.ID, target.ID, nil)
+	require.NoError(t, err)
+	_, err = svc.FlagUser(ctx, anotherFlagger.ID, target.ID, nil)
+	require.NoError(t, err)
+	assertInfected(t, svc, target.ID, true)
+
+	_, err = svc.RetractFlag(ctx, flagger.ID, target.ID)
+	require.NoError(t, err)
+	assertInfected(t, svc, target.ID, false)
+
+	res, err := svc.Find(ctx, target.ID)
+	require.NoError(t, err)
+	require.Len(t, res.FlagMonitor, 1)
+	assert.Equal(t, anotherFlagger.ID, res.FlagMonitor[0].UserID)
+
+	_, err = svc.RetractFlag(ctx, flagger.ID, target.ID)
+	require.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	audits, err := storage.FindInfectionAudits(ctx, target.ID)
+	require.NoError(t, err)
+	require.Len(t, audits, 3)
+	assert.Equal(t, AuditActionRetract, audits[2].Action)
+	assert.Equal(t, flagger.ID, audits[2].ActorID)
+	assert.False(t, audits[2].Infected)
+	assert.Contains(t, audits[2].Inputs, `"was_infected":true`)
+
+	// the flag can be raised again once it was retracted
+	_, err = svc.FlagUser(ctx, flagger.ID, target.ID, nil)
+	require.NoError(t, err)
+	assertInfected(t, svc, target.ID, true)
+}
+
+func TestSubmitAppeal(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage)
+	require.NoError(t, err)
+
+	u, _, _ := createUsers(t, svc, 1)
+	_, err = svc.SubmitAppeal(ctx, u.ID, "I'm fine")
+	require.True(t, errors.Is(err, ErrNotInfected))
+
+	require.NoError(t, storage.UpdateInfectedStatus(ctx, u.ID))
+	appeal, err := svc.SubmitAppeal(ctx, u.ID, "I'm fine")
+	require.NoError(t, err)
+	assert.NotEmpty(t, appeal.ID)
+	assert.Equal(t, u.ID, appeal.UserID)
+	assert.Equal(t, string(store.AppealPending), appeal.Status)
+
+	_, err = svc.SubmitAppeal(ctx, u.ID, "Really")
+	require.True(t, errors.Is(err, ErrAppealPending))
+
+	res, err := svc.FindAppeal(ctx, appeal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "I'm fine", res.Reason)
+}
+
+func TestVoteAppealApproved(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage, WithInfectionThreshold(2), WithAppealQuorum(2))
+	require.NoError(t, err)
+
+	target, flagger, anotherFlagger := createUsers(t, svc, 3)
+	_, err = svc.FlagUser(ctx, flagger.ID, target.ID, nil)
+	require.NoError(t, err)
+	_, err = svc.FlagUser(ctx, anotherFlagger.ID, target.ID, nil)
+	require.NoError(t, err)
+	appeal, err := svc.SubmitAppeal(ctx, target.ID, "It's ketchup")
+	require.NoError(t, err)
+
+	voter, anotherVoter, infectedVoter := createUsers(t, svc, 3)
+	require.NoError(t, storage.UpdateInfectedStatus(ctx, infectedVoter.ID))
+
+	_, err = svc.VoteAppeal(ctx, infectedVoter.ID, appeal.ID, true)
+	require.True(t, errors.Is(err, ErrNotEligibleVoter))
+	_, err = svc.VoteAppeal(ctx, target.ID, appeal.ID, true)
+	require.True(t, errors.Is(err, ErrNotEligibleVoter))
+
+	res, err := svc.VoteAppeal(ctx, voter.ID, appeal.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Approvals)
+	assert.Equal(t, string(store.AppealPending), res.Status)
+	_, err = svc.VoteAppeal(ctx, voter.ID, appeal.ID, false)
+	require.True(t, errors.Is(err, store.ErrDuplicateVote))
+
+	res, err = svc.VoteAppeal(ctx, anotherVoter.ID, appeal.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, string(store.AppealApproved), res.Status)
+	assert.NotNil(t, res.ResolvedAt)
+	assert.Empty(t, res.ResolvedBy)
+
+	usr, err := svc.Find(ctx, target.ID)
+	require.NoError(t, err)
+	assert.False(t, usr.Infected)
+	assert.Empty(t, usr.FlagMonitor)
+
+	_, err = svc.VoteAppeal(ctx, flagger.ID, appeal.ID, false)
+	require.True(t, errors.Is(err, ErrAppealResolved))
+
+	audits, err := storage.FindInfectionAudits(ctx, target.ID)
+	require.NoError(t, err)
+	last := audits[len(audits)-1]
+	assert.Equal(t, AuditActionAppealApproved, last.Action)
+	assert.Equal(t, anotherVoter.ID, last.ActorID)
+	assert.Contains(t, last.Inputs, `"overturned_flags":2`)
+}
+
+func TestVoteAppealRejected(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage, WithAppealQuorum(1))
+	require.NoError(t, err)
+
+	target, voter, _ := createUsers(t, svc, 2)
+	require.NoError(t, storage.UpdateInfectedStatus(ctx, target.ID))
+	appeal, err := svc.SubmitAppeal(ctx, target.ID, "It's ketchup")
+	require.NoError(t, err)
+
+	res, err := svc.VoteAppeal(ctx, voter.ID, appeal.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, string(store.AppealRejected), res.Status)
+	assert.Equal(t, 1, res.Rejections)
+	assertInfected(t, svc, target.ID, true)
+
+	// a new appeal can be submitted once the previous one was rejected
+	_, err = svc.SubmitAppeal(ctx, target.ID, "It's really ketchup")
+	require.NoError(t, err)
+}
+
+func TestResolveAppeal(t *testing.T) {
+	ctx := context.Background()
+	target, admin, survivor := newUser(t), newUser(t), newUser(t)
+	svc, err := New(storage)
+	require.NoError(t, err)
+	for _, u := range []*entities.User{target, admin, survivor} {
+		require.NoError(t, svc.Create(ctx, u))
+	}
+	require.NoError(t, storage.UpdateRole(ctx, admin.ID, store.RoleAdmin))
+	require.NoError(t, storage.UpdateInfectedStatus(ctx, target.ID))
+	appeal, err := svc.SubmitAppeal(ctx, target.ID, "It's paint")
+	require.NoError(t, err)
+
+	_, err = svc.ResolveAppeal(ctx, survivor.ID, appeal.ID, true)
+	require.True(t, errors.Is(err, ErrNotAdmin))
+
+	res, err := svc.ResolveAppeal(ctx, admin.ID, appeal.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, string(store.AppealApproved), res.Status)
+	assert.Equal(t, admin.ID, res.ResolvedBy)
+	assertInfected(t, svc, target.ID, false)
+
+	_, err = svc.ResolveAppeal(ctx, admin.ID, appeal.ID, false)
+	require.True(t, errors.Is(err, ErrAppealResolved))
+}
+
+func createUsers(t *testing.T, svc IUserService, n int) (*entities.User, *entities.User, *entities.User) {
+	t.Helper()
+	res := make([]*entities.User, 3)
+	for i := 0; i < n; i++ {
+		res[i] = newUser(t)
+		require.NoError(t, svc.Create(context.Background(), res[i]))
+	}
+	return res[0], res[1], res[2]
+}
+
+func assertInfected(t *testing.T, svc IUserService, id string, expected bool) {
+	t.Helper()
+	ok, err := svc.IsInfected(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, expected, ok)
+}
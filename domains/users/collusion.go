@@ -0,0 +1,321 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"zssn/domains/entities"
+	"zssn/domains/users/store"
+)
+
+// signals the collusion detection looks for
+const (
+	// SignalSignupBurst the flagger signed up around the same time as other flaggers, none of them having traded since
+	SignalSignupBurst = "signup_burst"
+	// SignalSharedTargets the flagger flagged several of the same survivors as other flaggers, or one who has since recovered
+	SignalSharedTargets = "shared_targets"
+	// SignalNoTrades the flagger never traded with anyone
+	SignalNoTrades = "no_trades"
+)
+
+// ErrReviewResolved is returned when resolving a collusion review an admin already decided on
+var ErrReviewResolved = errors.New("collusion review has already been resolved")
+
+// TradeActivityFunc returns the number of trades every given survivor took part in, survivors who never traded can be left out
+type TradeActivityFunc func(ctx context.Context, ids ...string) (map[string]int, error)
+
+// CollusionRules decides which flaggers the collusion detection queues for review
+type CollusionRules struct {
+	// Window only flags raised within the window are analysed
+	Window time.Duration
+	// SignupWindow flaggers who signed up within this duration of each other and never traded are a signup burst
+	SignupWindow time.Duration
+	// ClusterSize number of flaggers, including the flagger, needed for a signup burst or a cluster sharing targets
+	ClusterSize int
+	// SharedTargets number of survivors two flaggers have to have both flagged to share targets.
+	// Flagging the same survivor is what honest flaggers of an infection do, so one is only enough when the survivor has since recovered.
+	SharedTargets int
+	// MinSignals number of signals a flagger needs to be queued for review
+	MinSignals int
+	// Discount leaves the flags of pending and confirmed flaggers out of the infection policy
+	Discount bool
+}
+
+// DefaultCollusionRules returns the rules used when none are configured
+func DefaultCollusionRules() CollusionRules {
+	return CollusionRules{
+		Window:        7 * 24 * time.Hour,
+		SignupWindow:  time.Hour,
+		ClusterSize:   3,
+		SharedTargets: 2,
+		MinSignals:    2,
+	}
+}
+
+// WithCollusionRules sets the rules of the collusion detection, defaults are used for non-positive values
+func WithCollusionRules(r CollusionRules) Option {
+	return func(u *UserService) {
+		d := DefaultCollusionRules()
+		if r.Window <= 0 {
+			r.Window = d.Window
+		}
+		if r.SignupWindow <= 0 {
+			r.SignupWindow = d.SignupWindow
+		}
+		if r.ClusterSize < 2 {
+			r.ClusterSize = d.ClusterSize
+		}
+		if r.SharedTargets < 1 {
+			r.SharedTargets = d.SharedTargets
+		}
+		if r.MinSignals < 1 {
+			r.MinSignals = d.MinSignals
+		}
+		u.collusion = r
+	}
+}
+
+// WithTradeActivity sets where the collusion detection looks up the trades of the flaggers.
+// Without it flaggers are never suspected for not trading and every flagger who signed up in a burst is, traded or not.
+func WithTradeActivity(fn TradeActivityFunc) Option {
+	return func(u *UserService) {
+		u.tradeActivity = fn
+	}
+}
+
+// DetectCollusion analyses the flags raised within the window and queues the suspicious flaggers for review.
+// It returns the reviews that were created or refreshed, reviews an admin already decided on are left alone.
+func (u *UserService) DetectCollusion(ctx context.Context) ([]*entities.CollusionReview, error) {
+	flags, err := u.Storage.FindFlagsSince(ctx, time.Now().Add(-u.collusion.Window))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		targets   = make(map[string]map[string]bool)
+		flaggedID []string
+		seen      = make(map[string]bool)
+	)
+	for _, v := range flags {
+		if targets[v.UserID] == nil {
+			targets[v.UserID] = make(map[string]bool)
+		}
+		targets[v.UserID][v.InfectedUserID] = true
+		if !seen[v.InfectedUserID] {
+			seen[v.InfectedUserID] = true
+			flaggedID = append(flaggedID, v.InfectedUserID)
+		}
+	}
+	if len(targets) == 0 {
+		return []*entities.CollusionReview{}, nil
+	}
+	flagged, err := u.Storage.FindUsers(ctx, flaggedID...)
+	if err != nil {
+		return nil, err
+	}
+	recovered := make(map[string]bool)
+	for id, v := range flagged {
+		if v.Status == store.StatusRecovered {
+			recovered[id] = true
+		}
+	}
+	ids := make([]string, 0, len(targets))
+	for id := range targets {
+		ids = append(ids, id)
+	}
+	flaggers, err := u.Storage.FindUsers(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+	// flaggers who have been deleted since can't be reviewed
+	ids = ids[:0]
+	for id := range flaggers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := flaggers[ids[i]], flaggers[ids[j]]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	// accounts that traded are in use, signing up with others doesn't make them suspicious
+	idle := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		idle[id] = true
+	}
+	if u.tradeActivity != nil {
+		trades, err := u.tradeActivity(ctx, ids...)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			idle[id] = trades[id] == 0
+		}
+	}
+
+	signals := make(map[string][]string, len(ids))
+	for _, id := range ids {
+		if u.signupBurst(flaggers, idle, ids, id) {
+			signals[id] = append(signals[id], SignalSignupBurst)
+		}
+		if u.sharedTargets(targets, recovered, ids, id) {
+			signals[id] = append(signals[id], SignalSharedTargets)
+		}
+		if u.tradeActivity != nil && idle[id] {
+			signals[id] = append(signals[id], SignalNoTrades)
+		}
+	}
+
+	res := []*entities.CollusionReview{}
+	for _, id := range ids {
+		if len(signals[id]) < u.collusion.MinSignals {
+			continue
+		}
+		own := make([]string, 0, len(targets[id]))
+		for t := range targets[id] {
+			own = append(own, t)
+		}
+		sort.Strings(own)
+		review := &store.CollusionReview{
+			UserID:  id,
+			Signals: strings.Join(signals[id], ","),
+			Targets: strings.Join(own, ","),
+			Score:   float64(len(signals[id])),
+		}
+		if err := u.Storage.SaveCollusionReview(ctx, review); err != nil {
+			return nil, err
+		}
+		if review.Status == store.ReviewPending {
+			res = append(res, entities.FromCollusionReviewDBEntity(review))
+		}
+	}
+	return res, nil
+}
+
+// signupBurst reports whether the flagger and enough other flaggers signed up within the signup window of each other
+// without trading since. Only idle flaggers are part of a burst.
+func (u *UserService) signupBurst(flaggers map[string]*store.User, idle map[string]bool, ids []string, id string) bool {
+	if !idle[id] {
+		return false
+	}
+	n := 1
+	created := flaggers[id].CreatedAt
+	for _, other := range ids {
+		if other == id || !idle[other] {
+			continue
+		}
+		d := flaggers[other].CreatedAt.Sub(created)
+		if d < 0 {
+			d = -d
+		}
+		if d <= u.collusion.SignupWindow {
+			n++
+		}
+	}
+	return n >= u.collusion.ClusterSize
+}
+
+// sharedTargets reports whether enough other flaggers flagged at least SharedTargets of the survivors the flagger flagged,
+// or one of them who has since recovered. Survivors cleared on appeal lose their flags, so they never count here.
+func (u *UserService) sharedTargets(targets map[string]map[string]bool, recovered map[string]bool, ids []string, id string) bool {
+	n := 1
+	for _, other := range ids {
+		if other == id {
+			continue
+		}
+		shared := 0
+		for t := range targets[id] {
+			if !targets[other][t] {
+				continue
+			}
+			if recovered[t] {
+				shared = u.collusion.SharedTargets
+				break
+			}
+			shared++
+		}
+		if shared >= u.collusion.SharedTargets {
+			n++
+		}
+	}
+	return n >= u.collusion.ClusterSize
+}
+
+// CollusionReviews returns the reviews with the given status, or every review if it's empty, oldest first
+func (u *UserService) CollusionReviews(ctx context.Context, adminID, status string) ([]*entities.CollusionReview, error) {
+	if err := u.requireAdmin(ctx, adminID); err != nil {
+		return nil, err
+	}
+	var s store.ReviewStatus
+	if status != "" {
+		var err error
+		if s, err = store.ParseReviewStatus(status); err != nil {
+			return nil, err
+		}
+	}
+	reviews, err := u.Storage.FindCollusionReviews(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entities.CollusionReview, 0, len(reviews))
+	for _, v := range reviews {
+		res = append(res, entities.FromCollusionReviewDBEntity(v))
+	}
+	return res, nil
+}
+
+// ResolveCollusionReview lets an admin confirm or dismiss a pending review.
+// Dismissed flaggers are never queued again, the flags of confirmed flaggers stay discounted when discounting is enabled.
+func (u *UserService) ResolveCollusionReview(ctx context.Context, adminID, id string, confirm bool) (*entities.CollusionReview, error) {
+	if err := u.requireAdmin(ctx, adminID); err != nil {
+		return nil, err
+	}
+	review, err := u.Storage.FindCollusionReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != store.ReviewPending {
+		return nil, ErrReviewResolved
+	}
+	status := store.ReviewDismissed
+	if confirm {
+		status = store.ReviewConfirmed
+	}
+	if err := u.Storage.ResolveCollusionReview(ctx, id, status, adminID); err != nil {
+		return nil, err
+	}
+	if review, err = u.Storage.FindCollusionReview(ctx, id); err != nil {
+		return nil, err
+	}
+	return entities.FromCollusionReviewDBEntity(review), nil
+}
+
+// withoutSuspiciousFlaggers returns a copy of the user without the flags raised by flaggers under collusion review.
+// The user is returned as is when discounting is disabled.
+func (u *UserService) withoutSuspiciousFlaggers(ctx context.Context, storage store.IUserStorage, usr *store.User) (*store.User, int, error) {
+	if !u.collusion.Discount || len(usr.FlagMonitor) == 0 {
+		return usr, 0, nil
+	}
+	ids := make([]string, 0, len(usr.FlagMonitor))
+	for _, v := range usr.FlagMonitor {
+		ids = append(ids, v.UserID)
+	}
+	reviews, err := storage.FindUserCollusionReviews(ctx, ids...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	target := *usr
+	target.FlagMonitor = make([]store.FlagMonitor, 0, len(usr.FlagMonitor))
+	for _, v := range usr.FlagMonitor {
+		if r, ok := reviews[v.UserID]; ok && r.Status.Suspicious() {
+			continue
+		}
+		target.FlagMonitor = append(target.FlagMonitor, v)
+	}
+	return &target, len(usr.FlagMonitor) - len(target.FlagMonitor), nil
+}
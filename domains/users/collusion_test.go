@@ -0,0 +1,267 @@
+package users_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"zssn/database"
+	"zssn/domains/users"
+	"zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flaggingRing holds the survivors of a collusion scenario, honest flaggers signed up days apart and traded,
+// the ring signed up together and never traded
+type flaggingRing struct {
+	storage store.IUserStorage
+	admin   *store.User
+	victim  *store.User
+	honest  []*store.User
+	ring    []*store.User
+}
+
+// newFlaggingRing runs against the test database so no other test's flags end up in the analysis
+func newFlaggingRing(t *testing.T) *flaggingRing {
+	t.Helper()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+
+	now := time.Now()
+	r := &flaggingRing{
+		storage: storage,
+		admin:   createUserAt(t, storage, now.Add(-30*24*time.Hour)),
+		victim:  createUserAt(t, storage, now.Add(-20*24*time.Hour)),
+		honest:  []*store.User{createUserAt(t, storage, now.Add(-72*time.Hour)), createUserAt(t, storage, now.Add(-50*time.Hour))},
+	}
+	for i := 0; i < 3; i++ {
+		r.ring = append(r.ring, createUserAt(t, storage, now.Add(-time.Duration(i)*time.Minute)))
+	}
+	return r
+}
+
+func (r *flaggingRing) service(t *testing.T, opts ...users.Option) users.IUserService {
+	t.Helper()
+	trades := func(ctx context.Context, ids ...string) (map[string]int, error) {
+		return map[string]int{r.honest[0].ID: 2, r.honest[1].ID: 1, r.admin.ID: 4}, nil
+	}
+	require.NoError(t, r.storage.UpdateRole(context.Background(), r.admin.ID, store.RoleAdmin))
+	opts = append([]users.Option{
+		users.WithInfectionThreshold(10),
+		users.WithTradeActivity(trades),
+	}, opts...)
+	svc, err := users.New(r.storage, opts...)
+	require.NoError(t, err)
+	return svc
+}
+
+func TestDetectCollusion(t *testing.T) {
+	ctx := context.Background()
+	r := newFlaggingRing(t)
+	svc := r.service(t)
+	for _, v := range append(append([]*store.User{}, r.honest...), r.ring...) {
+		_, err := svc.FlagUser(ctx, v.ID, r.victim.ID, nil)
+		require.NoError(t, err)
+	}
+	// the ring goes after a second survivor too
+	decoy := createUser(t, r.storage)
+	for _, v := range r.ring {
+		_, err := svc.FlagUser(ctx, v.ID, decoy.ID, nil)
+		require.NoError(t, err)
+	}
+	targets := []string{r.victim.ID, decoy.ID}
+	sort.Strings(targets)
+
+	res, err := svc.DetectCollusion(ctx)
+	require.NoError(t, err)
+	// the honest flaggers share a single target with everyone else, which doesn't make them suspicious
+	require.Len(t, res, len(r.ring))
+	for i, v := range res {
+		assert.Equal(t, r.ring[len(r.ring)-1-i].ID, v.UserID)
+		assert.Equal(t, []string{users.SignalSignupBurst, users.SignalSharedTargets, users.SignalNoTrades}, v.Signals)
+		assert.Equal(t, targets, v.Targets)
+		assert.Equal(t, float64(3), v.Score)
+		assert.Equal(t, "pending", v.Status)
+	}
+
+	// running again refreshes the queue instead of growing it
+	again, err := svc.DetectCollusion(ctx)
+	require.NoError(t, err)
+	require.Len(t, again, len(res))
+	assert.Equal(t, res[0].ID, again[0].ID)
+
+	_, err = svc.CollusionReviews(ctx, r.ring[0].ID, "pending")
+	require.True(t, errors.Is(err, users.ErrNotAdmin))
+	_, err = svc.CollusionReviews(ctx, r.admin.ID, "forgotten")
+	require.Error(t, err)
+
+	dismissed, err := svc.ResolveCollusionReview(ctx, r.admin.ID, res[0].ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, "dismissed", dismissed.Status)
+	assert.Equal(t, r.admin.ID, dismissed.ReviewedBy)
+	_, err = svc.ResolveCollusionReview(ctx, r.admin.ID, res[0].ID, true)
+	require.True(t, errors.Is(err, users.ErrReviewResolved))
+	_, err = svc.ResolveCollusionReview(ctx, r.ring[1].ID, res[1].ID, true)
+	require.True(t, errors.Is(err, users.ErrNotAdmin))
+
+	// dismissed flaggers are never queued again
+	again, err = svc.DetectCollusion(ctx)
+	require.NoError(t, err)
+	assert.Len(t, again, len(res)-1)
+	pending, err := svc.CollusionReviews(ctx, r.admin.ID, "pending")
+	require.NoError(t, err)
+	assert.Len(t, pending, len(res)-1)
+	all, err := svc.CollusionReviews(ctx, r.admin.ID, "")
+	require.NoError(t, err)
+	assert.Len(t, all, len(res))
+}
+
+func TestDetectCollusionHonestFlaggers(t *testing.T) {
+	ctx := context.Background()
+	r := newFlaggingRing(t)
+	now := time.Now()
+	honest := append([]*store.User{}, r.honest...)
+	honest = append(honest, createUserAt(t, r.storage, now.Add(-30*time.Hour)), createUserAt(t, r.storage, now.Add(-10*time.Hour)))
+	trades := func(ctx context.Context, ids ...string) (map[string]int, error) {
+		res := make(map[string]int)
+		for _, v := range ids {
+			res[v] = 1
+		}
+		return res, nil
+	}
+	// any single signal would queue a flagger
+	svc, err := users.New(r.storage, users.WithInfectionThreshold(len(honest)), users.WithTradeActivity(trades),
+		users.WithCollusionRules(users.CollusionRules{MinSignals: 1}))
+	require.NoError(t, err)
+
+	// survivors who signed up apart and trade all report the same infection
+	for _, v := range honest {
+		_, err = svc.FlagUser(ctx, v.ID, r.victim.ID, nil)
+		require.NoError(t, err)
+	}
+	infected, err := svc.IsInfected(ctx, r.victim.ID)
+	require.NoError(t, err)
+	require.True(t, infected)
+
+	res, err := svc.DetectCollusion(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	// a survivor who recovered from what they were flagged for makes a single shared target suspicious
+	require.NoError(t, r.storage.UpdateStatus(ctx, r.victim.ID, store.StatusRecovered))
+	res, err = svc.DetectCollusion(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, len(honest))
+	for _, v := range res {
+		assert.Equal(t, []string{users.SignalSharedTargets}, v.Signals)
+	}
+}
+
+func TestDetectCollusionWithoutTradeActivity(t *testing.T) {
+	ctx := context.Background()
+	r := newFlaggingRing(t)
+	svc, err := users.New(r.storage, users.WithInfectionThreshold(10), users.WithCollusionRules(users.CollusionRules{MinSignals: 3}))
+	require.NoError(t, err)
+	for _, v := range r.ring {
+		_, err = svc.FlagUser(ctx, v.ID, r.victim.ID, nil)
+		require.NoError(t, err)
+	}
+
+	// without the trades only two signals can be found
+	res, err := svc.DetectCollusion(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func TestSignupBurstOnlyIdleAccounts(t *testing.T) {
+	ctx := context.Background()
+	r := newFlaggingRing(t)
+	trades := func(ctx context.Context, ids ...string) (map[string]int, error) {
+		return map[string]int{r.ring[0].ID: 1}, nil
+	}
+	svc, err := users.New(r.storage, users.WithInfectionThreshold(10), users.WithTradeActivity(trades),
+		users.WithCollusionRules(users.CollusionRules{MinSignals: 1}))
+	require.NoError(t, err)
+	for _, v := range r.ring {
+		_, err = svc.FlagUser(ctx, v.ID, r.victim.ID, nil)
+		require.NoError(t, err)
+	}
+
+	// the ring signed up together, but one of them traded, which leaves too few idle accounts for a burst
+	res, err := svc.DetectCollusion(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, len(r.ring)-1)
+	for _, v := range res {
+		assert.NotEqual(t, r.ring[0].ID, v.UserID)
+		assert.Equal(t, []string{users.SignalNoTrades}, v.Signals)
+	}
+
+	// without the trades every flagger who signed up together counts
+	svc, err = users.New(r.storage, users.WithInfectionThreshold(10), users.WithCollusionRules(users.CollusionRules{MinSignals: 1}))
+	require.NoError(t, err)
+	res, err = svc.DetectCollusion(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, len(r.ring))
+	for _, v := range res {
+		assert.Equal(t, []string{users.SignalSignupBurst}, v.Signals)
+	}
+}
+
+func TestCollusionDiscount(t *testing.T) {
+	ctx := context.Background()
+	r := newFlaggingRing(t)
+	svc := r.service(t, users.WithInfectionThreshold(2), users.WithCollusionRules(users.CollusionRules{Discount: true}))
+	decoy := createUser(t, r.storage)
+	for _, v := range r.ring {
+		_, err := svc.FlagUser(ctx, v.ID, decoy.ID, nil)
+		require.NoError(t, err)
+	}
+	res, err := svc.DetectCollusion(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, len(r.ring))
+
+	// the flags of the queued ring don't count anymore
+	_, err = svc.FlagUser(ctx, r.ring[0].ID, r.victim.ID, nil)
+	require.NoError(t, err)
+	_, err = svc.FlagUser(ctx, r.ring[1].ID, r.victim.ID, nil)
+	require.NoError(t, err)
+	infected, err := svc.IsInfected(ctx, r.victim.ID)
+	require.NoError(t, err)
+	assert.False(t, infected)
+
+	audits, err := r.storage.FindInfectionAudits(ctx, r.victim.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, audits)
+	var inputs map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(audits[len(audits)-1].Inputs), &inputs))
+	assert.Equal(t, float64(2), inputs["discounted_flags"])
+
+	// dismissed flaggers are trusted again
+	for _, v := range res {
+		if v.UserID == r.ring[0].ID {
+			_, err = svc.ResolveCollusionReview(ctx, r.admin.ID, v.ID, false)
+			require.NoError(t, err)
+		}
+	}
+	_, err = svc.FlagUser(ctx, r.honest[0].ID, r.victim.ID, nil)
+	require.NoError(t, err)
+	infected, err = svc.IsInfected(ctx, r.victim.ID)
+	require.NoError(t, err)
+	assert.True(t, infected)
+}
+
+func createUserAt(t *testing.T, storage store.IUserStorage, created time.Time) *store.User {
+	t.Helper()
+	u := storetest.NewUser(t)
+	u.CreatedAt = created
+	require.NoError(t, storage.Create(context.Background(), u))
+	return u
+}
@@ -0,0 +1,117 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"zssn/domains/users/store"
+)
+
+// ErrEffectsPending is returned when a status change was saved but applying its effects failed.
+// The effects are applied again with the next change of the survivor or by ApplyPendingTransitions.
+var ErrEffectsPending = errors.New("status changed, its effects are pending")
+
+// InventoryAccessFunc blocks or unblocks the inventory of the given survivor
+type InventoryAccessFunc func(ctx context.Context, id string, blocked bool) error
+
+// WithInventoryAccess sets how the inventories are blocked and unblocked as the status of their owners changes.
+// Without it the inventories are left alone.
+func WithInventoryAccess(fn InventoryAccessFunc) Option {
+	return func(u *UserService) {
+		u.inventoryAccess = fn
+	}
+}
+
+// WithInventoryRestoredOnRecovery decides if recovered survivors get their blocked inventory back, they do by default
+func WithInventoryRestoredOnRecovery(restore bool) Option {
+	return func(u *UserService) {
+		u.restoreInventoryOnRecovery = restore
+	}
+}
+
+// WithContactTracing traces and warns the contacts of survivors once they are found infected, it's disabled by default
+func WithContactTracing(enabled bool) Option {
+	return func(u *UserService) {
+		u.contactTracing = enabled
+	}
+}
+
+// ApplyPendingTransitions applies the effects of every status change they failed for and returns how many changes were applied.
+// A failure only holds back the later changes of the same survivor, the first failure is returned.
+func (u *UserService) ApplyPendingTransitions(ctx context.Context) (int, error) {
+	pending, err := u.Storage.FindPendingTransitions(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	var (
+		applied  int
+		firstErr error
+		failed   = make(map[string]bool)
+	)
+	for _, v := range pending {
+		if failed[v.UserID] {
+			continue
+		}
+		if err := u.apply(ctx, v); err != nil {
+			failed[v.UserID] = true
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		applied++
+	}
+	return applied, firstErr
+}
+
+// settle applies the effects of the status change that was just made, along with the earlier changes of the survivor still pending.
+// The changes are applied in the order they were made, so the inventory always ends up following the latest status.
+func (u *UserService) settle(ctx context.Context, t *store.StatusTransition) error {
+	if t == nil {
+		return nil
+	}
+	pending, err := u.Storage.FindPendingTransitions(ctx, t.UserID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEffectsPending, err)
+	}
+	for _, v := range pending {
+		if err := u.apply(ctx, v); err != nil {
+			return fmt.Errorf("%w: %v", ErrEffectsPending, err)
+		}
+	}
+	return nil
+}
+
+// apply runs the effects of the status change and marks it applied.
+// The inventory follows the status and the contacts of survivors who just got infected are warned,
+// both are safe to repeat when marking the change fails.
+func (u *UserService) apply(ctx context.Context, t *store.StatusTransition) error {
+	if err := u.syncInventory(ctx, t); err != nil {
+		return err
+	}
+	if u.contactTracing && t.FromStatus.Clean() && t.ToStatus.Infected() {
+		if _, err := u.TraceContacts(ctx, t.UserID); err != nil {
+			return err
+		}
+	}
+	return u.Storage.MarkTransitionApplied(ctx, t.ID)
+}
+
+// syncInventory blocks the inventory of survivors who are no longer clean and unblocks it once they are clean again.
+// Recovered survivors only get their inventory back if the recovery policy allows it.
+func (u *UserService) syncInventory(ctx context.Context, t *store.StatusTransition) error {
+	if u.inventoryAccess == nil {
+		return nil
+	}
+	switch {
+	case t.FromStatus.Clean() && !t.ToStatus.Clean():
+		return u.inventoryAccess(ctx, t.UserID, true)
+	case !t.FromStatus.Clean() && t.ToStatus.Clean():
+		if t.ToStatus == store.StatusRecovered && !u.restoreInventoryOnRecovery {
+			return nil
+		}
+		return u.inventoryAccess(ctx, t.UserID, false)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"zssn/domains/users/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusEffectsRetried(t *testing.T) {
+	ctx := context.Background()
+	var (
+		failing bool
+		access  = make(map[string][]bool)
+	)
+	svc, err := New(storage, WithInfectionThreshold(1), WithInventoryAccess(func(ctx context.Context, id string, blocked bool) error {
+		if failing {
+			return errors.New("inventory unavailable")
+		}
+		access[id] = append(access[id], blocked)
+		return nil
+	}))
+	require.NoError(t, err)
+	target, flagger, admin := createUsers(t, svc, 3)
+	require.NoError(t, storage.UpdateRole(ctx, admin.ID, store.RoleAdmin))
+
+	// the infection is kept even though the inventory couldn't be blocked
+	failing = true
+	_, err = svc.FlagUser(ctx, flagger.ID, target.ID, nil)
+	require.True(t, errors.Is(err, ErrEffectsPending))
+	assertStatus(t, svc, target.ID, store.StatusInfected)
+	assert.Empty(t, access[target.ID])
+
+	// the next change applies the pending one first, so the inventory ends up following the latest status
+	failing = false
+	_, err = svc.UpdateStatus(ctx, admin.ID, target.ID, store.StatusRecovered, "cured")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, access[target.ID])
+
+	failing = true
+	_, err = svc.UpdateStatus(ctx, admin.ID, target.ID, store.StatusQuarantined, "bitten")
+	require.True(t, errors.Is(err, ErrEffectsPending))
+	_, err = svc.ApplyPendingTransitions(ctx)
+	require.Error(t, err)
+	failing = false
+	applied, err := svc.ApplyPendingTransitions(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, applied, 1)
+	assert.Equal(t, []bool{true, false, true}, access[target.ID])
+
+	pending, err := storage.FindPendingTransitions(ctx, target.ID)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestRecoveryKeepsInventoryBlocked(t *testing.T) {
+	ctx := context.Background()
+	access := make(map[string][]bool)
+	svc, err := New(storage, WithInventoryRestoredOnRecovery(false), WithInventoryAccess(func(ctx context.Context, id string, blocked bool) error {
+		access[id] = append(access[id], blocked)
+		return nil
+	}))
+	require.NoError(t, err)
+	target, admin, _ := createUsers(t, svc, 2)
+	require.NoError(t, storage.UpdateRole(ctx, admin.ID, store.RoleAdmin))
+
+	for _, status := range []store.Status{store.StatusQuarantined, store.StatusRecovered, store.StatusSuspected} {
+		_, err = svc.UpdateStatus(ctx, admin.ID, target.ID, status, "")
+		require.NoError(t, err)
+	}
+	// only leaving the clean statuses changes the inventory, recovering doesn't give it back
+	assert.Equal(t, []bool{true}, access[target.ID])
+}
@@ -0,0 +1,85 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"zssn/domains/entities"
+	"zssn/domains/users/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestFlagUserEvidence(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage)
+	require.NoError(t, err)
+
+	target, flagger, anotherFlagger := createUsers(t, svc, 3)
+	lat, long := 12.5, -45.25
+	_, err = svc.FlagUser(ctx, flagger.ID, target.ID, &entities.FlagEvidence{
+		Note:      "coughing blood",
+		Latitude:  &lat,
+		Longitude: &long,
+		Severity:  "high",
+	})
+	require.NoError(t, err)
+	// without evidence the flagger's last known location is recorded
+	_, err = svc.FlagUser(ctx, anotherFlagger.ID, target.ID, nil)
+	require.NoError(t, err)
+
+	flags, err := svc.FindFlags(ctx, target.ID, target.ID)
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+	byNote := map[string]*entities.Flag{flags[0].Note: flags[0], flags[1].Note: flags[1]}
+	require.Contains(t, byNote, "coughing blood")
+	assert.Equal(t, "high", byNote["coughing blood"].Severity)
+	assert.Equal(t, "medium", byNote[""].Severity)
+
+	_, err = svc.FlagUser(ctx, flagger.ID, anotherFlagger.ID, &entities.FlagEvidence{Severity: "extreme"})
+	require.Error(t, err)
+	flags, err = svc.FindFlags(ctx, anotherFlagger.ID, anotherFlagger.ID)
+	require.NoError(t, err)
+	assert.Empty(t, flags)
+}
+
+func TestFindFlags(t *testing.T) {
+	ctx := context.Background()
+	target, flagger, admin := newUser(t), newUser(t), newUser(t)
+	svc, err := New(storage)
+	require.NoError(t, err)
+	require.NoError(t, svc.Create(ctx, target))
+	require.NoError(t, svc.Create(ctx, flagger))
+	require.NoError(t, svc.Create(ctx, admin))
+	require.NoError(t, storage.UpdateRole(ctx, admin.ID, store.RoleAdmin))
+	_, err = svc.FlagUser(ctx, flagger.ID, target.ID, &entities.FlagEvidence{Note: "bitten"})
+	require.NoError(t, err)
+
+	// admins see everything
+	flags, err := svc.FindFlags(ctx, admin.ID, target.ID)
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Equal(t, flagger.ID, flags[0].FlaggerID)
+	assert.Equal(t, flagger.Latitude, *flags[0].Latitude)
+	assert.Equal(t, flagger.Longitude, *flags[0].Longitude)
+	assert.Equal(t, "bitten", flags[0].Note)
+
+	// the flagged survivor doesn't get to know who flagged them or where
+	flags, err = svc.FindFlags(ctx, target.ID, target.ID)
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Empty(t, flags[0].FlaggerID)
+	assert.Nil(t, flags[0].Latitude)
+	assert.Nil(t, flags[0].Longitude)
+	assert.Equal(t, "bitten", flags[0].Note)
+
+	_, err = svc.FindFlags(ctx, flagger.ID, target.ID)
+	require.True(t, errors.Is(err, ErrFlagsHidden))
+
+	_, err = svc.FindFlags(ctx, admin.ID, uuid.NewString())
+	require.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
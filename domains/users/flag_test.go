@@ -0,0 +1,117 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"zssn/database"
+	"zssn/domains/users"
+	"zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParallelFlagging runs against the test database since the mocks can't prove anything about locking
+func TestParallelFlagging(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+	svc, err := users.New(storage, users.WithInfectionThreshold(3))
+	require.NoError(t, err)
+
+	target := createUser(t, storage)
+	flaggers := make([]*store.User, 10)
+	for i := range flaggers {
+		flaggers[i] = createUser(t, storage)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(flaggers))
+	for _, f := range flaggers {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			_, err := svc.FlagUser(ctx, id, target.ID, nil)
+			errs <- err
+		}(f.ID)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	res, err := storage.Find(ctx, target.ID)
+	require.NoError(t, err)
+	assert.True(t, res.Infected)
+	assert.Len(t, res.FlagMonitor, len(flaggers))
+
+	// every flag was evaluated after the previous one was committed,
+	// so the policy saw 1, 2 and 3 flags and nothing after the survivor got infected
+	audits, err := storage.FindInfectionAudits(ctx, target.ID)
+	require.NoError(t, err)
+	require.Len(t, audits, 3)
+	scores := make(map[float64]bool)
+	for _, v := range audits {
+		scores[v.Score] = true
+		assert.Equal(t, v.Score == 3, v.Infected)
+	}
+	assert.Equal(t, map[float64]bool{1: true, 2: true, 3: true}, scores)
+}
+
+func TestParallelDuplicateFlags(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+	svc, err := users.New(storage, users.WithInfectionThreshold(2))
+	require.NoError(t, err)
+
+	target, flagger := createUser(t, storage), createUser(t, storage)
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		ok, failed int
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.FlagUser(ctx, flagger.ID, target.ID, nil)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				ok++
+			case errors.Is(err, store.ErrDuplicateFlag):
+				failed++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, ok)
+	assert.Equal(t, 4, failed)
+
+	// the duplicates didn't count towards the threshold
+	res, err := storage.Find(ctx, target.ID)
+	require.NoError(t, err)
+	assert.False(t, res.Infected)
+	assert.Len(t, res.FlagMonitor, 1)
+}
+
+func createUser(t *testing.T, storage store.IUserStorage) *store.User {
+	t.Helper()
+	u := storetest.NewUser(t)
+	require.NoError(t, storage.Create(context.Background(), u))
+	return u
+}
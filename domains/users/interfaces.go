@@ -2,8 +2,10 @@ package users
 
 import (
 	"context"
+	"time"
 
 	"zssn/domains/entities"
+	"zssn/domains/users/store"
 )
 
 // IUserService interface describing the contracts between the services
@@ -13,6 +15,34 @@ type IUserService interface {
 	FindUsers(ctx context.Context, ids ...string) (map[string]*entities.User, error)
 	FindByEmail(ctx context.Context, email string) (*entities.User, error)
 	UpdateLocation(ctx context.Context, id string, lat, long float64) error
-	FlagUser(ctx context.Context, id, infectedUser string) error
+	FlagUser(ctx context.Context, id, infectedUser string, evidence *entities.FlagEvidence) (*entities.StatusTransition, error)
+	FindFlags(ctx context.Context, requesterID, userID string) ([]*entities.Flag, error)
 	IsInfected(ctx context.Context, id string) (bool, error)
+	RetractFlag(ctx context.Context, id, infectedUser string) (*entities.StatusTransition, error)
+	SubmitAppeal(ctx context.Context, userID, reason string) (*entities.Appeal, error)
+	FindAppeal(ctx context.Context, id string) (*entities.Appeal, error)
+	VoteAppeal(ctx context.Context, voterID, appealID string, approve bool) (*entities.Appeal, error)
+	ResolveAppeal(ctx context.Context, adminID, appealID string, approve bool) (*entities.Appeal, error)
+	UpdateStatus(ctx context.Context, adminID, userID string, status store.Status, reason string) (*entities.StatusTransition, error)
+	StatusHistory(ctx context.Context, requesterID, userID string) ([]*entities.StatusTransition, error)
+	ApplyPendingTransitions(ctx context.Context) (int, error)
+	DetectCollusion(ctx context.Context) ([]*entities.CollusionReview, error)
+	CollusionReviews(ctx context.Context, adminID, status string) ([]*entities.CollusionReview, error)
+	ResolveCollusionReview(ctx context.Context, adminID, id string, confirm bool) (*entities.CollusionReview, error)
+	Profile(ctx context.Context, id string) (*entities.Profile, error)
+	FindPartners(ctx context.Context, requesterID string, minReputation float64, limit int) ([]*entities.Profile, error)
+	Nearby(ctx context.Context, id string, radiusKm float64) ([]*entities.NearbySurvivor, error)
+	LocationHistory(ctx context.Context, id string, from, to time.Time) ([]*entities.LocationPoint, error)
+	PruneLocations(ctx context.Context) (int64, error)
+	TraceContacts(ctx context.Context, id string) ([]*entities.Exposure, error)
+	Notifications(ctx context.Context, id string, unreadOnly bool) ([]*entities.Notification, error)
+	ReadNotification(ctx context.Context, id, notificationID string) error
+	CreateZone(ctx context.Context, adminID string, zone *entities.Zone) (*entities.Zone, error)
+	Zones(ctx context.Context) ([]*entities.Zone, error)
+	DeleteZone(ctx context.Context, adminID, id string) error
+	ZoneEvents(ctx context.Context, adminID, zoneID string, from, to time.Time) ([]*entities.ZoneEvent, error)
+	UserZones(ctx context.Context, ids ...string) (map[string][]*entities.Zone, error)
+	IsAdmin(ctx context.Context, id string) (bool, error)
+	UpdateVisibility(ctx context.Context, id, visibility string) error
+	LocationAudits(ctx context.Context, id string) ([]*entities.LocationAudit, error)
 }
@@ -0,0 +1,76 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"zssn/domains/entities"
+)
+
+// ErrInvalidTimeRange is returned when the end of a time range is before its start
+var ErrInvalidTimeRange = errors.New("the end of the time range cannot be before its start")
+
+// LocationRules decides how long the location history is kept and how detailed it stays
+type LocationRules struct {
+	// Retention points older than this are deleted, zero keeps them forever
+	Retention time.Duration
+	// DownsampleAfter points older than this are thinned out to one point every DownsampleInterval, zero disables downsampling
+	DownsampleAfter    time.Duration
+	DownsampleInterval time.Duration
+}
+
+// DefaultLocationRules returns the rules used when none are configured, the history is kept for 30 days without downsampling
+func DefaultLocationRules() LocationRules {
+	return LocationRules{
+		Retention: 30 * 24 * time.Hour,
+	}
+}
+
+// WithLocationRules sets how long the location history is kept and how it's downsampled
+func WithLocationRules(r LocationRules) Option {
+	return func(u *UserService) {
+		u.locations = r
+	}
+}
+
+// LocationHistory returns the locations the survivor reported between from and to, oldest first.
+// Zero times leave the range open.
+func (u *UserService) LocationHistory(ctx context.Context, id string, from, to time.Time) ([]*entities.LocationPoint, error) {
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, ErrInvalidTimeRange
+	}
+	if _, err := u.Storage.Find(ctx, id); err != nil {
+		return nil, err
+	}
+	points, err := u.Storage.FindLocationHistory(ctx, id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entities.LocationPoint, 0, len(points))
+	for _, v := range points {
+		res = append(res, entities.FromLocationPointDBEntity(v))
+	}
+	return res, nil
+}
+
+// PruneLocations applies the retention and downsampling rules to the location history, it returns the number of points removed
+func (u *UserService) PruneLocations(ctx context.Context) (int64, error) {
+	var removed int64
+	now := time.Now()
+	if u.locations.Retention > 0 {
+		n, err := u.Storage.DeleteLocationPoints(ctx, now.Add(-u.locations.Retention))
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	if u.locations.DownsampleAfter > 0 && u.locations.DownsampleInterval > 0 {
+		n, err := u.Storage.DownsampleLocationPoints(ctx, now.Add(-u.locations.DownsampleAfter), u.locations.DownsampleInterval)
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	return removed, nil
+}
@@ -0,0 +1,100 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"zssn/database"
+	"zssn/domains/users"
+	"zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestLocationHistory(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+	svc, err := users.New(storage)
+	require.NoError(t, err)
+	u := storetest.NewUser(t)
+	require.NoError(t, storage.Create(ctx, u))
+
+	start := time.Now().Add(-time.Second)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, svc.UpdateLocation(ctx, u.ID, float64(i), float64(-i)))
+	}
+	res, err := svc.LocationHistory(ctx, u.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	for i, v := range res {
+		assert.Equal(t, float64(i+1), v.Latitude)
+		assert.Equal(t, float64(-i-1), v.Longitude)
+		assert.False(t, v.CreatedAt.Before(start))
+	}
+	current, err := svc.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), current.Latitude)
+
+	res, err = svc.LocationHistory(ctx, u.ID, time.Now().Add(time.Hour), time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	_, err = svc.LocationHistory(ctx, u.ID, time.Now(), time.Now().Add(-time.Hour))
+	assert.True(t, errors.Is(err, users.ErrInvalidTimeRange))
+	_, err = svc.LocationHistory(ctx, "unknown", time.Time{}, time.Time{})
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	// failed updates don't leave points behind
+	require.Error(t, svc.UpdateLocation(ctx, "unknown", 1, 1))
+	points, err := storage.FindLocationHistory(ctx, "unknown", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, points)
+}
+
+func TestPruneLocations(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+	u := storetest.NewUser(t)
+	require.NoError(t, storage.Create(ctx, u))
+
+	now := time.Now()
+	for i, d := range []time.Duration{72 * time.Hour, 5 * time.Hour, 270 * time.Minute, 4 * time.Hour, time.Hour} {
+		p := &store.LocationPoint{UserID: u.ID, Latitude: float64(i), CreatedAt: now.Add(-d)}
+		require.NoError(t, storage.CreateLocationPoint(ctx, p))
+	}
+
+	// the defaults only apply the retention of 30 days
+	svc, err := users.New(storage)
+	require.NoError(t, err)
+	removed, err := svc.PruneLocations(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+
+	svc, err = users.New(storage, users.WithLocationRules(users.LocationRules{
+		Retention:          48 * time.Hour,
+		DownsampleAfter:    2 * time.Hour,
+		DownsampleInterval: time.Hour,
+	}))
+	require.NoError(t, err)
+	removed, err = svc.PruneLocations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	res, err := svc.LocationHistory(ctx, u.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	lats := make([]float64, 0, len(res))
+	for _, v := range res {
+		lats = append(lats, v.Latitude)
+	}
+	assert.Equal(t, []float64{1, 3, 4}, lats)
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
 
 	"github.com/brianvoe/gofakeit"
 	"github.com/google/uuid"
@@ -14,6 +15,12 @@ import (
 	"gorm.io/gorm"
 )
 
+func TestMockStorageConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.IUserStorage {
+		return NewMockStore()
+	})
+}
+
 func TestMockStorageService(t *testing.T) {
 	ctx := context.Background()
 	mockSvc := NewMockStore()
@@ -65,7 +72,7 @@ func TestMockMethodNotDefined(t *testing.T) {
 	require.NoError(t, storage.Create(ctx, infectedUser))
 	assert.NotEmpty(t, infectedUser.ID)
 
-	err := failureMockSvc.FlagUser(ctx, user.ID, infectedUser.ID)
+	err := failureMockSvc.FlagUser(ctx, user.ID, infectedUser.ID, nil)
 	require.EqualError(t, err, errMockNotDefined.Error())
 }
 
@@ -126,14 +133,14 @@ func TestMockFlagUser(t *testing.T) {
 	require.NoError(t, storage.Create(ctx, infectedUser))
 	assert.NotEmpty(t, infectedUser.ID)
 
-	err := storage.FlagUser(ctx, user.ID, infectedUser.ID)
+	err := storage.FlagUser(ctx, user.ID, infectedUser.ID, nil)
 	assert.NoError(t, err)
 
 	res, err := storage.Find(ctx, infectedUser.ID)
 	require.NoError(t, err)
 	assert.NotNil(t, res)
 
-	err = storage.FlagUser(ctx, user.ID, uuid.NewString())
+	err = storage.FlagUser(ctx, user.ID, uuid.NewString(), nil)
 	assert.EqualError(t, err, gorm.ErrRecordNotFound.Error())
 }
 
@@ -3,7 +3,12 @@ package users
 import (
 	"context"
 	"errors"
+	"sort"
+	"sync"
+	"time"
 
+	"zssn/domains/geo"
+	"zssn/domains/reputation"
 	"zssn/domains/users/store"
 
 	"github.com/google/uuid"
@@ -14,42 +19,151 @@ var (
 	_ store.IUserStorage = (*MockUserStorage)(nil)
 
 	errMockNotDefined = errors.New("mock function not defined")
+	errDuplicateEmail = errors.New("email already exists")
 	mockdDB           = make(map[string]*store.User)
+	mockAudits        = make(map[string][]*store.InfectionAudit)
+	mockAppeals       = make(map[string]*store.Appeal)
+	mockTransitions   = make(map[string][]*store.StatusTransition)
+	mockReviews       = make(map[string]*store.CollusionReview)
+	mockLocations     = make(map[string][]*store.LocationPoint)
+	mockNotifications = make(map[string][]*store.Notification)
+	mockZones         = make(map[string]*store.Zone)
+	// mockZoneMembers zone IDs keyed by user ID
+	mockZoneMembers = make(map[string]map[string]bool)
+	mockZoneEvents  = make(map[string][]*store.ZoneEvent)
+	// mockLocationAudits location audits keyed by the user whose location was seen
+	mockLocationAudits = make(map[string][]*store.LocationAudit)
+	// mockTxLock serializes the mocked transactions the same way row locks serialize them in the database
+	mockTxLock sync.Mutex
 )
 
 // MockUserStorage returns a mocked storage object
 type MockUserStorage struct {
-	CreateFunc               func(ctx context.Context, user *store.User) error
-	FlagUserFunc             func(ctx context.Context, id, infectedUser string) error
-	UpdateInfectedStatusFunc func(ctx context.Context, id string) error
-	FindFunc                 func(ctx context.Context, id string) (*store.User, error)
-	FindByEmailFunc          func(ctx context.Context, email string) (*store.User, error)
-	UpdateLocationFunc       func(ctx context.Context, id string, lat float64, long float64) error
-	FindUsersFunc            func(ctx context.Context, ids ...string) (map[string]*store.User, error)
+	WithTxFunc                   func(ctx context.Context, fn func(tx store.IUserStorage) error) error
+	LockUserFunc                 func(ctx context.Context, id string) error
+	CreateFunc                   func(ctx context.Context, user *store.User) error
+	FlagUserFunc                 func(ctx context.Context, id, infectedUser string, evidence *store.FlagEvidence) error
+	FindFlagsFunc                func(ctx context.Context, infectedUser string) ([]*store.FlagMonitor, error)
+	FindFlagsSinceFunc           func(ctx context.Context, since time.Time) ([]*store.FlagMonitor, error)
+	UpdateInfectedStatusFunc     func(ctx context.Context, id string) error
+	FindFunc                     func(ctx context.Context, id string) (*store.User, error)
+	FindByEmailFunc              func(ctx context.Context, email string) (*store.User, error)
+	UpdateLocationFunc           func(ctx context.Context, id string, lat float64, long float64) error
+	UpdateVisibilityFunc         func(ctx context.Context, id string, visibility store.Visibility) error
+	UpdateRoleFunc               func(ctx context.Context, id string, role store.Role) error
+	CreateLocationAuditsFunc     func(ctx context.Context, audits ...*store.LocationAudit) error
+	FindLocationAuditsFunc       func(ctx context.Context, userID string) ([]*store.LocationAudit, error)
+	FindUsersFunc                func(ctx context.Context, ids ...string) (map[string]*store.User, error)
+	FindNearbyFunc               func(ctx context.Context, center geo.Point, radiusKm float64, statuses ...store.Status) ([]*store.User, error)
+	CreateInfectionAuditFunc     func(ctx context.Context, audit *store.InfectionAudit) error
+	FindInfectionAuditsFunc      func(ctx context.Context, userID string) ([]*store.InfectionAudit, error)
+	UpdateStatusFunc             func(ctx context.Context, id string, status store.Status) error
+	DeleteFlagFunc               func(ctx context.Context, userID, infectedUser string) error
+	DeleteFlagsFunc              func(ctx context.Context, infectedUser string) (int64, error)
+	CreateAppealFunc             func(ctx context.Context, appeal *store.Appeal) error
+	FindAppealFunc               func(ctx context.Context, id string) (*store.Appeal, error)
+	FindUserAppealsFunc          func(ctx context.Context, userID string) ([]*store.Appeal, error)
+	ResolveAppealFunc            func(ctx context.Context, id string, status store.AppealStatus, resolvedBy string) error
+	CreateAppealVoteFunc         func(ctx context.Context, vote *store.AppealVote) error
+	CreateStatusTransitionFunc   func(ctx context.Context, transition *store.StatusTransition) error
+	FindStatusTransitionsFunc    func(ctx context.Context, userID string) ([]*store.StatusTransition, error)
+	FindPendingTransitionsFunc   func(ctx context.Context, userID string) ([]*store.StatusTransition, error)
+	MarkTransitionAppliedFunc    func(ctx context.Context, id string) error
+	FindByStatusFunc             func(ctx context.Context, statuses ...store.Status) ([]*store.User, error)
+	AddRejectedFlagsFunc         func(ctx context.Context, userIDs ...string) error
+	FindByReputationFunc         func(ctx context.Context, q store.ReputationQuery) ([]*store.ScoredUser, error)
+	CreateLocationPointFunc      func(ctx context.Context, point *store.LocationPoint) error
+	FindLocationHistoryFunc      func(ctx context.Context, userID string, from, to time.Time) ([]*store.LocationPoint, error)
+	DeleteLocationPointsFunc     func(ctx context.Context, before time.Time) (int64, error)
+	DownsampleLocationPointsFunc func(ctx context.Context, before time.Time, interval time.Duration) (int64, error)
+	FindLastLocationPointsFunc   func(ctx context.Context, from, to time.Time) (map[string]*store.LocationPoint, error)
+	FindLocationPointsInBoxFunc  func(ctx context.Context, box geo.Box, from, to time.Time) ([]*store.LocationPoint, error)
+	CreateNotificationFunc       func(ctx context.Context, n *store.Notification) error
+	FindNotificationsFunc        func(ctx context.Context, userID string, unreadOnly bool) ([]*store.Notification, error)
+	MarkNotificationReadFunc     func(ctx context.Context, userID, id string) error
+	FindInBoxFunc                func(ctx context.Context, box geo.Box) ([]*store.User, error)
+	CreateZoneFunc               func(ctx context.Context, zone *store.Zone) error
+	FindZoneFunc                 func(ctx context.Context, id string) (*store.Zone, error)
+	FindZonesFunc                func(ctx context.Context) ([]*store.Zone, error)
+	DeleteZoneFunc               func(ctx context.Context, id string) error
+	FindZonesAtFunc              func(ctx context.Context, p geo.Point) ([]*store.Zone, error)
+	FindUserZonesFunc            func(ctx context.Context, userIDs ...string) (map[string][]*store.Zone, error)
+	AddZoneMemberFunc            func(ctx context.Context, member *store.ZoneMember) error
+	DeleteZoneMemberFunc         func(ctx context.Context, userID, zoneID string) error
+	CreateZoneEventFunc          func(ctx context.Context, event *store.ZoneEvent) error
+	FindZoneEventsFunc           func(ctx context.Context, zoneID string, from, to time.Time) ([]*store.ZoneEvent, error)
+
+	SaveCollusionReviewFunc      func(ctx context.Context, review *store.CollusionReview) error
+	FindCollusionReviewFunc      func(ctx context.Context, id string) (*store.CollusionReview, error)
+	FindCollusionReviewsFunc     func(ctx context.Context, status store.ReviewStatus) ([]*store.CollusionReview, error)
+	FindUserCollusionReviewsFunc func(ctx context.Context, userIDs ...string) (map[string]*store.CollusionReview, error)
+	ResolveCollusionReviewFunc   func(ctx context.Context, id string, status store.ReviewStatus, reviewedBy string) error
 }
 
 // NewMockStore returns a new mock implementation of the functions
 func NewMockStore() *MockUserStorage {
-	return &MockUserStorage{
+	m := &MockUserStorage{
+		LockUserFunc: func(ctx context.Context, id string) error {
+			if _, ok := mockdDB[id]; !ok {
+				return gorm.ErrRecordNotFound
+			}
+			return nil
+		},
 		CreateFunc: func(ctx context.Context, user *store.User) error {
+			for _, v := range mockdDB {
+				if v.Email == user.Email {
+					return errDuplicateEmail
+				}
+			}
 			user.ID = uuid.NewString()
+			if user.Status == "" {
+				user.Status = store.StatusHealthy
+				if user.Infected {
+					user.Status = store.StatusInfected
+				}
+			}
+			if user.Visibility == "" {
+				user.Visibility = store.VisibilityApproximate
+			}
+			if user.Role == "" {
+				user.Role = store.RoleSurvivor
+			}
+			user.Infected = user.Status.Infected()
+			if user.CreatedAt.IsZero() {
+				user.CreatedAt = time.Now()
+			}
 			mockdDB[user.ID] = user
 			return nil
 		},
-		FlagUserFunc: func(ctx context.Context, id, infectedUserID string) error {
-			v, ok := mockdDB[id]
-			if !ok {
+		FlagUserFunc: func(ctx context.Context, id, infectedUserID string, evidence *store.FlagEvidence) error {
+			if id == infectedUserID {
+				return nil
+			}
+			if _, ok := mockdDB[id]; !ok {
 				return gorm.ErrRecordNotFound
 			}
 			infectedUser, ok := mockdDB[infectedUserID]
 			if !ok {
 				return gorm.ErrRecordNotFound
 			}
-			infectedUser.FlagMonitor = append(v.FlagMonitor, store.FlagMonitor{
+			for _, f := range infectedUser.FlagMonitor {
+				if f.UserID == id {
+					return store.ErrDuplicateFlag
+				}
+			}
+			f := store.FlagMonitor{
 				ID:             uuid.NewString(),
 				UserID:         id,
 				InfectedUserID: infectedUserID,
-			})
+			}
+			if evidence != nil {
+				f.FlagEvidence = *evidence
+			}
+			if f.Severity == "" {
+				f.Severity = store.SeverityMedium
+			}
+			f.CreatedAt = time.Now()
+			infectedUser.FlagMonitor = append(infectedUser.FlagMonitor, f)
 
 			mockdDB[infectedUserID] = infectedUser
 			return nil
@@ -74,7 +188,7 @@ func NewMockStore() *MockUserStorage {
 			if !ok {
 				return gorm.ErrRecordNotFound
 			}
-			v.Infected = true
+			v.Status, v.Infected = store.StatusInfected, true
 			mockdDB[id] = v
 			return nil
 		},
@@ -88,6 +202,40 @@ func NewMockStore() *MockUserStorage {
 			mockdDB[id] = v
 			return nil
 		},
+		UpdateVisibilityFunc: func(ctx context.Context, id string, visibility store.Visibility) error {
+			v, ok := mockdDB[id]
+			if !ok {
+				return gorm.ErrRecordNotFound
+			}
+			v.Visibility = visibility
+			return nil
+		},
+		UpdateRoleFunc: func(ctx context.Context, id string, role store.Role) error {
+			v, ok := mockdDB[id]
+			if !ok {
+				return gorm.ErrRecordNotFound
+			}
+			v.Role = role
+			return nil
+		},
+		CreateLocationAuditsFunc: func(ctx context.Context, audits ...*store.LocationAudit) error {
+			for _, v := range audits {
+				v.ID = uuid.NewString()
+				if v.CreatedAt.IsZero() {
+					v.CreatedAt = time.Now()
+				}
+				mockLocationAudits[v.UserID] = append(mockLocationAudits[v.UserID], v)
+			}
+			return nil
+		},
+		FindLocationAuditsFunc: func(ctx context.Context, userID string) ([]*store.LocationAudit, error) {
+			audits := mockLocationAudits[userID]
+			res := make([]*store.LocationAudit, 0, len(audits))
+			for i := len(audits) - 1; i >= 0; i-- {
+				res = append(res, audits[i])
+			}
+			return res, nil
+		},
 		FindUsersFunc: func(ctx context.Context, ids ...string) (map[string]*store.User, error) {
 			result := make(map[string]*store.User)
 
@@ -98,15 +246,536 @@ func NewMockStore() *MockUserStorage {
 			}
 			return result, nil
 		},
+		FindNearbyFunc: func(ctx context.Context, center geo.Point, radiusKm float64, statuses ...store.Status) ([]*store.User, error) {
+			var result []*store.User
+			for _, v := range mockdDB {
+				if len(statuses) > 0 && !hasStatus(v.Status, statuses) {
+					continue
+				}
+				if geo.Within(center, geo.Point{Latitude: v.Latitude, Longitude: v.Longitude}, radiusKm) {
+					result = append(result, v)
+				}
+			}
+			return result, nil
+		},
+		CreateInfectionAuditFunc: func(ctx context.Context, audit *store.InfectionAudit) error {
+			audit.ID = uuid.NewString()
+			audit.CreatedAt = time.Now()
+			mockAudits[audit.UserID] = append(mockAudits[audit.UserID], audit)
+			return nil
+		},
+		FindInfectionAuditsFunc: func(ctx context.Context, userID string) ([]*store.InfectionAudit, error) {
+			return mockAudits[userID], nil
+		},
+		FindFlagsFunc: func(ctx context.Context, infectedUser string) ([]*store.FlagMonitor, error) {
+			var res []*store.FlagMonitor
+			if v, ok := mockdDB[infectedUser]; ok {
+				for i := range v.FlagMonitor {
+					f := v.FlagMonitor[i]
+					res = append(res, &f)
+				}
+			}
+			return res, nil
+		},
+		FindFlagsSinceFunc: func(ctx context.Context, since time.Time) ([]*store.FlagMonitor, error) {
+			var res []*store.FlagMonitor
+			for _, v := range mockdDB {
+				for i := range v.FlagMonitor {
+					if f := v.FlagMonitor[i]; !f.CreatedAt.Before(since) {
+						res = append(res, &f)
+					}
+				}
+			}
+			sort.Slice(res, func(i, j int) bool {
+				return res[i].CreatedAt.Before(res[j].CreatedAt)
+			})
+			return res, nil
+		},
+		UpdateStatusFunc: func(ctx context.Context, id string, status store.Status) error {
+			v, ok := mockdDB[id]
+			if !ok {
+				return gorm.ErrRecordNotFound
+			}
+			v.Status, v.Infected = status, status.Infected()
+			return nil
+		},
+		DeleteFlagFunc: func(ctx context.Context, userID, infectedUser string) error {
+			v, ok := mockdDB[infectedUser]
+			if !ok {
+				return gorm.ErrRecordNotFound
+			}
+			for i, f := range v.FlagMonitor {
+				if f.UserID == userID {
+					v.FlagMonitor = append(v.FlagMonitor[:i:i], v.FlagMonitor[i+1:]...)
+					return nil
+				}
+			}
+			return gorm.ErrRecordNotFound
+		},
+		DeleteFlagsFunc: func(ctx context.Context, infectedUser string) (int64, error) {
+			v, ok := mockdDB[infectedUser]
+			if !ok {
+				return 0, nil
+			}
+			n := len(v.FlagMonitor)
+			v.FlagMonitor = nil
+			return int64(n), nil
+		},
+		CreateAppealFunc: func(ctx context.Context, appeal *store.Appeal) error {
+			appeal.ID = uuid.NewString()
+			if appeal.Status == "" {
+				appeal.Status = store.AppealPending
+			}
+			appeal.CreatedAt = time.Now()
+			mockAppeals[appeal.ID] = appeal
+			return nil
+		},
+		FindAppealFunc: func(ctx context.Context, id string) (*store.Appeal, error) {
+			v, ok := mockAppeals[id]
+			if !ok {
+				return nil, gorm.ErrRecordNotFound
+			}
+			return v, nil
+		},
+		FindUserAppealsFunc: func(ctx context.Context, userID string) ([]*store.Appeal, error) {
+			var res []*store.Appeal
+			for _, v := range mockAppeals {
+				if v.UserID == userID {
+					res = append(res, v)
+				}
+			}
+			sort.Slice(res, func(i, j int) bool {
+				return res[i].CreatedAt.Before(res[j].CreatedAt)
+			})
+			return res, nil
+		},
+		ResolveAppealFunc: func(ctx context.Context, id string, status store.AppealStatus, resolvedBy string) error {
+			v, ok := mockAppeals[id]
+			if !ok {
+				return gorm.ErrRecordNotFound
+			}
+			now := time.Now()
+			v.Status, v.ResolvedBy, v.ResolvedAt = status, resolvedBy, &now
+			return nil
+		},
+		CreateAppealVoteFunc: func(ctx context.Context, vote *store.AppealVote) error {
+			v, ok := mockAppeals[vote.AppealID]
+			if !ok {
+				return gorm.ErrRecordNotFound
+			}
+			for _, existing := range v.Votes {
+				if existing.VoterID == vote.VoterID {
+					return store.ErrDuplicateVote
+				}
+			}
+			vote.ID = uuid.NewString()
+			vote.CreatedAt = time.Now()
+			v.Votes = append(v.Votes, *vote)
+			return nil
+		},
+		CreateStatusTransitionFunc: func(ctx context.Context, transition *store.StatusTransition) error {
+			transition.ID = uuid.NewString()
+			transition.CreatedAt = time.Now()
+			mockTransitions[transition.UserID] = append(mockTransitions[transition.UserID], transition)
+			return nil
+		},
+		FindStatusTransitionsFunc: func(ctx context.Context, userID string) ([]*store.StatusTransition, error) {
+			return mockTransitions[userID], nil
+		},
+		FindPendingTransitionsFunc: func(ctx context.Context, userID string) ([]*store.StatusTransition, error) {
+			var res []*store.StatusTransition
+			for k, v := range mockTransitions {
+				if userID != "" && k != userID {
+					continue
+				}
+				for _, t := range v {
+					if t.AppliedAt == nil {
+						res = append(res, t)
+					}
+				}
+			}
+			sort.SliceStable(res, func(i, j int) bool {
+				return res[i].CreatedAt.Before(res[j].CreatedAt)
+			})
+			return res, nil
+		},
+		MarkTransitionAppliedFunc: func(ctx context.Context, id string) error {
+			for _, v := range mockTransitions {
+				for _, t := range v {
+					if t.ID == id {
+						now := time.Now()
+						t.AppliedAt = &now
+					}
+				}
+			}
+			return nil
+		},
+		FindByStatusFunc: func(ctx context.Context, statuses ...store.Status) ([]*store.User, error) {
+			var res []*store.User
+			for _, v := range mockdDB {
+				if hasStatus(v.Status, statuses) {
+					res = append(res, v)
+				}
+			}
+			sort.Slice(res, func(i, j int) bool {
+				return res[i].CreatedAt.Before(res[j].CreatedAt)
+			})
+			return res, nil
+		},
+		AddRejectedFlagsFunc: func(ctx context.Context, userIDs ...string) error {
+			for _, id := range userIDs {
+				if v, ok := mockdDB[id]; ok {
+					v.RejectedFlags++
+				}
+			}
+			return nil
+		},
+		// FindByReputationFunc scores the survivors without trades, the mocked store doesn't know about them
+		FindByReputationFunc: func(ctx context.Context, q store.ReputationQuery) ([]*store.ScoredUser, error) {
+			accurate := make(map[string]int)
+			for _, target := range mockdDB {
+				if hasStatus(target.Status, store.AccurateStatuses()) {
+					for _, f := range target.FlagMonitor {
+						accurate[f.UserID]++
+					}
+				}
+			}
+			ids := make(map[string]bool, len(q.IDs))
+			for _, v := range q.IDs {
+				ids[v] = true
+			}
+			now := time.Now()
+			res := []*store.ScoredUser{}
+			for _, v := range mockdDB {
+				if len(ids) > 0 && !ids[v.ID] || len(q.Statuses) > 0 && !hasStatus(v.Status, q.Statuses) || v.ID == q.ExcludeID {
+					continue
+				}
+				in := reputation.Inputs{
+					AccurateFlags: accurate[v.ID],
+					RejectedFlags: v.RejectedFlags,
+					FlagsReceived: len(v.FlagMonitor),
+					AccountAge:    now.Sub(v.CreatedAt),
+				}
+				if score := q.Weights.Score(in); score >= q.MinReputation {
+					res = append(res, &store.ScoredUser{User: v, Inputs: in, Reputation: score})
+				}
+			}
+			sort.Slice(res, func(i, j int) bool {
+				if res[i].Reputation != res[j].Reputation {
+					return res[i].Reputation > res[j].Reputation
+				}
+				return res[i].ID < res[j].ID
+			})
+			if q.Limit > 0 && len(res) > q.Limit {
+				res = res[:q.Limit]
+			}
+			return res, nil
+		},
+		CreateLocationPointFunc: func(ctx context.Context, point *store.LocationPoint) error {
+			point.ID = uuid.NewString()
+			if point.CreatedAt.IsZero() {
+				point.CreatedAt = time.Now()
+			}
+			mockLocations[point.UserID] = append(mockLocations[point.UserID], point)
+			sort.SliceStable(mockLocations[point.UserID], func(i, j int) bool {
+				return mockLocations[point.UserID][i].CreatedAt.Before(mockLocations[point.UserID][j].CreatedAt)
+			})
+			return nil
+		},
+		FindLocationHistoryFunc: func(ctx context.Context, userID string, from, to time.Time) ([]*store.LocationPoint, error) {
+			var res []*store.LocationPoint
+			for _, v := range mockLocations[userID] {
+				if (from.IsZero() || !v.CreatedAt.Before(from)) && (to.IsZero() || !v.CreatedAt.After(to)) {
+					res = append(res, v)
+				}
+			}
+			return res, nil
+		},
+		DeleteLocationPointsFunc: func(ctx context.Context, before time.Time) (int64, error) {
+			var deleted int64
+			for id, points := range mockLocations {
+				kept := points[:0]
+				for _, v := range points {
+					if v.CreatedAt.Before(before) {
+						deleted++
+						continue
+					}
+					kept = append(kept, v)
+				}
+				mockLocations[id] = kept
+			}
+			return deleted, nil
+		},
+		DownsampleLocationPointsFunc: func(ctx context.Context, before time.Time, interval time.Duration) (int64, error) {
+			var deleted int64
+			for id, points := range mockLocations {
+				var last time.Time
+				kept := points[:0]
+				for i, v := range points {
+					if v.CreatedAt.Before(before) && i > 0 && v.CreatedAt.Sub(last) < interval {
+						deleted++
+						continue
+					}
+					last = v.CreatedAt
+					kept = append(kept, v)
+				}
+				mockLocations[id] = kept
+			}
+			return deleted, nil
+		},
+		FindLastLocationPointsFunc: func(ctx context.Context, from, to time.Time) (map[string]*store.LocationPoint, error) {
+			res := make(map[string]*store.LocationPoint)
+			for id, points := range mockLocations {
+				for _, v := range points {
+					if (from.IsZero() || !v.CreatedAt.Before(from)) && (to.IsZero() || !v.CreatedAt.After(to)) {
+						res[id] = v
+					}
+				}
+			}
+			return res, nil
+		},
+		FindLocationPointsInBoxFunc: func(ctx context.Context, box geo.Box, from, to time.Time) ([]*store.LocationPoint, error) {
+			var res []*store.LocationPoint
+			for _, points := range mockLocations {
+				for _, v := range points {
+					if v.CreatedAt.Before(from) || v.CreatedAt.After(to) {
+						continue
+					}
+					if box.Contains(geo.Point{Latitude: v.Latitude, Longitude: v.Longitude}) {
+						res = append(res, v)
+					}
+				}
+			}
+			sort.Slice(res, func(i, j int) bool {
+				return res[i].CreatedAt.Before(res[j].CreatedAt)
+			})
+			return res, nil
+		},
+		CreateNotificationFunc: func(ctx context.Context, n *store.Notification) error {
+			for _, v := range mockNotifications[n.UserID] {
+				if v.Kind == n.Kind && v.SourceID == n.SourceID {
+					return nil
+				}
+			}
+			n.ID = uuid.NewString()
+			if n.CreatedAt.IsZero() {
+				n.CreatedAt = time.Now()
+			}
+			mockNotifications[n.UserID] = append(mockNotifications[n.UserID], n)
+			return nil
+		},
+		FindNotificationsFunc: func(ctx context.Context, userID string, unreadOnly bool) ([]*store.Notification, error) {
+			var res []*store.Notification
+			for i := len(mockNotifications[userID]) - 1; i >= 0; i-- {
+				if v := mockNotifications[userID][i]; !unreadOnly || v.ReadAt == nil {
+					res = append(res, v)
+				}
+			}
+			return res, nil
+		},
+		MarkNotificationReadFunc: func(ctx context.Context, userID, id string) error {
+			for _, v := range mockNotifications[userID] {
+				if v.ID == id {
+					if v.ReadAt == nil {
+						now := time.Now()
+						v.ReadAt = &now
+					}
+					return nil
+				}
+			}
+			return gorm.ErrRecordNotFound
+		},
+		FindInBoxFunc: func(ctx context.Context, box geo.Box) ([]*store.User, error) {
+			var res []*store.User
+			for _, v := range mockdDB {
+				if box.Contains(geo.Point{Latitude: v.Latitude, Longitude: v.Longitude}) {
+					res = append(res, v)
+				}
+			}
+			return res, nil
+		},
+		CreateZoneFunc: func(ctx context.Context, zone *store.Zone) error {
+			zone.ID = uuid.NewString()
+			zone.CreatedAt = time.Now()
+			zone.UpdatedAt = zone.CreatedAt
+			mockZones[zone.ID] = zone
+			return nil
+		},
+		FindZoneFunc: func(ctx context.Context, id string) (*store.Zone, error) {
+			zone, ok := mockZones[id]
+			if !ok {
+				return nil, gorm.ErrRecordNotFound
+			}
+			return zone, nil
+		},
+		FindZonesFunc: func(ctx context.Context) ([]*store.Zone, error) {
+			var res []*store.Zone
+			for _, v := range mockZones {
+				res = append(res, v)
+			}
+			sortZones(res)
+			return res, nil
+		},
+		DeleteZoneFunc: func(ctx context.Context, id string) error {
+			if _, ok := mockZones[id]; !ok {
+				return gorm.ErrRecordNotFound
+			}
+			delete(mockZones, id)
+			for _, zones := range mockZoneMembers {
+				delete(zones, id)
+			}
+			return nil
+		},
+		FindZonesAtFunc: func(ctx context.Context, p geo.Point) ([]*store.Zone, error) {
+			var res []*store.Zone
+			for _, v := range mockZones {
+				if v.Contains(p) {
+					res = append(res, v)
+				}
+			}
+			sortZones(res)
+			return res, nil
+		},
+		FindUserZonesFunc: func(ctx context.Context, userIDs ...string) (map[string][]*store.Zone, error) {
+			res := make(map[string][]*store.Zone)
+			for _, id := range userIDs {
+				for zoneID := range mockZoneMembers[id] {
+					res[id] = append(res[id], mockZones[zoneID])
+				}
+				sortZones(res[id])
+			}
+			return res, nil
+		},
+		AddZoneMemberFunc: func(ctx context.Context, member *store.ZoneMember) error {
+			if mockZoneMembers[member.UserID] == nil {
+				mockZoneMembers[member.UserID] = make(map[string]bool)
+			}
+			mockZoneMembers[member.UserID][member.ZoneID] = true
+			return nil
+		},
+		DeleteZoneMemberFunc: func(ctx context.Context, userID, zoneID string) error {
+			delete(mockZoneMembers[userID], zoneID)
+			return nil
+		},
+		CreateZoneEventFunc: func(ctx context.Context, event *store.ZoneEvent) error {
+			event.ID = uuid.NewString()
+			if event.CreatedAt.IsZero() {
+				event.CreatedAt = time.Now()
+			}
+			mockZoneEvents[event.ZoneID] = append(mockZoneEvents[event.ZoneID], event)
+			return nil
+		},
+		FindZoneEventsFunc: func(ctx context.Context, zoneID string, from, to time.Time) ([]*store.ZoneEvent, error) {
+			var res []*store.ZoneEvent
+			for _, v := range mockZoneEvents[zoneID] {
+				if (from.IsZero() || !v.CreatedAt.Before(from)) && (to.IsZero() || !v.CreatedAt.After(to)) {
+					res = append(res, v)
+				}
+			}
+			return res, nil
+		},
+		SaveCollusionReviewFunc: func(ctx context.Context, review *store.CollusionReview) error {
+			for _, v := range mockReviews {
+				if v.UserID != review.UserID {
+					continue
+				}
+				if v.Status == store.ReviewPending {
+					v.Signals, v.Targets, v.Score, v.UpdatedAt = review.Signals, review.Targets, review.Score, time.Now()
+				}
+				*review = *v
+				return nil
+			}
+			review.ID = uuid.NewString()
+			review.Status = store.ReviewPending
+			review.CreatedAt, review.UpdatedAt = time.Now(), time.Now()
+			r := *review
+			mockReviews[review.ID] = &r
+			return nil
+		},
+		FindCollusionReviewFunc: func(ctx context.Context, id string) (*store.CollusionReview, error) {
+			v, ok := mockReviews[id]
+			if !ok {
+				return nil, gorm.ErrRecordNotFound
+			}
+			r := *v
+			return &r, nil
+		},
+		FindCollusionReviewsFunc: func(ctx context.Context, status store.ReviewStatus) ([]*store.CollusionReview, error) {
+			var res []*store.CollusionReview
+			for _, v := range mockReviews {
+				if status == "" || v.Status == status {
+					r := *v
+					res = append(res, &r)
+				}
+			}
+			sort.Slice(res, func(i, j int) bool {
+				return res[i].CreatedAt.Before(res[j].CreatedAt)
+			})
+			return res, nil
+		},
+		FindUserCollusionReviewsFunc: func(ctx context.Context, userIDs ...string) (map[string]*store.CollusionReview, error) {
+			ids := make(map[string]bool, len(userIDs))
+			for _, v := range userIDs {
+				ids[v] = true
+			}
+			res := make(map[string]*store.CollusionReview)
+			for _, v := range mockReviews {
+				if ids[v.UserID] {
+					r := *v
+					res[v.UserID] = &r
+				}
+			}
+			return res, nil
+		},
+		ResolveCollusionReviewFunc: func(ctx context.Context, id string, status store.ReviewStatus, reviewedBy string) error {
+			v, ok := mockReviews[id]
+			if !ok {
+				return gorm.ErrRecordNotFound
+			}
+			now := time.Now()
+			v.Status, v.ReviewedBy, v.ReviewedAt = status, reviewedBy, &now
+			return nil
+		},
+	}
+	// changes made before a failure aren't rolled back
+	m.WithTxFunc = func(ctx context.Context, fn func(tx store.IUserStorage) error) error {
+		mockTxLock.Lock()
+		defer mockTxLock.Unlock()
+		return fn(m)
+	}
+	return m
+}
+
+// WithTx implements IUserStorage
+func (m *MockUserStorage) WithTx(ctx context.Context, fn func(tx store.IUserStorage) error) error {
+	if m.WithTxFunc == nil {
+		return errMockNotDefined
 	}
+	return m.WithTxFunc(ctx, fn)
+}
+
+// LockUser implements IUserStorage
+func (m *MockUserStorage) LockUser(ctx context.Context, id string) error {
+	if m.LockUserFunc == nil {
+		return errMockNotDefined
+	}
+	return m.LockUserFunc(ctx, id)
 }
 
 // FlagUser implements IUserStorage
-func (m *MockUserStorage) FlagUser(ctx context.Context, id string, infectedUser string) error {
+func (m *MockUserStorage) FlagUser(ctx context.Context, id string, infectedUser string, evidence *store.FlagEvidence) error {
 	if m.FlagUserFunc == nil {
 		return errMockNotDefined
 	}
-	return m.FlagUserFunc(ctx, id, infectedUser)
+	return m.FlagUserFunc(ctx, id, infectedUser, evidence)
+}
+
+// FindFlags implements IUserStorage
+func (m *MockUserStorage) FindFlags(ctx context.Context, infectedUser string) ([]*store.FlagMonitor, error) {
+	if m.FindFlagsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindFlagsFunc(ctx, infectedUser)
 }
 
 // UpdateInfectedStatus implements IUserStorage
@@ -141,6 +810,38 @@ func (m *MockUserStorage) FindByEmail(ctx context.Context, email string) (*store
 	return m.FindByEmailFunc(ctx, email)
 }
 
+// UpdateVisibility implements IUserStorage
+func (m *MockUserStorage) UpdateVisibility(ctx context.Context, id string, visibility store.Visibility) error {
+	if m.UpdateVisibilityFunc == nil {
+		return errMockNotDefined
+	}
+	return m.UpdateVisibilityFunc(ctx, id, visibility)
+}
+
+// UpdateRole implements IUserStorage
+func (m *MockUserStorage) UpdateRole(ctx context.Context, id string, role store.Role) error {
+	if m.UpdateRoleFunc == nil {
+		return errMockNotDefined
+	}
+	return m.UpdateRoleFunc(ctx, id, role)
+}
+
+// CreateLocationAudits implements IUserStorage
+func (m *MockUserStorage) CreateLocationAudits(ctx context.Context, audits ...*store.LocationAudit) error {
+	if m.CreateLocationAuditsFunc == nil {
+		return errMockNotDefined
+	}
+	return m.CreateLocationAuditsFunc(ctx, audits...)
+}
+
+// FindLocationAudits implements IUserStorage
+func (m *MockUserStorage) FindLocationAudits(ctx context.Context, userID string) ([]*store.LocationAudit, error) {
+	if m.FindLocationAuditsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindLocationAuditsFunc(ctx, userID)
+}
+
 // UpdateLocation implements IUserStorage
 func (m *MockUserStorage) UpdateLocation(ctx context.Context, id string, lat float64, long float64) error {
 	if m.UpdateLocationFunc == nil {
@@ -149,6 +850,94 @@ func (m *MockUserStorage) UpdateLocation(ctx context.Context, id string, lat flo
 	return m.UpdateLocationFunc(ctx, id, lat, long)
 }
 
+// FindNearby implements IUserStorage
+func (m *MockUserStorage) FindNearby(ctx context.Context, center geo.Point, radiusKm float64, statuses ...store.Status) ([]*store.User, error) {
+	if m.FindNearbyFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindNearbyFunc(ctx, center, radiusKm, statuses...)
+}
+
+// CreateInfectionAudit implements IUserStorage
+func (m *MockUserStorage) CreateInfectionAudit(ctx context.Context, audit *store.InfectionAudit) error {
+	if m.CreateInfectionAuditFunc == nil {
+		return errMockNotDefined
+	}
+	return m.CreateInfectionAuditFunc(ctx, audit)
+}
+
+// FindInfectionAudits implements IUserStorage
+func (m *MockUserStorage) FindInfectionAudits(ctx context.Context, userID string) ([]*store.InfectionAudit, error) {
+	if m.FindInfectionAuditsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindInfectionAuditsFunc(ctx, userID)
+}
+
+// UpdateStatus implements IUserStorage
+func (m *MockUserStorage) UpdateStatus(ctx context.Context, id string, status store.Status) error {
+	if m.UpdateStatusFunc == nil {
+		return errMockNotDefined
+	}
+	return m.UpdateStatusFunc(ctx, id, status)
+}
+
+// DeleteFlag implements IUserStorage
+func (m *MockUserStorage) DeleteFlag(ctx context.Context, userID, infectedUser string) error {
+	if m.DeleteFlagFunc == nil {
+		return errMockNotDefined
+	}
+	return m.DeleteFlagFunc(ctx, userID, infectedUser)
+}
+
+// DeleteFlags implements IUserStorage
+func (m *MockUserStorage) DeleteFlags(ctx context.Context, infectedUser string) (int64, error) {
+	if m.DeleteFlagsFunc == nil {
+		return 0, errMockNotDefined
+	}
+	return m.DeleteFlagsFunc(ctx, infectedUser)
+}
+
+// CreateAppeal implements IUserStorage
+func (m *MockUserStorage) CreateAppeal(ctx context.Context, appeal *store.Appeal) error {
+	if m.CreateAppealFunc == nil {
+		return errMockNotDefined
+	}
+	return m.CreateAppealFunc(ctx, appeal)
+}
+
+// FindAppeal implements IUserStorage
+func (m *MockUserStorage) FindAppeal(ctx context.Context, id string) (*store.Appeal, error) {
+	if m.FindAppealFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindAppealFunc(ctx, id)
+}
+
+// FindUserAppeals implements IUserStorage
+func (m *MockUserStorage) FindUserAppeals(ctx context.Context, userID string) ([]*store.Appeal, error) {
+	if m.FindUserAppealsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindUserAppealsFunc(ctx, userID)
+}
+
+// ResolveAppeal implements IUserStorage
+func (m *MockUserStorage) ResolveAppeal(ctx context.Context, id string, status store.AppealStatus, resolvedBy string) error {
+	if m.ResolveAppealFunc == nil {
+		return errMockNotDefined
+	}
+	return m.ResolveAppealFunc(ctx, id, status, resolvedBy)
+}
+
+// CreateAppealVote implements IUserStorage
+func (m *MockUserStorage) CreateAppealVote(ctx context.Context, vote *store.AppealVote) error {
+	if m.CreateAppealVoteFunc == nil {
+		return errMockNotDefined
+	}
+	return m.CreateAppealVoteFunc(ctx, vote)
+}
+
 // Create mocked the create function
 func (m *MockUserStorage) Create(ctx context.Context, user *store.User) error {
 	if m.CreateFunc == nil {
@@ -156,3 +945,286 @@ func (m *MockUserStorage) Create(ctx context.Context, user *store.User) error {
 	}
 	return m.CreateFunc(ctx, user)
 }
+
+// CreateStatusTransition implements IUserStorage
+func (m *MockUserStorage) CreateStatusTransition(ctx context.Context, transition *store.StatusTransition) error {
+	if m.CreateStatusTransitionFunc == nil {
+		return errMockNotDefined
+	}
+	return m.CreateStatusTransitionFunc(ctx, transition)
+}
+
+// FindStatusTransitions implements IUserStorage
+func (m *MockUserStorage) FindStatusTransitions(ctx context.Context, userID string) ([]*store.StatusTransition, error) {
+	if m.FindStatusTransitionsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindStatusTransitionsFunc(ctx, userID)
+}
+
+// FindPendingTransitions implements IUserStorage
+func (m *MockUserStorage) FindPendingTransitions(ctx context.Context, userID string) ([]*store.StatusTransition, error) {
+	if m.FindPendingTransitionsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindPendingTransitionsFunc(ctx, userID)
+}
+
+// MarkTransitionApplied implements IUserStorage
+func (m *MockUserStorage) MarkTransitionApplied(ctx context.Context, id string) error {
+	if m.MarkTransitionAppliedFunc == nil {
+		return errMockNotDefined
+	}
+	return m.MarkTransitionAppliedFunc(ctx, id)
+}
+
+// FindFlagsSince implements IUserStorage
+func (m *MockUserStorage) FindFlagsSince(ctx context.Context, since time.Time) ([]*store.FlagMonitor, error) {
+	if m.FindFlagsSinceFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindFlagsSinceFunc(ctx, since)
+}
+
+// SaveCollusionReview implements IUserStorage
+func (m *MockUserStorage) SaveCollusionReview(ctx context.Context, review *store.CollusionReview) error {
+	if m.SaveCollusionReviewFunc == nil {
+		return errMockNotDefined
+	}
+	return m.SaveCollusionReviewFunc(ctx, review)
+}
+
+// FindCollusionReview implements IUserStorage
+func (m *MockUserStorage) FindCollusionReview(ctx context.Context, id string) (*store.CollusionReview, error) {
+	if m.FindCollusionReviewFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindCollusionReviewFunc(ctx, id)
+}
+
+// FindCollusionReviews implements IUserStorage
+func (m *MockUserStorage) FindCollusionReviews(ctx context.Context, status store.ReviewStatus) ([]*store.CollusionReview, error) {
+	if m.FindCollusionReviewsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindCollusionReviewsFunc(ctx, status)
+}
+
+// FindUserCollusionReviews implements IUserStorage
+func (m *MockUserStorage) FindUserCollusionReviews(ctx context.Context, userIDs ...string) (map[string]*store.CollusionReview, error) {
+	if m.FindUserCollusionReviewsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindUserCollusionReviewsFunc(ctx, userIDs...)
+}
+
+// ResolveCollusionReview implements IUserStorage
+func (m *MockUserStorage) ResolveCollusionReview(ctx context.Context, id string, status store.ReviewStatus, reviewedBy string) error {
+	if m.ResolveCollusionReviewFunc == nil {
+		return errMockNotDefined
+	}
+	return m.ResolveCollusionReviewFunc(ctx, id, status, reviewedBy)
+}
+
+// FindByStatus implements IUserStorage
+func (m *MockUserStorage) FindByStatus(ctx context.Context, statuses ...store.Status) ([]*store.User, error) {
+	if m.FindByStatusFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindByStatusFunc(ctx, statuses...)
+}
+
+// AddRejectedFlags implements IUserStorage
+func (m *MockUserStorage) AddRejectedFlags(ctx context.Context, userIDs ...string) error {
+	if m.AddRejectedFlagsFunc == nil {
+		return errMockNotDefined
+	}
+	return m.AddRejectedFlagsFunc(ctx, userIDs...)
+}
+
+// FindByReputation implements IUserStorage
+func (m *MockUserStorage) FindByReputation(ctx context.Context, q store.ReputationQuery) ([]*store.ScoredUser, error) {
+	if m.FindByReputationFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindByReputationFunc(ctx, q)
+}
+
+func hasStatus(s store.Status, statuses []store.Status) bool {
+	for _, v := range statuses {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateLocationPoint implements IUserStorage
+func (m *MockUserStorage) CreateLocationPoint(ctx context.Context, point *store.LocationPoint) error {
+	if m.CreateLocationPointFunc == nil {
+		return errMockNotDefined
+	}
+	return m.CreateLocationPointFunc(ctx, point)
+}
+
+// FindLocationHistory implements IUserStorage
+func (m *MockUserStorage) FindLocationHistory(ctx context.Context, userID string, from, to time.Time) ([]*store.LocationPoint, error) {
+	if m.FindLocationHistoryFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindLocationHistoryFunc(ctx, userID, from, to)
+}
+
+// DeleteLocationPoints implements IUserStorage
+func (m *MockUserStorage) DeleteLocationPoints(ctx context.Context, before time.Time) (int64, error) {
+	if m.DeleteLocationPointsFunc == nil {
+		return 0, errMockNotDefined
+	}
+	return m.DeleteLocationPointsFunc(ctx, before)
+}
+
+// DownsampleLocationPoints implements IUserStorage
+func (m *MockUserStorage) DownsampleLocationPoints(ctx context.Context, before time.Time, interval time.Duration) (int64, error) {
+	if m.DownsampleLocationPointsFunc == nil {
+		return 0, errMockNotDefined
+	}
+	return m.DownsampleLocationPointsFunc(ctx, before, interval)
+}
+
+// FindLastLocationPoints implements IUserStorage
+func (m *MockUserStorage) FindLastLocationPoints(ctx context.Context, from, to time.Time) (map[string]*store.LocationPoint, error) {
+	if m.FindLastLocationPointsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindLastLocationPointsFunc(ctx, from, to)
+}
+
+// FindLocationPointsInBox implements IUserStorage
+func (m *MockUserStorage) FindLocationPointsInBox(ctx context.Context, box geo.Box, from, to time.Time) ([]*store.LocationPoint, error) {
+	if m.FindLocationPointsInBoxFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindLocationPointsInBoxFunc(ctx, box, from, to)
+}
+
+// CreateNotification implements IUserStorage
+func (m *MockUserStorage) CreateNotification(ctx context.Context, n *store.Notification) error {
+	if m.CreateNotificationFunc == nil {
+		return errMockNotDefined
+	}
+	return m.CreateNotificationFunc(ctx, n)
+}
+
+// FindNotifications implements IUserStorage
+func (m *MockUserStorage) FindNotifications(ctx context.Context, userID string, unreadOnly bool) ([]*store.Notification, error) {
+	if m.FindNotificationsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindNotificationsFunc(ctx, userID, unreadOnly)
+}
+
+// MarkNotificationRead implements IUserStorage
+func (m *MockUserStorage) MarkNotificationRead(ctx context.Context, userID, id string) error {
+	if m.MarkNotificationReadFunc == nil {
+		return errMockNotDefined
+	}
+	return m.MarkNotificationReadFunc(ctx, userID, id)
+}
+
+// FindInBox implements IUserStorage
+func (m *MockUserStorage) FindInBox(ctx context.Context, box geo.Box) ([]*store.User, error) {
+	if m.FindInBoxFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindInBoxFunc(ctx, box)
+}
+
+// CreateZone implements IUserStorage
+func (m *MockUserStorage) CreateZone(ctx context.Context, zone *store.Zone) error {
+	if m.CreateZoneFunc == nil {
+		return errMockNotDefined
+	}
+	return m.CreateZoneFunc(ctx, zone)
+}
+
+// FindZone implements IUserStorage
+func (m *MockUserStorage) FindZone(ctx context.Context, id string) (*store.Zone, error) {
+	if m.FindZoneFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindZoneFunc(ctx, id)
+}
+
+// FindZones implements IUserStorage
+func (m *MockUserStorage) FindZones(ctx context.Context) ([]*store.Zone, error) {
+	if m.FindZonesFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindZonesFunc(ctx)
+}
+
+// DeleteZone implements IUserStorage
+func (m *MockUserStorage) DeleteZone(ctx context.Context, id string) error {
+	if m.DeleteZoneFunc == nil {
+		return errMockNotDefined
+	}
+	return m.DeleteZoneFunc(ctx, id)
+}
+
+// FindZonesAt implements IUserStorage
+func (m *MockUserStorage) FindZonesAt(ctx context.Context, p geo.Point) ([]*store.Zone, error) {
+	if m.FindZonesAtFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindZonesAtFunc(ctx, p)
+}
+
+// FindUserZones implements IUserStorage
+func (m *MockUserStorage) FindUserZones(ctx context.Context, userIDs ...string) (map[string][]*store.Zone, error) {
+	if m.FindUserZonesFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindUserZonesFunc(ctx, userIDs...)
+}
+
+// AddZoneMember implements IUserStorage
+func (m *MockUserStorage) AddZoneMember(ctx context.Context, member *store.ZoneMember) error {
+	if m.AddZoneMemberFunc == nil {
+		return errMockNotDefined
+	}
+	return m.AddZoneMemberFunc(ctx, member)
+}
+
+// DeleteZoneMember implements IUserStorage
+func (m *MockUserStorage) DeleteZoneMember(ctx context.Context, userID, zoneID string) error {
+	if m.DeleteZoneMemberFunc == nil {
+		return errMockNotDefined
+	}
+	return m.DeleteZoneMemberFunc(ctx, userID, zoneID)
+}
+
+// CreateZoneEvent implements IUserStorage
+func (m *MockUserStorage) CreateZoneEvent(ctx context.Context, event *store.ZoneEvent) error {
+	if m.CreateZoneEventFunc == nil {
+		return errMockNotDefined
+	}
+	return m.CreateZoneEventFunc(ctx, event)
+}
+
+// FindZoneEvents implements IUserStorage
+func (m *MockUserStorage) FindZoneEvents(ctx context.Context, zoneID string, from, to time.Time) ([]*store.ZoneEvent, error) {
+	if m.FindZoneEventsFunc == nil {
+		return nil, errMockNotDefined
+	}
+	return m.FindZoneEventsFunc(ctx, zoneID, from, to)
+}
+
+// sortZones orders the zones by name like the database does
+func sortZones(zones []*store.Zone) {
+	sort.Slice(zones, func(i, j int) bool {
+		if zones[i].Name != zones[j].Name {
+			return zones[i].Name < zones[j].Name
+		}
+		return zones[i].ID < zones[j].ID
+	})
+}
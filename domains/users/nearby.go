@@ -0,0 +1,91 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+	"zssn/domains/users/store"
+)
+
+const (
+	// DefaultNearbyRadiusKm radius searched when none is given
+	DefaultNearbyRadiusKm = 5
+	// MaxNearbyRadiusKm largest radius that can be searched
+	MaxNearbyRadiusKm = 50
+	// NearbyDistanceStepKm distances to nearby survivors are rounded up to a multiple of it
+	NearbyDistanceStepKm = 1
+)
+
+var (
+	// ErrInvalidRadius is returned when searching for nearby survivors with a radius out of range
+	ErrInvalidRadius = fmt.Errorf("radius must be between 0 and %d km", MaxNearbyRadiusKm)
+	// ErrNotCleanSearcher is returned when a survivor who isn't clean searches for nearby survivors
+	ErrNotCleanSearcher = errors.New("only clean survivors can search for nearby survivors")
+)
+
+// Nearby returns the clean survivors within radiusKm of the requester's last known location, closest first.
+// The default radius is used when radiusKm is zero. Survivors who hide their location are left out unless the requester is an admin.
+func (u *UserService) Nearby(ctx context.Context, id string, radiusKm float64) ([]*entities.NearbySurvivor, error) {
+	if radiusKm == 0 {
+		radiusKm = DefaultNearbyRadiusKm
+	}
+	if radiusKm < 0 || radiusKm > MaxNearbyRadiusKm {
+		return nil, ErrInvalidRadius
+	}
+	usr, err := u.Storage.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !usr.Status.Clean() {
+		return nil, ErrNotCleanSearcher
+	}
+	center := geo.Point{Latitude: usr.Latitude, Longitude: usr.Longitude}
+	nearby, err := u.Storage.FindNearby(ctx, center, radiusKm, store.CleanStatuses()...)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer, err := u.locationViewer(ctx, id, AuditResourceNearby)
+	if err != nil {
+		return nil, err
+	}
+	// the distance is worked out from the location the requester is allowed to see, hidden survivors aren't found
+	distances := make(map[string]float64, len(nearby))
+	candidates := make([]*store.User, 0, len(nearby))
+	for _, v := range nearby {
+		if v.ID == id {
+			continue
+		}
+		p, ok := viewer.Location(v.ID, v.Visibility, geo.Point{Latitude: v.Latitude, Longitude: v.Longitude})
+		if !ok {
+			continue
+		}
+		distances[v.ID] = geo.Distance(center, p)
+		candidates = append(candidates, v)
+	}
+	if err := viewer.Save(ctx, u.Storage); err != nil {
+		return nil, err
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if distances[a.ID] != distances[b.ID] {
+			return distances[a.ID] < distances[b.ID]
+		}
+		return a.ID < b.ID
+	})
+
+	res := make([]*entities.NearbySurvivor, 0, len(candidates))
+	for _, v := range candidates {
+		res = append(res, &entities.NearbySurvivor{
+			ID:         v.ID,
+			Name:       v.Name,
+			Status:     string(v.Status),
+			DistanceKm: geo.Coarse(distances[v.ID], NearbyDistanceStepKm),
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,59 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"zssn/database"
+	"zssn/domains/entities"
+	"zssn/domains/users"
+	"zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNearby(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+	svc, err := users.New(storage)
+	require.NoError(t, err)
+
+	createAt := func(lat, long float64) *store.User {
+		u := storetest.NewUser(t)
+		u.Latitude, u.Longitude = 6.5+lat, 3.3+long
+		require.NoError(t, storage.Create(ctx, u))
+		// exact locations keep the distances as they are, visibility is covered in the privacy tests
+		require.NoError(t, storage.UpdateVisibility(ctx, u.ID, store.VisibilityExact))
+		return u
+	}
+	requester := createAt(0, 0)
+	far, mid, near := createAt(0.2, 0), createAt(0.02, 0), createAt(0.004, 0)
+	infected := createAt(0, 0.004)
+	require.NoError(t, storage.UpdateStatus(ctx, infected.ID, store.StatusInfected))
+
+	res, err := svc.Nearby(ctx, requester.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []*entities.NearbySurvivor{
+		{ID: near.ID, Name: near.Name, Status: string(store.StatusHealthy), DistanceKm: 1},
+		{ID: mid.ID, Name: mid.Name, Status: string(store.StatusHealthy), DistanceKm: 3},
+	}, res)
+
+	res, err = svc.Nearby(ctx, requester.ID, 30)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	assert.Equal(t, far.ID, res[2].ID)
+	assert.Equal(t, float64(23), res[2].DistanceKm)
+
+	for _, radius := range []float64{-1, users.MaxNearbyRadiusKm + 1} {
+		_, err = svc.Nearby(ctx, requester.ID, radius)
+		assert.True(t, errors.Is(err, users.ErrInvalidRadius), radius)
+	}
+	_, err = svc.Nearby(ctx, infected.ID, 0)
+	assert.True(t, errors.Is(err, users.ErrNotCleanSearcher))
+}
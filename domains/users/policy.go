@@ -0,0 +1,250 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"zssn/domains/geo"
+	"zssn/domains/reputation"
+	"zssn/domains/users/store"
+)
+
+const (
+	// PolicyThreshold infects a survivor after a fixed number of flags
+	PolicyThreshold = "threshold"
+	// PolicyNearby infects a survivor once a percentage of the survivors around them flagged them
+	PolicyNearby = "nearby"
+	// PolicyReputation infects a survivor once the flags, weighted by the reputation of the flaggers, reach a threshold
+	PolicyReputation = "reputation"
+)
+
+// InfectionPolicy decides if a flagged survivor should be considered infected
+type InfectionPolicy interface {
+	Name() string
+	Evaluate(ctx context.Context, storage store.IUserStorage, target *store.User) (*InfectionDecision, error)
+}
+
+// InfectionDecision the outcome of an infection policy and the inputs it was based on
+type InfectionDecision struct {
+	Infected bool
+	Score    float64
+	Required float64
+	Inputs   map[string]interface{}
+}
+
+// PolicyConfig contains the settings of every infection policy, only the ones of the selected policy are used
+type PolicyConfig struct {
+	Name             string
+	Threshold        int
+	NearbyRadiusKm   float64
+	NearbyPercentage float64
+	NearbyMinFlags   int
+	MinReputation    float64
+}
+
+// NewInfectionPolicy returns the policy selected in the config
+func NewInfectionPolicy(cfg PolicyConfig) (InfectionPolicy, error) {
+	switch cfg.Name {
+	case "", PolicyThreshold:
+		return NewThresholdPolicy(cfg.Threshold), nil
+	case PolicyNearby:
+		return NewNearbyPolicy(cfg.NearbyRadiusKm, cfg.NearbyPercentage, cfg.NearbyMinFlags), nil
+	case PolicyReputation:
+		return NewReputationPolicy(float64(cfg.Threshold), cfg.MinReputation, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown infection policy %q", cfg.Name)
+	}
+}
+
+// ThresholdPolicy infects a survivor after a fixed number of flags
+type ThresholdPolicy struct {
+	Threshold int
+}
+
+// NewThresholdPolicy returns a threshold policy, the default threshold is used for non-positive values
+func NewThresholdPolicy(threshold int) *ThresholdPolicy {
+	if threshold < 1 {
+		threshold = DefaultInfectionThreshold
+	}
+	return &ThresholdPolicy{Threshold: threshold}
+}
+
+// Name implements InfectionPolicy
+func (p *ThresholdPolicy) Name() string {
+	return PolicyThreshold
+}
+
+// Evaluate implements InfectionPolicy
+func (p *ThresholdPolicy) Evaluate(ctx context.Context, storage store.IUserStorage, target *store.User) (*InfectionDecision, error) {
+	flags := len(target.FlagMonitor)
+	return &InfectionDecision{
+		Infected: flags >= p.Threshold,
+		Score:    float64(flags),
+		Required: float64(p.Threshold),
+		Inputs: map[string]interface{}{
+			"flags":     flags,
+			"threshold": p.Threshold,
+		},
+	}, nil
+}
+
+// NearbyPolicy infects a survivor once a percentage of the clean survivors within the radius flagged them.
+// MinFlags keeps a single flag from infecting someone who is on their own.
+type NearbyPolicy struct {
+	RadiusKm   float64
+	Percentage float64
+	MinFlags   int
+}
+
+// NewNearbyPolicy returns a nearby policy, defaults are used for non-positive values
+func NewNearbyPolicy(radiusKm, percentage float64, minFlags int) *NearbyPolicy {
+	if radiusKm <= 0 {
+		radiusKm = 5
+	}
+	if percentage <= 0 || percentage > 100 {
+		percentage = 50
+	}
+	if minFlags < 1 {
+		minFlags = 2
+	}
+	return &NearbyPolicy{RadiusKm: radiusKm, Percentage: percentage, MinFlags: minFlags}
+}
+
+// Name implements InfectionPolicy
+func (p *NearbyPolicy) Name() string {
+	return PolicyNearby
+}
+
+// Evaluate implements InfectionPolicy
+func (p *NearbyPolicy) Evaluate(ctx context.Context, storage store.IUserStorage, target *store.User) (*InfectionDecision, error) {
+	nearby, err := storage.FindNearby(ctx, geo.Point{Latitude: target.Latitude, Longitude: target.Longitude}, p.RadiusKm)
+	if err != nil {
+		return nil, err
+	}
+	neighbours := 0
+	for _, v := range nearby {
+		if v.ID != target.ID && !v.Infected {
+			neighbours++
+		}
+	}
+	flags := len(target.FlagMonitor)
+	required := math.Max(float64(p.MinFlags), math.Ceil(float64(neighbours)*p.Percentage/100))
+	return &InfectionDecision{
+		Infected: float64(flags) >= required,
+		Score:    float64(flags),
+		Required: required,
+		Inputs: map[string]interface{}{
+			"flags":      flags,
+			"neighbours": neighbours,
+			"radius_km":  p.RadiusKm,
+			"percentage": p.Percentage,
+			"min_flags":  p.MinFlags,
+		},
+	}, nil
+}
+
+// ReputationFunc returns how much the flags of each of the given flaggers are worth, between 0 and 1
+type ReputationFunc func(ctx context.Context, storage store.IUserStorage, flaggers ...*store.User) (map[string]float64, error)
+
+// DefaultReputation weights the flags by the reputation the flaggers have on their profiles with the default weights
+func DefaultReputation(ctx context.Context, storage store.IUserStorage, flaggers ...*store.User) (map[string]float64, error) {
+	return ProfileReputation(reputation.DefaultWeights())(ctx, storage, flaggers...)
+}
+
+// ProfileReputation weights the flags by the reputation the weights give the flaggers on their profiles.
+// Flaggers with at least the reputation every survivor starts with count fully, the ones below it count for less
+// and infected survivors aren't trusted at all.
+func ProfileReputation(w reputation.Weights) ReputationFunc {
+	return func(ctx context.Context, storage store.IUserStorage, flaggers ...*store.User) (map[string]float64, error) {
+		res := make(map[string]float64, len(flaggers))
+		ids := make([]string, 0, len(flaggers))
+		for _, v := range flaggers {
+			res[v.ID] = 0
+			if !v.Infected {
+				ids = append(ids, v.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return res, nil
+		}
+		scored, err := storage.FindByReputation(ctx, store.ReputationQuery{Weights: w, IDs: ids})
+		if err != nil {
+			return nil, err
+		}
+		start := w.Base
+		if start <= 0 {
+			start = reputation.MaxScore
+		}
+		for _, v := range scored {
+			res[v.ID] = math.Min(1, v.Reputation/start)
+		}
+		return res, nil
+	}
+}
+
+// ReputationPolicy infects a survivor once the sum of the reputation of their flaggers reaches the threshold.
+// Flaggers below the minimum reputation are ignored.
+type ReputationPolicy struct {
+	Threshold     float64
+	MinReputation float64
+	Reputation    ReputationFunc
+}
+
+// NewReputationPolicy returns a reputation weighted policy.
+// Without fn the flaggers are weighted by their profile reputation, scored with the weights of the user service.
+func NewReputationPolicy(threshold, minReputation float64, fn ReputationFunc) *ReputationPolicy {
+	if threshold <= 0 {
+		threshold = DefaultInfectionThreshold
+	}
+	return &ReputationPolicy{Threshold: threshold, MinReputation: minReputation, Reputation: fn}
+}
+
+// Name implements InfectionPolicy
+func (p *ReputationPolicy) Name() string {
+	return PolicyReputation
+}
+
+// Evaluate implements InfectionPolicy
+func (p *ReputationPolicy) Evaluate(ctx context.Context, storage store.IUserStorage, target *store.User) (*InfectionDecision, error) {
+	ids := make([]string, 0, len(target.FlagMonitor))
+	for _, v := range target.FlagMonitor {
+		ids = append(ids, v.UserID)
+	}
+	flaggers, err := storage.FindUsers(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	fn := p.Reputation
+	if fn == nil {
+		fn = DefaultReputation
+	}
+	found := make([]*store.User, 0, len(flaggers))
+	for _, id := range ids {
+		if flagger, ok := flaggers[id]; ok {
+			found = append(found, flagger)
+		}
+	}
+	weights, err := fn(ctx, storage, found...)
+	if err != nil {
+		return nil, err
+	}
+
+	var score float64
+	for _, w := range weights {
+		if w >= p.MinReputation {
+			score += w
+		}
+	}
+	return &InfectionDecision{
+		Infected: score >= p.Threshold,
+		Score:    score,
+		Required: p.Threshold,
+		Inputs: map[string]interface{}{
+			"flags":          len(ids),
+			"weights":        weights,
+			"min_reputation": p.MinReputation,
+		},
+	}, nil
+}
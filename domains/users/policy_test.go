@@ -0,0 +1,208 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+	"zssn/domains/users/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInfectionPolicy(t *testing.T) {
+	table := []struct {
+		name     string
+		cfg      PolicyConfig
+		expected InfectionPolicy
+	}{
+		{"Default", PolicyConfig{}, &ThresholdPolicy{Threshold: 3}},
+		{"Threshold", PolicyConfig{Name: PolicyThreshold, Threshold: 5}, &ThresholdPolicy{Threshold: 5}},
+		{"Nearby", PolicyConfig{Name: PolicyNearby, NearbyRadiusKm: 2, NearbyPercentage: 30, NearbyMinFlags: 3}, &NearbyPolicy{RadiusKm: 2, Percentage: 30, MinFlags: 3}},
+	}
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewInfectionPolicy(tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p)
+		})
+	}
+
+	p, err := NewInfectionPolicy(PolicyConfig{Name: PolicyReputation, Threshold: 2, MinReputation: 0.5})
+	require.NoError(t, err)
+	assert.Equal(t, PolicyReputation, p.Name())
+
+	_, err = NewInfectionPolicy(PolicyConfig{Name: "coin-toss"})
+	require.EqualError(t, err, `unknown infection policy "coin-toss"`)
+}
+
+func TestFlagUserRecordsAudit(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage)
+	require.NoError(t, err)
+
+	infectedUser := newUser(t)
+	require.NoError(t, svc.Create(ctx, infectedUser))
+
+	var flaggers []string
+	for i := 0; i < 3; i++ {
+		flagger := newUser(t)
+		require.NoError(t, svc.Create(ctx, flagger))
+		_, err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
+		require.NoError(t, err)
+		flaggers = append(flaggers, flagger.ID)
+	}
+
+	audits, err := storage.FindInfectionAudits(ctx, infectedUser.ID)
+	require.NoError(t, err)
+	require.Len(t, audits, 3)
+	for i, v := range audits {
+		assert.Equal(t, flaggers[i], v.ActorID)
+		assert.Equal(t, AuditActionFlag, v.Action)
+		assert.Equal(t, PolicyThreshold, v.Policy)
+		assert.Equal(t, float64(i+1), v.Score)
+		assert.Equal(t, 3.0, v.Required)
+		assert.Equal(t, i == 2, v.Infected)
+
+		var inputs map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(v.Inputs), &inputs))
+		assert.Equal(t, float64(i+1), inputs["flags"])
+	}
+
+	// infected survivors aren't evaluated again
+	flagger := newUser(t)
+	require.NoError(t, svc.Create(ctx, flagger))
+	_, err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
+	require.NoError(t, err)
+	audits, err = storage.FindInfectionAudits(ctx, infectedUser.ID)
+	require.NoError(t, err)
+	assert.Len(t, audits, 3)
+}
+
+func TestNearbyPolicy(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage, WithInfectionPolicy(NewNearbyPolicy(1, 50, 2)))
+	require.NoError(t, err)
+
+	// somewhere nobody else in the mock store lives
+	target := newUser(t)
+	target.Latitude, target.Longitude = -45.0001, 120.0001
+	require.NoError(t, svc.Create(ctx, target))
+
+	var neighbours []*entities.User
+	for i := 0; i < 6; i++ {
+		u := newUser(t)
+		u.Latitude, u.Longitude = target.Latitude+float64(i)*0.001, target.Longitude
+		require.NoError(t, svc.Create(ctx, u))
+		neighbours = append(neighbours, u)
+	}
+	// far away survivors don't count
+	stranger := newUser(t)
+	stranger.Latitude, stranger.Longitude = target.Latitude+1, target.Longitude
+	require.NoError(t, svc.Create(ctx, stranger))
+
+	_, err = svc.FlagUser(ctx, stranger.ID, target.ID, nil)
+	require.NoError(t, err)
+	_, err = svc.FlagUser(ctx, neighbours[0].ID, target.ID, nil)
+	require.NoError(t, err)
+	ok, err := svc.IsInfected(ctx, target.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// half of the six neighbours
+	_, err = svc.FlagUser(ctx, neighbours[1].ID, target.ID, nil)
+	require.NoError(t, err)
+	ok, err = svc.IsInfected(ctx, target.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	audits, err := storage.FindInfectionAudits(ctx, target.ID)
+	require.NoError(t, err)
+	require.Len(t, audits, 3)
+	last := audits[2]
+	assert.Equal(t, PolicyNearby, last.Policy)
+	assert.Equal(t, 3.0, last.Required)
+
+	var inputs map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(last.Inputs), &inputs))
+	assert.Equal(t, float64(6), inputs["neighbours"])
+}
+
+func TestNearbyPolicyMinFlags(t *testing.T) {
+	target := &store.User{
+		ID:          "lonely",
+		Latitude:    -60,
+		Longitude:   -150,
+		FlagMonitor: []store.FlagMonitor{{UserID: "flagger"}},
+	}
+	st := &MockUserStorage{
+		FindNearbyFunc: func(ctx context.Context, center geo.Point, radiusKm float64, statuses ...store.Status) ([]*store.User, error) {
+			return []*store.User{target}, nil
+		},
+	}
+
+	res, err := NewNearbyPolicy(5, 50, 2).Evaluate(context.Background(), st, target)
+	require.NoError(t, err)
+	assert.False(t, res.Infected)
+	assert.Equal(t, 2.0, res.Required)
+}
+
+func TestReputationPolicy(t *testing.T) {
+	ctx := context.Background()
+	users := map[string]*store.User{
+		"trusted":   {ID: "trusted"},
+		"suspected": {ID: "suspected"},
+		"infected":  {ID: "infected", Infected: true},
+	}
+	scores := map[string]float64{"trusted": 60, "suspected": 25, "infected": 80}
+	st := &MockUserStorage{
+		FindUsersFunc: func(ctx context.Context, ids ...string) (map[string]*store.User, error) {
+			res := make(map[string]*store.User)
+			for _, id := range ids {
+				if v, ok := users[id]; ok {
+					res[id] = v
+				}
+			}
+			return res, nil
+		},
+		FindByReputationFunc: func(ctx context.Context, q store.ReputationQuery) ([]*store.ScoredUser, error) {
+			res := make([]*store.ScoredUser, 0, len(q.IDs))
+			for _, id := range q.IDs {
+				res = append(res, &store.ScoredUser{User: users[id], Reputation: scores[id]})
+			}
+			return res, nil
+		},
+	}
+	target := &store.User{ID: "target"}
+	for id := range users {
+		target.FlagMonitor = append(target.FlagMonitor, store.FlagMonitor{UserID: id, InfectedUserID: target.ID})
+	}
+
+	// flaggers count fully from the reputation every survivor starts with, infected ones don't count at all
+	res, err := NewReputationPolicy(1.5, 0, nil).Evaluate(ctx, st, target)
+	require.NoError(t, err)
+	assert.True(t, res.Infected)
+	assert.Equal(t, 1.5, res.Score)
+	assert.Equal(t, map[string]float64{"trusted": 1, "suspected": 0.5, "infected": 0}, res.Inputs["weights"])
+
+	// low reputation flaggers are ignored
+	res, err = NewReputationPolicy(1.5, 0.6, nil).Evaluate(ctx, st, target)
+	require.NoError(t, err)
+	assert.False(t, res.Infected)
+	assert.Equal(t, 1.0, res.Score)
+
+	// the reputation is pluggable
+	flat := func(ctx context.Context, storage store.IUserStorage, flaggers ...*store.User) (map[string]float64, error) {
+		res := make(map[string]float64, len(flaggers))
+		for _, v := range flaggers {
+			res[v.ID] = 1
+		}
+		return res, nil
+	}
+	res, err = NewReputationPolicy(3, 0, flat).Evaluate(ctx, st, target)
+	require.NoError(t, err)
+	assert.True(t, res.Infected)
+}
@@ -0,0 +1,118 @@
+package users
+
+import (
+	"context"
+
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+	"zssn/domains/users/store"
+)
+
+const (
+	// DefaultApproximatePrecision geohash length approximate locations are snapped to, cells are about 1.2km by 0.6km
+	DefaultApproximatePrecision = 6
+	// MaxApproximatePrecision longest geohash approximate locations can be snapped to, cells are about 150m wide
+	MaxApproximatePrecision = 7
+)
+
+// Resources recorded in the location audit when an admin sees exact locations through the user service
+const (
+	AuditResourceNearby     = "nearby"
+	AuditResourceFlags      = "flags"
+	AuditResourceZoneEvents = "zone_events"
+)
+
+// WithApproximatePrecision sets the geohash length approximate locations are snapped to, values out of range are ignored
+func WithApproximatePrecision(precision int) Option {
+	return func(u *UserService) {
+		if precision >= 1 && precision <= MaxApproximatePrecision {
+			u.approximatePrecision = precision
+		}
+	}
+}
+
+// LocationViewer applies the visibility every survivor chose to their locations before they are shown to someone else.
+// Admins see exact locations, every survivor whose location an admin saw is recorded once per viewer and written by Save.
+type LocationViewer struct {
+	requesterID string
+	admin       bool
+	resource    string
+	precision   int
+	audits      []*store.LocationAudit
+	audited     map[string]bool
+}
+
+// NewLocationViewer returns the viewer for the requester, resource says what the locations are part of in the audit
+func NewLocationViewer(requesterID string, admin bool, resource string, precision int) *LocationViewer {
+	return &LocationViewer{
+		requesterID: requesterID,
+		admin:       admin,
+		resource:    resource,
+		precision:   precision,
+		audited:     make(map[string]bool),
+	}
+}
+
+// Location returns the location of the survivor as the requester gets to see it, false if it's hidden from them.
+// Survivors always see their own location as it is.
+func (v *LocationViewer) Location(userID string, visibility store.Visibility, p geo.Point) (geo.Point, bool) {
+	if userID == v.requesterID {
+		return p, true
+	}
+	if !v.admin {
+		return visibility.Reveal(p, v.precision)
+	}
+	if !v.audited[userID] {
+		v.audited[userID] = true
+		v.audits = append(v.audits, &store.LocationAudit{AdminID: v.requesterID, UserID: userID, Resource: v.resource})
+	}
+	return p, true
+}
+
+// Save writes the audit entries of the exact locations the admin saw so far
+func (v *LocationViewer) Save(ctx context.Context, storage store.IUserStorage) error {
+	if len(v.audits) == 0 {
+		return nil
+	}
+	if err := storage.CreateLocationAudits(ctx, v.audits...); err != nil {
+		return err
+	}
+	v.audits = nil
+	return nil
+}
+
+// locationViewer returns the viewer for the requester
+func (u *UserService) locationViewer(ctx context.Context, requesterID, resource string) (*LocationViewer, error) {
+	admin, err := u.isAdmin(ctx, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocationViewer(requesterID, admin, resource, u.approximatePrecision), nil
+}
+
+// IsAdmin returns true if the survivor has been granted the admin role
+func (u *UserService) IsAdmin(ctx context.Context, id string) (bool, error) {
+	return u.isAdmin(ctx, id)
+}
+
+// UpdateVisibility changes how precisely other survivors see the survivor's location
+func (u *UserService) UpdateVisibility(ctx context.Context, id, visibility string) error {
+	v, err := store.ParseVisibility(visibility)
+	if err != nil {
+		return err
+	}
+	return u.Storage.UpdateVisibility(ctx, id, v)
+}
+
+// LocationAudits returns the times admins saw the exact location of the survivor, newest first
+func (u *UserService) LocationAudits(ctx context.Context, id string) ([]*entities.LocationAudit, error) {
+	audits, err := u.Storage.FindLocationAudits(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entities.LocationAudit, 0, len(audits))
+	for _, v := range audits {
+		res = append(res, entities.FromLocationAuditDBEntity(v))
+	}
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"zssn/database"
+	"zssn/domains/geo"
+	"zssn/domains/users"
+	"zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationViewer(t *testing.T) {
+	p := geo.Point{Latitude: 6.5, Longitude: 3.3}
+
+	viewer := users.NewLocationViewer("requester", false, users.AuditResourceNearby, users.DefaultApproximatePrecision)
+	got, ok := viewer.Location("requester", store.VisibilityHidden, p)
+	require.True(t, ok)
+	assert.Equal(t, p, got, "survivors see their own location")
+	got, ok = viewer.Location("other", store.VisibilityApproximate, p)
+	require.True(t, ok)
+	assert.NotEqual(t, p, got)
+	_, ok = viewer.Location("other", store.VisibilityHidden, p)
+	assert.False(t, ok)
+
+	admin := users.NewLocationViewer("admin", true, users.AuditResourceNearby, users.DefaultApproximatePrecision)
+	got, ok = admin.Location("other", store.VisibilityHidden, p)
+	require.True(t, ok)
+	assert.Equal(t, p, got)
+}
+
+func TestNearbyVisibility(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+
+	createAt := func(lat float64, visibility store.Visibility) *store.User {
+		u := storetest.NewUser(t)
+		u.Latitude, u.Longitude = 6.5+lat, 3.3
+		require.NoError(t, storage.Create(ctx, u))
+		require.NoError(t, storage.UpdateVisibility(ctx, u.ID, visibility))
+		return u
+	}
+	requester, admin := createAt(0, store.VisibilityHidden), createAt(0, store.VisibilityExact)
+	approximate, hidden := createAt(0.01, store.VisibilityApproximate), createAt(0.02, store.VisibilityHidden)
+	require.NoError(t, storage.UpdateRole(ctx, admin.ID, store.RoleAdmin))
+	svc, err := users.New(storage)
+	require.NoError(t, err)
+
+	// hiding your own location doesn't stop you from searching, hidden survivors aren't found
+	res, err := svc.Nearby(ctx, requester.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, admin.ID, res[0].ID)
+	assert.Equal(t, approximate.ID, res[1].ID)
+	// the distance is worked out from the approximate location
+	assert.Greater(t, res[1].DistanceKm, float64(0))
+
+	// admins find everyone and the survivors can see it
+	res, err = svc.Nearby(ctx, admin.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	audits, err := svc.LocationAudits(ctx, hidden.ID)
+	require.NoError(t, err)
+	require.Len(t, audits, 1)
+	assert.Equal(t, admin.ID, audits[0].AdminID)
+	assert.Equal(t, users.AuditResourceNearby, audits[0].Resource)
+	audits, err = svc.LocationAudits(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.Empty(t, audits)
+
+	ok, err := svc.IsAdmin(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, svc.UpdateVisibility(ctx, hidden.ID, "exact"))
+	res, err = svc.Nearby(ctx, requester.ID, 0)
+	require.NoError(t, err)
+	assert.Len(t, res, 3)
+	err = svc.UpdateVisibility(ctx, hidden.ID, "blurry")
+	assert.True(t, errors.Is(err, store.ErrUnknownVisibility))
+}
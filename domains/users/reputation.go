@@ -0,0 +1,68 @@
+package users
+
+import (
+	"context"
+
+	"zssn/domains/entities"
+	"zssn/domains/reputation"
+	"zssn/domains/users/store"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultPartnerLimit number of trade partners returned when no limit is given
+	DefaultPartnerLimit = 20
+	// MaxPartnerLimit most trade partners returned at once
+	MaxPartnerLimit = 100
+)
+
+// WithReputationWeights sets the weights the survivors are scored with on their profiles,
+// the reputation policy weights the flags by the same scores unless it was given its own reputation
+func WithReputationWeights(w reputation.Weights) Option {
+	return func(u *UserService) {
+		u.reputationWeights = w
+	}
+}
+
+// Profile returns the public profile of a survivor with their reputation
+func (u *UserService) Profile(ctx context.Context, id string) (*entities.Profile, error) {
+	res, err := u.Storage.FindByReputation(ctx, store.ReputationQuery{Weights: u.reputationWeights, IDs: []string{id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return profile(res[0]), nil
+}
+
+// FindPartners returns the clean survivors, other than the requester, with at least the given reputation.
+// The most reputable survivors come first, at most limit of them are returned.
+func (u *UserService) FindPartners(ctx context.Context, requesterID string, minReputation float64, limit int) ([]*entities.Profile, error) {
+	if limit <= 0 {
+		limit = DefaultPartnerLimit
+	}
+	if limit > MaxPartnerLimit {
+		limit = MaxPartnerLimit
+	}
+	partners, err := u.Storage.FindByReputation(ctx, store.ReputationQuery{
+		Weights:       u.reputationWeights,
+		Statuses:      store.CleanStatuses(),
+		ExcludeID:     requesterID,
+		MinReputation: minReputation,
+		Limit:         limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entities.Profile, 0, len(partners))
+	for _, v := range partners {
+		res = append(res, profile(v))
+	}
+	return res, nil
+}
+
+func profile(v *store.ScoredUser) *entities.Profile {
+	return entities.FromUserDBEntityProfile(v.User, v.Reputation, v.Inputs.Trades)
+}
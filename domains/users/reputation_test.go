@@ -0,0 +1,151 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"zssn/database"
+	"zssn/domains/entities"
+	"zssn/domains/reputation"
+	trStore "zssn/domains/trade/store"
+	trStoretest "zssn/domains/trade/store/storetest"
+	"zssn/domains/users"
+	"zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// reputationScenario a flagged survivor who turned out infected and one who won their appeal
+type reputationScenario struct {
+	svc                    users.IUserService
+	admin, victim, cleared *store.User
+	accurate, rejected     []*store.User
+}
+
+// newReputationScenario scores every input apart from the account age so the scores are exact
+func newReputationScenario(t *testing.T) *reputationScenario {
+	t.Helper()
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+
+	now := time.Now()
+	s := &reputationScenario{
+		admin:   createUserAt(t, storage, now.Add(-4*time.Hour)),
+		victim:  createUserAt(t, storage, now.Add(-3*time.Hour)),
+		cleared: createUserAt(t, storage, now.Add(-2*time.Hour)),
+	}
+	for i := 0; i < 2; i++ {
+		s.accurate = append(s.accurate, createUserAt(t, storage, now.Add(-time.Duration(10+i)*time.Minute)))
+		s.rejected = append(s.rejected, createUserAt(t, storage, now.Add(-time.Duration(20+i)*time.Minute)))
+	}
+	trades, err := trStore.New(db)
+	require.NoError(t, err)
+	partner := uuid.NewString()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, trades.Execute(ctx, trStoretest.NewTradeItems(t, s.accurate[0].ID), trStoretest.NewTradeItems(t, partner)))
+	}
+	require.NoError(t, storage.UpdateRole(ctx, s.admin.ID, store.RoleAdmin))
+	s.svc, err = users.New(storage,
+		users.WithInfectionThreshold(2),
+		users.WithReputationWeights(reputation.Weights{Base: 50, Trade: 10, AccurateFlag: 5, RejectedFlag: -20, FlagReceived: -1}),
+	)
+	require.NoError(t, err)
+
+	for i := range s.accurate {
+		_, err = s.svc.FlagUser(ctx, s.accurate[i].ID, s.victim.ID, nil)
+		require.NoError(t, err)
+		_, err = s.svc.FlagUser(ctx, s.rejected[i].ID, s.cleared.ID, nil)
+		require.NoError(t, err)
+	}
+	appeal, err := s.svc.SubmitAppeal(ctx, s.cleared.ID, "It's ketchup")
+	require.NoError(t, err)
+	_, err = s.svc.ResolveAppeal(ctx, s.admin.ID, appeal.ID, true)
+	require.NoError(t, err)
+	return s
+}
+
+func TestProfile(t *testing.T) {
+	ctx := context.Background()
+	s := newReputationScenario(t)
+
+	expected := map[string]float64{
+		s.accurate[0].ID: 85,
+		s.accurate[1].ID: 55,
+		s.rejected[0].ID: 30,
+		s.rejected[1].ID: 30,
+		s.victim.ID:      48,
+		s.cleared.ID:     50,
+	}
+	for id, score := range expected {
+		res, err := s.svc.Profile(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, score, res.Reputation, id)
+	}
+
+	res, err := s.svc.Profile(ctx, s.accurate[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, s.accurate[0].Name, res.Name)
+	assert.Equal(t, string(store.StatusHealthy), res.Status)
+	assert.Equal(t, 3, res.Trades)
+	assert.WithinDuration(t, s.accurate[0].CreatedAt, res.MemberSince, time.Second)
+
+	_, err = s.svc.Profile(ctx, "unknown")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func TestFindPartners(t *testing.T) {
+	ctx := context.Background()
+	s := newReputationScenario(t)
+
+	res, err := s.svc.FindPartners(ctx, s.accurate[1].ID, 50, 0)
+	require.NoError(t, err)
+	// the victim is infected and the requester can't trade with themselves
+	require.Len(t, res, 3)
+	assert.Equal(t, s.accurate[0].ID, res[0].ID)
+	assert.ElementsMatch(t, []string{s.admin.ID, s.cleared.ID}, profileIDs(res[1:]))
+
+	res, err = s.svc.FindPartners(ctx, s.accurate[1].ID, 50, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{s.accurate[0].ID}, profileIDs(res))
+
+	res, err = s.svc.FindPartners(ctx, s.accurate[1].ID, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, res, 5)
+	for i := 1; i < len(res); i++ {
+		assert.GreaterOrEqual(t, res[i-1].Reputation, res[i].Reputation)
+	}
+}
+
+func TestProfileWithoutTradeActivity(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+	u := storetest.NewUser(t)
+	require.NoError(t, storage.Create(ctx, u))
+
+	svc, err := users.New(storage)
+	require.NoError(t, err)
+	res, err := svc.Profile(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, res.Trades)
+	assert.Equal(t, reputation.DefaultWeights().Base, res.Reputation)
+}
+
+func profileIDs(profiles []*entities.Profile) []string {
+	res := make([]string, 0, len(profiles))
+	for _, v := range profiles {
+		res = append(res, v.ID)
+	}
+	return res
+}
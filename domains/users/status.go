@@ -0,0 +1,136 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"zssn/domains/entities"
+	"zssn/domains/users/store"
+)
+
+// ErrInvalidTransition is returned when a survivor can't move from their current status to the requested one
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// transitions lists the statuses a survivor can move to from each status.
+// Infected survivors go back to suspected or healthy when flags against them are retracted or overturned,
+// deceased is final.
+var transitions = map[store.Status][]store.Status{
+	store.StatusHealthy:     {store.StatusSuspected, store.StatusInfected, store.StatusQuarantined, store.StatusDeceased},
+	store.StatusSuspected:   {store.StatusHealthy, store.StatusInfected, store.StatusQuarantined, store.StatusDeceased},
+	store.StatusInfected:    {store.StatusHealthy, store.StatusSuspected, store.StatusQuarantined, store.StatusRecovered, store.StatusDeceased},
+	store.StatusQuarantined: {store.StatusHealthy, store.StatusInfected, store.StatusRecovered, store.StatusDeceased},
+	store.StatusRecovered:   {store.StatusSuspected, store.StatusInfected, store.StatusQuarantined, store.StatusDeceased},
+}
+
+// CanTransition reports whether a survivor can move from one status to the other
+func CanTransition(from, to store.Status) bool {
+	for _, v := range transitions[from] {
+		if v == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateStatus lets an admin move a survivor to another status, e.g. to quarantine or cure them.
+// The effects of the change are applied once it is saved, ErrEffectsPending is returned when that fails.
+func (u *UserService) UpdateStatus(ctx context.Context, adminID, userID string, status store.Status, reason string) (*entities.StatusTransition, error) {
+	if err := u.requireAdmin(ctx, adminID); err != nil {
+		return nil, err
+	}
+
+	var res *store.StatusTransition
+	err := u.Storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		if err := tx.LockUser(ctx, userID); err != nil {
+			return err
+		}
+		usr, err := tx.Find(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if usr.Status == status {
+			return fmt.Errorf("%w: survivor is already %s", ErrInvalidTransition, status)
+		}
+		res, err = u.transition(ctx, tx, usr, status, adminID, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.settle(ctx, res); err != nil {
+		return nil, err
+	}
+	return entities.FromStatusTransitionDBEntity(res), nil
+}
+
+// StatusHistory returns every status change of the survivor, oldest first.
+// Only admins get to see who caused a change, for everyone else it could give away who flagged the survivor.
+func (u *UserService) StatusHistory(ctx context.Context, requesterID, userID string) ([]*entities.StatusTransition, error) {
+	admin, err := u.isAdmin(ctx, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.Storage.Find(ctx, userID); err != nil {
+		return nil, err
+	}
+	res, err := u.Storage.FindStatusTransitions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*entities.StatusTransition, 0, len(res))
+	for _, v := range res {
+		t := entities.FromStatusTransitionDBEntity(v)
+		if !admin {
+			t.ActorID = ""
+		}
+		history = append(history, t)
+	}
+	return history, nil
+}
+
+// transition moves the survivor to the given status and records the change, nothing happens if the status doesn't change.
+// The caller has to hold the lock on the survivor and settle the change once the transaction is committed.
+func (u *UserService) transition(ctx context.Context, tx store.IUserStorage, usr *store.User, to store.Status, actorID, reason string) (*store.StatusTransition, error) {
+	from := usr.Status
+	if from == to {
+		return nil, nil
+	}
+	if !CanTransition(from, to) {
+		return nil, fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, to)
+	}
+	if err := tx.UpdateStatus(ctx, usr.ID, to); err != nil {
+		return nil, err
+	}
+	res := &store.StatusTransition{
+		UserID:     usr.ID,
+		ActorID:    actorID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+	}
+	if err := tx.CreateStatusTransition(ctx, res); err != nil {
+		return nil, err
+	}
+	usr.Status, usr.Infected = to, to.Infected()
+	return res, nil
+}
+
+func (u *UserService) requireAdmin(ctx context.Context, id string) error {
+	admin, err := u.isAdmin(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return ErrNotAdmin
+	}
+	return nil
+}
+
+func (u *UserService) isAdmin(ctx context.Context, id string) (bool, error) {
+	usr, err := u.Storage.Find(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return usr.Role == store.RoleAdmin, nil
+}
@@ -0,0 +1,190 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"zssn/domains/users/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCanTransition(t *testing.T) {
+	table := []struct {
+		from, to store.Status
+		allowed  bool
+	}{
+		{store.StatusHealthy, store.StatusSuspected, true},
+		{store.StatusSuspected, store.StatusInfected, true},
+		{store.StatusInfected, store.StatusRecovered, true},
+		{store.StatusQuarantined, store.StatusRecovered, true},
+		{store.StatusRecovered, store.StatusInfected, true},
+		{store.StatusHealthy, store.StatusRecovered, false},
+		{store.StatusRecovered, store.StatusHealthy, false},
+		{store.StatusDeceased, store.StatusHealthy, false},
+		{store.StatusHealthy, store.StatusHealthy, false},
+	}
+	for _, tt := range table {
+		t.Run(string(tt.from)+"-"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.allowed, CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestFlagUserStatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage, WithInfectionThreshold(2))
+	require.NoError(t, err)
+
+	target, flagger, anotherFlagger := createUsers(t, svc, 3)
+	assertStatus(t, svc, target.ID, store.StatusHealthy)
+
+	transition, err := svc.FlagUser(ctx, flagger.ID, target.ID, nil)
+	require.NoError(t, err)
+	require.NotNil(t, transition)
+	assert.Equal(t, "suspected", transition.To)
+	assertStatus(t, svc, target.ID, store.StatusSuspected)
+	transition, err = svc.FlagUser(ctx, anotherFlagger.ID, target.ID, nil)
+	require.NoError(t, err)
+	require.NotNil(t, transition)
+	assert.Equal(t, "suspected", transition.From)
+	assert.Equal(t, "infected", transition.To)
+	assertStatus(t, svc, target.ID, store.StatusInfected)
+
+	// flags on a survivor who is already infected are recorded without changing anything
+	late, _, _ := createUsers(t, svc, 1)
+	transition, err = svc.FlagUser(ctx, late.ID, target.ID, nil)
+	require.NoError(t, err)
+	assert.Nil(t, transition)
+	_, err = svc.RetractFlag(ctx, late.ID, target.ID)
+	require.NoError(t, err)
+
+	_, err = svc.RetractFlag(ctx, anotherFlagger.ID, target.ID)
+	require.NoError(t, err)
+	assertStatus(t, svc, target.ID, store.StatusSuspected)
+	_, err = svc.RetractFlag(ctx, flagger.ID, target.ID)
+	require.NoError(t, err)
+	assertStatus(t, svc, target.ID, store.StatusHealthy)
+
+	admin, _, _ := createUsers(t, svc, 1)
+	require.NoError(t, storage.UpdateRole(ctx, admin.ID, store.RoleAdmin))
+	history, err := svc.StatusHistory(ctx, admin.ID, target.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, "healthy", history[0].From)
+	assert.Equal(t, "suspected", history[0].To)
+	assert.Equal(t, flagger.ID, history[0].ActorID)
+	assert.Equal(t, "infected", history[1].To)
+	assert.Equal(t, "infection policy met", history[1].Reason)
+	assert.Equal(t, "suspected", history[2].To)
+	assert.Equal(t, "flag retracted", history[2].Reason)
+	assert.Equal(t, "healthy", history[3].To)
+
+	// nobody else gets to know who flagged the survivor, the survivor included
+	for _, requester := range []string{target.ID, anotherFlagger.ID} {
+		history, err = svc.StatusHistory(ctx, requester, target.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 4)
+		for _, v := range history {
+			assert.Empty(t, v.ActorID)
+		}
+	}
+
+	_, err = svc.StatusHistory(ctx, admin.ID, uuid.NewString())
+	require.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func TestRetractFlagKeepsAdminStatus(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage, WithInfectionThreshold(2))
+	require.NoError(t, err)
+	target, flagger, admin := createUsers(t, svc, 3)
+	require.NoError(t, storage.UpdateRole(ctx, admin.ID, store.RoleAdmin))
+
+	_, err = svc.FlagUser(ctx, flagger.ID, target.ID, nil)
+	require.NoError(t, err)
+	assertStatus(t, svc, target.ID, store.StatusSuspected)
+	_, err = svc.UpdateStatus(ctx, admin.ID, target.ID, store.StatusInfected, "bitten")
+	require.NoError(t, err)
+
+	// the only flag is taken back, but the infection was the admin's call
+	transition, err := svc.RetractFlag(ctx, flagger.ID, target.ID)
+	require.NoError(t, err)
+	assert.Nil(t, transition)
+	assertStatus(t, svc, target.ID, store.StatusInfected)
+}
+
+func TestUpdateStatus(t *testing.T) {
+	ctx := context.Background()
+	target, admin, survivor := newUser(t), newUser(t), newUser(t)
+	svc, err := New(storage)
+	require.NoError(t, err)
+	require.NoError(t, svc.Create(ctx, target))
+	require.NoError(t, svc.Create(ctx, admin))
+	require.NoError(t, storage.UpdateRole(ctx, admin.ID, store.RoleAdmin))
+	require.NoError(t, svc.Create(ctx, survivor))
+	require.NoError(t, storage.UpdateInfectedStatus(ctx, target.ID))
+
+	_, err = svc.UpdateStatus(ctx, survivor.ID, target.ID, store.StatusQuarantined, "isolated")
+	require.True(t, errors.Is(err, ErrNotAdmin))
+
+	res, err := svc.UpdateStatus(ctx, admin.ID, target.ID, store.StatusQuarantined, "isolated")
+	require.NoError(t, err)
+	assert.Equal(t, "infected", res.From)
+	assert.Equal(t, "quarantined", res.To)
+	assert.Equal(t, admin.ID, res.ActorID)
+	assertInfected(t, svc, target.ID, true)
+
+	_, err = svc.UpdateStatus(ctx, admin.ID, target.ID, store.StatusQuarantined, "isolated")
+	require.True(t, errors.Is(err, ErrInvalidTransition))
+
+	_, err = svc.UpdateStatus(ctx, admin.ID, target.ID, store.StatusRecovered, "cured")
+	require.NoError(t, err)
+	assertStatus(t, svc, target.ID, store.StatusRecovered)
+	assertInfected(t, svc, target.ID, false)
+
+	// recovered survivors can flag again
+	_, err = svc.FlagUser(ctx, target.ID, survivor.ID, nil)
+	require.NoError(t, err)
+
+	_, err = svc.UpdateStatus(ctx, admin.ID, target.ID, store.StatusDeceased, "")
+	require.NoError(t, err)
+	_, err = svc.UpdateStatus(ctx, admin.ID, target.ID, store.StatusHealthy, "")
+	require.True(t, errors.Is(err, ErrInvalidTransition))
+	_, err = svc.FlagUser(ctx, target.ID, admin.ID, nil)
+	require.True(t, errors.Is(err, ErrInfectedFlagger))
+
+	_, err = svc.UpdateStatus(ctx, admin.ID, uuid.NewString(), store.StatusDeceased, "")
+	require.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func TestApprovedAppealClearsStatus(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage, WithAppealQuorum(1))
+	require.NoError(t, err)
+
+	target, voter, _ := createUsers(t, svc, 2)
+	require.NoError(t, storage.UpdateStatus(ctx, target.ID, store.StatusQuarantined))
+	appeal, err := svc.SubmitAppeal(ctx, target.ID, "It's ketchup")
+	require.NoError(t, err)
+	_, err = svc.VoteAppeal(ctx, voter.ID, appeal.ID, true)
+	require.NoError(t, err)
+	assertStatus(t, svc, target.ID, store.StatusHealthy)
+
+	history, err := svc.StatusHistory(ctx, target.ID, target.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "quarantined", history[0].From)
+	assert.Equal(t, "appeal approved", history[0].Reason)
+}
+
+func assertStatus(t *testing.T, svc IUserService, id string, expected store.Status) {
+	t.Helper()
+	res, err := svc.Find(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), res.Status)
+}
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReviewStatus the stage a collusion review is in
+type ReviewStatus string
+
+const (
+	// ReviewPending the flagger is waiting for an admin
+	ReviewPending ReviewStatus = "pending"
+	// ReviewConfirmed an admin agreed the flagger is part of a flagging ring
+	ReviewConfirmed ReviewStatus = "confirmed"
+	// ReviewDismissed an admin cleared the flagger, the detection leaves them alone from then on
+	ReviewDismissed ReviewStatus = "dismissed"
+)
+
+// ParseReviewStatus returns the review status with the given name
+func ParseReviewStatus(s string) (ReviewStatus, error) {
+	switch ReviewStatus(s) {
+	case ReviewPending, ReviewConfirmed, ReviewDismissed:
+		return ReviewStatus(s), nil
+	default:
+		return "", fmt.Errorf("unknown review status %q", s)
+	}
+}
+
+// Suspicious returns true if the flags of a flagger under this review shouldn't be trusted
+func (s ReviewStatus) Suspicious() bool {
+	return s == ReviewPending || s == ReviewConfirmed
+}
+
+// CollusionReview a flagger the collusion detection found suspicious.
+// Signals and Targets are comma separated, the targets are the survivors the flagger flagged when the review was last updated.
+type CollusionReview struct {
+	ID         string       `json:"id" gorm:"primaryKey"`
+	UserID     string       `json:"user_id" gorm:"size:50;uniqueIndex"`
+	Signals    string       `json:"signals"`
+	Targets    string       `json:"targets"`
+	Score      float64      `json:"score"`
+	Status     ReviewStatus `json:"status" gorm:"size:20;index"`
+	ReviewedBy string       `json:"reviewed_by" gorm:"size:50"`
+	ReviewedAt *time.Time   `json:"reviewed_at"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
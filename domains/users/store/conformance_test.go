@@ -0,0 +1,22 @@
+package store_test
+
+import (
+	"testing"
+
+	"zssn/database"
+	"zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageConformance(t *testing.T) {
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+
+	storetest.Run(t, func(t *testing.T) store.IUserStorage {
+		return storage
+	})
+}
@@ -1,7 +1,9 @@
 package store
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -18,18 +20,32 @@ const (
 	GenderFemale
 )
 
+// Role what a user is allowed to do, it can only be changed from the command line
+type Role string
+
+const (
+	// RoleSurvivor every user starts out as a plain survivor
+	RoleSurvivor Role = "survivor"
+	// RoleAdmin can review flags, appeals and exact locations
+	RoleAdmin Role = "admin"
+)
+
 // User contains the user db entities
 type User struct {
-	ID          string        `json:"id" gorm:"primaryKey"`
-	Email       string        `json:"email" gorm:"size:50;uniqueIndex"` // let's keep email, zombie apocalypse shouldn't make us forget that :)
-	Name        string        `json:"name"`
-	Age         uint32        `json:"age"`
-	Gender      Gender        `json:"gender"`
-	Latitude    float64       `json:"latitude"`
-	Longitude   float64       `json:"longitude"`
-	FlagMonitor []FlagMonitor `json:"flag_monitor" gorm:"foreignKey:InfectedUserID"`
-	Infected    bool          `json:"infected"`
-	Token       string        `json:"token" gorm:"-"`
+	ID            string        `json:"id" gorm:"primaryKey"`
+	Email         string        `json:"email" gorm:"size:50;uniqueIndex"` // let's keep email, zombie apocalypse shouldn't make us forget that :)
+	Name          string        `json:"name"`
+	Age           uint32        `json:"age"`
+	Gender        Gender        `json:"gender"`
+	Latitude      float64       `json:"latitude"`
+	Longitude     float64       `json:"longitude"`
+	FlagMonitor   []FlagMonitor `json:"flag_monitor" gorm:"foreignKey:InfectedUserID"`
+	Infected      bool          `json:"infected"`
+	Status        Status        `json:"status" gorm:"size:20;not null;default:healthy;index"`
+	RejectedFlags int           `json:"rejected_flags" gorm:"not null;default:0"`
+	Visibility    Visibility    `json:"visibility" gorm:"size:20;not null;default:approximate"`
+	Role          Role          `json:"role" gorm:"size:20;not null;default:survivor"`
+	Token         string        `json:"token" gorm:"-"`
 	gorm.Model
 }
 
@@ -42,9 +58,42 @@ type FlagMonitor struct {
 	User           *User  `json:"user" gorm:"foreignKey:UserID"`
 	InfectedUserID string `json:"infected_user_id,omitempty" gorm:"size:50; index:idx_flagged,unique"`
 	InfectedUser   *User  `json:"infected_user,omitempty" gorm:"foreignKey:InfectedUserID"`
+	FlagEvidence
 	gorm.Model
 }
 
+// Severity how sure the flagger is that the survivor is infected
+type Severity string
+
+const (
+	// SeverityLow the survivor looks a bit off
+	SeverityLow Severity = "low"
+	// SeverityMedium the default severity
+	SeverityMedium Severity = "medium"
+	// SeverityHigh the flagger saw the survivor bite someone
+	SeverityHigh Severity = "high"
+)
+
+// ParseSeverity returns the severity with the given name, an empty name is medium
+func ParseSeverity(s string) (Severity, error) {
+	switch Severity(s) {
+	case "":
+		return SeverityMedium, nil
+	case SeverityLow, SeverityMedium, SeverityHigh:
+		return Severity(s), nil
+	default:
+		return "", fmt.Errorf("unknown severity %q", s)
+	}
+}
+
+// FlagEvidence the context a flagger gives with a flag, the location is where the flagger was at the time
+type FlagEvidence struct {
+	Note      string   `json:"note"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Severity  Severity `json:"severity" gorm:"size:10;not null;default:medium"`
+}
+
 // String representation of the gender constants
 func (g Gender) String() string {
 	switch g {
@@ -70,3 +119,51 @@ func GenderFromString(g string) Gender {
 		return GenderOthers
 	}
 }
+
+// InfectionAudit records every decision an infection policy made, together with the inputs it was based on
+type InfectionAudit struct {
+	ID        string  `json:"id" gorm:"primaryKey"`
+	UserID    string  `json:"user_id" gorm:"size:50;index"`
+	ActorID   string  `json:"actor_id" gorm:"size:50"`
+	Action    string  `json:"action" gorm:"size:50"`
+	Policy    string  `json:"policy" gorm:"size:50"`
+	Infected  bool    `json:"infected"`
+	Score     float64 `json:"score"`
+	Required  float64 `json:"required"`
+	Inputs    string  `json:"inputs"`
+	CreatedAt time.Time
+}
+
+// AppealStatus the stage an infection appeal is in
+type AppealStatus string
+
+const (
+	// AppealPending the appeal is waiting for votes or an admin
+	AppealPending AppealStatus = "pending"
+	// AppealApproved the survivor was cleared
+	AppealApproved AppealStatus = "approved"
+	// AppealRejected the survivor stays infected
+	AppealRejected AppealStatus = "rejected"
+)
+
+// Appeal an infected survivor's request to have their infection overturned
+type Appeal struct {
+	ID         string       `json:"id" gorm:"primaryKey"`
+	UserID     string       `json:"user_id" gorm:"size:50;index"`
+	Reason     string       `json:"reason"`
+	Status     AppealStatus `json:"status" gorm:"size:20"`
+	ResolvedBy string       `json:"resolved_by" gorm:"size:50"`
+	ResolvedAt *time.Time   `json:"resolved_at"`
+	Votes      []AppealVote `json:"votes" gorm:"foreignKey:AppealID"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// AppealVote a clean survivor's vote on an appeal
+type AppealVote struct {
+	ID        string `json:"id" gorm:"primaryKey"`
+	AppealID  string `json:"appeal_id" gorm:"size:50;index:idx_appeal_voter,unique"`
+	VoterID   string `json:"voter_id" gorm:"size:50;index:idx_appeal_voter,unique"`
+	Approve   bool   `json:"approve"`
+	CreatedAt time.Time
+}
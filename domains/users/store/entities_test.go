@@ -3,6 +3,8 @@ package store
 import (
 	"testing"
 
+	"zssn/domains/geo"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -77,3 +79,37 @@ func TestGetGenderFromString(t *testing.T) {
 		})
 	}
 }
+
+func TestVisibilityReveal(t *testing.T) {
+	p := geo.Point{Latitude: 52.5163, Longitude: 13.3777}
+	table := []struct {
+		name       string
+		visibility Visibility
+		ok         bool
+		exact      bool
+	}{
+		{name: "Exact", visibility: VisibilityExact, ok: true, exact: true},
+		{name: "Approximate", visibility: VisibilityApproximate, ok: true},
+		{name: "Hidden", visibility: VisibilityHidden},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.visibility.Reveal(p, 6)
+			require.Equal(t, tt.ok, ok)
+			if !ok {
+				return
+			}
+			require.Equal(t, tt.exact, got == p)
+			require.Less(t, geo.Distance(p, got), 1.0)
+		})
+	}
+
+	// the same cell always gives the same location
+	a, _ := VisibilityApproximate.Reveal(p, 6)
+	b, _ := VisibilityApproximate.Reveal(geo.Point{Latitude: 52.5164, Longitude: 13.3778}, 6)
+	require.Equal(t, a, b)
+
+	_, err := ParseVisibility("blurry")
+	require.ErrorIs(t, err, ErrUnknownVisibility)
+}
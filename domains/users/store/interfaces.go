@@ -2,15 +2,110 @@ package store
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"zssn/domains/geo"
+)
+
+var (
+	// ErrDuplicateFlag is returned when a survivor flags the same survivor more than once
+	ErrDuplicateFlag = errors.New("survivor has already been flagged by this user")
+	// ErrDuplicateVote is returned when a survivor votes on the same appeal more than once
+	ErrDuplicateVote = errors.New("survivor has already voted on this appeal")
 )
 
 // IUserStorage interface describing the expectations for storage engine
 type IUserStorage interface {
+	// WithTx runs fn in a transaction, the storage passed to fn has to be used for every call that is part of it.
+	WithTx(ctx context.Context, fn func(tx IUserStorage) error) error
+	// LockUser locks the user row until the end of the surrounding transaction
+	LockUser(ctx context.Context, id string) error
 	Create(ctx context.Context, user *User) error
 	Find(ctx context.Context, id string) (*User, error)
 	FindUsers(ctx context.Context, ids ...string) (map[string]*User, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
+	// FindByStatus returns every user with one of the given statuses
+	FindByStatus(ctx context.Context, statuses ...Status) ([]*User, error)
 	UpdateLocation(ctx context.Context, id string, lat, long float64) error
-	FlagUser(ctx context.Context, userID, infectedUser string) error
+	UpdateVisibility(ctx context.Context, id string, visibility Visibility) error
+	// UpdateRole changes what the user is allowed to do
+	UpdateRole(ctx context.Context, id string, role Role) error
+	// CreateLocationAudits records that admins saw the exact locations
+	CreateLocationAudits(ctx context.Context, audits ...*LocationAudit) error
+	// FindLocationAudits returns the times admins saw the exact location of the user, newest first
+	FindLocationAudits(ctx context.Context, userID string) ([]*LocationAudit, error)
+	FlagUser(ctx context.Context, userID, infectedUser string, evidence *FlagEvidence) error
+	// FindFlags returns the flags raised against the user, oldest first
+	FindFlags(ctx context.Context, infectedUser string) ([]*FlagMonitor, error)
+	// FindFlagsSince returns every flag raised after the given time, oldest first
+	FindFlagsSince(ctx context.Context, since time.Time) ([]*FlagMonitor, error)
 	UpdateInfectedStatus(ctx context.Context, id string) error
+	// UpdateStatus changes the status and keeps the infected flag in sync with it
+	UpdateStatus(ctx context.Context, id string, status Status) error
+	DeleteFlag(ctx context.Context, userID, infectedUser string) error
+	DeleteFlags(ctx context.Context, infectedUser string) (int64, error)
+	// AddRejectedFlags counts one more overturned flag for every given user
+	AddRejectedFlags(ctx context.Context, userIDs ...string) error
+	// FindByReputation returns the users the query selects with their reputation, the most reputable first
+	FindByReputation(ctx context.Context, q ReputationQuery) ([]*ScoredUser, error)
+	// CreateLocationPoint appends the point to the location history of its user
+	CreateLocationPoint(ctx context.Context, point *LocationPoint) error
+	// FindLocationHistory returns the points of the user between from and to, oldest first. Zero times leave the range open
+	FindLocationHistory(ctx context.Context, userID string, from, to time.Time) ([]*LocationPoint, error)
+	// DeleteLocationPoints deletes every point older than before
+	DeleteLocationPoints(ctx context.Context, before time.Time) (int64, error)
+	// DownsampleLocationPoints thins the points older than before out so the points of every user are at least interval apart
+	DownsampleLocationPoints(ctx context.Context, before time.Time, interval time.Duration) (int64, error)
+	// FindLastLocationPoints returns the latest point of every user recorded between from and to keyed by user ID.
+	// Zero times leave the range open, users without points in the range are left out
+	FindLastLocationPoints(ctx context.Context, from, to time.Time) (map[string]*LocationPoint, error)
+	// FindLocationPointsInBox returns the points of every user inside the box that were recorded between from and to, oldest first
+	FindLocationPointsInBox(ctx context.Context, box geo.Box, from, to time.Time) ([]*LocationPoint, error)
+	// CreateNotification stores the notification, it's ignored if the user already got one of the same kind for the same source
+	CreateNotification(ctx context.Context, n *Notification) error
+	// FindNotifications returns the notifications of the user, newest first
+	FindNotifications(ctx context.Context, userID string, unreadOnly bool) ([]*Notification, error)
+	// MarkNotificationRead marks the notification of the user as read
+	MarkNotificationRead(ctx context.Context, userID, id string) error
+	// FindNearby returns the users within radiusKm of the center, only the users in one of the statuses if any are given
+	FindNearby(ctx context.Context, center geo.Point, radiusKm float64, statuses ...Status) ([]*User, error)
+	// FindInBox returns the users whose last known location is inside the box
+	FindInBox(ctx context.Context, box geo.Box) ([]*User, error)
+	CreateZone(ctx context.Context, zone *Zone) error
+	FindZone(ctx context.Context, id string) (*Zone, error)
+	// FindZones returns every zone ordered by name
+	FindZones(ctx context.Context) ([]*Zone, error)
+	// DeleteZone deletes the zone and its members, its events are kept
+	DeleteZone(ctx context.Context, id string) error
+	// FindZonesAt returns the zones containing the point
+	FindZonesAt(ctx context.Context, p geo.Point) ([]*Zone, error)
+	// FindUserZones returns the zones every given user is currently in keyed by user ID, users outside every zone are left out
+	FindUserZones(ctx context.Context, userIDs ...string) (map[string][]*Zone, error)
+	// AddZoneMember adds the user to the zone, it's ignored if they are already in it
+	AddZoneMember(ctx context.Context, member *ZoneMember) error
+	DeleteZoneMember(ctx context.Context, userID, zoneID string) error
+	CreateZoneEvent(ctx context.Context, event *ZoneEvent) error
+	// FindZoneEvents returns the events of the zone between from and to, oldest first. Zero times leave the range open
+	FindZoneEvents(ctx context.Context, zoneID string, from, to time.Time) ([]*ZoneEvent, error)
+	CreateInfectionAudit(ctx context.Context, audit *InfectionAudit) error
+	FindInfectionAudits(ctx context.Context, userID string) ([]*InfectionAudit, error)
+	CreateAppeal(ctx context.Context, appeal *Appeal) error
+	FindAppeal(ctx context.Context, id string) (*Appeal, error)
+	FindUserAppeals(ctx context.Context, userID string) ([]*Appeal, error)
+	ResolveAppeal(ctx context.Context, id string, status AppealStatus, resolvedBy string) error
+	CreateAppealVote(ctx context.Context, vote *AppealVote) error
+	CreateStatusTransition(ctx context.Context, transition *StatusTransition) error
+	FindStatusTransitions(ctx context.Context, userID string) ([]*StatusTransition, error)
+	FindPendingTransitions(ctx context.Context, userID string) ([]*StatusTransition, error)
+	MarkTransitionApplied(ctx context.Context, id string) error
+	// SaveCollusionReview queues the flagger for review or refreshes their pending review.
+	// Reviews an admin already decided on are left alone, review is updated with the stored record either way.
+	SaveCollusionReview(ctx context.Context, review *CollusionReview) error
+	FindCollusionReview(ctx context.Context, id string) (*CollusionReview, error)
+	// FindCollusionReviews returns the reviews with the given status, or every review if it's empty, oldest first
+	FindCollusionReviews(ctx context.Context, status ReviewStatus) ([]*CollusionReview, error)
+	// FindUserCollusionReviews returns the reviews of the given users keyed by user ID
+	FindUserCollusionReviews(ctx context.Context, userIDs ...string) (map[string]*CollusionReview, error)
+	ResolveCollusionReview(ctx context.Context, id string, status ReviewStatus, reviewedBy string) error
 }
@@ -0,0 +1,17 @@
+package store
+
+import "time"
+
+// LocationPoint a location the survivor reported, every location update adds one to the trail
+type LocationPoint struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"size:50;index:idx_location_history_user_time,priority:1"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_location_history_user_time,priority:2;index"`
+}
+
+// TableName overrides the default table name
+func (LocationPoint) TableName() string {
+	return "location_history"
+}
@@ -0,0 +1,18 @@
+package store
+
+import "time"
+
+// NotificationExposure warns a survivor that they traded with or stood close to a survivor who was found infected
+const NotificationExposure = "exposure"
+
+// Notification a message for a survivor. A survivor gets at most one notification of a kind for the same source,
+// e.g. one exposure warning for every infected survivor they were in contact with.
+type Notification struct {
+	ID        string     `json:"id" gorm:"primaryKey"`
+	UserID    string     `json:"user_id" gorm:"size:50;uniqueIndex:idx_notifications_source,priority:1"`
+	Kind      string     `json:"kind" gorm:"size:30;uniqueIndex:idx_notifications_source,priority:2"`
+	SourceID  string     `json:"source_id" gorm:"size:50;uniqueIndex:idx_notifications_source,priority:3"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
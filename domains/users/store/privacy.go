@@ -0,0 +1,58 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"zssn/domains/geo"
+)
+
+// Visibility how precisely other survivors see a survivor's location
+type Visibility string
+
+const (
+	// VisibilityExact others see the location as it was reported
+	VisibilityExact Visibility = "exact"
+	// VisibilityApproximate others see the middle of the grid cell the location is in, the default
+	VisibilityApproximate Visibility = "approximate"
+	// VisibilityHidden others don't see the location at all
+	VisibilityHidden Visibility = "hidden"
+)
+
+// ErrUnknownVisibility is returned when the visibility isn't one of the known levels
+var ErrUnknownVisibility = errors.New("unknown visibility")
+
+// ParseVisibility returns the visibility with the given name
+func ParseVisibility(s string) (Visibility, error) {
+	switch Visibility(s) {
+	case VisibilityExact, VisibilityApproximate, VisibilityHidden:
+		return Visibility(s), nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnknownVisibility, s)
+	}
+}
+
+// Reveal returns the location as other survivors get to see it, false if it's hidden from them.
+// Approximate locations are snapped to the middle of their geohash cell of the given precision,
+// so the same location always ends up at the same spot and averaging doesn't bring it back.
+func (v Visibility) Reveal(p geo.Point, precision int) (geo.Point, bool) {
+	switch v {
+	case VisibilityExact:
+		return p, true
+	case VisibilityHidden:
+		return geo.Point{}, false
+	default:
+		box, _ := geo.GeohashBox(geo.Geohash(p, precision))
+		return box.Center(), true
+	}
+}
+
+// LocationAudit an admin seeing the exact location of a survivor, Resource is what they looked at
+type LocationAudit struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	AdminID   string    `json:"admin_id" gorm:"size:50;index"`
+	UserID    string    `json:"user_id" gorm:"size:50;index:idx_location_audits_user,priority:1"`
+	Resource  string    `json:"resource" gorm:"size:50"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_location_audits_user,priority:2"`
+}
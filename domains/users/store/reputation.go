@@ -0,0 +1,32 @@
+package store
+
+import (
+	"zssn/domains/reputation"
+)
+
+// ReputationQuery selects users by the reputation the weights give them
+type ReputationQuery struct {
+	Weights reputation.Weights
+	// IDs only these users are scored when given
+	IDs []string
+	// Statuses only users in one of these statuses are scored when given
+	Statuses []Status
+	// ExcludeID leaves this user out
+	ExcludeID string
+	// MinReputation users scored below it are left out
+	MinReputation float64
+	// Limit most users returned, every user is returned when it isn't positive
+	Limit int
+}
+
+// ScoredUser a user with their reputation and what it was scored from
+type ScoredUser struct {
+	*User
+	Inputs     reputation.Inputs
+	Reputation float64
+}
+
+// AccurateStatuses flags raised against survivors in these statuses turned out to be right
+func AccurateStatuses() []Status {
+	return []Status{StatusInfected, StatusQuarantined, StatusRecovered}
+}
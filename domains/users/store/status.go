@@ -0,0 +1,71 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status the health status of a survivor
+type Status string
+
+const (
+	// StatusHealthy the survivor has never been flagged or all flags against them have been overturned
+	StatusHealthy Status = "healthy"
+	// StatusSuspected the survivor has been flagged but the infection policy isn't met yet
+	StatusSuspected Status = "suspected"
+	// StatusInfected the survivor met the infection policy
+	StatusInfected Status = "infected"
+	// StatusQuarantined the survivor is infected and isolated
+	StatusQuarantined Status = "quarantined"
+	// StatusRecovered the survivor was cured
+	StatusRecovered Status = "recovered"
+	// StatusDeceased the survivor died, this is a final status
+	StatusDeceased Status = "deceased"
+)
+
+// Statuses every known status
+var Statuses = []Status{StatusHealthy, StatusSuspected, StatusInfected, StatusQuarantined, StatusRecovered, StatusDeceased}
+
+// ParseStatus returns the status with the given name
+func ParseStatus(s string) (Status, error) {
+	for _, v := range Statuses {
+		if string(v) == s {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unknown status %q", s)
+}
+
+// Infected reports whether survivors with this status count as infected, the infected column mirrors it
+func (s Status) Infected() bool {
+	return s == StatusInfected || s == StatusQuarantined
+}
+
+// Clean reports whether survivors with this status can flag, vote, trade and move around
+func (s Status) Clean() bool {
+	return s == StatusHealthy || s == StatusSuspected || s == StatusRecovered
+}
+
+// CleanStatuses the statuses of the survivors who count as clean
+func CleanStatuses() []Status {
+	return []Status{StatusHealthy, StatusSuspected, StatusRecovered}
+}
+
+// InfectedStatuses the statuses of the survivors who count as infected
+func InfectedStatuses() []Status {
+	return []Status{StatusInfected, StatusQuarantined}
+}
+
+// StatusTransition records a status change, who made it and why.
+// ActorID is the survivor whose action caused the change, e.g. the flagger whose flag met the infection policy.
+// AppliedAt is set once the effects of the change, like blocking the inventory, have been applied.
+type StatusTransition struct {
+	ID         string `json:"id" gorm:"primaryKey"`
+	UserID     string `json:"user_id" gorm:"size:50;index"`
+	ActorID    string `json:"actor_id" gorm:"size:50"`
+	FromStatus Status `json:"from" gorm:"size:20"`
+	ToStatus   Status `json:"to" gorm:"size:20"`
+	Reason     string `json:"reason"`
+	CreatedAt  time.Time
+	AppliedAt  *time.Time `json:"applied_at"`
+}
@@ -2,9 +2,18 @@ package store
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"zssn/database"
+	"zssn/database/migrations"
+	"zssn/domains/geo"
+	"zssn/domains/reputation"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserStorage user storage implementation
@@ -13,8 +22,9 @@ type UserStorage struct {
 }
 
 // New creates a new instance of user storage with the given db connection
+// The database has to be migrated to the latest schema version beforehand.
 func New(db *gorm.DB) (IUserStorage, error) {
-	if err := db.AutoMigrate(&User{}, &FlagMonitor{}); err != nil {
+	if err := migrations.Verify(context.Background(), db); err != nil {
 		return nil, err
 	}
 	return &UserStorage{
@@ -25,26 +35,265 @@ func New(db *gorm.DB) (IUserStorage, error) {
 // Create creates a new user record
 func (u *UserStorage) Create(ctx context.Context, user *User) error {
 	user.ID = uuid.NewString()
+	if user.Status == "" {
+		user.Status = StatusHealthy
+		if user.Infected {
+			user.Status = StatusInfected
+		}
+	}
+	if user.Visibility == "" {
+		user.Visibility = VisibilityApproximate
+	}
+	if user.Role == "" {
+		user.Role = RoleSurvivor
+	}
+	user.Infected = user.Status.Infected()
 	return u.DB.Create(&user).Error
 }
 
-// FlagUser creates a new flag record against the infected user
-func (u *UserStorage) FlagUser(ctx context.Context, id string, infectedUser string) error {
+// WithTx runs fn in a database transaction
+func (u *UserStorage) WithTx(ctx context.Context, fn func(tx IUserStorage) error) error {
+	return u.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&UserStorage{DB: tx})
+	})
+}
+
+// LockUser locks the user row with SELECT ... FOR UPDATE.
+// sqlite has no row locks, its transactions are serialized by the single connection instead.
+func (u *UserStorage) LockUser(ctx context.Context, id string) error {
+	db := u.DB.WithContext(ctx)
+	if !database.IsSQLite(db) {
+		db = db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var user User
+	return db.Select("id").Where("id = ?", id).First(&user).Error
+}
+
+// FlagUser creates a new flag record against the infected user, the evidence is optional
+func (u *UserStorage) FlagUser(ctx context.Context, id string, infectedUser string, evidence *FlagEvidence) error {
 	// Not sure if user can flag themselves as infected
 	if id == infectedUser {
 		return nil
 	}
+	var count int64
+	err := u.DB.WithContext(ctx).Model(&FlagMonitor{}).
+		Where("user_id = ? AND infected_user_id = ?", id, infectedUser).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicateFlag
+	}
 	f := FlagMonitor{
 		ID:             uuid.NewString(),
 		UserID:         id,
 		InfectedUserID: infectedUser,
 	}
-	return u.DB.Create(&f).Error
+	if evidence != nil {
+		f.FlagEvidence = *evidence
+	}
+	if f.Severity == "" {
+		f.Severity = SeverityMedium
+	}
+	err = u.DB.WithContext(ctx).Create(&f).Error
+	// the same flag raised concurrently gets past the check above, the unique index rejects all but one of them
+	if database.IsUniqueViolation(err) {
+		return ErrDuplicateFlag
+	}
+	return err
+}
+
+// FindFlags implements IUserStorage
+func (u *UserStorage) FindFlags(ctx context.Context, infectedUser string) ([]*FlagMonitor, error) {
+	var res []*FlagMonitor
+	err := u.DB.WithContext(ctx).Where("infected_user_id = ?", infectedUser).Order("created_at, id").Find(&res).Error
+	return res, err
+}
+
+// FindFlagsSince implements IUserStorage
+func (u *UserStorage) FindFlagsSince(ctx context.Context, since time.Time) ([]*FlagMonitor, error) {
+	var res []*FlagMonitor
+	err := u.DB.WithContext(ctx).Where("created_at >= ?", since).Order("created_at, id").Find(&res).Error
+	return res, err
 }
 
 // UpdateInfectedStatus implements IUserStorage
 func (u *UserStorage) UpdateInfectedStatus(ctx context.Context, id string) error {
-	return u.DB.Model(&User{}).Where("id = ?", id).Update("infected", true).Error
+	return u.UpdateStatus(ctx, id, StatusInfected)
+}
+
+// UpdateStatus implements IUserStorage
+func (u *UserStorage) UpdateStatus(ctx context.Context, id string, status Status) error {
+	res := u.DB.WithContext(ctx).Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":   status,
+		"infected": status.Infected(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return u.exists(ctx, id)
+	}
+	return nil
+}
+
+// DeleteFlag removes the flag the user raised against the infected user.
+// Flags are deleted for good so the user can flag the same survivor again later.
+func (u *UserStorage) DeleteFlag(ctx context.Context, userID, infectedUser string) error {
+	res := u.DB.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND infected_user_id = ?", userID, infectedUser).
+		Delete(&FlagMonitor{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteFlags removes every flag raised against the infected user and returns how many there were
+func (u *UserStorage) DeleteFlags(ctx context.Context, infectedUser string) (int64, error) {
+	res := u.DB.WithContext(ctx).Unscoped().Where("infected_user_id = ?", infectedUser).Delete(&FlagMonitor{})
+	return res.RowsAffected, res.Error
+}
+
+// AddRejectedFlags implements IUserStorage
+func (u *UserStorage) AddRejectedFlags(ctx context.Context, userIDs ...string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return u.DB.WithContext(ctx).Model(&User{}).Where("id IN (?)", userIDs).
+		UpdateColumn("rejected_flags", gorm.Expr("rejected_flags + ?", 1)).Error
+}
+
+// FindByReputation implements IUserStorage.
+// The inputs are counted and the users filtered, ordered and limited by their score in a single query, a trade is every
+// transaction sharing the same reference. The score is clamped but not rounded in SQL, so it's scored again on the result.
+func (u *UserStorage) FindByReputation(ctx context.Context, q ReputationQuery) ([]*ScoredUser, error) {
+	db := u.DB.WithContext(ctx)
+	now := time.Now()
+
+	sellers := db.Table("transactions").Select("seller_id AS participant, reference").Where("deleted_at IS NULL")
+	buyers := db.Table("transactions").Select("buyer_id AS participant, reference").Where("deleted_at IS NULL")
+	if len(q.IDs) > 0 {
+		sellers, buyers = sellers.Where("seller_id IN ?", q.IDs), buyers.Where("buyer_id IN ?", q.IDs)
+	}
+	participants := db.Raw("? UNION ALL ?", sellers, buyers)
+	trades := db.Table("(?) AS participants", participants).
+		Select("participant, COUNT(DISTINCT reference) AS total").
+		Group("participant")
+	accurate := db.Model(&FlagMonitor{}).
+		Select("flag_monitors.user_id, COUNT(*) AS total").
+		Joins("JOIN users AS targets ON targets.id = flag_monitors.infected_user_id AND targets.deleted_at IS NULL").
+		Where("targets.status IN ?", AccurateStatuses()).
+		Group("flag_monitors.user_id")
+	received := db.Model(&FlagMonitor{}).
+		Select("infected_user_id, COUNT(*) AS total").
+		Group("infected_user_id")
+
+	inputs := db.Model(&User{}).
+		Select("users.id, COALESCE(trades.total, 0) AS trades, COALESCE(accurate.total, 0) AS accurate_flags, "+
+			"users.rejected_flags, COALESCE(received.total, 0) AS flags_received, ? - "+u.epochExpr("users.created_at")+" AS age",
+			now.Unix()).
+		Joins("LEFT JOIN (?) AS trades ON trades.participant = users.id", trades).
+		Joins("LEFT JOIN (?) AS accurate ON accurate.user_id = users.id", accurate).
+		Joins("LEFT JOIN (?) AS received ON received.infected_user_id = users.id", received)
+	if len(q.IDs) > 0 {
+		inputs = inputs.Where("users.id IN ?", q.IDs)
+	}
+	if len(q.Statuses) > 0 {
+		inputs = inputs.Where("users.status IN ?", q.Statuses)
+	}
+	if q.ExcludeID != "" {
+		inputs = inputs.Where("users.id <> ?", q.ExcludeID)
+	}
+
+	score := scoreExpr(q.Weights)
+	query := db.Table("(?) AS inputs", inputs).Select("inputs.*")
+	if q.MinReputation > reputation.MinScore {
+		// the scores are rounded to two decimals, anything rounding up to the minimum is in
+		query = query.Where(score+" >= ?", q.MinReputation-0.005)
+	}
+	query = query.Order(score + " DESC, id")
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	var rows []struct {
+		ID            string
+		Trades        int
+		AccurateFlags int
+		FlagsReceived int
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []*ScoredUser{}, nil
+	}
+
+	ids := make([]string, 0, len(rows))
+	for _, v := range rows {
+		ids = append(ids, v.ID)
+	}
+	users, err := u.FindUsers(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*ScoredUser, 0, len(rows))
+	for _, v := range rows {
+		usr, ok := users[v.ID]
+		if !ok {
+			continue
+		}
+		in := reputation.Inputs{
+			Trades:        v.Trades,
+			AccurateFlags: v.AccurateFlags,
+			RejectedFlags: usr.RejectedFlags,
+			FlagsReceived: v.FlagsReceived,
+			AccountAge:    now.Sub(usr.CreatedAt),
+		}
+		scored := &ScoredUser{User: usr, Inputs: in, Reputation: q.Weights.Score(in)}
+		if scored.Reputation >= q.MinReputation {
+			res = append(res, scored)
+		}
+	}
+	return res, nil
+}
+
+// epochExpr returns the SQL expression of the time in column as seconds since the epoch
+func (u *UserStorage) epochExpr(column string) string {
+	switch u.DB.Dialector.Name() {
+	case "postgres":
+		return fmt.Sprintf("CAST(EXTRACT(EPOCH FROM %s) AS BIGINT)", column)
+	case "mysql":
+		return fmt.Sprintf("UNIX_TIMESTAMP(%s)", column)
+	default:
+		return fmt.Sprintf("CAST(strftime('%%s', %s) AS INTEGER)", column)
+	}
+}
+
+// scoreExpr returns the SQL expression of reputation.Weights.Score over the columns of FindByReputation, without the rounding
+func scoreExpr(w reputation.Weights) string {
+	trades := "trades"
+	if w.MaxTrades > 0 {
+		trades = fmt.Sprintf("CASE WHEN trades > %d THEN %d ELSE trades END", w.MaxTrades, w.MaxTrades)
+	}
+	age := "CASE WHEN age < 0 THEN 0 ELSE age END"
+	if w.MaxAccountAge > 0 {
+		max := int64(w.MaxAccountAge / time.Second)
+		age = fmt.Sprintf("CASE WHEN age < 0 THEN 0 WHEN age > %d THEN %d ELSE age END", max, max)
+	}
+	raw := fmt.Sprintf("(%s + %s * (%s) + %s * accurate_flags + %s * rejected_flags + %s * flags_received + %s * (%s) / 86400.0)",
+		sqlFloat(w.Base), sqlFloat(w.Trade), trades, sqlFloat(w.AccurateFlag), sqlFloat(w.RejectedFlag),
+		sqlFloat(w.FlagReceived), sqlFloat(w.AccountDay), age)
+	return fmt.Sprintf("(CASE WHEN %s < %d THEN %d WHEN %s > %d THEN %d ELSE %s END)",
+		raw, reputation.MinScore, reputation.MinScore, raw, reputation.MaxScore, reputation.MaxScore, raw)
+}
+
+func sqlFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
 }
 
 // Find implements IUserStorage
@@ -78,11 +327,548 @@ func (u *UserStorage) FindByEmail(ctx context.Context, email string) (*User, err
 	return user, err
 }
 
+// FindByStatus implements IUserStorage
+func (u *UserStorage) FindByStatus(ctx context.Context, statuses ...Status) ([]*User, error) {
+	var users []*User
+	err := u.DB.WithContext(ctx).Preload("FlagMonitor").Where("status IN (?)", statuses).Order("created_at, id").Find(&users).Error
+	return users, err
+}
+
 // UpdateLocation implements IUserStorage
 func (u *UserStorage) UpdateLocation(ctx context.Context, id string, lat float64, long float64) error {
 	d := map[string]interface{}{
 		"latitude":  lat,
 		"longitude": long,
 	}
-	return u.DB.Model(&User{}).Where("id = ?", id).Updates(d).Error
+	res := u.DB.Model(&User{}).Where("id = ?", id).Updates(d)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return u.exists(ctx, id)
+	}
+	return nil
+}
+
+// UpdateVisibility implements IUserStorage
+func (u *UserStorage) UpdateVisibility(ctx context.Context, id string, visibility Visibility) error {
+	res := u.DB.WithContext(ctx).Model(&User{}).Where("id = ?", id).Update("visibility", visibility)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return u.exists(ctx, id)
+	}
+	return nil
+}
+
+// UpdateRole implements IUserStorage
+func (u *UserStorage) UpdateRole(ctx context.Context, id string, role Role) error {
+	res := u.DB.WithContext(ctx).Model(&User{}).Where("id = ?", id).Update("role", role)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return u.exists(ctx, id)
+	}
+	return nil
+}
+
+// CreateLocationAudits implements IUserStorage
+func (u *UserStorage) CreateLocationAudits(ctx context.Context, audits ...*LocationAudit) error {
+	if len(audits) == 0 {
+		return nil
+	}
+	for _, v := range audits {
+		v.ID = uuid.NewString()
+	}
+	return u.DB.WithContext(ctx).Create(audits).Error
+}
+
+// FindLocationAudits implements IUserStorage
+func (u *UserStorage) FindLocationAudits(ctx context.Context, userID string) ([]*LocationAudit, error) {
+	var res []*LocationAudit
+	err := u.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC, id").Find(&res).Error
+	return res, err
+}
+
+// FindNearby returns every survivor within radiusKm of the center, only the survivors in one of the statuses if any are given
+func (u *UserStorage) FindNearby(ctx context.Context, center geo.Point, radiusKm float64, statuses ...Status) ([]*User, error) {
+	var (
+		users  []*User
+		result []*User
+	)
+	box := geo.BoundingBox(center, radiusKm)
+	query := u.DB.WithContext(ctx).
+		Where("latitude BETWEEN ? AND ?", box.MinLatitude, box.MaxLatitude).
+		Where("longitude BETWEEN ? AND ?", box.MinLongitude, box.MaxLongitude)
+	if len(statuses) > 0 {
+		query = query.Where("status IN (?)", statuses)
+	}
+	err := query.Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	// the box is only an approximation of the circle
+	for _, v := range users {
+		if geo.Within(center, geo.Point{Latitude: v.Latitude, Longitude: v.Longitude}, radiusKm) {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// CreateInfectionAudit records an infection policy decision
+func (u *UserStorage) CreateInfectionAudit(ctx context.Context, audit *InfectionAudit) error {
+	audit.ID = uuid.NewString()
+	return u.DB.WithContext(ctx).Create(audit).Error
+}
+
+// FindInfectionAudits returns the audit trail of the given user, oldest first
+func (u *UserStorage) FindInfectionAudits(ctx context.Context, userID string) ([]*InfectionAudit, error) {
+	var res []*InfectionAudit
+	err := u.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at, id").Find(&res).Error
+	return res, err
+}
+
+// FindPendingTransitions returns the status changes whose effects haven't been applied yet, oldest first.
+// The changes of every survivor are returned when no user is given.
+func (u *UserStorage) FindPendingTransitions(ctx context.Context, userID string) ([]*StatusTransition, error) {
+	var res []*StatusTransition
+	q := u.DB.WithContext(ctx).Where("applied_at IS NULL")
+	if userID != "" {
+		q = q.Where("user_id = ?", userID)
+	}
+	err := q.Order("created_at, id").Find(&res).Error
+	return res, err
+}
+
+// MarkTransitionApplied records that the effects of the status change have been applied
+func (u *UserStorage) MarkTransitionApplied(ctx context.Context, id string) error {
+	return u.DB.WithContext(ctx).Model(&StatusTransition{}).Where("id = ?", id).Update("applied_at", time.Now()).Error
+}
+
+// CreateAppeal creates a new pending appeal
+func (u *UserStorage) CreateAppeal(ctx context.Context, appeal *Appeal) error {
+	appeal.ID = uuid.NewString()
+	if appeal.Status == "" {
+		appeal.Status = AppealPending
+	}
+	return u.DB.WithContext(ctx).Omit("Votes").Create(appeal).Error
+}
+
+// FindAppeal returns the appeal together with its votes
+func (u *UserStorage) FindAppeal(ctx context.Context, id string) (*Appeal, error) {
+	var appeal *Appeal
+	err := u.DB.WithContext(ctx).Preload("Votes").Where("id = ?", id).First(&appeal).Error
+	return appeal, err
+}
+
+// FindUserAppeals returns every appeal of the user, oldest first
+func (u *UserStorage) FindUserAppeals(ctx context.Context, userID string) ([]*Appeal, error) {
+	var res []*Appeal
+	err := u.DB.WithContext(ctx).Preload("Votes").Where("user_id = ?", userID).Order("created_at, id").Find(&res).Error
+	return res, err
+}
+
+// ResolveAppeal records the outcome of an appeal
+func (u *UserStorage) ResolveAppeal(ctx context.Context, id string, status AppealStatus, resolvedBy string) error {
+	now := time.Now()
+	res := u.DB.WithContext(ctx).Model(&Appeal{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      status,
+		"resolved_by": resolvedBy,
+		"resolved_at": &now,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateAppealVote records a vote, every survivor can only vote once per appeal
+func (u *UserStorage) CreateAppealVote(ctx context.Context, vote *AppealVote) error {
+	var count int64
+	err := u.DB.WithContext(ctx).Model(&AppealVote{}).
+		Where("appeal_id = ? AND voter_id = ?", vote.AppealID, vote.VoterID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicateVote
+	}
+	vote.ID = uuid.NewString()
+	return u.DB.WithContext(ctx).Create(vote).Error
+}
+
+// CreateStatusTransition records a status change
+func (u *UserStorage) CreateStatusTransition(ctx context.Context, transition *StatusTransition) error {
+	transition.ID = uuid.NewString()
+	return u.DB.WithContext(ctx).Create(transition).Error
+}
+
+// FindStatusTransitions returns the status history of the given user, oldest first
+func (u *UserStorage) FindStatusTransitions(ctx context.Context, userID string) ([]*StatusTransition, error) {
+	var res []*StatusTransition
+	err := u.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at, id").Find(&res).Error
+	return res, err
+}
+
+// SaveCollusionReview implements IUserStorage
+func (u *UserStorage) SaveCollusionReview(ctx context.Context, review *CollusionReview) error {
+	var existing CollusionReview
+	res := u.DB.WithContext(ctx).Where("user_id = ?", review.UserID).Limit(1).Find(&existing)
+	switch {
+	case res.Error != nil:
+		return res.Error
+	case res.RowsAffected == 0:
+		review.ID = uuid.NewString()
+		review.Status = ReviewPending
+		return u.DB.WithContext(ctx).Create(review).Error
+	case existing.Status != ReviewPending:
+		*review = existing
+		return nil
+	}
+	err := u.DB.WithContext(ctx).Model(&existing).Updates(map[string]interface{}{
+		"signals": review.Signals,
+		"targets": review.Targets,
+		"score":   review.Score,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return u.DB.WithContext(ctx).Where("id = ?", existing.ID).First(review).Error
+}
+
+// FindCollusionReview implements IUserStorage
+func (u *UserStorage) FindCollusionReview(ctx context.Context, id string) (*CollusionReview, error) {
+	var review *CollusionReview
+	err := u.DB.WithContext(ctx).Where("id = ?", id).First(&review).Error
+	return review, err
+}
+
+// FindCollusionReviews implements IUserStorage
+func (u *UserStorage) FindCollusionReviews(ctx context.Context, status ReviewStatus) ([]*CollusionReview, error) {
+	var res []*CollusionReview
+	db := u.DB.WithContext(ctx)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	err := db.Order("created_at, id").Find(&res).Error
+	return res, err
+}
+
+// FindUserCollusionReviews implements IUserStorage
+func (u *UserStorage) FindUserCollusionReviews(ctx context.Context, userIDs ...string) (map[string]*CollusionReview, error) {
+	var reviews []*CollusionReview
+	result := make(map[string]*CollusionReview)
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+	if err := u.DB.WithContext(ctx).Where("user_id IN (?)", userIDs).Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range reviews {
+		result[v.UserID] = v
+	}
+	return result, nil
+}
+
+// ResolveCollusionReview implements IUserStorage
+func (u *UserStorage) ResolveCollusionReview(ctx context.Context, id string, status ReviewStatus, reviewedBy string) error {
+	now := time.Now()
+	res := u.DB.WithContext(ctx).Model(&CollusionReview{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      status,
+		"reviewed_by": reviewedBy,
+		"reviewed_at": &now,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// exists returns gorm.ErrRecordNotFound if there's no user with the given ID.
+// Some drivers report zero affected rows when an update doesn't change anything, so we can't rely on that alone.
+func (u *UserStorage) exists(ctx context.Context, id string) error {
+	var user User
+	return u.DB.Select("id").Where("id = ?", id).First(&user).Error
+}
+
+// CreateLocationPoint implements IUserStorage
+func (u *UserStorage) CreateLocationPoint(ctx context.Context, point *LocationPoint) error {
+	point.ID = uuid.NewString()
+	return u.DB.WithContext(ctx).Create(point).Error
+}
+
+// FindLocationHistory implements IUserStorage
+func (u *UserStorage) FindLocationHistory(ctx context.Context, userID string, from, to time.Time) ([]*LocationPoint, error) {
+	var res []*LocationPoint
+	query := u.DB.WithContext(ctx).Where("user_id = ?", userID)
+	if !from.IsZero() {
+		query = query.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("created_at <= ?", to)
+	}
+	err := query.Order("created_at, id").Find(&res).Error
+	return res, err
+}
+
+// DeleteLocationPoints implements IUserStorage
+func (u *UserStorage) DeleteLocationPoints(ctx context.Context, before time.Time) (int64, error) {
+	res := u.DB.WithContext(ctx).Where("created_at < ?", before).Delete(&LocationPoint{})
+	return res.RowsAffected, res.Error
+}
+
+// downsampleBatch number of points deleted per statement while downsampling
+const downsampleBatch = 500
+
+// DownsampleLocationPoints implements IUserStorage.
+// The points are thinned out one user at a time, keeping the oldest point and every point at least interval after the last kept one.
+func (u *UserStorage) DownsampleLocationPoints(ctx context.Context, before time.Time, interval time.Duration) (int64, error) {
+	db := u.DB.WithContext(ctx)
+	var userIDs []string
+	err := db.Model(&LocationPoint{}).Where("created_at < ?", before).Distinct().Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, userID := range userIDs {
+		var points []*LocationPoint
+		err := db.Select("id, created_at").Where("user_id = ? AND created_at < ?", userID, before).
+			Order("created_at, id").Find(&points).Error
+		if err != nil {
+			return deleted, err
+		}
+		var (
+			ids  []string
+			kept time.Time
+		)
+		for i, v := range points {
+			if i == 0 || v.CreatedAt.Sub(kept) >= interval {
+				kept = v.CreatedAt
+				continue
+			}
+			ids = append(ids, v.ID)
+		}
+		for len(ids) > 0 {
+			n := len(ids)
+			if n > downsampleBatch {
+				n = downsampleBatch
+			}
+			res := db.Where("id IN (?)", ids[:n]).Delete(&LocationPoint{})
+			if res.Error != nil {
+				return deleted, res.Error
+			}
+			deleted += res.RowsAffected
+			ids = ids[n:]
+		}
+	}
+	return deleted, nil
+}
+
+// FindLastLocationPoints implements IUserStorage
+func (u *UserStorage) FindLastLocationPoints(ctx context.Context, from, to time.Time) (map[string]*LocationPoint, error) {
+	db := u.DB.WithContext(ctx)
+	latest := db.Model(&LocationPoint{}).Select("user_id, MAX(created_at) AS created_at").Group("user_id")
+	if !from.IsZero() {
+		latest = latest.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		latest = latest.Where("created_at <= ?", to)
+	}
+	var points []*LocationPoint
+	err := db.Table("location_history AS lh").Select("lh.*").
+		Joins("JOIN (?) AS latest ON latest.user_id = lh.user_id AND latest.created_at = lh.created_at", latest).
+		Order("lh.created_at, lh.id").
+		Find(&points).Error
+	if err != nil {
+		return nil, err
+	}
+	// points recorded at the same time are told apart by their ID
+	res := make(map[string]*LocationPoint, len(points))
+	for _, v := range points {
+		res[v.UserID] = v
+	}
+	return res, nil
+}
+
+// FindLocationPointsInBox implements IUserStorage
+func (u *UserStorage) FindLocationPointsInBox(ctx context.Context, box geo.Box, from, to time.Time) ([]*LocationPoint, error) {
+	var points []*LocationPoint
+	err := u.DB.WithContext(ctx).
+		Where("created_at BETWEEN ? AND ?", from, to).
+		Where("latitude BETWEEN ? AND ?", box.MinLatitude, box.MaxLatitude).
+		Where("longitude BETWEEN ? AND ?", box.MinLongitude, box.MaxLongitude).
+		Order("created_at, id").
+		Find(&points).Error
+	return points, err
+}
+
+// CreateNotification implements IUserStorage
+func (u *UserStorage) CreateNotification(ctx context.Context, n *Notification) error {
+	n.ID = uuid.NewString()
+	return u.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(n).Error
+}
+
+// FindNotifications implements IUserStorage
+func (u *UserStorage) FindNotifications(ctx context.Context, userID string, unreadOnly bool) ([]*Notification, error) {
+	var res []*Notification
+	query := u.DB.WithContext(ctx).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Order("created_at DESC, id").Find(&res).Error
+	return res, err
+}
+
+// MarkNotificationRead implements IUserStorage
+func (u *UserStorage) MarkNotificationRead(ctx context.Context, userID, id string) error {
+	var n Notification
+	if err := u.DB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&n).Error; err != nil {
+		return err
+	}
+	if n.ReadAt != nil {
+		return nil
+	}
+	return u.DB.WithContext(ctx).Model(&n).Update("read_at", time.Now()).Error
+}
+
+// FindInBox implements IUserStorage
+func (u *UserStorage) FindInBox(ctx context.Context, box geo.Box) ([]*User, error) {
+	var users []*User
+	err := u.DB.WithContext(ctx).
+		Where("latitude BETWEEN ? AND ?", box.MinLatitude, box.MaxLatitude).
+		Where("longitude BETWEEN ? AND ?", box.MinLongitude, box.MaxLongitude).
+		Order("created_at, id").
+		Find(&users).Error
+	return users, err
+}
+
+// CreateZone implements IUserStorage
+func (u *UserStorage) CreateZone(ctx context.Context, zone *Zone) error {
+	zone.ID = uuid.NewString()
+	box := zone.Box()
+	zone.MinLatitude, zone.MaxLatitude = box.MinLatitude, box.MaxLatitude
+	zone.MinLongitude, zone.MaxLongitude = box.MinLongitude, box.MaxLongitude
+	return u.DB.WithContext(ctx).Create(zone).Error
+}
+
+// FindZone implements IUserStorage
+func (u *UserStorage) FindZone(ctx context.Context, id string) (*Zone, error) {
+	var zone Zone
+	if err := u.DB.WithContext(ctx).Where("id = ?", id).First(&zone).Error; err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+// FindZones implements IUserStorage
+func (u *UserStorage) FindZones(ctx context.Context) ([]*Zone, error) {
+	var res []*Zone
+	err := u.DB.WithContext(ctx).Order("name, id").Find(&res).Error
+	return res, err
+}
+
+// DeleteZone implements IUserStorage
+func (u *UserStorage) DeleteZone(ctx context.Context, id string) error {
+	res := u.DB.WithContext(ctx).Where("id = ?", id).Delete(&Zone{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return u.DB.WithContext(ctx).Where("zone_id = ?", id).Delete(&ZoneMember{}).Error
+}
+
+// FindZonesAt implements IUserStorage
+func (u *UserStorage) FindZonesAt(ctx context.Context, p geo.Point) ([]*Zone, error) {
+	var (
+		zones  []*Zone
+		result []*Zone
+	)
+	err := u.DB.WithContext(ctx).
+		Where("min_latitude <= ? AND max_latitude >= ?", p.Latitude, p.Latitude).
+		Where("min_longitude <= ? AND max_longitude >= ?", p.Longitude, p.Longitude).
+		Order("name, id").
+		Find(&zones).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range zones {
+		if v.Contains(p) {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// FindUserZones implements IUserStorage
+func (u *UserStorage) FindUserZones(ctx context.Context, userIDs ...string) (map[string][]*Zone, error) {
+	res := make(map[string][]*Zone)
+	if len(userIDs) == 0 {
+		return res, nil
+	}
+	var members []*ZoneMember
+	err := u.DB.WithContext(ctx).Where("user_id IN (?)", userIDs).Find(&members).Error
+	if err != nil || len(members) == 0 {
+		return res, err
+	}
+	zoneIDs := make([]string, 0, len(members))
+	for _, v := range members {
+		zoneIDs = append(zoneIDs, v.ZoneID)
+	}
+	var zones []*Zone
+	if err := u.DB.WithContext(ctx).Where("id IN (?)", zoneIDs).Order("name, id").Find(&zones).Error; err != nil {
+		return nil, err
+	}
+	byZone := make(map[string][]string)
+	for _, v := range members {
+		byZone[v.ZoneID] = append(byZone[v.ZoneID], v.UserID)
+	}
+	for _, z := range zones {
+		for _, id := range byZone[z.ID] {
+			res[id] = append(res[id], z)
+		}
+	}
+	return res, nil
+}
+
+// AddZoneMember implements IUserStorage
+func (u *UserStorage) AddZoneMember(ctx context.Context, member *ZoneMember) error {
+	return u.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error
+}
+
+// DeleteZoneMember implements IUserStorage
+func (u *UserStorage) DeleteZoneMember(ctx context.Context, userID, zoneID string) error {
+	return u.DB.WithContext(ctx).Where("user_id = ? AND zone_id = ?", userID, zoneID).Delete(&ZoneMember{}).Error
+}
+
+// CreateZoneEvent implements IUserStorage
+func (u *UserStorage) CreateZoneEvent(ctx context.Context, event *ZoneEvent) error {
+	event.ID = uuid.NewString()
+	return u.DB.WithContext(ctx).Create(event).Error
+}
+
+// FindZoneEvents implements IUserStorage
+func (u *UserStorage) FindZoneEvents(ctx context.Context, zoneID string, from, to time.Time) ([]*ZoneEvent, error) {
+	var res []*ZoneEvent
+	query := u.DB.WithContext(ctx).Where("zone_id = ?", zoneID)
+	if !from.IsZero() {
+		query = query.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("created_at <= ?", to)
+	}
+	err := query.Order("created_at, id").Find(&res).Error
+	return res, err
 }
@@ -2,14 +2,18 @@ package store
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
+	"time"
+
+	"zssn/database"
+	"zssn/database/migrations"
 
 	"github.com/brianvoe/gofakeit"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
@@ -43,6 +47,18 @@ func TestNewStorageService(t *testing.T) {
 	assert.IsType(t, &UserStorage{}, svc)
 }
 
+func TestNewStorageWithOutdatedSchema(t *testing.T) {
+	d, err := database.Open(database.TestConfig())
+	require.NoError(t, err)
+	if !database.IsSQLite(d) {
+		t.Skip("shared databases are already migrated")
+	}
+
+	svc, err := New(d)
+	require.True(t, errors.Is(err, migrations.ErrSchemaOutdated))
+	assert.Nil(t, svc)
+}
+
 func TestCreateNewUser(t *testing.T) {
 	ctx := context.Background()
 	u := &User{
@@ -125,7 +141,7 @@ func TestFlagUser(t *testing.T) {
 	flagger := newUser(t)
 	require.NoError(t, storage.Create(ctx, flagger))
 
-	err := storage.FlagUser(ctx, flagger.ID, infectedUser.ID)
+	err := storage.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
 	require.NoError(t, err)
 
 	res, err := storage.Find(ctx, infectedUser.ID)
@@ -134,13 +150,13 @@ func TestFlagUser(t *testing.T) {
 	assert.Len(t, res.FlagMonitor, 1)
 
 	// lets try to flag from the same user, should result in an error
-	err = storage.FlagUser(ctx, flagger.ID, infectedUser.ID)
+	err = storage.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
 	require.NotNil(t, err)
 
 	anotherFlagger := newUser(t)
 	require.NoError(t, storage.Create(ctx, anotherFlagger))
 
-	err = storage.FlagUser(ctx, anotherFlagger.ID, infectedUser.ID)
+	err = storage.FlagUser(ctx, anotherFlagger.ID, infectedUser.ID, nil)
 	require.NoError(t, err)
 
 	res, err = storage.Find(ctx, infectedUser.ID)
@@ -154,10 +170,35 @@ func TestRandomUserCannotFlag(t *testing.T) {
 	infectedUser := newUser(t)
 	require.NoError(t, storage.Create(ctx, infectedUser))
 
-	err := storage.FlagUser(ctx, uuid.NewString(), infectedUser.ID)
+	err := storage.FlagUser(ctx, uuid.NewString(), infectedUser.ID, nil)
 	require.NotNil(t, err)
 }
 
+func TestConcurrentDuplicateFlag(t *testing.T) {
+	ctx := context.Background()
+	d, err := database.OpenTest()
+	require.NoError(t, err)
+	st, err := New(d)
+	require.NoError(t, err)
+	infectedUser, flagger := newUser(t), newUser(t)
+	require.NoError(t, st.Create(ctx, infectedUser))
+	require.NoError(t, st.Create(ctx, flagger))
+
+	// the same flag is saved by someone else between the duplicate check and the insert
+	require.NoError(t, d.Callback().Create().Before("gorm:begin_transaction").Register("test:concurrent_flag", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*FlagMonitor); ok {
+			d.Exec("INSERT INTO flag_monitors (id, user_id, infected_user_id, severity, created_at) VALUES (?, ?, ?, ?, ?)",
+				uuid.NewString(), flagger.ID, infectedUser.ID, SeverityMedium, time.Now())
+		}
+	}))
+	err = st.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
+	require.True(t, errors.Is(err, ErrDuplicateFlag))
+
+	flags, err := st.FindFlags(ctx, infectedUser.ID)
+	require.NoError(t, err)
+	assert.Len(t, flags, 1)
+}
+
 func TestFindMultipleUsers(t *testing.T) {
 	ctx := context.Background()
 	ids := []string{}
@@ -212,7 +253,7 @@ func TestUpdateRandomNonExistingUser(t *testing.T) {
 	infectedUser := newUser(t)
 
 	err := storage.UpdateInfectedStatus(ctx, infectedUser.ID)
-	require.NoError(t, err)
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
 
 	res, err := storage.Find(ctx, infectedUser.ID)
 	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
@@ -252,7 +293,7 @@ func TestUpdateLocationForNonExistingUser(t *testing.T) {
 
 	newLat, newLong := gofakeit.Latitude(), gofakeit.Longitude()
 	err := storage.UpdateLocation(ctx, randomUser.ID, newLat, newLong)
-	require.NoError(t, err)
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
 
 	res, err := storage.Find(ctx, randomUser.ID)
 	require.NotNil(t, err)
@@ -260,17 +301,36 @@ func TestUpdateLocationForNonExistingUser(t *testing.T) {
 	assert.EqualError(t, err, gorm.ErrRecordNotFound.Error())
 }
 
+func TestWithTxRollback(t *testing.T) {
+	ctx := context.Background()
+	u, flagger := newUser(t), newUser(t)
+	require.NoError(t, storage.Create(ctx, u))
+	require.NoError(t, storage.Create(ctx, flagger))
+
+	errTx := errors.New("rollback")
+	err := storage.WithTx(ctx, func(tx IUserStorage) error {
+		if err := tx.FlagUser(ctx, flagger.ID, u.ID, nil); err != nil {
+			return err
+		}
+		if err := tx.UpdateInfectedStatus(ctx, u.ID); err != nil {
+			return err
+		}
+		return errTx
+	})
+	require.Equal(t, errTx, err)
+
+	res, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.False(t, res.Infected)
+	assert.Empty(t, res.FlagMonitor)
+}
+
 func fullName() string {
 	return gofakeit.FirstName() + " " + gofakeit.LastName()
 }
 
 func setupTestDB() (*gorm.DB, error) {
-	env := os.Getenv("ENVIRONMENT")
-	dsn := "root:@tcp(127.0.0.1:3306)/zssn?charset=utf8mb4&parseTime=True&loc=Local"
-	if env == "cicd" {
-		dsn = "zssn_user:password@tcp(127.0.0.1:33306)/zssn?charset=utf8mb4&parseTime=True&loc=Local"
-	}
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	return database.OpenTest()
 }
 
 func cleanup() {
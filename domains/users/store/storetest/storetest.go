@@ -0,0 +1,1078 @@
+// Package storetest contains the behavioural contract every IUserStorage implementation has to satisfy.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"zssn/domains/geo"
+	"zssn/domains/reputation"
+	"zssn/domains/users/store"
+
+	"github.com/brianvoe/gofakeit"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Factory returns the storage implementation under test
+type Factory func(t *testing.T) store.IUserStorage
+
+// Run runs the conformance suite against the storage returned by newStorage
+func Run(t *testing.T, newStorage Factory) {
+	t.Run("Create", func(t *testing.T) { testCreate(t, newStorage(t)) })
+	t.Run("CreateDuplicateEmail", func(t *testing.T) { testCreateDuplicateEmail(t, newStorage(t)) })
+	t.Run("Find", func(t *testing.T) { testFind(t, newStorage(t)) })
+	t.Run("FindUsers", func(t *testing.T) { testFindUsers(t, newStorage(t)) })
+	t.Run("FindByEmail", func(t *testing.T) { testFindByEmail(t, newStorage(t)) })
+	t.Run("UpdateLocation", func(t *testing.T) { testUpdateLocation(t, newStorage(t)) })
+	t.Run("UpdateInfectedStatus", func(t *testing.T) { testUpdateInfectedStatus(t, newStorage(t)) })
+	t.Run("FlagUser", func(t *testing.T) { testFlagUser(t, newStorage(t)) })
+	t.Run("SelfFlagIsIgnored", func(t *testing.T) { testSelfFlag(t, newStorage(t)) })
+	t.Run("FlagUnknownUsers", func(t *testing.T) { testFlagUnknownUsers(t, newStorage(t)) })
+	t.Run("FindFlags", func(t *testing.T) { testFindFlags(t, newStorage(t)) })
+	t.Run("FindNearby", func(t *testing.T) { testFindNearby(t, newStorage(t)) })
+	t.Run("InfectionAudits", func(t *testing.T) { testInfectionAudits(t, newStorage(t)) })
+	t.Run("WithTx", func(t *testing.T) { testWithTx(t, newStorage(t)) })
+	t.Run("UpdateStatus", func(t *testing.T) { testUpdateStatus(t, newStorage(t)) })
+	t.Run("DeleteFlag", func(t *testing.T) { testDeleteFlag(t, newStorage(t)) })
+	t.Run("DeleteFlags", func(t *testing.T) { testDeleteFlags(t, newStorage(t)) })
+	t.Run("Appeals", func(t *testing.T) { testAppeals(t, newStorage(t)) })
+	t.Run("StatusTransitions", func(t *testing.T) { testStatusTransitions(t, newStorage(t)) })
+	t.Run("FindFlagsSince", func(t *testing.T) { testFindFlagsSince(t, newStorage(t)) })
+	t.Run("CollusionReviews", func(t *testing.T) { testCollusionReviews(t, newStorage(t)) })
+	t.Run("FindByStatus", func(t *testing.T) { testFindByStatus(t, newStorage(t)) })
+	t.Run("AddRejectedFlags", func(t *testing.T) { testAddRejectedFlags(t, newStorage(t)) })
+	t.Run("FindByReputation", func(t *testing.T) { testFindByReputation(t, newStorage(t)) })
+	t.Run("LocationHistory", func(t *testing.T) { testLocationHistory(t, newStorage(t)) })
+	t.Run("FindLocationPointsInBox", func(t *testing.T) { testFindLocationPointsInBox(t, newStorage(t)) })
+	t.Run("FindLastLocationPoints", func(t *testing.T) { testFindLastLocationPoints(t, newStorage(t)) })
+	t.Run("LocationPrivacy", func(t *testing.T) { testLocationPrivacy(t, newStorage(t)) })
+	t.Run("UpdateRole", func(t *testing.T) { testUpdateRole(t, newStorage(t)) })
+	t.Run("Notifications", func(t *testing.T) { testNotifications(t, newStorage(t)) })
+	t.Run("FindInBox", func(t *testing.T) { testFindInBox(t, newStorage(t)) })
+	t.Run("Zones", func(t *testing.T) { testZones(t, newStorage(t)) })
+	t.Run("ZoneMembers", func(t *testing.T) { testZoneMembers(t, newStorage(t)) })
+	t.Run("ZoneEvents", func(t *testing.T) { testZoneEvents(t, newStorage(t)) })
+}
+
+func testCreate(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u := NewUser(t)
+	require.NoError(t, storage.Create(ctx, u))
+	assert.NotEmpty(t, u.ID)
+
+	res, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u.Email, res.Email)
+	assert.Equal(t, u.Name, res.Name)
+	assert.Equal(t, u.Age, res.Age)
+	assert.Equal(t, u.Gender, res.Gender)
+	assert.False(t, res.Infected)
+}
+
+func testCreateDuplicateEmail(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u := NewUser(t)
+	require.NoError(t, storage.Create(ctx, u))
+
+	duplicate := NewUser(t)
+	duplicate.Email = u.Email
+	require.Error(t, storage.Create(ctx, duplicate))
+}
+
+func testFind(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u := createUser(t, storage)
+
+	res, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, res.ID)
+
+	res, err = storage.Find(ctx, uuid.NewString())
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+	assert.Empty(t, res)
+}
+
+func testFindUsers(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	first, second := createUser(t, storage), createUser(t, storage)
+
+	res, err := storage.FindUsers(ctx, first.ID, second.ID, uuid.NewString())
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, first.Email, res[first.ID].Email)
+	assert.Equal(t, second.Email, res[second.ID].Email)
+}
+
+func testFindByEmail(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u := createUser(t, storage)
+
+	res, err := storage.FindByEmail(ctx, u.Email)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, res.ID)
+
+	res, err = storage.FindByEmail(ctx, uuid.NewString()+"@zssn.io")
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+	assert.Empty(t, res)
+}
+
+func testUpdateLocation(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u, other := createUser(t, storage), createUser(t, storage)
+
+	lat, long := gofakeit.Latitude(), gofakeit.Longitude()
+	require.NoError(t, storage.UpdateLocation(ctx, u.ID, lat, long))
+
+	res, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, lat, res.Latitude)
+	assert.Equal(t, long, res.Longitude)
+	assert.False(t, res.Infected)
+
+	res, err = storage.Find(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, other.Latitude, res.Latitude)
+	assert.Equal(t, other.Longitude, res.Longitude)
+
+	err = storage.UpdateLocation(ctx, uuid.NewString(), lat, long)
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+}
+
+func testUpdateInfectedStatus(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u := createUser(t, storage)
+
+	require.NoError(t, storage.UpdateInfectedStatus(ctx, u.ID))
+	res, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.True(t, res.Infected)
+
+	// updating an already infected user is not an error
+	require.NoError(t, storage.UpdateInfectedStatus(ctx, u.ID))
+
+	err = storage.UpdateInfectedStatus(ctx, uuid.NewString())
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+}
+
+func testFlagUser(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	infected, flagger, anotherFlagger := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+
+	require.NoError(t, storage.FlagUser(ctx, flagger.ID, infected.ID, nil))
+	res, err := storage.Find(ctx, infected.ID)
+	require.NoError(t, err)
+	require.Len(t, res.FlagMonitor, 1)
+	assert.Equal(t, flagger.ID, res.FlagMonitor[0].UserID)
+	assert.Equal(t, infected.ID, res.FlagMonitor[0].InfectedUserID)
+
+	// a survivor can only flag another survivor once
+	err = storage.FlagUser(ctx, flagger.ID, infected.ID, nil)
+	require.True(t, errors.Is(err, store.ErrDuplicateFlag))
+
+	require.NoError(t, storage.FlagUser(ctx, anotherFlagger.ID, infected.ID, nil))
+	res, err = storage.Find(ctx, infected.ID)
+	require.NoError(t, err)
+	assert.Len(t, res.FlagMonitor, 2)
+
+	// flagging doesn't change the infection status, that is left to the service
+	assert.False(t, res.Infected)
+
+	// flags are recorded against the flagged survivor only
+	res, err = storage.Find(ctx, flagger.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res.FlagMonitor)
+}
+
+func testSelfFlag(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u := createUser(t, storage)
+
+	require.NoError(t, storage.FlagUser(ctx, u.ID, u.ID, nil))
+	res, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res.FlagMonitor)
+}
+
+func testFlagUnknownUsers(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u := createUser(t, storage)
+
+	require.Error(t, storage.FlagUser(ctx, uuid.NewString(), u.ID, nil))
+	require.Error(t, storage.FlagUser(ctx, u.ID, uuid.NewString(), nil))
+
+	res, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res.FlagMonitor)
+}
+
+func testFindFlags(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	infected, flagger, anotherFlagger := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+
+	lat, long := 51.5, -0.12
+	require.NoError(t, storage.FlagUser(ctx, flagger.ID, infected.ID, &store.FlagEvidence{
+		Note:      "growling at the moon",
+		Latitude:  &lat,
+		Longitude: &long,
+		Severity:  store.SeverityHigh,
+	}))
+	require.NoError(t, storage.FlagUser(ctx, anotherFlagger.ID, infected.ID, nil))
+
+	res, err := storage.FindFlags(ctx, infected.ID)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	byFlagger := map[string]*store.FlagMonitor{res[0].UserID: res[0], res[1].UserID: res[1]}
+	require.Contains(t, byFlagger, flagger.ID)
+	f := byFlagger[flagger.ID]
+	assert.Equal(t, "growling at the moon", f.Note)
+	require.NotNil(t, f.Latitude)
+	require.NotNil(t, f.Longitude)
+	assert.Equal(t, lat, *f.Latitude)
+	assert.Equal(t, long, *f.Longitude)
+	assert.Equal(t, store.SeverityHigh, f.Severity)
+	assert.False(t, f.CreatedAt.IsZero())
+
+	// flags without evidence have no location and the default severity
+	require.Contains(t, byFlagger, anotherFlagger.ID)
+	f = byFlagger[anotherFlagger.ID]
+	assert.Empty(t, f.Note)
+	assert.Nil(t, f.Latitude)
+	assert.Equal(t, store.SeverityMedium, f.Severity)
+
+	res, err = storage.FindFlags(ctx, flagger.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testFindNearby(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	center := geo.Point{Latitude: gofakeit.Float64Range(-60, 60), Longitude: gofakeit.Float64Range(-170, 170)}
+	nearby := map[string]geo.Point{
+		"center": center,
+		"near":   {Latitude: center.Latitude + 0.01, Longitude: center.Longitude - 0.01},
+		"far":    {Latitude: center.Latitude + 1, Longitude: center.Longitude},
+	}
+	ids := make(map[string]string)
+	for name, p := range nearby {
+		u := NewUser(t)
+		u.Latitude, u.Longitude = p.Latitude, p.Longitude
+		require.NoError(t, storage.Create(ctx, u))
+		ids[u.ID] = name
+	}
+
+	res, err := storage.FindNearby(ctx, center, 5)
+	require.NoError(t, err)
+	found := make(map[string]bool)
+	for _, v := range res {
+		assert.True(t, geo.Within(center, geo.Point{Latitude: v.Latitude, Longitude: v.Longitude}, 5))
+		if name, ok := ids[v.ID]; ok {
+			found[name] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{"center": true, "near": true}, found)
+
+	infected := NewUser(t)
+	infected.Latitude, infected.Longitude = center.Latitude, center.Longitude+0.01
+	require.NoError(t, storage.Create(ctx, infected))
+	require.NoError(t, storage.UpdateStatus(ctx, infected.ID, store.StatusInfected))
+	res, err = storage.FindNearby(ctx, center, 5, store.StatusHealthy, store.StatusRecovered)
+	require.NoError(t, err)
+	found = make(map[string]bool)
+	for _, v := range res {
+		assert.NotEqual(t, infected.ID, v.ID)
+		if name, ok := ids[v.ID]; ok {
+			found[name] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{"center": true, "near": true}, found)
+}
+
+func testInfectionAudits(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u, flagger := createUser(t, storage), createUser(t, storage)
+
+	res, err := storage.FindInfectionAudits(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	for _, infected := range []bool{false, true} {
+		audit := &store.InfectionAudit{
+			UserID:   u.ID,
+			ActorID:  flagger.ID,
+			Action:   "flag",
+			Policy:   "threshold",
+			Infected: infected,
+			Score:    2,
+			Required: 3,
+			Inputs:   `{"flags":2}`,
+		}
+		require.NoError(t, storage.CreateInfectionAudit(ctx, audit))
+		assert.NotEmpty(t, audit.ID)
+	}
+
+	res, err = storage.FindInfectionAudits(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, flagger.ID, res[0].ActorID)
+	assert.Equal(t, "threshold", res[0].Policy)
+	assert.Equal(t, `{"flags":2}`, res[0].Inputs)
+	assert.Equal(t, 3.0, res[0].Required)
+	assert.False(t, res[0].CreatedAt.IsZero())
+
+	res, err = storage.FindInfectionAudits(ctx, flagger.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testWithTx(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u, flagger := createUser(t, storage), createUser(t, storage)
+
+	err := storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		if err := tx.LockUser(ctx, u.ID); err != nil {
+			return err
+		}
+		if err := tx.FlagUser(ctx, flagger.ID, u.ID, nil); err != nil {
+			return err
+		}
+		return tx.UpdateInfectedStatus(ctx, u.ID)
+	})
+	require.NoError(t, err)
+
+	res, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.True(t, res.Infected)
+	assert.Len(t, res.FlagMonitor, 1)
+
+	// errors are returned as they are
+	errTx := errors.New("rollback")
+	err = storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		return errTx
+	})
+	require.Equal(t, errTx, err)
+
+	err = storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		return tx.LockUser(ctx, uuid.NewString())
+	})
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+}
+
+func testUpdateStatus(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u := createUser(t, storage)
+	assert.Equal(t, store.StatusHealthy, u.Status)
+
+	require.NoError(t, storage.UpdateInfectedStatus(ctx, u.ID))
+	res, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.StatusInfected, res.Status)
+
+	require.NoError(t, storage.UpdateStatus(ctx, u.ID, store.StatusQuarantined))
+	res, err = storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.StatusQuarantined, res.Status)
+	assert.True(t, res.Infected)
+
+	require.NoError(t, storage.UpdateStatus(ctx, u.ID, store.StatusRecovered))
+	res, err = storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.StatusRecovered, res.Status)
+	assert.False(t, res.Infected)
+
+	// setting the current status again is not an error
+	require.NoError(t, storage.UpdateStatus(ctx, u.ID, store.StatusRecovered))
+
+	err = storage.UpdateStatus(ctx, uuid.NewString(), store.StatusHealthy)
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+}
+
+func testDeleteFlag(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	infected, flagger, anotherFlagger := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+	require.NoError(t, storage.FlagUser(ctx, flagger.ID, infected.ID, nil))
+	require.NoError(t, storage.FlagUser(ctx, anotherFlagger.ID, infected.ID, nil))
+
+	require.NoError(t, storage.DeleteFlag(ctx, flagger.ID, infected.ID))
+	res, err := storage.Find(ctx, infected.ID)
+	require.NoError(t, err)
+	require.Len(t, res.FlagMonitor, 1)
+	assert.Equal(t, anotherFlagger.ID, res.FlagMonitor[0].UserID)
+
+	err = storage.DeleteFlag(ctx, flagger.ID, infected.ID)
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+
+	// a retracted flag can be raised again
+	require.NoError(t, storage.FlagUser(ctx, flagger.ID, infected.ID, nil))
+}
+
+func testDeleteFlags(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	infected, flagger, anotherFlagger := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+	require.NoError(t, storage.FlagUser(ctx, flagger.ID, infected.ID, nil))
+	require.NoError(t, storage.FlagUser(ctx, anotherFlagger.ID, infected.ID, nil))
+	require.NoError(t, storage.FlagUser(ctx, infected.ID, flagger.ID, nil))
+
+	n, err := storage.DeleteFlags(ctx, infected.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	res, err := storage.Find(ctx, infected.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res.FlagMonitor)
+
+	// flags against other survivors are left alone
+	res, err = storage.Find(ctx, flagger.ID)
+	require.NoError(t, err)
+	assert.Len(t, res.FlagMonitor, 1)
+}
+
+func testAppeals(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u, voter, admin := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+
+	res, err := storage.FindUserAppeals(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	appeal := &store.Appeal{UserID: u.ID, Reason: "it was a rash"}
+	require.NoError(t, storage.CreateAppeal(ctx, appeal))
+	assert.NotEmpty(t, appeal.ID)
+	assert.Equal(t, store.AppealPending, appeal.Status)
+
+	vote := &store.AppealVote{AppealID: appeal.ID, VoterID: voter.ID, Approve: true}
+	require.NoError(t, storage.CreateAppealVote(ctx, vote))
+	assert.NotEmpty(t, vote.ID)
+	err = storage.CreateAppealVote(ctx, &store.AppealVote{AppealID: appeal.ID, VoterID: voter.ID})
+	require.True(t, errors.Is(err, store.ErrDuplicateVote))
+
+	found, err := storage.FindAppeal(ctx, appeal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, found.UserID)
+	assert.Equal(t, "it was a rash", found.Reason)
+	assert.Nil(t, found.ResolvedAt)
+	require.Len(t, found.Votes, 1)
+	assert.Equal(t, voter.ID, found.Votes[0].VoterID)
+	assert.True(t, found.Votes[0].Approve)
+
+	require.NoError(t, storage.ResolveAppeal(ctx, appeal.ID, store.AppealApproved, admin.ID))
+	found, err = storage.FindAppeal(ctx, appeal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.AppealApproved, found.Status)
+	assert.Equal(t, admin.ID, found.ResolvedBy)
+	require.NotNil(t, found.ResolvedAt)
+
+	res, err = storage.FindUserAppeals(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, appeal.ID, res[0].ID)
+
+	_, err = storage.FindAppeal(ctx, uuid.NewString())
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+	err = storage.ResolveAppeal(ctx, uuid.NewString(), store.AppealRejected, admin.ID)
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+}
+
+func createUser(t *testing.T, storage store.IUserStorage) *store.User {
+	t.Helper()
+	u := NewUser(t)
+	require.NoError(t, storage.Create(context.Background(), u))
+	return u
+}
+
+// NewUser returns a new user record with random details
+func NewUser(t *testing.T) *store.User {
+	t.Helper()
+	return &store.User{
+		Email:     uuid.NewString() + "@" + gofakeit.DomainName(),
+		Name:      gofakeit.FirstName() + " " + gofakeit.LastName(),
+		Age:       20,
+		Gender:    store.GenderMale,
+		Latitude:  gofakeit.Latitude(),
+		Longitude: gofakeit.Longitude(),
+	}
+}
+
+func testStatusTransitions(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u, admin := createUser(t, storage), createUser(t, storage)
+
+	require.NoError(t, storage.CreateStatusTransition(ctx, &store.StatusTransition{
+		UserID:     u.ID,
+		FromStatus: store.StatusHealthy,
+		ToStatus:   store.StatusInfected,
+		Reason:     "infection policy",
+	}))
+	second := &store.StatusTransition{
+		UserID:     u.ID,
+		ActorID:    admin.ID,
+		FromStatus: store.StatusInfected,
+		ToStatus:   store.StatusRecovered,
+		Reason:     "cured",
+	}
+	require.NoError(t, storage.CreateStatusTransition(ctx, second))
+	assert.NotEmpty(t, second.ID)
+
+	res, err := storage.FindStatusTransitions(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, store.StatusInfected, res[0].ToStatus)
+	assert.Empty(t, res[0].ActorID)
+	assert.Equal(t, store.StatusRecovered, res[1].ToStatus)
+	assert.Equal(t, admin.ID, res[1].ActorID)
+	assert.Equal(t, "cured", res[1].Reason)
+	assert.False(t, res[1].CreatedAt.IsZero())
+
+	res, err = storage.FindStatusTransitions(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	// the effects of both changes are pending until they are marked applied
+	pending, err := storage.FindPendingTransitions(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, second.ID, pending[1].ID)
+	require.NoError(t, storage.MarkTransitionApplied(ctx, pending[0].ID))
+	pending, err = storage.FindPendingTransitions(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, second.ID, pending[0].ID)
+	pending, err = storage.FindPendingTransitions(ctx, "")
+	require.NoError(t, err)
+	assert.Contains(t, transitionIDs(pending), second.ID)
+	pending, err = storage.FindPendingTransitions(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func transitionIDs(transitions []*store.StatusTransition) []string {
+	res := make([]string, 0, len(transitions))
+	for _, v := range transitions {
+		res = append(res, v.ID)
+	}
+	return res
+}
+
+func testFindFlagsSince(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	infected, flagger := createUser(t, storage), createUser(t, storage)
+	start := time.Now().Add(-time.Second)
+
+	require.NoError(t, storage.FlagUser(ctx, flagger.ID, infected.ID, nil))
+	res, err := storage.FindFlagsSince(ctx, start)
+	require.NoError(t, err)
+	var found bool
+	for _, v := range res {
+		assert.False(t, v.CreatedAt.Before(start))
+		if v.UserID == flagger.ID && v.InfectedUserID == infected.ID {
+			found = true
+		}
+	}
+	assert.True(t, found)
+
+	res, err = storage.FindFlagsSince(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testCollusionReviews(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	flagger, other, admin := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+
+	review := &store.CollusionReview{UserID: flagger.ID, Signals: "signup_burst,no_trades", Targets: "a", Score: 2}
+	require.NoError(t, storage.SaveCollusionReview(ctx, review))
+	require.NotEmpty(t, review.ID)
+	assert.Equal(t, store.ReviewPending, review.Status)
+
+	// pending reviews are refreshed instead of queued twice
+	refreshed := &store.CollusionReview{UserID: flagger.ID, Signals: "signup_burst,shared_targets,no_trades", Targets: "a,b", Score: 3}
+	require.NoError(t, storage.SaveCollusionReview(ctx, refreshed))
+	assert.Equal(t, review.ID, refreshed.ID)
+	res, err := storage.FindCollusionReview(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b", res.Targets)
+	assert.Equal(t, float64(3), res.Score)
+
+	require.NoError(t, storage.SaveCollusionReview(ctx, &store.CollusionReview{UserID: other.ID, Signals: "no_trades", Score: 1}))
+	pending, err := storage.FindCollusionReviews(ctx, store.ReviewPending)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{flagger.ID, other.ID}, reviewedUsers(pending, flagger.ID, other.ID))
+
+	require.NoError(t, storage.ResolveCollusionReview(ctx, review.ID, store.ReviewDismissed, admin.ID))
+	res, err = storage.FindCollusionReview(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.ReviewDismissed, res.Status)
+	assert.Equal(t, admin.ID, res.ReviewedBy)
+	assert.NotNil(t, res.ReviewedAt)
+
+	// reviews an admin decided on are left alone
+	again := &store.CollusionReview{UserID: flagger.ID, Signals: "no_trades", Score: 1}
+	require.NoError(t, storage.SaveCollusionReview(ctx, again))
+	assert.Equal(t, store.ReviewDismissed, again.Status)
+	assert.Equal(t, "a,b", again.Targets)
+
+	pending, err = storage.FindCollusionReviews(ctx, store.ReviewPending)
+	require.NoError(t, err)
+	assert.Equal(t, []string{other.ID}, reviewedUsers(pending, flagger.ID, other.ID))
+	all, err := storage.FindCollusionReviews(ctx, "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{flagger.ID, other.ID}, reviewedUsers(all, flagger.ID, other.ID))
+	byUser, err := storage.FindUserCollusionReviews(ctx, flagger.ID, other.ID, admin.ID)
+	require.NoError(t, err)
+	require.Len(t, byUser, 2)
+	assert.Equal(t, store.ReviewDismissed, byUser[flagger.ID].Status)
+	assert.Equal(t, store.ReviewPending, byUser[other.ID].Status)
+
+	_, err = storage.FindCollusionReview(ctx, uuid.NewString())
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+	err = storage.ResolveCollusionReview(ctx, uuid.NewString(), store.ReviewConfirmed, admin.ID)
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+}
+
+// reviewedUsers returns the users of the reviews that belong to one of the given users, other tests might share the storage
+func reviewedUsers(reviews []*store.CollusionReview, userIDs ...string) []string {
+	wanted := make(map[string]bool, len(userIDs))
+	for _, v := range userIDs {
+		wanted[v] = true
+	}
+	var res []string
+	for _, v := range reviews {
+		if wanted[v.UserID] {
+			res = append(res, v.UserID)
+		}
+	}
+	return res
+}
+
+func testFindByStatus(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	healthy, infected, flagger := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+	require.NoError(t, storage.FlagUser(ctx, flagger.ID, healthy.ID, nil))
+	require.NoError(t, storage.UpdateStatus(ctx, infected.ID, store.StatusInfected))
+
+	res, err := storage.FindByStatus(ctx, store.StatusHealthy, store.StatusRecovered)
+	require.NoError(t, err)
+	found := make(map[string]*store.User)
+	for _, v := range res {
+		assert.Contains(t, []store.Status{store.StatusHealthy, store.StatusRecovered}, v.Status)
+		found[v.ID] = v
+	}
+	require.Contains(t, found, healthy.ID)
+	assert.Len(t, found[healthy.ID].FlagMonitor, 1)
+	assert.Contains(t, found, flagger.ID)
+	assert.NotContains(t, found, infected.ID)
+
+	res, err = storage.FindByStatus(ctx, store.StatusInfected)
+	require.NoError(t, err)
+	found = make(map[string]*store.User)
+	for _, v := range res {
+		found[v.ID] = v
+	}
+	assert.Contains(t, found, infected.ID)
+	assert.NotContains(t, found, healthy.ID)
+}
+
+func testAddRejectedFlags(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	a, b := createUser(t, storage), createUser(t, storage)
+	assert.Equal(t, 0, a.RejectedFlags)
+
+	require.NoError(t, storage.AddRejectedFlags(ctx, a.ID, b.ID))
+	require.NoError(t, storage.AddRejectedFlags(ctx, a.ID))
+	// unknown users and empty calls are ignored
+	require.NoError(t, storage.AddRejectedFlags(ctx, uuid.NewString()))
+	require.NoError(t, storage.AddRejectedFlags(ctx))
+
+	res, err := storage.FindUsers(ctx, a.ID, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, res[a.ID].RejectedFlags)
+	assert.Equal(t, 1, res[b.ID].RejectedFlags)
+}
+
+func testFindByReputation(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	flagger, other := createUser(t, storage), createUser(t, storage)
+	infected, recovered, healthy := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+	for _, v := range []*store.User{infected, recovered, healthy} {
+		require.NoError(t, storage.FlagUser(ctx, flagger.ID, v.ID, nil))
+	}
+	require.NoError(t, storage.FlagUser(ctx, other.ID, healthy.ID, nil))
+	require.NoError(t, storage.UpdateStatus(ctx, infected.ID, store.StatusInfected))
+	require.NoError(t, storage.UpdateStatus(ctx, recovered.ID, store.StatusRecovered))
+	require.NoError(t, storage.AddRejectedFlags(ctx, other.ID))
+
+	// the account age is left out so the scores don't move while the test runs
+	w := reputation.Weights{Base: 50, AccurateFlag: 5, RejectedFlag: -10, FlagReceived: -8}
+	res, err := storage.FindByReputation(ctx, store.ReputationQuery{Weights: w, IDs: []string{healthy.ID, other.ID, flagger.ID}})
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	assert.Equal(t, flagger.ID, res[0].ID)
+	assert.Equal(t, 2, res[0].Inputs.AccurateFlags)
+	assert.Equal(t, float64(60), res[0].Reputation)
+	assert.Equal(t, other.ID, res[1].ID)
+	assert.Equal(t, 1, res[1].Inputs.RejectedFlags)
+	assert.Equal(t, float64(40), res[1].Reputation)
+	assert.Equal(t, healthy.ID, res[2].ID)
+	assert.Equal(t, 2, res[2].Inputs.FlagsReceived)
+	assert.Equal(t, float64(34), res[2].Reputation)
+
+	res, err = storage.FindByReputation(ctx, store.ReputationQuery{
+		Weights:       w,
+		Statuses:      []store.Status{store.StatusHealthy},
+		ExcludeID:     flagger.ID,
+		MinReputation: 40,
+	})
+	require.NoError(t, err)
+	for _, v := range res {
+		assert.NotContains(t, []string{flagger.ID, infected.ID, recovered.ID, healthy.ID}, v.ID)
+		assert.GreaterOrEqual(t, v.Reputation, float64(40))
+	}
+	assert.Contains(t, scoredIDs(res), other.ID)
+
+	res, err = storage.FindByReputation(ctx, store.ReputationQuery{Weights: w, IDs: []string{flagger.ID, other.ID}, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, flagger.ID, res[0].ID)
+
+	res, err = storage.FindByReputation(ctx, store.ReputationQuery{Weights: w, IDs: []string{uuid.NewString()}})
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func scoredIDs(users []*store.ScoredUser) []string {
+	res := make([]string, 0, len(users))
+	for _, v := range users {
+		res = append(res, v.ID)
+	}
+	return res
+}
+
+func testLocationHistory(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u, other := createUser(t, storage), createUser(t, storage)
+	now := time.Now().Truncate(time.Second)
+	offsets := []time.Duration{-3 * time.Hour, -150 * time.Minute, -2 * time.Hour, -time.Hour, 0}
+	for i, d := range offsets {
+		p := &store.LocationPoint{UserID: u.ID, Latitude: float64(i), Longitude: float64(-i), CreatedAt: now.Add(d)}
+		require.NoError(t, storage.CreateLocationPoint(ctx, p))
+		require.NotEmpty(t, p.ID)
+	}
+	require.NoError(t, storage.CreateLocationPoint(ctx, &store.LocationPoint{UserID: other.ID, CreatedAt: now.Add(-150 * time.Minute)}))
+
+	res, err := storage.FindLocationHistory(ctx, u.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, res, len(offsets))
+	for i, v := range res {
+		assert.Equal(t, float64(i), v.Latitude)
+		assert.WithinDuration(t, now.Add(offsets[i]), v.CreatedAt, time.Second)
+	}
+	res, err = storage.FindLocationHistory(ctx, u.ID, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{2, 3}, latitudes(res))
+
+	// the point 30 minutes after the oldest one goes, the other old points are an hour apart
+	deleted, err := storage.DownsampleLocationPoints(ctx, now.Add(-90*time.Minute), time.Hour)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+	res, err = storage.FindLocationHistory(ctx, u.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0, 2, 3, 4}, latitudes(res))
+	res, err = storage.FindLocationHistory(ctx, other.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, res, 1)
+
+	deleted, err = storage.DeleteLocationPoints(ctx, now.Add(-90*time.Minute))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(3))
+	res, err = storage.FindLocationHistory(ctx, u.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{3, 4}, latitudes(res))
+}
+
+func latitudes(points []*store.LocationPoint) []float64 {
+	res := make([]float64, 0, len(points))
+	for _, v := range points {
+		res = append(res, v.Latitude)
+	}
+	return res
+}
+
+func testFindLocationPointsInBox(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	center := geo.Point{Latitude: gofakeit.Float64Range(-60, 60), Longitude: gofakeit.Float64Range(-170, 170)}
+	box := geo.BoundingBox(center, 0.1)
+	now := time.Now().Truncate(time.Second)
+	a, b := createUser(t, storage), createUser(t, storage)
+	points := map[string]*store.LocationPoint{
+		"inside":      {UserID: a.ID, Latitude: center.Latitude + 0.0005, Longitude: center.Longitude, CreatedAt: now},
+		"inside-late": {UserID: b.ID, Latitude: center.Latitude, Longitude: center.Longitude + 0.0005, CreatedAt: now.Add(2 * time.Hour)},
+		"outside":     {UserID: b.ID, Latitude: center.Latitude + 0.1, Longitude: center.Longitude, CreatedAt: now},
+	}
+	names := make(map[string]string)
+	for name, p := range points {
+		require.NoError(t, storage.CreateLocationPoint(ctx, p))
+		names[p.ID] = name
+	}
+
+	res, err := storage.FindLocationPointsInBox(ctx, box, now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	found := make(map[string]bool)
+	for _, v := range res {
+		assert.True(t, box.Contains(geo.Point{Latitude: v.Latitude, Longitude: v.Longitude}))
+		if name, ok := names[v.ID]; ok {
+			found[name] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{"inside": true}, found)
+}
+
+func testFindLastLocationPoints(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	a, b, idle := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+	now := time.Now().Truncate(time.Second)
+	for _, p := range []*store.LocationPoint{
+		{UserID: a.ID, Latitude: 1, CreatedAt: now.Add(-3 * time.Hour)},
+		{UserID: a.ID, Latitude: 2, CreatedAt: now.Add(-time.Hour)},
+		{UserID: a.ID, Latitude: 3, CreatedAt: now},
+		{UserID: b.ID, Latitude: 4, CreatedAt: now.Add(-2 * time.Hour)},
+	} {
+		require.NoError(t, storage.CreateLocationPoint(ctx, p))
+	}
+
+	res, err := storage.FindLastLocationPoints(ctx, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Contains(t, res, a.ID)
+	require.Contains(t, res, b.ID)
+	assert.NotContains(t, res, idle.ID)
+	assert.Equal(t, 3.0, res[a.ID].Latitude)
+	assert.Equal(t, 4.0, res[b.ID].Latitude)
+	assert.Equal(t, a.ID, res[a.ID].UserID)
+	assert.WithinDuration(t, now, res[a.ID].CreatedAt, time.Second)
+
+	res, err = storage.FindLastLocationPoints(ctx, now.Add(-150*time.Minute), now.Add(-30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, res[a.ID].Latitude)
+	assert.Equal(t, 4.0, res[b.ID].Latitude)
+
+	res, err = storage.FindLastLocationPoints(ctx, now.Add(-90*time.Minute), now.Add(-30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, res[a.ID].Latitude)
+	assert.NotContains(t, res, b.ID)
+}
+
+func testUpdateRole(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u := createUser(t, storage)
+	assert.Equal(t, store.RoleSurvivor, u.Role)
+
+	require.NoError(t, storage.UpdateRole(ctx, u.ID, store.RoleAdmin))
+	found, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.RoleAdmin, found.Role)
+	// granting the same role again is not an error
+	require.NoError(t, storage.UpdateRole(ctx, u.ID, store.RoleAdmin))
+	assert.True(t, errors.Is(storage.UpdateRole(ctx, uuid.NewString(), store.RoleAdmin), gorm.ErrRecordNotFound))
+}
+
+func testLocationPrivacy(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u, admin, other := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+	assert.Equal(t, store.VisibilityApproximate, u.Visibility)
+
+	require.NoError(t, storage.UpdateVisibility(ctx, u.ID, store.VisibilityHidden))
+	found, err := storage.Find(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.VisibilityHidden, found.Visibility)
+	// setting the same visibility again is not an error
+	require.NoError(t, storage.UpdateVisibility(ctx, u.ID, store.VisibilityHidden))
+	assert.True(t, errors.Is(storage.UpdateVisibility(ctx, uuid.NewString(), store.VisibilityExact), gorm.ErrRecordNotFound))
+
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, storage.CreateLocationAudits(ctx))
+	require.NoError(t, storage.CreateLocationAudits(ctx,
+		&store.LocationAudit{AdminID: admin.ID, UserID: u.ID, Resource: "zone_events", CreatedAt: now.Add(-time.Minute)},
+		&store.LocationAudit{AdminID: admin.ID, UserID: other.ID, Resource: "zone_events", CreatedAt: now.Add(-time.Minute)},
+	))
+	latest := &store.LocationAudit{AdminID: admin.ID, UserID: u.ID, Resource: "exports", CreatedAt: now}
+	require.NoError(t, storage.CreateLocationAudits(ctx, latest))
+	require.NotEmpty(t, latest.ID)
+
+	res, err := storage.FindLocationAudits(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "exports", res[0].Resource)
+	assert.Equal(t, "zone_events", res[1].Resource)
+	assert.Equal(t, admin.ID, res[1].AdminID)
+	res, err = storage.FindLocationAudits(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testNotifications(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u, source, other := createUser(t, storage), createUser(t, storage), createUser(t, storage)
+	now := time.Now().Truncate(time.Second)
+
+	first := &store.Notification{UserID: u.ID, Kind: store.NotificationExposure, SourceID: source.ID, Message: "first", CreatedAt: now.Add(-time.Minute)}
+	require.NoError(t, storage.CreateNotification(ctx, first))
+	require.NotEmpty(t, first.ID)
+	// a second notification of the same kind for the same source is ignored
+	require.NoError(t, storage.CreateNotification(ctx, &store.Notification{UserID: u.ID, Kind: store.NotificationExposure, SourceID: source.ID, Message: "again"}))
+	second := &store.Notification{UserID: u.ID, Kind: store.NotificationExposure, SourceID: other.ID, Message: "second", CreatedAt: now}
+	require.NoError(t, storage.CreateNotification(ctx, second))
+
+	res, err := storage.FindNotifications(ctx, u.ID, false)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "second", res[0].Message)
+	assert.Equal(t, "first", res[1].Message)
+	assert.Nil(t, res[1].ReadAt)
+
+	require.NoError(t, storage.MarkNotificationRead(ctx, u.ID, first.ID))
+	// marking it again is not an error
+	require.NoError(t, storage.MarkNotificationRead(ctx, u.ID, first.ID))
+	res, err = storage.FindNotifications(ctx, u.ID, true)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, second.ID, res[0].ID)
+
+	// survivors can only read their own notifications
+	err = storage.MarkNotificationRead(ctx, other.ID, second.ID)
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+	res, err = storage.FindNotifications(ctx, other.ID, false)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testFindInBox(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	inside, outside := createUser(t, storage), createUser(t, storage)
+	require.NoError(t, storage.UpdateLocation(ctx, inside.ID, -33.91, 18.42))
+	require.NoError(t, storage.UpdateLocation(ctx, outside.ID, -33.99, 18.42))
+
+	res, err := storage.FindInBox(ctx, geo.Box{MinLatitude: -33.95, MaxLatitude: -33.9, MinLongitude: 18.4, MaxLongitude: 18.45})
+	require.NoError(t, err)
+	ids := make(map[string]bool)
+	for _, v := range res {
+		ids[v.ID] = true
+	}
+	assert.True(t, ids[inside.ID])
+	assert.False(t, ids[outside.ID])
+}
+
+func testZones(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	circle := &store.Zone{Name: "zz circle", Kind: store.ZoneSafe, Latitude: 40.71, Longitude: -74.0, RadiusKm: 1}
+	require.NoError(t, storage.CreateZone(ctx, circle))
+	require.NotEmpty(t, circle.ID)
+	polygon := &store.Zone{Name: "zz polygon", Kind: store.ZoneDanger, Vertices: []geo.Point{
+		{Latitude: 40.7, Longitude: -74.01},
+		{Latitude: 40.7, Longitude: -73.99},
+		{Latitude: 40.72, Longitude: -73.99},
+	}}
+	require.NoError(t, storage.CreateZone(ctx, polygon))
+
+	res, err := storage.FindZone(ctx, polygon.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.ZoneDanger, res.Kind)
+	assert.Equal(t, polygon.Vertices, res.Vertices)
+
+	// both zones contain the center of the circle, only the circle contains the point west of the polygon
+	at, err := storage.FindZonesAt(ctx, geo.Point{Latitude: 40.71, Longitude: -74.0})
+	require.NoError(t, err)
+	assert.Equal(t, []string{circle.ID, polygon.ID}, zoneIDs(at, circle.ID, polygon.ID))
+	at, err = storage.FindZonesAt(ctx, geo.Point{Latitude: 40.715, Longitude: -74.005})
+	require.NoError(t, err)
+	assert.Equal(t, []string{circle.ID}, zoneIDs(at, circle.ID, polygon.ID))
+
+	all, err := storage.FindZones(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{circle.ID, polygon.ID}, zoneIDs(all, circle.ID, polygon.ID))
+
+	require.NoError(t, storage.DeleteZone(ctx, circle.ID))
+	deleted, err := storage.FindZone(ctx, circle.ID)
+	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
+	assert.Nil(t, deleted)
+	require.EqualError(t, storage.DeleteZone(ctx, circle.ID), gorm.ErrRecordNotFound.Error())
+}
+
+func testZoneMembers(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u, other := createUser(t, storage), createUser(t, storage)
+	a := &store.Zone{Name: "zz a", Kind: store.ZoneSafe, Latitude: 48.85, Longitude: 2.35, RadiusKm: 1}
+	b := &store.Zone{Name: "zz b", Kind: store.ZoneQuarantine, Latitude: 48.85, Longitude: 2.35, RadiusKm: 2}
+	require.NoError(t, storage.CreateZone(ctx, a))
+	require.NoError(t, storage.CreateZone(ctx, b))
+
+	require.NoError(t, storage.AddZoneMember(ctx, &store.ZoneMember{UserID: u.ID, ZoneID: b.ID}))
+	require.NoError(t, storage.AddZoneMember(ctx, &store.ZoneMember{UserID: u.ID, ZoneID: a.ID}))
+	// adding a member twice is ignored
+	require.NoError(t, storage.AddZoneMember(ctx, &store.ZoneMember{UserID: u.ID, ZoneID: a.ID}))
+	require.NoError(t, storage.AddZoneMember(ctx, &store.ZoneMember{UserID: other.ID, ZoneID: b.ID}))
+
+	res, err := storage.FindUserZones(ctx, u.ID, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{a.ID, b.ID}, zoneIDs(res[u.ID]))
+	assert.Equal(t, []string{b.ID}, zoneIDs(res[other.ID]))
+
+	require.NoError(t, storage.DeleteZoneMember(ctx, u.ID, a.ID))
+	// deleting a zone drops its members
+	require.NoError(t, storage.DeleteZone(ctx, b.ID))
+	res, err = storage.FindUserZones(ctx, u.ID, other.ID)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+	require.NoError(t, storage.DeleteZone(ctx, a.ID))
+}
+
+func testZoneEvents(t *testing.T, storage store.IUserStorage) {
+	ctx := context.Background()
+	u := createUser(t, storage)
+	zone := &store.Zone{Name: "zz events", Kind: store.ZoneDanger, Latitude: 35.68, Longitude: 139.69, RadiusKm: 1}
+	require.NoError(t, storage.CreateZone(ctx, zone))
+	now := time.Now().Truncate(time.Second)
+
+	for i, kind := range []store.ZoneEventKind{store.ZoneEnter, store.ZoneExit, store.ZoneEnter} {
+		require.NoError(t, storage.CreateZoneEvent(ctx, &store.ZoneEvent{
+			ZoneID:    zone.ID,
+			UserID:    u.ID,
+			Kind:      kind,
+			Latitude:  float64(i),
+			CreatedAt: now.Add(time.Duration(i-3) * time.Hour),
+		}))
+	}
+
+	res, err := storage.FindZoneEvents(ctx, zone.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	assert.Equal(t, store.ZoneEnter, res[0].Kind)
+	assert.Equal(t, store.ZoneExit, res[1].Kind)
+
+	res, err = storage.FindZoneEvents(ctx, zone.ID, now.Add(-150*time.Minute), time.Time{})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, float64(1), res[0].Latitude)
+
+	res, err = storage.FindZoneEvents(ctx, zone.ID, time.Time{}, now.Add(-150*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, u.ID, res[0].UserID)
+}
+
+// zoneIDs returns the IDs of the zones in order, only the given ones if any are given
+func zoneIDs(zones []*store.Zone, only ...string) []string {
+	keep := make(map[string]bool)
+	for _, v := range only {
+		keep[v] = true
+	}
+	var res []string
+	for _, v := range zones {
+		if len(only) == 0 || keep[v.ID] {
+			res = append(res, v.ID)
+		}
+	}
+	return res
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"zssn/domains/geo"
+)
+
+// ZoneKind what a zone means to the survivors inside it
+type ZoneKind string
+
+const (
+	// ZoneSafe a guarded area, trades can be restricted to safe zones
+	ZoneSafe ZoneKind = "safe"
+	// ZoneDanger an area overrun by zombies
+	ZoneDanger ZoneKind = "danger"
+	// ZoneQuarantine an area where infected survivors are kept
+	ZoneQuarantine ZoneKind = "quarantine"
+)
+
+// ParseZoneKind returns the zone kind with the given name
+func ParseZoneKind(s string) (ZoneKind, error) {
+	switch ZoneKind(s) {
+	case ZoneSafe, ZoneDanger, ZoneQuarantine:
+		return ZoneKind(s), nil
+	default:
+		return "", fmt.Errorf("unknown zone kind %q", s)
+	}
+}
+
+// ZoneEventKind whether a survivor entered or left a zone
+type ZoneEventKind string
+
+const (
+	// ZoneEnter the survivor moved into the zone
+	ZoneEnter ZoneEventKind = "enter"
+	// ZoneExit the survivor moved out of the zone
+	ZoneExit ZoneEventKind = "exit"
+)
+
+// Zone an area defined by an admin, either a circle around the center or a polygon when it has vertices.
+// The bounding box is stored with the zone so the zones around a location can be pre-filtered in the database.
+type Zone struct {
+	ID           string      `json:"id" gorm:"primaryKey"`
+	Name         string      `json:"name" gorm:"size:100"`
+	Kind         ZoneKind    `json:"kind" gorm:"size:20;index"`
+	Latitude     float64     `json:"latitude"`
+	Longitude    float64     `json:"longitude"`
+	RadiusKm     float64     `json:"radius_km"`
+	Vertices     []geo.Point `json:"vertices" gorm:"serializer:json"`
+	MinLatitude  float64     `json:"-"`
+	MaxLatitude  float64     `json:"-"`
+	MinLongitude float64     `json:"-"`
+	MaxLongitude float64     `json:"-"`
+	CreatedBy    string      `json:"created_by" gorm:"size:50"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Box returns the smallest box containing the zone
+func (z *Zone) Box() geo.Box {
+	if len(z.Vertices) > 0 {
+		return geo.PolygonBox(z.Vertices)
+	}
+	return geo.BoundingBox(geo.Point{Latitude: z.Latitude, Longitude: z.Longitude}, z.RadiusKm)
+}
+
+// Contains returns true if the point is inside the zone
+func (z *Zone) Contains(p geo.Point) bool {
+	if len(z.Vertices) > 0 {
+		return geo.InPolygon(p, z.Vertices)
+	}
+	return geo.Within(geo.Point{Latitude: z.Latitude, Longitude: z.Longitude}, p, z.RadiusKm)
+}
+
+// ZoneMember a survivor whose last known location is inside the zone
+type ZoneMember struct {
+	UserID    string `gorm:"primaryKey;size:50"`
+	ZoneID    string `gorm:"primaryKey;size:50;index"`
+	CreatedAt time.Time
+}
+
+// ZoneEvent a survivor entering or leaving a zone, the location is the one that moved them in or out
+type ZoneEvent struct {
+	ID        string        `json:"id" gorm:"primaryKey"`
+	ZoneID    string        `json:"zone_id" gorm:"size:50;index:idx_zone_events_zone,priority:1"`
+	UserID    string        `json:"user_id" gorm:"size:50;index"`
+	Kind      ZoneEventKind `json:"kind" gorm:"size:10"`
+	Latitude  float64       `json:"latitude"`
+	Longitude float64       `json:"longitude"`
+	CreatedAt time.Time     `json:"created_at" gorm:"index:idx_zone_events_zone,priority:2"`
+}
@@ -0,0 +1,238 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+	"zssn/domains/users/store"
+
+	"gorm.io/gorm"
+)
+
+// TradePartnersFunc returns the survivors who traded with the given survivor since the given time
+type TradePartnersFunc func(ctx context.Context, id string, since time.Time) ([]string, error)
+
+// TracingRules decides who counts as exposed to an infected survivor
+type TracingRules struct {
+	// Window only trades and locations within the window before the infection are traced
+	Window time.Duration
+	// RadiusKm survivors who reported a location within the radius of the infected survivor were close to them
+	RadiusKm float64
+	// ContactWindow the locations have to be reported within this duration of each other
+	ContactWindow time.Duration
+	// MarkSuspected moves healthy and recovered exposed survivors to suspected
+	MarkSuspected bool
+}
+
+// DefaultTracingRules returns the rules used when none are configured
+func DefaultTracingRules() TracingRules {
+	return TracingRules{
+		Window:        14 * 24 * time.Hour,
+		RadiusKm:      0.1,
+		ContactWindow: 30 * time.Minute,
+	}
+}
+
+// WithTracingRules sets who the contact tracing considers exposed, defaults are used for non-positive values
+func WithTracingRules(r TracingRules) Option {
+	return func(u *UserService) {
+		d := DefaultTracingRules()
+		if r.Window <= 0 {
+			r.Window = d.Window
+		}
+		if r.RadiusKm <= 0 {
+			r.RadiusKm = d.RadiusKm
+		}
+		if r.ContactWindow <= 0 {
+			r.ContactWindow = d.ContactWindow
+		}
+		u.tracing = r
+	}
+}
+
+// WithTradePartners sets where the contact tracing looks up the trade partners of infected survivors.
+// Without it only co-location is traced.
+func WithTradePartners(fn TradePartnersFunc) Option {
+	return func(u *UserService) {
+		u.tradePartners = fn
+	}
+}
+
+// TraceContacts finds the clean survivors who traded with or stood close to the infected survivor within the tracing window.
+// Every exposed survivor is notified once per infected survivor and, if the rules say so, marked suspected.
+// The exposures are returned ordered by survivor ID.
+func (u *UserService) TraceContacts(ctx context.Context, id string) ([]*entities.Exposure, error) {
+	infected, err := u.Storage.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().Add(-u.tracing.Window)
+	exposures := make(map[string]*entities.Exposure)
+	exposure := func(userID string) *entities.Exposure {
+		if exposures[userID] == nil {
+			exposures[userID] = &entities.Exposure{UserID: userID}
+		}
+		return exposures[userID]
+	}
+
+	if u.tradePartners != nil {
+		partners, err := u.tradePartners(ctx, id, since)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range partners {
+			if v != id {
+				exposure(v).Traded = true
+			}
+		}
+	}
+
+	trail, err := u.Storage.FindLocationHistory(ctx, id, since, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	// the trail is looked up a slice at a time, every slice spans one contact window,
+	// so a query only covers where the survivor went within it and the candidates are only matched against its points
+	for start := 0; start < len(trail); {
+		end := start + 1
+		for end < len(trail) && trail[end].CreatedAt.Sub(trail[start].CreatedAt) <= u.tracing.ContactWindow {
+			end++
+		}
+		slice := trail[start:end]
+		start = end
+
+		box, from, to := u.trailBounds(slice)
+		candidates, err := u.Storage.FindLocationPointsInBox(ctx, box, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range candidates {
+			if v.UserID == id || !u.coLocated(slice, v) {
+				continue
+			}
+			e := exposure(v.UserID)
+			if e.LastCoLocated == nil || v.CreatedAt.After(*e.LastCoLocated) {
+				at := v.CreatedAt
+				e.LastCoLocated = &at
+			}
+		}
+	}
+
+	res := []*entities.Exposure{}
+	if len(exposures) == 0 {
+		return res, nil
+	}
+	ids := make([]string, 0, len(exposures))
+	for v := range exposures {
+		ids = append(ids, v)
+	}
+	sort.Strings(ids)
+
+	err = u.Storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		for _, v := range ids {
+			if err := tx.LockUser(ctx, v); errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			usr, err := tx.Find(ctx, v)
+			if err != nil {
+				return err
+			}
+			// survivors who are already infected don't need a warning
+			if !usr.Status.Clean() {
+				continue
+			}
+			e := exposures[v]
+			if err := tx.CreateNotification(ctx, &store.Notification{
+				UserID:   v,
+				Kind:     store.NotificationExposure,
+				SourceID: id,
+				Message:  exposureMessage(infected, e),
+			}); err != nil {
+				return err
+			}
+			if u.tracing.MarkSuspected && usr.Status != store.StatusSuspected {
+				if _, err := u.transition(ctx, tx, usr, store.StatusSuspected, id, "exposed to an infected survivor"); err != nil {
+					return err
+				}
+				e.Suspected = true
+			}
+			res = append(res, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// trailBounds returns the box holding every point within the tracing radius of the slice of the trail,
+// and the time range from the contact window before its first point to the contact window after its last one
+func (u *UserService) trailBounds(trail []*store.LocationPoint) (geo.Box, time.Time, time.Time) {
+	box := geo.BoundingBox(geo.Point{Latitude: trail[0].Latitude, Longitude: trail[0].Longitude}, u.tracing.RadiusKm)
+	from, to := trail[0].CreatedAt, trail[0].CreatedAt
+	for _, p := range trail[1:] {
+		b := geo.BoundingBox(geo.Point{Latitude: p.Latitude, Longitude: p.Longitude}, u.tracing.RadiusKm)
+		box.MinLatitude, box.MaxLatitude = math.Min(box.MinLatitude, b.MinLatitude), math.Max(box.MaxLatitude, b.MaxLatitude)
+		box.MinLongitude, box.MaxLongitude = math.Min(box.MinLongitude, b.MinLongitude), math.Max(box.MaxLongitude, b.MaxLongitude)
+		if p.CreatedAt.Before(from) {
+			from = p.CreatedAt
+		}
+		if p.CreatedAt.After(to) {
+			to = p.CreatedAt
+		}
+	}
+	return box, from.Add(-u.tracing.ContactWindow), to.Add(u.tracing.ContactWindow)
+}
+
+// coLocated reports whether the point was recorded within the tracing radius and the contact window of any point of the slice of the trail
+func (u *UserService) coLocated(trail []*store.LocationPoint, v *store.LocationPoint) bool {
+	at := geo.Point{Latitude: v.Latitude, Longitude: v.Longitude}
+	for _, p := range trail {
+		d := v.CreatedAt.Sub(p.CreatedAt)
+		if d < 0 {
+			d = -d
+		}
+		if d <= u.tracing.ContactWindow && geo.Within(geo.Point{Latitude: p.Latitude, Longitude: p.Longitude}, at, u.tracing.RadiusKm) {
+			return true
+		}
+	}
+	return false
+}
+
+// Notifications returns the notifications of the survivor, newest first
+func (u *UserService) Notifications(ctx context.Context, id string, unreadOnly bool) ([]*entities.Notification, error) {
+	notifications, err := u.Storage.FindNotifications(ctx, id, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entities.Notification, 0, len(notifications))
+	for _, v := range notifications {
+		res = append(res, entities.FromNotificationDBEntity(v))
+	}
+	return res, nil
+}
+
+// ReadNotification marks the notification of the survivor as read
+func (u *UserService) ReadNotification(ctx context.Context, id, notificationID string) error {
+	return u.Storage.MarkNotificationRead(ctx, id, notificationID)
+}
+
+func exposureMessage(infected *store.User, e *entities.Exposure) string {
+	switch {
+	case e.Traded && e.LastCoLocated != nil:
+		return fmt.Sprintf("%s, who you traded with and were close to, has been found infected", infected.Name)
+	case e.Traded:
+		return fmt.Sprintf("%s, who you traded with, has been found infected", infected.Name)
+	default:
+		return fmt.Sprintf("you were close to %s, who has been found infected, on %s", infected.Name, e.LastCoLocated.Format(time.RFC1123))
+	}
+}
@@ -0,0 +1,192 @@
+package users_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"zssn/database"
+	"zssn/domains/geo"
+	"zssn/domains/users"
+	"zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tracingScenario an infected survivor, a trade partner and survivors who were close to the infected survivor or not
+type tracingScenario struct {
+	storage                     store.IUserStorage
+	infected, partner, near     *store.User
+	recovered, sick, far, later *store.User
+}
+
+func newTracingScenario(t *testing.T) *tracingScenario {
+	t.Helper()
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+
+	s := &tracingScenario{storage: storage}
+	for _, u := range []**store.User{&s.infected, &s.partner, &s.near, &s.recovered, &s.sick, &s.far, &s.later} {
+		*u = storetest.NewUser(t)
+		require.NoError(t, storage.Create(ctx, *u))
+	}
+	require.NoError(t, storage.UpdateStatus(ctx, s.infected.ID, store.StatusInfected))
+	require.NoError(t, storage.UpdateStatus(ctx, s.sick.ID, store.StatusInfected))
+	require.NoError(t, storage.UpdateStatus(ctx, s.recovered.ID, store.StatusRecovered))
+
+	met := time.Now().Add(-48 * time.Hour)
+	points := []*store.LocationPoint{
+		{UserID: s.infected.ID, Latitude: 6.5, Longitude: 3.3, CreatedAt: met},
+		{UserID: s.near.ID, Latitude: 6.5003, Longitude: 3.3, CreatedAt: met.Add(10 * time.Minute)},
+		{UserID: s.recovered.ID, Latitude: 6.5, Longitude: 3.3003, CreatedAt: met.Add(-5 * time.Minute)},
+		{UserID: s.sick.ID, Latitude: 6.5, Longitude: 3.3, CreatedAt: met},
+		{UserID: s.far.ID, Latitude: 6.6, Longitude: 3.3, CreatedAt: met},
+		{UserID: s.later.ID, Latitude: 6.5, Longitude: 3.3, CreatedAt: met.Add(3 * time.Hour)},
+	}
+	for _, p := range points {
+		require.NoError(t, storage.CreateLocationPoint(ctx, p))
+	}
+	return s
+}
+
+func (s *tracingScenario) service(t *testing.T, rules users.TracingRules) users.IUserService {
+	t.Helper()
+	partners := func(ctx context.Context, id string, since time.Time) ([]string, error) {
+		if id != s.infected.ID {
+			return nil, nil
+		}
+		return []string{s.partner.ID, s.infected.ID}, nil
+	}
+	svc, err := users.New(s.storage, users.WithTracingRules(rules), users.WithTradePartners(partners))
+	require.NoError(t, err)
+	return svc
+}
+
+func TestTraceContacts(t *testing.T) {
+	ctx := context.Background()
+	s := newTracingScenario(t)
+	svc := s.service(t, users.TracingRules{MarkSuspected: true})
+
+	res, err := svc.TraceContacts(ctx, s.infected.ID)
+	require.NoError(t, err)
+	expected := []string{s.partner.ID, s.near.ID, s.recovered.ID}
+	sort.Strings(expected)
+	require.Len(t, res, len(expected))
+	for i, v := range res {
+		assert.Equal(t, expected[i], v.UserID)
+		assert.True(t, v.Suspected)
+		switch v.UserID {
+		case s.partner.ID:
+			assert.True(t, v.Traded)
+			assert.Nil(t, v.LastCoLocated)
+		default:
+			assert.False(t, v.Traded)
+			assert.NotNil(t, v.LastCoLocated)
+		}
+	}
+	for _, id := range expected {
+		assertUserStatus(t, s.storage, id, store.StatusSuspected)
+		notifications, err := svc.Notifications(ctx, id, true)
+		require.NoError(t, err)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, store.NotificationExposure, notifications[0].Kind)
+		assert.Equal(t, s.infected.ID, notifications[0].SourceID)
+		assert.Contains(t, notifications[0].Message, s.infected.Name)
+	}
+	for _, id := range []string{s.far.ID, s.later.ID, s.sick.ID} {
+		notifications, err := svc.Notifications(ctx, id, false)
+		require.NoError(t, err)
+		assert.Empty(t, notifications)
+	}
+	assertUserStatus(t, s.storage, s.sick.ID, store.StatusInfected)
+
+	// tracing the same survivor again doesn't warn anyone twice
+	res, err = svc.TraceContacts(ctx, s.infected.ID)
+	require.NoError(t, err)
+	require.Len(t, res, len(expected))
+	assert.False(t, res[0].Suspected)
+	notifications, err := svc.Notifications(ctx, s.near.ID, false)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+
+	require.NoError(t, svc.ReadNotification(ctx, s.near.ID, notifications[0].ID))
+	notifications, err = svc.Notifications(ctx, s.near.ID, true)
+	require.NoError(t, err)
+	assert.Empty(t, notifications)
+}
+
+func TestTraceContactsWithoutMarking(t *testing.T) {
+	ctx := context.Background()
+	s := newTracingScenario(t)
+	svc := s.service(t, users.TracingRules{ContactWindow: time.Minute})
+
+	res, err := svc.TraceContacts(ctx, s.infected.ID)
+	require.NoError(t, err)
+	// the survivors close by reported their location too long before or after the infected survivor
+	require.Len(t, res, 1)
+	assert.Equal(t, s.partner.ID, res[0].UserID)
+	assert.False(t, res[0].Suspected)
+	assertUserStatus(t, s.storage, s.partner.ID, store.StatusHealthy)
+}
+
+// countingStorage records the time ranges of the location queries of the contact tracing
+type countingStorage struct {
+	store.IUserStorage
+	ranges []time.Duration
+}
+
+func (c *countingStorage) FindLocationPointsInBox(ctx context.Context, box geo.Box, from, to time.Time) ([]*store.LocationPoint, error) {
+	c.ranges = append(c.ranges, to.Sub(from))
+	return c.IUserStorage.FindLocationPointsInBox(ctx, box, from, to)
+}
+
+func TestTraceContactsAlongTrail(t *testing.T) {
+	ctx := context.Background()
+	s := newTracingScenario(t)
+	storage := &countingStorage{IUserStorage: s.storage}
+	svc, err := users.New(storage)
+	require.NoError(t, err)
+
+	// the infected survivor moved on a few hours later, someone was at the first place by then and someone else at the next one
+	moved := time.Now().Add(-45 * time.Hour)
+	stayed, met := storetest.NewUser(t), storetest.NewUser(t)
+	require.NoError(t, storage.Create(ctx, stayed))
+	require.NoError(t, storage.Create(ctx, met))
+	for _, p := range []*store.LocationPoint{
+		{UserID: s.infected.ID, Latitude: 7.5, Longitude: 4.3, CreatedAt: moved},
+		{UserID: stayed.ID, Latitude: 6.5, Longitude: 3.3, CreatedAt: moved},
+		{UserID: met.ID, Latitude: 7.5002, Longitude: 4.3, CreatedAt: moved.Add(5 * time.Minute)},
+	} {
+		require.NoError(t, storage.CreateLocationPoint(ctx, p))
+	}
+
+	res, err := svc.TraceContacts(ctx, s.infected.ID)
+	require.NoError(t, err)
+	// the trail is looked up one contact window at a time, not as a whole
+	require.Len(t, storage.ranges, 2)
+	for _, v := range storage.ranges {
+		assert.LessOrEqual(t, v, 3*users.DefaultTracingRules().ContactWindow)
+	}
+	found := make(map[string]bool)
+	for _, v := range res {
+		found[v.UserID] = true
+	}
+	assert.True(t, found[met.ID])
+	assert.True(t, found[s.near.ID])
+	assert.False(t, found[stayed.ID])
+	assert.False(t, found[s.far.ID])
+	assert.False(t, found[s.later.ID])
+}
+
+func assertUserStatus(t *testing.T, storage store.IUserStorage, id string, expected store.Status) {
+	t.Helper()
+	u, err := storage.Find(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, expected, u.Status)
+}
@@ -2,24 +2,88 @@ package users
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 
 	"zssn/domains/entities"
+	"zssn/domains/geo"
+	"zssn/domains/reputation"
 	"zssn/domains/users/store"
 )
 
 var (
 	_ IUserService = (*UserService)(nil)
+
+	// ErrInfectedFlagger is returned when an infected, quarantined or deceased survivor tries to flag someone, their flags don't count
+	ErrInfectedFlagger = errors.New("only clean survivors can flag other survivors")
+	// ErrFlagsHidden is returned when someone other than an admin or the flagged survivor asks for the flags
+	ErrFlagsHidden = errors.New("only admins and the flagged survivor can see the flags")
 )
 
+// DefaultInfectionThreshold number of flags after which a survivor is considered infected
+const DefaultInfectionThreshold = 3
+
 type UserService struct {
 	Storage store.IUserStorage
+
+	policy            InfectionPolicy
+	appealQuorum      int
+	collusion         CollusionRules
+	tradeActivity     TradeActivityFunc
+	reputationWeights reputation.Weights
+	locations         LocationRules
+	tracing           TracingRules
+	tradePartners     TradePartnersFunc
+
+	inventoryAccess            InventoryAccessFunc
+	restoreInventoryOnRecovery bool
+	contactTracing             bool
+
+	approximatePrecision int
+}
+
+// Option configures the user service
+type Option func(*UserService)
+
+// WithInfectionThreshold uses a fixed threshold policy with the given number of flags
+func WithInfectionThreshold(n int) Option {
+	return WithInfectionPolicy(NewThresholdPolicy(n))
+}
+
+// WithInfectionPolicy sets the policy that decides when a flagged survivor is infected
+func WithInfectionPolicy(p InfectionPolicy) Option {
+	return func(u *UserService) {
+		if p != nil {
+			u.policy = p
+		}
+	}
 }
 
 // New create a new user service object
-func New(storage store.IUserStorage) (IUserService, error) {
-	return &UserService{
-		Storage: storage,
-	}, nil
+func New(storage store.IUserStorage, opts ...Option) (IUserService, error) {
+	svc := &UserService{
+		Storage:           storage,
+		policy:            NewThresholdPolicy(DefaultInfectionThreshold),
+		appealQuorum:      DefaultAppealQuorum,
+		collusion:         DefaultCollusionRules(),
+		reputationWeights: reputation.DefaultWeights(),
+		locations:         DefaultLocationRules(),
+		tracing:           DefaultTracingRules(),
+
+		restoreInventoryOnRecovery: true,
+
+		approximatePrecision: DefaultApproximatePrecision,
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	// flaggers are trusted as much as their profiles say, so the policy scores them with the same weights
+	if p, ok := svc.policy.(*ReputationPolicy); ok && p.Reputation == nil {
+		scored := *p
+		scored.Reputation = ProfileReputation(svc.reputationWeights)
+		svc.policy = &scored
+	}
+	return svc, nil
 }
 
 // Create converts the entities and creates a new record inside the database
@@ -64,27 +128,201 @@ func (u *UserService) FindByEmail(ctx context.Context, email string) (*entities.
 	return entities.FromUserDBEntity(res), nil
 }
 
-// UpdateLocation updates user's location
+// UpdateLocation updates user's location, appends it to their location history and moves them in and out of the zones
 func (u *UserService) UpdateLocation(ctx context.Context, id string, lat, long float64) error {
-	return u.Storage.UpdateLocation(ctx, id, lat, long)
+	return u.Storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		if err := tx.LockUser(ctx, id); err != nil {
+			return err
+		}
+		if err := tx.UpdateLocation(ctx, id, lat, long); err != nil {
+			return err
+		}
+		if err := tx.CreateLocationPoint(ctx, &store.LocationPoint{UserID: id, Latitude: lat, Longitude: long}); err != nil {
+			return err
+		}
+		return updateZones(ctx, tx, id, geo.Point{Latitude: lat, Longitude: long})
+	})
+}
+
+// FlagUser flags a user and lets the infection policy decide if the flagged user is infected.
+// The flag, the decision and the status change happen in one transaction while the flagged user is locked,
+// so concurrent flags are evaluated one after the other. Every decision is recorded in the infection audit trail.
+// The evidence is optional, the flagger's last known location is recorded when it doesn't contain one.
+// Returns the status change the flag caused, nil if the survivor's status didn't change.
+// Its effects are applied once the flag is saved, ErrEffectsPending is returned when that fails.
+func (u *UserService) FlagUser(ctx context.Context, id, infectedUserID string, evidence *entities.FlagEvidence) (*entities.StatusTransition, error) {
+	var res *store.StatusTransition
+	err := u.Storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		if err := tx.LockUser(ctx, infectedUserID); err != nil {
+			return err
+		}
+		flagger, err := tx.Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !flagger.Status.Clean() {
+			return ErrInfectedFlagger
+		}
+		if id == infectedUserID {
+			return nil
+		}
+		ev, err := flagEvidence(flagger, evidence)
+		if err != nil {
+			return err
+		}
+		if err := tx.FlagUser(ctx, id, infectedUserID, ev); err != nil {
+			return err
+		}
+
+		usr, err := tx.Find(ctx, infectedUserID)
+		if err != nil {
+			return err
+		}
+		// the flag is recorded but only clean survivors can get infected
+		if !usr.Status.Clean() {
+			return nil
+		}
+		target, ignored, err := withoutInfectedFlaggers(ctx, tx, usr)
+		if err != nil {
+			return err
+		}
+		target, discounted, err := u.withoutSuspiciousFlaggers(ctx, tx, target)
+		if err != nil {
+			return err
+		}
+
+		decision, err := u.policy.Evaluate(ctx, tx, target)
+		if err != nil {
+			return err
+		}
+		if decision.Inputs == nil {
+			decision.Inputs = make(map[string]interface{})
+		}
+		decision.Inputs["ignored_flags"] = ignored
+		if u.collusion.Discount {
+			decision.Inputs["discounted_flags"] = discounted
+		}
+		switch {
+		case decision.Infected:
+			res, err = u.transition(ctx, tx, usr, store.StatusInfected, id, "infection policy met")
+		case len(target.FlagMonitor) > 0:
+			res, err = u.transition(ctx, tx, usr, store.StatusSuspected, id, "flagged")
+		}
+		if err != nil {
+			return err
+		}
+		return u.auditDecision(ctx, tx, AuditActionFlag, id, infectedUserID, decision)
+	})
+	if err != nil || res == nil {
+		return nil, err
+	}
+	if err := u.settle(ctx, res); err != nil {
+		return nil, err
+	}
+	return entities.FromStatusTransitionDBEntity(res), nil
 }
 
-// FlagUser flags a user using the storage service and if the infected user has been flagged 3 times or more
-// then the infection status of the user is updated
-func (u *UserService) FlagUser(ctx context.Context, id, infectedUserID string) error {
-	usr, err := u.Storage.Find(ctx, infectedUserID)
+// flagEvidence converts the evidence given by the flagger, their last known location is used when it has none
+func flagEvidence(flagger *store.User, evidence *entities.FlagEvidence) (*store.FlagEvidence, error) {
+	lat, long := flagger.Latitude, flagger.Longitude
+	res := &store.FlagEvidence{
+		Latitude:  &lat,
+		Longitude: &long,
+		Severity:  store.SeverityMedium,
+	}
+	if evidence == nil {
+		return res, nil
+	}
+	severity, err := store.ParseSeverity(evidence.Severity)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	res.Severity = severity
+	res.Note = evidence.Note
+	if evidence.Latitude != nil && evidence.Longitude != nil {
+		res.Latitude, res.Longitude = evidence.Latitude, evidence.Longitude
 	}
+	return res, nil
+}
 
-	if len(usr.FlagMonitor)+1 >= 3 && !usr.Infected {
-		// update the user as infected once we get to 3 and the user hasn't been flagged already
-		if err := u.Storage.UpdateInfectedStatus(ctx, infectedUserID); err != nil {
-			return err
+// FindFlags returns the flags raised against the survivor, oldest first.
+// Only admins and the flagged survivor can see them, the flagged survivor doesn't get to know who flagged them or where.
+func (u *UserService) FindFlags(ctx context.Context, requesterID, userID string) ([]*entities.Flag, error) {
+	admin, err := u.isAdmin(ctx, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	if !admin && requesterID != userID {
+		return nil, ErrFlagsHidden
+	}
+	if _, err := u.Storage.Find(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	flags, err := u.Storage.FindFlags(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// the location of a flag is where the flagger was, admins seeing it is audited like any other exact location
+	viewer := NewLocationViewer(requesterID, admin, AuditResourceFlags, u.approximatePrecision)
+	res := make([]*entities.Flag, 0, len(flags))
+	for _, v := range flags {
+		f := entities.FromFlagDBEntity(v)
+		switch {
+		case !admin:
+			f.FlaggerID, f.Latitude, f.Longitude = "", nil, nil
+		case f.Latitude != nil && f.Longitude != nil:
+			viewer.Location(f.FlaggerID, "", geo.Point{Latitude: *f.Latitude, Longitude: *f.Longitude})
+		}
+		res = append(res, f)
+	}
+	if err := viewer.Save(ctx, u.Storage); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// withoutInfectedFlaggers returns a copy of the user without the flags raised by survivors who have since been infected
+func withoutInfectedFlaggers(ctx context.Context, storage store.IUserStorage, usr *store.User) (*store.User, int, error) {
+	ids := make([]string, 0, len(usr.FlagMonitor))
+	for _, v := range usr.FlagMonitor {
+		ids = append(ids, v.UserID)
+	}
+	flaggers, err := storage.FindUsers(ctx, ids...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	target := *usr
+	target.FlagMonitor = make([]store.FlagMonitor, 0, len(usr.FlagMonitor))
+	for _, v := range usr.FlagMonitor {
+		if f, ok := flaggers[v.UserID]; ok && f.Infected {
+			continue
 		}
+		target.FlagMonitor = append(target.FlagMonitor, v)
 	}
+	return &target, len(usr.FlagMonitor) - len(target.FlagMonitor), nil
+}
 
-	return u.Storage.FlagUser(ctx, id, infectedUserID)
+func (u *UserService) auditDecision(ctx context.Context, storage store.IUserStorage, action, actorID, userID string, decision *InfectionDecision) error {
+	return u.audit(ctx, storage, &store.InfectionAudit{
+		UserID:   userID,
+		ActorID:  actorID,
+		Action:   action,
+		Policy:   u.policy.Name(),
+		Infected: decision.Infected,
+		Score:    decision.Score,
+		Required: decision.Required,
+	}, decision.Inputs)
+}
+
+func (u *UserService) audit(ctx context.Context, storage store.IUserStorage, entry *store.InfectionAudit, inputs map[string]interface{}) error {
+	b, err := json.Marshal(inputs)
+	if err != nil {
+		return err
+	}
+	entry.Inputs = string(b)
+	return storage.CreateInfectionAudit(ctx, entry)
 }
 
 // IsInfected return if a user is infected or not
@@ -2,6 +2,7 @@ package users
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 
@@ -218,7 +219,7 @@ func TestFlagUser(t *testing.T) {
 	require.NoError(t, svc.Create(ctx, flagger))
 	require.NotEmpty(t, flagger.ID)
 
-	err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID)
+	_, err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
 	require.NoError(t, err)
 
 	res, err := svc.Find(ctx, infectedUser.ID)
@@ -239,7 +240,7 @@ func TestFlagNonExistingUser(t *testing.T) {
 	require.NoError(t, svc.Create(ctx, flagger))
 	require.NotEmpty(t, flagger.ID)
 
-	err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID)
+	_, err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
 	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
 }
 
@@ -255,10 +256,35 @@ func TestFlagUserWithNonExistingUserAccount(t *testing.T) {
 
 	flagger := newUser(t)
 
-	err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID)
+	_, err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
 	require.EqualError(t, err, gorm.ErrRecordNotFound.Error())
 }
 
+func TestFlagUserWithInfectionThreshold(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage, WithInfectionThreshold(2))
+	require.NoError(t, err)
+	require.NotNil(t, svc)
+
+	infectedUser := newUser(t)
+	require.NoError(t, svc.Create(ctx, infectedUser))
+
+	for i := 0; i < 2; i++ {
+		ok, err := svc.IsInfected(ctx, infectedUser.ID)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		flagger := newUser(t)
+		require.NoError(t, svc.Create(ctx, flagger))
+		_, err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
+		require.NoError(t, err)
+	}
+
+	ok, err := svc.IsInfected(ctx, infectedUser.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
 func TestIsInfected(t *testing.T) {
 	ctx := context.Background()
 	svc, err := New(storage)
@@ -307,3 +333,57 @@ func newUser(t *testing.T) *entities.User {
 		Longitude: gofakeit.Longitude(),
 	}
 }
+
+func TestFlagUserTwice(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage, WithInfectionThreshold(2))
+	require.NoError(t, err)
+
+	infectedUser, flagger := newUser(t), newUser(t)
+	require.NoError(t, svc.Create(ctx, infectedUser))
+	require.NoError(t, svc.Create(ctx, flagger))
+
+	_, err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
+	require.NoError(t, err)
+	_, err = svc.FlagUser(ctx, flagger.ID, infectedUser.ID, nil)
+	require.True(t, errors.Is(err, store.ErrDuplicateFlag))
+
+	ok, err := svc.IsInfected(ctx, infectedUser.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFlagUserByInfectedSurvivor(t *testing.T) {
+	ctx := context.Background()
+	svc, err := New(storage, WithInfectionThreshold(2))
+	require.NoError(t, err)
+
+	target, infectedFlagger, flagger := newUser(t), newUser(t), newUser(t)
+	require.NoError(t, svc.Create(ctx, target))
+	require.NoError(t, svc.Create(ctx, infectedFlagger))
+	require.NoError(t, svc.Create(ctx, flagger))
+
+	// flags raised before the flagger got infected don't count either
+	_, err = svc.FlagUser(ctx, infectedFlagger.ID, target.ID, nil)
+	require.NoError(t, err)
+	require.NoError(t, storage.UpdateInfectedStatus(ctx, infectedFlagger.ID))
+
+	_, err = svc.FlagUser(ctx, infectedFlagger.ID, uuid.NewString(), nil)
+	require.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	anotherTarget := newUser(t)
+	require.NoError(t, svc.Create(ctx, anotherTarget))
+	_, err = svc.FlagUser(ctx, infectedFlagger.ID, anotherTarget.ID, nil)
+	require.True(t, errors.Is(err, ErrInfectedFlagger))
+
+	_, err = svc.FlagUser(ctx, flagger.ID, target.ID, nil)
+	require.NoError(t, err)
+	ok, err := svc.IsInfected(ctx, target.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	audits, err := storage.FindInfectionAudits(ctx, target.ID)
+	require.NoError(t, err)
+	require.Len(t, audits, 2)
+	assert.Equal(t, 1.0, audits[1].Score)
+	assert.Contains(t, audits[1].Inputs, `"ignored_flags":1`)
+}
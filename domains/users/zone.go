@@ -0,0 +1,193 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+	"zssn/domains/users/store"
+)
+
+// ErrInvalidZone is returned when a zone has no name or isn't exactly one of a circle and a polygon
+var ErrInvalidZone = errors.New("a zone needs a name and either a center with a positive radius or a polygon with at least 3 vertices")
+
+// CreateZone lets an admin define a zone. Survivors whose last known location is inside the zone become members right away,
+// without entry events since they didn't move.
+func (u *UserService) CreateZone(ctx context.Context, adminID string, zone *entities.Zone) (*entities.Zone, error) {
+	if err := u.requireAdmin(ctx, adminID); err != nil {
+		return nil, err
+	}
+	if err := validateZone(zone); err != nil {
+		return nil, err
+	}
+
+	z := zone.ToZoneDBEntity()
+	z.Name = strings.TrimSpace(z.Name)
+	z.CreatedBy = adminID
+	err := u.Storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		if err := tx.CreateZone(ctx, z); err != nil {
+			return err
+		}
+		users, err := tx.FindInBox(ctx, z.Box())
+		if err != nil {
+			return err
+		}
+		for _, v := range users {
+			if !z.Contains(geo.Point{Latitude: v.Latitude, Longitude: v.Longitude}) {
+				continue
+			}
+			if err := tx.AddZoneMember(ctx, &store.ZoneMember{UserID: v.ID, ZoneID: z.ID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entities.FromZoneDBEntity(z), nil
+}
+
+// Zones returns every zone ordered by name
+func (u *UserService) Zones(ctx context.Context) ([]*entities.Zone, error) {
+	zones, err := u.Storage.FindZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entities.Zone, 0, len(zones))
+	for _, v := range zones {
+		res = append(res, entities.FromZoneDBEntity(v))
+	}
+	return res, nil
+}
+
+// DeleteZone lets an admin remove a zone, its members are dropped without exit events and its events are kept
+func (u *UserService) DeleteZone(ctx context.Context, adminID, id string) error {
+	if err := u.requireAdmin(ctx, adminID); err != nil {
+		return err
+	}
+	return u.Storage.WithTx(ctx, func(tx store.IUserStorage) error {
+		return tx.DeleteZone(ctx, id)
+	})
+}
+
+// ZoneEvents returns the survivors who entered or left the zone between from and to, oldest first.
+// Only admins can see them, zero times leave the range open. Every survivor in the events is added to the location audit.
+func (u *UserService) ZoneEvents(ctx context.Context, adminID, zoneID string, from, to time.Time) ([]*entities.ZoneEvent, error) {
+	if err := u.requireAdmin(ctx, adminID); err != nil {
+		return nil, err
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, ErrInvalidTimeRange
+	}
+	if _, err := u.Storage.FindZone(ctx, zoneID); err != nil {
+		return nil, err
+	}
+	events, err := u.Storage.FindZoneEvents(ctx, zoneID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	viewer := NewLocationViewer(adminID, true, AuditResourceZoneEvents, u.approximatePrecision)
+	res := make([]*entities.ZoneEvent, 0, len(events))
+	for _, v := range events {
+		viewer.Location(v.UserID, "", geo.Point{Latitude: v.Latitude, Longitude: v.Longitude})
+		res = append(res, entities.FromZoneEventDBEntity(v))
+	}
+	if err := viewer.Save(ctx, u.Storage); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UserZones returns the zones every given survivor is currently in keyed by survivor ID, survivors outside every zone are left out
+func (u *UserService) UserZones(ctx context.Context, ids ...string) (map[string][]*entities.Zone, error) {
+	zones, err := u.Storage.FindUserZones(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]*entities.Zone, len(zones))
+	for id, v := range zones {
+		for _, z := range v {
+			res[id] = append(res[id], entities.FromZoneDBEntity(z))
+		}
+	}
+	return res, nil
+}
+
+// updateZones moves the survivor in and out of the zones after they reported a new location and records an event for every change
+func updateZones(ctx context.Context, tx store.IUserStorage, id string, p geo.Point) error {
+	inside, err := tx.FindZonesAt(ctx, p)
+	if err != nil {
+		return err
+	}
+	current, err := tx.FindUserZones(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	was := make(map[string]bool, len(current[id]))
+	for _, v := range current[id] {
+		was[v.ID] = true
+	}
+	is := make(map[string]bool, len(inside))
+	for _, v := range inside {
+		is[v.ID] = true
+		if was[v.ID] {
+			continue
+		}
+		if err := tx.AddZoneMember(ctx, &store.ZoneMember{UserID: id, ZoneID: v.ID}); err != nil {
+			return err
+		}
+		if err := createZoneEvent(ctx, tx, id, v.ID, store.ZoneEnter, p); err != nil {
+			return err
+		}
+	}
+
+	left := make([]string, 0, len(was))
+	for zoneID := range was {
+		if !is[zoneID] {
+			left = append(left, zoneID)
+		}
+	}
+	sort.Strings(left)
+	for _, zoneID := range left {
+		if err := tx.DeleteZoneMember(ctx, id, zoneID); err != nil {
+			return err
+		}
+		if err := createZoneEvent(ctx, tx, id, zoneID, store.ZoneExit, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createZoneEvent(ctx context.Context, tx store.IUserStorage, userID, zoneID string, kind store.ZoneEventKind, p geo.Point) error {
+	return tx.CreateZoneEvent(ctx, &store.ZoneEvent{
+		ZoneID:    zoneID,
+		UserID:    userID,
+		Kind:      kind,
+		Latitude:  p.Latitude,
+		Longitude: p.Longitude,
+	})
+}
+
+func validateZone(z *entities.Zone) error {
+	if _, err := store.ParseZoneKind(z.Kind); err != nil {
+		return err
+	}
+	switch {
+	case strings.TrimSpace(z.Name) == "":
+		return ErrInvalidZone
+	case len(z.Polygon) > 0:
+		if z.Center != nil || z.RadiusKm != 0 || len(z.Polygon) < 3 {
+			return ErrInvalidZone
+		}
+	case z.Center == nil || z.RadiusKm <= 0:
+		return ErrInvalidZone
+	}
+	return nil
+}
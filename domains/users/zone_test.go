@@ -0,0 +1,127 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"zssn/database"
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+	"zssn/domains/users"
+	"zssn/domains/users/store"
+	"zssn/domains/users/store/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestZones(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.OpenTest()
+	require.NoError(t, err)
+	storage, err := store.New(db)
+	require.NoError(t, err)
+	admin, u, outsider := storetest.NewUser(t), storetest.NewUser(t), storetest.NewUser(t)
+	for _, v := range []*store.User{admin, u, outsider} {
+		require.NoError(t, storage.Create(ctx, v))
+	}
+	require.NoError(t, storage.UpdateRole(ctx, admin.ID, store.RoleAdmin))
+	svc, err := users.New(storage)
+	require.NoError(t, err)
+
+	// survivors already inside a new zone become members without events
+	require.NoError(t, svc.UpdateLocation(ctx, u.ID, 52.52, 13.405))
+	require.NoError(t, svc.UpdateLocation(ctx, outsider.ID, 52.6, 13.405))
+	camp, err := svc.CreateZone(ctx, admin.ID, &entities.Zone{
+		Name:     " camp ",
+		Kind:     "safe",
+		Center:   &geo.Point{Latitude: 52.52, Longitude: 13.405},
+		RadiusKm: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "camp", camp.Name)
+	assert.Equal(t, admin.ID, camp.CreatedBy)
+	horde, err := svc.CreateZone(ctx, admin.ID, &entities.Zone{
+		Name: "horde",
+		Kind: "danger",
+		Polygon: []geo.Point{
+			{Latitude: 52.53, Longitude: 13.39},
+			{Latitude: 52.53, Longitude: 13.42},
+			{Latitude: 52.55, Longitude: 13.42},
+			{Latitude: 52.55, Longitude: 13.39},
+		},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, horde.Center)
+
+	zones, err := svc.UserZones(ctx, u.ID, outsider.ID)
+	require.NoError(t, err)
+	require.Len(t, zones[u.ID], 1)
+	assert.Equal(t, camp.ID, zones[u.ID][0].ID)
+	assert.NotContains(t, zones, outsider.ID)
+
+	// moving from the camp into the horde leaves one and enters the other, staying inside changes nothing
+	require.NoError(t, svc.UpdateLocation(ctx, u.ID, 52.54, 13.405))
+	require.NoError(t, svc.UpdateLocation(ctx, u.ID, 52.545, 13.41))
+	zones, err = svc.UserZones(ctx, u.ID)
+	require.NoError(t, err)
+	require.Len(t, zones[u.ID], 1)
+	assert.Equal(t, horde.ID, zones[u.ID][0].ID)
+
+	events, err := svc.ZoneEvents(ctx, admin.ID, camp.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "exit", events[0].Kind)
+	assert.Equal(t, u.ID, events[0].UserID)
+	assert.Equal(t, 52.54, events[0].Latitude)
+	events, err = svc.ZoneEvents(ctx, admin.ID, horde.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "enter", events[0].Kind)
+
+	_, err = svc.ZoneEvents(ctx, u.ID, horde.ID, time.Time{}, time.Time{})
+	assert.True(t, errors.Is(err, users.ErrNotAdmin))
+	_, err = svc.ZoneEvents(ctx, admin.ID, "unknown", time.Time{}, time.Time{})
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	// deleting a zone drops its members
+	assert.True(t, errors.Is(svc.DeleteZone(ctx, u.ID, horde.ID), users.ErrNotAdmin))
+	require.NoError(t, svc.DeleteZone(ctx, admin.ID, horde.ID))
+	zones, err = svc.UserZones(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Empty(t, zones)
+	all, err := svc.Zones(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, camp.ID, all[0].ID)
+}
+
+func TestCreateZoneValidation(t *testing.T) {
+	ctx := context.Background()
+	storage := users.NewMockStore()
+	admin := storetest.NewUser(t)
+	require.NoError(t, storage.Create(ctx, admin))
+	require.NoError(t, storage.UpdateRole(ctx, admin.ID, store.RoleAdmin))
+	svc, err := users.New(storage)
+	require.NoError(t, err)
+	center := &geo.Point{Latitude: 1, Longitude: 1}
+	triangle := []geo.Point{{Latitude: 0, Longitude: 0}, {Latitude: 1, Longitude: 0}, {Latitude: 0, Longitude: 1}}
+
+	for name, zone := range map[string]*entities.Zone{
+		"no name":         {Kind: "safe", Center: center, RadiusKm: 1},
+		"no radius":       {Name: "a", Kind: "safe", Center: center},
+		"no center":       {Name: "a", Kind: "safe", RadiusKm: 1},
+		"two vertices":    {Name: "a", Kind: "safe", Polygon: triangle[:2]},
+		"circle and area": {Name: "a", Kind: "safe", Center: center, RadiusKm: 1, Polygon: triangle},
+	} {
+		_, err := svc.CreateZone(ctx, admin.ID, zone)
+		assert.True(t, errors.Is(err, users.ErrInvalidZone), name)
+	}
+	_, err = svc.CreateZone(ctx, admin.ID, &entities.Zone{Name: "a", Kind: "haunted", Polygon: triangle})
+	assert.EqualError(t, err, `unknown zone kind "haunted"`)
+	_, err = svc.CreateZone(ctx, admin.ID, &entities.Zone{Name: "a", Kind: "quarantine", Polygon: triangle})
+	assert.NoError(t, err)
+}
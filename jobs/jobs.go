@@ -0,0 +1,31 @@
+// Package jobs runs the background work of the service, like the collusion detection, on a schedule.
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Func is the work a job does on every run
+type Func func(ctx context.Context) error
+
+// Every runs fn once every interval until ctx is done. The first run happens after the first interval.
+// Errors are logged and don't stop the job, a run that takes longer than the interval delays the next one.
+func Every(ctx context.Context, name string, interval time.Duration, fn Func) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				log.Printf("job %s: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Every(ctx, "test", time.Millisecond, func(ctx context.Context) error {
+			// failed runs don't stop the job
+			if atomic.AddInt32(&runs, 1) >= 3 {
+				cancel()
+			}
+			return errors.New("failed")
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job didn't stop after the context was cancelled")
+	}
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&runs), int32(3))
+}
+
+func TestEveryDisabled(t *testing.T) {
+	// a non-positive interval disables the job instead of blocking forever
+	Every(context.Background(), "test", 0, func(ctx context.Context) error {
+		t.Fatal("disabled job ran")
+		return nil
+	})
+}
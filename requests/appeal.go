@@ -0,0 +1,23 @@
+package requests
+
+import "fmt"
+
+var errInvalidReason = fmt.Errorf("invalid reason")
+
+// Appeal request format for appealing an infection
+type Appeal struct {
+	Reason string `json:"reason"`
+}
+
+// AppealVote request format for voting on or resolving an appeal
+type AppealVote struct {
+	Approve bool `json:"approve"`
+}
+
+// Validate makes sure the survivor explained why they aren't infected
+func (a *Appeal) Validate() error {
+	if a.Reason == "" {
+		return errInvalidReason
+	}
+	return nil
+}
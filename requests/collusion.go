@@ -0,0 +1,6 @@
+package requests
+
+// ResolveReview request format for confirming or dismissing a collusion review
+type ResolveReview struct {
+	Confirm bool `json:"confirm"`
+}
@@ -1,6 +1,11 @@
 package requests
 
-import "fmt"
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"zssn/domains/entities"
+)
 
 var (
 	errInvalidName      = fmt.Errorf("invalid name")
@@ -8,6 +13,8 @@ var (
 	errInvalidAge       = fmt.Errorf("invalid age")
 	errInvalidGender    = fmt.Errorf("invalid gender")
 	errInvalidInventory = fmt.Errorf("invalid inventory")
+	errInvalidNote      = fmt.Errorf("note cannot be longer than %d characters", maxNoteLength)
+	errInvalidLocation  = fmt.Errorf("invalid location")
 )
 
 // Survivor sample survivor request format
@@ -22,9 +29,16 @@ type Survivor struct {
 	Token     string      `json:"token"`
 }
 
-// FlagUser request format for flagging infected users
+// maxNoteLength maximum number of characters in a flag note
+const maxNoteLength = 500
+
+// FlagUser request format for flagging infected users, everything but the infected user is optional
 type FlagUser struct {
-	InfectedUserID string `json:"infected_user_id"`
+	InfectedUserID string   `json:"infected_user_id"`
+	Note           string   `json:"note"`
+	Latitude       *float64 `json:"latitude"`
+	Longitude      *float64 `json:"longitude"`
+	Severity       string   `json:"severity"`
 }
 
 // NewTokenRequest request format for requesting new tokens since no auths
@@ -38,6 +52,11 @@ type UpdateLocation struct {
 	Longitude float64 `json:"longitude"`
 }
 
+// UpdateVisibility request format for changing how precisely others see the user's location
+type UpdateVisibility struct {
+	Visibility string `json:"visibility"`
+}
+
 // Validate makes sure that all important fields are provided
 func (s *Survivor) Validate() error {
 	switch {
@@ -56,3 +75,33 @@ func (s *Survivor) Validate() error {
 		return nil
 	}
 }
+
+// UpdateStatus request format for moving a survivor to another status
+type UpdateStatus struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// Validate makes sure the evidence given with the flag is usable
+func (f *FlagUser) Validate() error {
+	switch {
+	case utf8.RuneCountInString(f.Note) > maxNoteLength:
+		return errInvalidNote
+	case (f.Latitude == nil) != (f.Longitude == nil):
+		return errInvalidLocation
+	case f.Latitude != nil && (*f.Latitude < -90 || *f.Latitude > 90 || *f.Longitude < -180 || *f.Longitude > 180):
+		return errInvalidLocation
+	default:
+		return nil
+	}
+}
+
+// Evidence returns the evidence given with the flag
+func (f *FlagUser) Evidence() *entities.FlagEvidence {
+	return &entities.FlagEvidence{
+		Note:      f.Note,
+		Latitude:  f.Latitude,
+		Longitude: f.Longitude,
+		Severity:  f.Severity,
+	}
+}
@@ -0,0 +1,40 @@
+package requests
+
+import (
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+)
+
+// Zone request format for defining a zone, either a center with a radius or a polygon
+type Zone struct {
+	Name     string      `json:"name"`
+	Kind     string      `json:"kind"`
+	Center   *geo.Point  `json:"center"`
+	RadiusKm float64     `json:"radius_km"`
+	Polygon  []geo.Point `json:"polygon"`
+}
+
+// Validate makes sure every coordinate of the zone is on the map, the shape is checked by the user service
+func (z *Zone) Validate() error {
+	points := z.Polygon
+	if z.Center != nil {
+		points = append([]geo.Point{*z.Center}, points...)
+	}
+	for _, v := range points {
+		if v.Latitude < -90 || v.Latitude > 90 || v.Longitude < -180 || v.Longitude > 180 {
+			return errInvalidLocation
+		}
+	}
+	return nil
+}
+
+// Zone returns the zone described by the request
+func (z *Zone) Zone() *entities.Zone {
+	return &entities.Zone{
+		Name:     z.Name,
+		Kind:     z.Kind,
+		Center:   z.Center,
+		RadiusKm: z.RadiusKm,
+		Polygon:  z.Polygon,
+	}
+}
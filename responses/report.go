@@ -0,0 +1,78 @@
+package responses
+
+import (
+	"time"
+
+	"zssn/domains/entities"
+)
+
+// SurvivorsPoint a point of the clean survivors series
+type SurvivorsPoint struct {
+	Time       time.Time `json:"time"`
+	Total      uint32    `json:"total_survivors"`
+	Clean      uint32    `json:"clean"`
+	Percentage float64   `json:"percentage_clean"`
+}
+
+// InfectedPoint a point of the infected survivors series
+type InfectedPoint struct {
+	Time       time.Time `json:"time"`
+	Total      uint32    `json:"total_survivors"`
+	Infected   uint32    `json:"infected_survivors"`
+	Percentage float64   `json:"percentage_infected"`
+}
+
+// ResourcesPoint a point of the resources series, the balance of every item survivors can still trade
+type ResourcesPoint struct {
+	Time      time.Time         `json:"time"`
+	Resources map[string]uint32 `json:"resources"`
+}
+
+// LostPointsPoint a point of the lost points series
+type LostPointsPoint struct {
+	Time       time.Time `json:"time"`
+	LostPoints uint32    `json:"lost_points"`
+}
+
+// SurvivorsHistory converts the snapshots to the clean survivors series
+func SurvivorsHistory(snapshots []*entities.ReportSnapshot) []*SurvivorsPoint {
+	res := make([]*SurvivorsPoint, 0, len(snapshots))
+	for _, v := range snapshots {
+		res = append(res, &SurvivorsPoint{Time: v.Time, Total: v.Total, Clean: v.Clean, Percentage: percentage(v.Clean, v.Total)})
+	}
+	return res
+}
+
+// InfectedHistory converts the snapshots to the infected survivors series
+func InfectedHistory(snapshots []*entities.ReportSnapshot) []*InfectedPoint {
+	res := make([]*InfectedPoint, 0, len(snapshots))
+	for _, v := range snapshots {
+		res = append(res, &InfectedPoint{Time: v.Time, Total: v.Total, Infected: v.Infected, Percentage: percentage(v.Infected, v.Total)})
+	}
+	return res
+}
+
+// ResourcesHistory converts the snapshots to the resources series
+func ResourcesHistory(snapshots []*entities.ReportSnapshot) []*ResourcesPoint {
+	res := make([]*ResourcesPoint, 0, len(snapshots))
+	for _, v := range snapshots {
+		res = append(res, &ResourcesPoint{Time: v.Time, Resources: v.Resources})
+	}
+	return res
+}
+
+// LostPointsHistory converts the snapshots to the lost points series
+func LostPointsHistory(snapshots []*entities.ReportSnapshot) []*LostPointsPoint {
+	res := make([]*LostPointsPoint, 0, len(snapshots))
+	for _, v := range snapshots {
+		res = append(res, &LostPointsPoint{Time: v.Time, LostPoints: v.LostPoints})
+	}
+	return res
+}
+
+func percentage(part, total uint32) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100.0
+}
@@ -0,0 +1,140 @@
+package servers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"zssn/domains/entities"
+	"zssn/domains/users"
+	iusr "zssn/domains/users/store"
+	"zssn/requests"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func (s *Server) appealRoutes() {
+	apr := s.Router.Group("/appeals")
+	apr.Get("/:id", s.findAppeal)
+
+	apr.Use(s.authMiddleware())
+	apr.Post("", s.submitAppeal)
+	apr.Post("/:id/votes", s.voteAppeal)
+	apr.Post("/:id/resolve", s.resolveAppeal)
+}
+
+func (s *Server) submitAppeal(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	var req *requests.Appeal
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	res, err := s.userService.SubmitAppeal(ctx.Context(), userID, req.Reason)
+	if err != nil {
+		return ctx.Status(appealErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusCreated).JSON(res)
+}
+
+func (s *Server) findAppeal(ctx *fiber.Ctx) error {
+	res, err := s.userService.FindAppeal(ctx.Context(), ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(appealErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) voteAppeal(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	var req *requests.AppealVote
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	res, err := s.userService.VoteAppeal(ctx.Context(), userID, ctx.Params("id"), req.Approve)
+	return s.appealResolved(ctx, res, err)
+}
+
+func (s *Server) resolveAppeal(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	var req *requests.AppealVote
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	res, err := s.userService.ResolveAppeal(ctx.Context(), userID, ctx.Params("id"), req.Approve)
+	return s.appealResolved(ctx, res, err)
+}
+
+// appealResolved answers a vote or a resolution of an appeal.
+// The reports change once the appeal cleared the survivor, approving the appeal of someone who has been cured
+// or died in the meantime doesn't change anything.
+func (s *Server) appealResolved(ctx *fiber.Ctx, appeal *entities.Appeal, err error) error {
+	if errors.Is(err, users.ErrEffectsPending) || err == nil && appeal.Transition != nil {
+		defer s.invalidateReports()
+	}
+	if err != nil {
+		return ctx.Status(appealErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(appeal)
+}
+
+func appealErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, users.ErrAppealPending), errors.Is(err, users.ErrAppealResolved),
+		errors.Is(err, iusr.ErrDuplicateVote):
+		return http.StatusConflict
+	case errors.Is(err, users.ErrNotAdmin), errors.Is(err, users.ErrNotEligibleVoter):
+		return http.StatusForbidden
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, users.ErrEffectsPending):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,77 @@
+package servers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"zssn/domains/entities"
+	"zssn/domains/users"
+	"zssn/requests"
+	"zssn/responses"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockedAppeals(t *testing.T) {
+	s := newMockSetup(t, WithUserOptions(users.WithAppealQuorum(2)))
+	svr := s.svr
+
+	target := createMockUser(t, svr)
+	b, err := json.Marshal(requests.FlagUser{InfectedUserID: target.ID})
+	require.NoError(t, err)
+	var flaggers []responses.User
+	for i := 0; i < 3; i++ {
+		u := createMockUser(t, svr)
+		flaggers = append(flaggers, u)
+		res := handleServerRequest(t, svr, http.MethodPost, "/users/flag", u.Token, b)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	// retracting a flag clears the survivor once the policy is no longer met
+	res := handleServerRequest(t, svr, http.MethodDelete, "/users/flag/"+target.ID, flaggers[0].Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{target.ID}, s.restored)
+	res = handleServerRequest(t, svr, http.MethodDelete, "/users/flag/"+target.ID, flaggers[0].Token, nil)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodPost, "/users/flag", flaggers[0].Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	b, err = json.Marshal(requests.Appeal{Reason: "It's ketchup"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/appeals", flaggers[0].Token, b)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodPost, "/appeals", target.Token, b)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	var appeal entities.Appeal
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&appeal))
+	res = handleServerRequest(t, svr, http.MethodPost, "/appeals", target.Token, b)
+	require.Equal(t, http.StatusConflict, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/appeals/"+appeal.ID, "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	b, err = json.Marshal(requests.AppealVote{Approve: true})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/appeals/"+appeal.ID+"/votes", target.Token, b)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodPost, "/appeals/"+appeal.ID+"/resolve", flaggers[1].Token, b)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	voter := createMockUser(t, svr)
+	res = handleServerRequest(t, svr, http.MethodPost, "/appeals/"+appeal.ID+"/votes", voter.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodPost, "/appeals/"+appeal.ID+"/votes", voter.Token, b)
+	require.Equal(t, http.StatusConflict, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodPost, "/appeals/"+appeal.ID+"/resolve", s.admin.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&appeal))
+	assert.Equal(t, "approved", appeal.Status)
+	assert.Equal(t, s.admin.ID, appeal.ResolvedBy)
+	assert.Equal(t, []string{target.ID, target.ID}, s.restored)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/appeals/unknown", "", nil)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
@@ -0,0 +1,77 @@
+package servers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"zssn/domains/users"
+	"zssn/requests"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func (s *Server) collusionRoutes() {
+	col := s.Router.Group("/collusion")
+	col.Use(s.authMiddleware())
+	col.Get("/reviews", s.collusionReviews)
+	col.Post("/reviews/:id/resolve", s.resolveCollusionReview)
+}
+
+func (s *Server) collusionReviews(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	res, err := s.userService.CollusionReviews(ctx.Context(), userID, ctx.Query("status"))
+	if err != nil {
+		return ctx.Status(collusionErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) resolveCollusionReview(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	var req *requests.ResolveReview
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	res, err := s.userService.ResolveCollusionReview(ctx.Context(), userID, ctx.Params("id"), req.Confirm)
+	if err != nil {
+		return ctx.Status(collusionErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func collusionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, users.ErrReviewResolved):
+		return http.StatusConflict
+	case errors.Is(err, users.ErrNotAdmin):
+		return http.StatusForbidden
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,71 @@
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"zssn/domains/entities"
+	"zssn/domains/users"
+	"zssn/requests"
+	"zssn/responses"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockedCollusionReviews(t *testing.T) {
+	s := newMockSetup(t, WithUserOptions(users.WithInfectionThreshold(10)))
+	svr, adminUser := s.svr, s.admin
+
+	// three accounts created together all flagging the same two survivors
+	target, decoy := createMockUser(t, svr), createMockUser(t, svr)
+	ring := map[string]bool{}
+	var flagger responses.User
+	for i := 0; i < 3; i++ {
+		flagger = createMockUser(t, svr)
+		ring[flagger.ID] = true
+		for _, id := range []string{target.ID, decoy.ID} {
+			b, err := json.Marshal(requests.FlagUser{InfectedUserID: id})
+			require.NoError(t, err)
+			res := handleServerRequest(t, svr, http.MethodPost, "/users/flag", flagger.Token, b)
+			require.Equal(t, http.StatusOK, res.StatusCode)
+		}
+	}
+	_, err := svr.userService.DetectCollusion(context.Background())
+	require.NoError(t, err)
+
+	res := handleServerRequest(t, svr, http.MethodGet, "/collusion/reviews?status=pending", flagger.Token, nil)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodGet, "/collusion/reviews?status=unknown", adminUser.Token, nil)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodGet, "/collusion/reviews?status=pending", adminUser.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var reviews []entities.CollusionReview
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&reviews))
+	var review entities.CollusionReview
+	queued := 0
+	for _, v := range reviews {
+		if ring[v.UserID] {
+			queued++
+			review = v
+			assert.Contains(t, v.Signals, users.SignalSharedTargets)
+			assert.ElementsMatch(t, []string{target.ID, decoy.ID}, v.Targets)
+		}
+	}
+	require.Equal(t, len(ring), queued)
+
+	b, err := json.Marshal(requests.ResolveReview{Confirm: true})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/collusion/reviews/"+review.ID+"/resolve", adminUser.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var resolved entities.CollusionReview
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&resolved))
+	assert.Equal(t, "confirmed", resolved.Status)
+	res = handleServerRequest(t, svr, http.MethodPost, "/collusion/reviews/"+review.ID+"/resolve", adminUser.Token, b)
+	require.Equal(t, http.StatusConflict, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodPost, "/collusion/reviews/"+uuid.NewString()+"/resolve", adminUser.Token, b)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
@@ -0,0 +1,72 @@
+package servers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"zssn/domains/export"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (s *Server) exportRoutes() {
+	ex := s.Router.Group("/exports")
+	ex.Use(s.authMiddleware())
+	ex.Get("/:layer", s.export)
+}
+
+func (s *Server) export(ctx *fiber.Ctx) error {
+	layer, err := export.ParseLayer(ctx.Params("layer"))
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	format, err := export.ParseFormat(ctx.Query("format"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	from, to, err := timeRange(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	box, err := boundingBox(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	userID := ctx.Locals("user_id").(string)
+	res, err := s.exportService.Export(ctx.Context(), userID, layer, export.Filter{From: from, To: to, Box: box})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, export.ErrInvalidTimeRange) || errors.Is(err, export.ErrInvalidBox) {
+			status = http.StatusBadRequest
+		}
+		return ctx.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, "zssn "+string(layer), res); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	ctx.Set(fiber.HeaderContentType, format.ContentType())
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="zssn-%s%s"`, layer, format.Extension()))
+	return ctx.Status(http.StatusOK).Send(buf.Bytes())
+}
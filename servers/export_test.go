@@ -0,0 +1,50 @@
+package servers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"zssn/domains/geo"
+	"zssn/requests"
+
+	"github.com/brianvoe/gofakeit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockedExport(t *testing.T) {
+	svr := newMockServer(t)
+	survivor := createMockUser(t, svr)
+	center := geo.Point{Latitude: gofakeit.Float64Range(-60, 60), Longitude: gofakeit.Float64Range(-170, 170)}
+	b, err := json.Marshal(requests.UpdateLocation{Latitude: center.Latitude, Longitude: center.Longitude})
+	require.NoError(t, err)
+	res := handleServerRequest(t, svr, http.MethodPatch, "/users/location", survivor.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	// other tests share the mock storage, the box only covers this survivor
+	bbox := fmt.Sprintf("%f,%f,%f,%f", center.Longitude-0.001, center.Latitude-0.001, center.Longitude+0.001, center.Latitude+0.001)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/exports/survivors?bbox="+bbox, "", nil)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/exports/survivors?bbox="+bbox, survivor.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/geo+json", res.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="zssn-survivors.geojson"`, res.Header.Get("Content-Disposition"))
+	var fc geo.FeatureCollection
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&fc))
+	require.Len(t, fc.Features, 1)
+	assert.Empty(t, fc.Features[0].ID)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/exports/zones?format=kml&bbox="+bbox, survivor.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/vnd.google-earth.kml+xml", res.Header.Get("Content-Type"))
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/exports/bunkers", survivor.Token, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	for _, query := range []string{"format=shp", "bbox=1,2,3", "bbox=3,0,1,1", "from=yesterday", "from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z"} {
+		res = handleServerRequest(t, svr, http.MethodGet, "/exports/infected?"+query, survivor.Token, nil)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+	}
+}
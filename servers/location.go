@@ -0,0 +1,129 @@
+package servers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+	iusr "zssn/domains/users/store"
+	"zssn/requests"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func (s *Server) locationHistory(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	from, to, err := timeRange(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	res, err := s.userService.LocationHistory(ctx.Context(), userID, from, to)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		return ctx.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) updateVisibility(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	var req *requests.UpdateVisibility
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if err := s.userService.UpdateVisibility(ctx.Context(), userID, req.Visibility); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, iusr.ErrUnknownVisibility):
+			status = http.StatusBadRequest
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		}
+		return ctx.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	defer s.invalidateReports()
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "location visibility updated successfully",
+	})
+}
+
+func (s *Server) locationAudits(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	res, err := s.userService.LocationAudits(ctx.Context(), userID)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if res == nil {
+		res = []*entities.LocationAudit{}
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+// timeRange parses the optional from and to query parameters, missing ones are left zero
+func timeRange(ctx *fiber.Ctx) (from, to time.Time, err error) {
+	for name, t := range map[string]*time.Time{"from": &from, "to": &to} {
+		v := ctx.Query(name)
+		if v == "" {
+			continue
+		}
+		if *t, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("invalid %s, expected an RFC 3339 time", name)
+		}
+	}
+	return from, to, nil
+}
+
+// boundingBox parses the optional bbox query parameter given as west,south,east,north like in GeoJSON, nil if it's missing
+func boundingBox(ctx *fiber.Ctx) (*geo.Box, error) {
+	v := ctx.Query("bbox")
+	if v == "" {
+		return nil, nil
+	}
+	box, err := geo.ParseBox(v)
+	if err != nil {
+		return nil, err
+	}
+	return &box, nil
+}
@@ -0,0 +1,88 @@
+package servers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"zssn/domains/entities"
+	"zssn/requests"
+	"zssn/responses"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockedLocationVisibility(t *testing.T) {
+	svr := newMockServer(t)
+	survivor := createMockUser(t, svr)
+	res := handleServerRequest(t, svr, http.MethodGet, "/users/me", survivor.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var u responses.User
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&u))
+	assert.Equal(t, "approximate", u.Visibility)
+
+	b, err := json.Marshal(requests.UpdateVisibility{Visibility: "hidden"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/me/visibility", survivor.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/me", survivor.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&u))
+	assert.Equal(t, "hidden", u.Visibility)
+
+	b, err = json.Marshal(requests.UpdateVisibility{Visibility: "blurry"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/me/visibility", survivor.Token, b)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/me/visibility", "", b)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/me/location-audits", survivor.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var audits []entities.LocationAudit
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&audits))
+	assert.Empty(t, audits)
+}
+
+func TestMockedLocationHistory(t *testing.T) {
+	svr := newMockServer(t)
+	survivor := createMockUser(t, svr)
+	start := time.Now().Add(-time.Second)
+	for i := 1; i <= 2; i++ {
+		b, err := json.Marshal(requests.UpdateLocation{Latitude: float64(i), Longitude: float64(-i)})
+		require.NoError(t, err)
+		res := handleServerRequest(t, svr, http.MethodPatch, "/users/location", survivor.Token, b)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	res := handleServerRequest(t, svr, http.MethodGet, "/users/me/locations", survivor.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var trail []entities.LocationPoint
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&trail))
+	require.Len(t, trail, 2)
+	assert.Equal(t, float64(1), trail[0].Latitude)
+	assert.Equal(t, float64(-2), trail[1].Longitude)
+
+	query := url.Values{"from": {start.Format(time.RFC3339)}, "to": {time.Now().Add(time.Minute).Format(time.RFC3339)}}
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/me/locations?"+query.Encode(), survivor.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	trail = nil
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&trail))
+	assert.Len(t, trail, 2)
+
+	query = url.Values{"from": {time.Now().Add(time.Hour).Format(time.RFC3339)}}
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/me/locations?"+query.Encode(), survivor.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	trail = nil
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&trail))
+	assert.Empty(t, trail)
+
+	query = url.Values{"from": {time.Now().Format(time.RFC3339)}, "to": {time.Now().Add(-time.Hour).Format(time.RFC3339)}}
+	for _, q := range []string{"from=yesterday", query.Encode()} {
+		res = handleServerRequest(t, svr, http.MethodGet, "/users/me/locations?"+q, survivor.Token, nil)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, q)
+	}
+}
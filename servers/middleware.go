@@ -1,15 +1,20 @@
 package servers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"zssn/domains/core"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
-func authMiddleware() fiber.Handler {
+// errAdminToken is returned when an admin uses, or asks for, a token that wasn't issued from the command line
+var errAdminToken = errors.New("admin tokens are only issued with zssn admins token")
+
+func (s *Server) authMiddleware() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		auth := ctx.Request().Header.Peek("Authorization")
 		tokenString := strings.Split(string(auth), " ")
@@ -26,6 +31,22 @@ func authMiddleware() fiber.Handler {
 				"error":   err.Error(),
 			})
 		}
+		if !td.Admin {
+			// admin rights come from the role, a token anyone could get for the admin's email mustn't carry them
+			admin, err := s.userService.IsAdmin(ctx.Context(), td.UserID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+					"success": false,
+					"error":   err.Error(),
+				})
+			}
+			if admin {
+				return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+					"success": false,
+					"error":   errAdminToken.Error(),
+				})
+			}
+		}
 		ctx.Locals("user_id", td.UserID)
 		return ctx.Next()
 	}
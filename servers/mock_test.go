@@ -0,0 +1,107 @@
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"zssn/domains/core"
+	"zssn/domains/entities"
+	"zssn/domains/export"
+	"zssn/domains/inventory"
+	"zssn/domains/reports"
+	"zssn/domains/trade"
+	tmocks "zssn/domains/trade/mocks"
+	"zssn/domains/users"
+	"zssn/domains/users/store"
+	"zssn/responses"
+
+	"github.com/stretchr/testify/require"
+)
+
+// grantAdmin gives the survivor the admin role in the mocked store and returns an admin token, like zssn admins does
+func grantAdmin(t *testing.T, id string) string {
+	t.Helper()
+	usr, err := users.NewMockStore().Find(context.Background(), id)
+	require.NoError(t, err)
+	require.NoError(t, users.NewMockStore().UpdateRole(context.Background(), id, store.RoleAdmin))
+	td := core.TokenData{UserID: id, Email: usr.Email, Admin: true}
+	token, err := td.Generate()
+	require.NoError(t, err)
+	return token
+}
+
+// newMockServer builds the server on top of the mocked stores.
+// The user service is built like setupServices does, with the user options and the status effects the options ask for.
+func newMockServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+	cfg := &Server{
+		inventoryService:           inventory.New(inventory.NewMockStore()),
+		restoreInventoryOnRecovery: true,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	usrSvc, err := users.New(users.NewMockStore(), append(cfg.statusEffects(), cfg.userOptions...)...)
+	require.NoError(t, err)
+	invSvc := cfg.inventoryService
+	trStore := tmocks.NewStoreMock()
+
+	defaults := []Option{
+		WithUserService(usrSvc),
+		WithInventoryService(invSvc),
+		WithTradeService(trade.New(trStore, usrSvc, invSvc)),
+		WithExportService(export.New(users.NewMockStore(), trStore)),
+		WithReportService(reports.New(&reports.MockReportRepository{
+			SurvivorsFunc: func(ctx context.Context) (*entities.Survivor, error) {
+				return &entities.Survivor{Total: 4, Clean: 3, Percentage: 75}, nil
+			},
+		})),
+	}
+	svr, err := New(nil, append(defaults, opts...)...)
+	require.NoError(t, err)
+	require.NotNil(t, svr)
+	return svr
+}
+
+// mockSetup a mocked server with an admin, recording whose inventory got blocked and restored
+type mockSetup struct {
+	svr               *Server
+	admin             responses.User
+	blocked, restored []string
+}
+
+// newMockSetup builds the mocked server on top of an inventory store recording the blocks and restores, and signs up an admin.
+// The options are applied after the inventory service, so a test can still replace it.
+func newMockSetup(t *testing.T, opts ...Option) *mockSetup {
+	t.Helper()
+	s := &mockSetup{}
+	invStore := inventory.NewMockStore()
+	block, restore := invStore.UpdateUserInventoryAccessibilityFunc, invStore.RestoreUserInventoryAccessibilityFunc
+	invStore.UpdateUserInventoryAccessibilityFunc = func(ctx context.Context, userID string) error {
+		s.blocked = append(s.blocked, userID)
+		return block(ctx, userID)
+	}
+	invStore.RestoreUserInventoryAccessibilityFunc = func(ctx context.Context, userID string) error {
+		s.restored = append(s.restored, userID)
+		return restore(ctx, userID)
+	}
+	s.svr = newMockServer(t, append([]Option{WithInventoryService(inventory.New(invStore))}, opts...)...)
+	s.admin = createMockUser(t, s.svr)
+	s.admin.Token = grantAdmin(t, s.admin.ID)
+	return s
+}
+
+func createMockUser(t *testing.T, svr *Server) responses.User {
+	t.Helper()
+	b, err := json.Marshal(newSurvivor(t))
+	require.NoError(t, err)
+
+	res := handleServerRequest(t, svr, http.MethodPost, "/users", "", b)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var result responses.User
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	return result
+}
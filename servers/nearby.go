@@ -0,0 +1,86 @@
+package servers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"zssn/domains/users"
+	"zssn/responses"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func (s *Server) nearbySurvivors(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	var radius float64
+	if v := ctx.Query("radius_km"); v != "" {
+		var err error
+		if radius, err = strconv.ParseFloat(v, 64); err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "invalid radius_km",
+			})
+		}
+	}
+	nearby, err := s.userService.Nearby(ctx.Context(), userID, radius)
+	if err != nil {
+		return ctx.Status(nearbyErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	res := make([]*responses.NearbySurvivor, 0, len(nearby))
+	if len(nearby) == 0 {
+		return ctx.Status(http.StatusOK).JSON(res)
+	}
+	ids := make([]string, 0, len(nearby))
+	for _, v := range nearby {
+		ids = append(ids, v.ID)
+	}
+	stock, err := s.inventoryService.FindMultipleInventory(ctx.Context(), ids...)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	for _, v := range nearby {
+		// only items the survivor still has and can access are tradable
+		items := []string{}
+		for _, inv := range stock[v.ID] {
+			if inv.Accessible && inv.Balance > 0 {
+				items = append(items, inv.Item.String())
+			}
+		}
+		sort.Strings(items)
+		res = append(res, &responses.NearbySurvivor{
+			ID:         v.ID,
+			Name:       v.Name,
+			Status:     v.Status,
+			DistanceKm: v.DistanceKm,
+			Items:      items,
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func nearbyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, users.ErrNotCleanSearcher):
+		return http.StatusForbidden
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,55 @@
+package servers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"zssn/requests"
+	"zssn/responses"
+
+	"github.com/brianvoe/gofakeit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockedNearbySurvivors(t *testing.T) {
+	svr := newMockServer(t)
+	createAt := func(lat, long float64) responses.User {
+		survivor := newSurvivor(t)
+		survivor.Latitude, survivor.Longitude = lat, long
+		b, err := json.Marshal(survivor)
+		require.NoError(t, err)
+		res := handleServerRequest(t, svr, http.MethodPost, "/users", "", b)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		var u responses.User
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&u))
+		return u
+	}
+	lat, long := gofakeit.Float64Range(-60, 60), gofakeit.Float64Range(-170, 170)
+	requester, neighbour := createAt(lat, long), createAt(lat+0.01, long)
+	// an exact location keeps the distance as it is
+	b, err := json.Marshal(requests.UpdateVisibility{Visibility: "exact"})
+	require.NoError(t, err)
+	res := handleServerRequest(t, svr, http.MethodPatch, "/users/me/visibility", neighbour.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/nearby?radius_km=2", requester.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var nearby []map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&nearby))
+	require.Len(t, nearby, 1)
+	assert.Equal(t, neighbour.ID, nearby[0]["id"])
+	assert.Equal(t, float64(2), nearby[0]["distance_km"])
+	assert.Equal(t, []interface{}{"Ammunition", "Food", "Medication", "Water"}, nearby[0]["items"])
+	// the exact location stays private
+	assert.NotContains(t, nearby[0], "latitude")
+	assert.NotContains(t, nearby[0], "longitude")
+
+	for _, query := range []string{"radius_km=far", "radius_km=-1", "radius_km=500"} {
+		res = handleServerRequest(t, svr, http.MethodGet, "/users/nearby?"+query, requester.Token, nil)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+	}
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/nearby", "", nil)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
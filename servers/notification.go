@@ -0,0 +1,51 @@
+package servers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func (s *Server) notifications(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	res, err := s.userService.Notifications(ctx.Context(), userID, ctx.Query("unread") == "true")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) readNotification(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	if err := s.userService.ReadNotification(ctx.Context(), userID, ctx.Params("id")); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		return ctx.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "notification marked as read",
+	})
+}
@@ -0,0 +1,60 @@
+package servers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"zssn/domains/entities"
+	"zssn/domains/users"
+	"zssn/requests"
+	"zssn/responses"
+
+	"github.com/brianvoe/gofakeit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockedContactTracing(t *testing.T) {
+	svr := newMockServer(t, WithContactTracing(true))
+	target, neighbour := createMockUser(t, svr), createMockUser(t, svr)
+	lat, long := gofakeit.Float64Range(-60, 60), gofakeit.Float64Range(-170, 170)
+	for _, u := range []responses.User{target, neighbour} {
+		b, err := json.Marshal(requests.UpdateLocation{Latitude: lat, Longitude: long})
+		require.NoError(t, err)
+		res := handleServerRequest(t, svr, http.MethodPatch, "/users/location", u.Token, b)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	b, err := json.Marshal(requests.FlagUser{InfectedUserID: target.ID})
+	require.NoError(t, err)
+	for i := 0; i < users.DefaultInfectionThreshold; i++ {
+		flagger := createMockUser(t, svr)
+		res := handleServerRequest(t, svr, http.MethodPost, "/users/flag", flagger.Token, b)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	res := handleServerRequest(t, svr, http.MethodGet, "/users/me/notifications?unread=true", neighbour.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var notifications []entities.Notification
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&notifications))
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "exposure", notifications[0].Kind)
+	assert.Equal(t, target.ID, notifications[0].SourceID)
+
+	res = handleServerRequest(t, svr, http.MethodPost, "/users/me/notifications/"+notifications[0].ID+"/read", target.Token, nil)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodPost, "/users/me/notifications/"+notifications[0].ID+"/read", neighbour.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/me/notifications?unread=true", neighbour.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	notifications = nil
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&notifications))
+	assert.Empty(t, notifications)
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/me/notifications", neighbour.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&notifications))
+	require.Len(t, notifications, 1)
+	assert.NotNil(t, notifications[0].ReadAt)
+}
@@ -1,24 +1,146 @@
 package servers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"zssn/domains/entities"
+	"zssn/domains/geo"
+	"zssn/domains/reports"
+	"zssn/responses"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func (s *Server) reportRoutes() {
-	rsr := s.Router.Group("/reports")
-	rsr.Get("/survivors", nonInfectedSurvivor)
-	rsr.Get("/infected", infectedSurvivor)
-	rsr.Get("/lost-points", lostPoints)
-	rsr.Get("/resources", averageResourceShare)
+	rsr := s.Router.Group("/reports", s.reportCache())
+	rsr.Get("/survivors", s.nonInfectedSurvivor)
+	rsr.Get("/infected", s.infectedSurvivor)
+	rsr.Get("/lost-points", s.lostPoints)
+	rsr.Get("/resources", s.averageResourceShare)
+	rsr.Get("/statuses", s.statuses)
+	rsr.Get("/zones", s.zoneReport)
+	rsr.Get("/heatmap", s.heatmap)
+	rsr.Get("/forecast", s.forecast)
+	rsr.Get("/trades", s.tradeReport)
+	rsr.Get("/survivors/history", s.reportHistory(func(v []*entities.ReportSnapshot) interface{} { return responses.SurvivorsHistory(v) }))
+	rsr.Get("/infected/history", s.reportHistory(func(v []*entities.ReportSnapshot) interface{} { return responses.InfectedHistory(v) }))
+	rsr.Get("/resources/history", s.reportHistory(func(v []*entities.ReportSnapshot) interface{} { return responses.ResourcesHistory(v) }))
+	rsr.Get("/lost-points/history", s.reportHistory(func(v []*entities.ReportSnapshot) interface{} { return responses.LostPointsHistory(v) }))
 }
 
-func infectedSurvivor(ctx *fiber.Ctx) error {
-	res, err := reportService.InfectedSurvivors(ctx.Context())
+// reportHistory returns the handler of a history series, series picks the report out of the snapshots
+func (s *Server) reportHistory(series func([]*entities.ReportSnapshot) interface{}) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		from, to, err := timeRange(ctx)
+		if err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		interval, err := reports.ParseInterval(ctx.Query("interval"))
+		if err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		res, err := s.reportService.History(ctx.Context(), from, to, interval)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, reports.ErrInvalidTimeRange) || errors.Is(err, reports.ErrInvalidInterval) {
+				status = http.StatusBadRequest
+			}
+			return ctx.Status(status).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusOK).JSON(series(res))
+	}
+}
+
+func (s *Server) tradeReport(ctx *fiber.Ctx) error {
+	from, to, err := timeRange(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	opts := reports.TradeReportOptions{From: from, To: to}
+	if opts.Interval, err = reports.ParseInterval(ctx.Query("interval")); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if v := ctx.Query("limit"); v != "" {
+		if opts.Limit, err = strconv.Atoi(v); err != nil || opts.Limit == 0 {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   reports.ErrInvalidLimit.Error(),
+			})
+		}
+	}
+
+	res, err := s.reportService.Trades(ctx.Context(), opts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, reports.ErrInvalidTimeRange) || errors.Is(err, reports.ErrInvalidInterval) || errors.Is(err, reports.ErrInvalidLimit) {
+			status = http.StatusBadRequest
+		}
+		return ctx.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) forecast(ctx *fiber.Ctx) error {
+	res, err := s.reportService.Forecast(ctx.Context())
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) zoneReport(ctx *fiber.Ctx) error {
+	res, err := s.reportService.Zones(ctx.Context())
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if res == nil {
+		res = []*entities.ZoneReport{}
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) statuses(ctx *fiber.Ctx) error {
+	res, err := s.reportService.Statuses(ctx.Context())
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) infectedSurvivor(ctx *fiber.Ctx) error {
+	res, err := s.reportService.InfectedSurvivors(ctx.Context())
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -29,8 +151,8 @@ func infectedSurvivor(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(res)
 }
 
-func nonInfectedSurvivor(ctx *fiber.Ctx) error {
-	res, err := reportService.NonInfectedSurvivors(ctx.Context())
+func (s *Server) nonInfectedSurvivor(ctx *fiber.Ctx) error {
+	res, err := s.reportService.NonInfectedSurvivors(ctx.Context())
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -41,8 +163,8 @@ func nonInfectedSurvivor(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(res)
 }
 
-func averageResourceShare(ctx *fiber.Ctx) error {
-	res, err := reportService.ResourceSharing(ctx.Context())
+func (s *Server) averageResourceShare(ctx *fiber.Ctx) error {
+	res, err := s.reportService.ResourceSharing(ctx.Context())
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -58,8 +180,8 @@ func averageResourceShare(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(resp)
 }
 
-func lostPoints(ctx *fiber.Ctx) error {
-	res, err := reportService.LostPoints(ctx.Context())
+func (s *Server) lostPoints(ctx *fiber.Ctx) error {
+	res, err := s.reportService.LostPoints(ctx.Context())
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -72,3 +194,70 @@ func lostPoints(ctx *fiber.Ctx) error {
 		"data":    res,
 	})
 }
+
+func (s *Server) heatmap(ctx *fiber.Ctx) error {
+	box, err := boundingBox(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	opts := reports.HeatmapOptions{Box: box}
+	if v := ctx.Query("precision"); v != "" {
+		if opts.Precision, err = strconv.Atoi(v); err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "invalid precision",
+			})
+		}
+	}
+	if v := ctx.Query("cell_size"); v != "" {
+		if opts.CellSize, err = strconv.ParseFloat(v, 64); err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "invalid cell_size",
+			})
+		}
+	}
+
+	res, err := s.reportService.Heatmap(ctx.Context(), opts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, reports.ErrInvalidPrecision) || errors.Is(err, reports.ErrInvalidCellSize) ||
+			errors.Is(err, reports.ErrPrecisionAndCellSize) || errors.Is(err, reports.ErrInvalidBox) {
+			status = http.StatusBadRequest
+		}
+		return ctx.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if wantsGeoJSON(ctx) {
+		return geoJSON(ctx, reports.HeatmapGeoJSON(res))
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+// wantsGeoJSON returns true if the client asked for GeoJSON with the format query parameter or the Accept header
+func wantsGeoJSON(ctx *fiber.Ctx) bool {
+	if f := ctx.Query("format"); f != "" {
+		return strings.EqualFold(f, "geojson")
+	}
+	return ctx.Accepts(fiber.MIMEApplicationJSON, geoJSONMIME) == geoJSONMIME
+}
+
+// geoJSONMIME media type of GeoJSON documents, RFC 7946
+const geoJSONMIME = "application/geo+json"
+
+func geoJSON(ctx *fiber.Ctx, fc *geo.FeatureCollection) error {
+	b, err := json.Marshal(fc)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	ctx.Set(fiber.HeaderContentType, geoJSONMIME)
+	return ctx.Status(http.StatusOK).Send(b)
+}
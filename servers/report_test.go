@@ -1,11 +1,19 @@
 package servers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
+	"zssn/domains/core"
 	"zssn/domains/entities"
+	"zssn/domains/geo"
+	"zssn/domains/reports"
+	"zssn/domains/reports/repo"
+	"zssn/responses"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -32,3 +40,195 @@ func TestSurvivorReport(t *testing.T) {
 	assert.Equal(t, uint32(2), result.Clean)
 	assert.Equal(t, float64(100), result.Percentage)
 }
+
+func TestMockedSurvivorReport(t *testing.T) {
+	svr := newMockServer(t)
+
+	res := handleServerRequest(t, svr, http.MethodGet, "/reports/survivors", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var result entities.Survivor
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	assert.Equal(t, uint32(4), result.Total)
+	assert.Equal(t, uint32(3), result.Clean)
+}
+
+func TestMockedHeatmap(t *testing.T) {
+	var box *geo.Box
+	svr := newMockServer(t,
+		WithReportService(reports.New(&reports.MockReportRepository{
+			LocationsFunc: func(ctx context.Context, b *geo.Box) ([]*entities.SurvivorLocation, error) {
+				box = b
+				return []*entities.SurvivorLocation{
+					{UserID: "a", Latitude: 52.52, Longitude: 13.405, Status: "healthy", Resources: map[core.Item]uint32{core.ItemWater: 2}},
+					{UserID: "b", Latitude: 52.5201, Longitude: 13.4051, Status: "infected", Resources: map[core.Item]uint32{core.ItemWater: 1}},
+				}, nil
+			},
+		})),
+	)
+
+	res := handleServerRequest(t, svr, http.MethodGet, "/reports/heatmap?precision=4&bbox=13,52,14,53", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var cells []entities.HeatmapCell
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&cells))
+	require.Len(t, cells, 1)
+	assert.Equal(t, uint32(2), cells[0].Total)
+	assert.Equal(t, uint32(1), cells[0].Infected)
+	assert.Equal(t, uint32(3), cells[0].Resources["water"])
+	require.NotNil(t, box)
+	// the box is grown to whole cells
+	assert.True(t, box.Contains(geo.Point{Latitude: 52, Longitude: 13}))
+	assert.True(t, box.Contains(geo.Point{Latitude: 53, Longitude: 14}))
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/reports/heatmap?cell_size=0.5&format=geojson", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/geo+json", res.Header.Get("Content-Type"))
+	var fc geo.FeatureCollection
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&fc))
+	assert.Equal(t, "FeatureCollection", fc.Type)
+	require.Len(t, fc.Features, 1)
+	assert.Equal(t, "Polygon", fc.Features[0].Geometry.Type)
+
+	for _, query := range []string{"precision=x", "precision=9", "cell_size=1&precision=3", "bbox=1,2,3", "bbox=0,0,200,10"} {
+		res = handleServerRequest(t, svr, http.MethodGet, "/reports/heatmap?"+query, "", nil)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+	}
+}
+
+func TestMockedReportHistory(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var from, to time.Time
+	svr := newMockServer(t,
+		WithReportService(reports.New(&reports.MockReportRepository{
+			FindSnapshotsFunc: func(ctx context.Context, f, t time.Time) ([]*repo.Snapshot, error) {
+				from, to = f, t
+				return []*repo.Snapshot{
+					{Hour: day.Add(9 * time.Hour), Total: 4, Clean: 3, Infected: 1, LostPoints: 8, Resources: map[string]uint32{"water": 6}},
+					{Hour: day.Add(10 * time.Hour), Total: 4, Clean: 2, Infected: 2, LostPoints: 12, Resources: map[string]uint32{"water": 4}},
+				}, nil
+			},
+		})),
+	)
+
+	query := url.Values{"from": {day.Format(time.RFC3339)}, "to": {day.AddDate(0, 0, 1).Format(time.RFC3339)}, "interval": {"hour"}}
+	res := handleServerRequest(t, svr, http.MethodGet, "/reports/infected/history?"+query.Encode(), "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var infected []responses.InfectedPoint
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&infected))
+	require.Len(t, infected, 2)
+	assert.True(t, day.Equal(from))
+	assert.True(t, day.AddDate(0, 0, 1).Equal(to))
+	assert.Equal(t, uint32(1), infected[0].Infected)
+	assert.Equal(t, float64(50), infected[1].Percentage)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/reports/survivors/history", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var survivors []responses.SurvivorsPoint
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&survivors))
+	require.Len(t, survivors, 1)
+	assert.True(t, day.Equal(survivors[0].Time))
+	assert.Equal(t, uint32(2), survivors[0].Clean)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/reports/resources/history?interval=day", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var resources []responses.ResourcesPoint
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&resources))
+	require.Len(t, resources, 1)
+	assert.Equal(t, uint32(4), resources[0].Resources["water"])
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/reports/lost-points/history?interval=hour", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var lost []responses.LostPointsPoint
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&lost))
+	require.Len(t, lost, 2)
+	assert.Equal(t, uint32(12), lost[1].LostPoints)
+
+	for _, query := range []string{"interval=week", "from=yesterday", "from=2026-03-02T00:00:00Z&to=2026-03-01T00:00:00Z"} {
+		res = handleServerRequest(t, svr, http.MethodGet, "/reports/survivors/history?"+query, "", nil)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+	}
+}
+
+func TestMockedForecast(t *testing.T) {
+	svr := newMockServer(t,
+		WithReportService(reports.New(&reports.MockReportRepository{
+			SurvivorsFunc: func(ctx context.Context) (*entities.Survivor, error) {
+				return &entities.Survivor{Total: 4, Clean: 2}, nil
+			},
+			ResourcesFunc: func(ctx context.Context) (map[core.Item]*entities.Resource, error) {
+				return map[core.Item]*entities.Resource{core.ItemWater: {Item: core.ItemWater, Balance: 6}}, nil
+			},
+			ZoneSuppliesFunc: func(ctx context.Context) ([]*entities.ZoneSupplies, error) {
+				return []*entities.ZoneSupplies{{ID: "camp", Name: "camp", Kind: "safe", Survivors: 1, Resources: map[core.Item]uint32{core.ItemFood: 5}}}, nil
+			},
+			FindSnapshotsFunc: func(ctx context.Context, from, to time.Time) ([]*repo.Snapshot, error) {
+				return nil, nil
+			},
+		}, reports.WithForecastRules(reports.ForecastRules{
+			Consumption: map[core.Item]float64{core.ItemWater: 1, core.ItemFood: 1},
+		}))),
+	)
+
+	res := handleServerRequest(t, svr, http.MethodGet, "/reports/forecast", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var result entities.Forecast
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	require.Len(t, result.Network.Items, 4)
+	assert.Equal(t, "water", result.Network.Items[0].Item)
+	require.NotNil(t, result.Network.Items[0].DaysLeft)
+	assert.Equal(t, float64(3), *result.Network.Items[0].DaysLeft)
+	assert.True(t, result.Network.Items[0].Shortage)
+	// food runs out straight away across the network, the zone has five days of it
+	assert.Equal(t, []string{"food"}, result.Network.RunsOutFirst)
+	require.Len(t, result.Zones, 1)
+	assert.Equal(t, "camp", result.Zones[0].Name)
+	assert.Equal(t, uint32(1), result.Zones[0].Survivors)
+	assert.Equal(t, []string{"water"}, result.Zones[0].RunsOutFirst)
+	assert.Equal(t, float64(5), *result.Zones[0].Items[1].DaysLeft)
+
+	svr = newMockServer(t)
+	res = handleServerRequest(t, svr, http.MethodGet, "/reports/forecast", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+}
+
+func TestMockedTradeReport(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var from, to time.Time
+	var limit int
+	svr := newMockServer(t,
+		WithReportService(reports.New(&reports.MockReportRepository{
+			TradeActivityFunc: func(ctx context.Context, f, tt time.Time, bucket time.Duration, topTraders int) (*repo.TradeActivity, error) {
+				from, to, limit = f, tt, topTraders
+				return &repo.TradeActivity{
+					Series:     []*repo.TradeBucket{{Start: day.Add(9 * time.Hour), Trades: 1, Volume: 24}},
+					Items:      []*repo.TradedItem{{Item: core.ItemWater, Quantity: 3, Trades: 1, Volume: 12}},
+					Swaps:      []*repo.Swap{{Given: core.ItemWater, Received: core.ItemFood, GivenQuantity: 3, ReceivedQuantity: 4, Trades: 1}},
+					Traders:    2,
+					TopTraders: []*repo.Trader{{ID: "ada", Name: "Ada", Trades: 1, Volume: 12}},
+				}, nil
+			},
+		}, reports.WithTraderPrivacy(reports.TradersPublic))),
+	)
+
+	query := url.Values{"from": {day.Format(time.RFC3339)}, "to": {day.AddDate(0, 0, 1).Format(time.RFC3339)}, "interval": {"hour"}, "limit": {"5"}}
+	res := handleServerRequest(t, svr, http.MethodGet, "/reports/trades?"+query.Encode(), "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var result entities.TradeReport
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	assert.True(t, day.Equal(from))
+	assert.True(t, day.AddDate(0, 0, 1).Equal(to))
+	assert.Equal(t, 5, limit)
+	assert.Equal(t, uint32(1), result.Trades)
+	assert.Equal(t, uint32(24), result.Volume)
+	require.Len(t, result.Series, 1)
+	assert.True(t, day.Add(9*time.Hour).Equal(result.Series[0].Time))
+	assert.Equal(t, "water", result.TopItems[0].Item)
+	assert.InDelta(t, 1.333, result.ExchangeRatios[0].Ratio, 0.001)
+	require.Len(t, result.TopTraders, 1)
+	assert.Equal(t, "Ada", result.TopTraders[0].Name)
+
+	for _, query := range []string{"interval=week", "limit=0", "limit=many", "limit=101", "from=yesterday", "from=2026-03-02T00:00:00Z&to=2026-03-01T00:00:00Z"} {
+		res = handleServerRequest(t, svr, http.MethodGet, "/reports/trades?"+query, "", nil)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+	}
+}
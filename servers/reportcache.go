@@ -0,0 +1,131 @@
+package servers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// cachedReport a report response as it's kept in the report cache
+type cachedReport struct {
+	Body         []byte `json:"body"`
+	ContentType  string `json:"content_type"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+}
+
+// reportCache serves the report responses from the report cache while they are fresh and answers conditional GETs.
+// The ETag and Last-Modified headers are sent even when the cache is disabled, the responses just aren't kept then.
+func (s *Server) reportCache() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Method() != fiber.MethodGet {
+			return ctx.Next()
+		}
+		key := reportCacheKey(ctx)
+		if s.reportStorage != nil {
+			if res := s.cachedReport(key); res != nil {
+				return res.send(ctx)
+			}
+		}
+
+		generation := s.reportGeneration.Load()
+		if err := ctx.Next(); err != nil {
+			return err
+		}
+		if ctx.Response().StatusCode() != http.StatusOK {
+			return nil
+		}
+		body := append([]byte(nil), ctx.Response().Body()...)
+		sum := sha256.Sum256(body)
+		res := &cachedReport{
+			Body:         body,
+			ContentType:  string(ctx.Response().Header.ContentType()),
+			ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+			LastModified: time.Now().UTC().Format(http.TimeFormat),
+		}
+		// a response worked out while the reports were invalidated could already be stale
+		if s.reportStorage != nil && s.reportGeneration.Load() == generation {
+			if b, err := json.Marshal(res); err == nil {
+				if err := s.reportStorage.Set(key, b, s.reportCacheTTL); err != nil {
+					log.Printf("failed to cache report %s: %v", key, err)
+				}
+			}
+		}
+		return res.send(ctx)
+	}
+}
+
+// cachedReport returns the cached response for key, nil when there is none
+func (s *Server) cachedReport(key string) *cachedReport {
+	b, err := s.reportStorage.Get(key)
+	if err != nil {
+		log.Printf("failed to read cached report %s: %v", key, err)
+		return nil
+	}
+	if b == nil {
+		return nil
+	}
+	var res cachedReport
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil
+	}
+	return &res
+}
+
+// invalidateReports drops the cached reports after something they are built from changed.
+// Failing to do it doesn't fail the request that made the change, the cached reports expire on their own anyway.
+func (s *Server) invalidateReports() {
+	s.reportGeneration.Add(1)
+	if s.reportStorage == nil {
+		return
+	}
+	if err := s.reportStorage.Reset(); err != nil {
+		log.Printf("failed to invalidate the cached reports: %v", err)
+	}
+}
+
+// reportCacheKey the heatmap is sent as GeoJSON depending on the Accept header, so it's part of the key
+func reportCacheKey(ctx *fiber.Ctx) string {
+	return ctx.OriginalURL() + "|" + ctx.Get(fiber.HeaderAccept)
+}
+
+func (r *cachedReport) send(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderETag, r.ETag)
+	ctx.Set(fiber.HeaderLastModified, r.LastModified)
+	// clients can keep the reports but have to check they are still fresh before using them
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	if r.notModified(ctx) {
+		ctx.Response().ResetBody()
+		return ctx.SendStatus(http.StatusNotModified)
+	}
+	ctx.Set(fiber.HeaderContentType, r.ContentType)
+	return ctx.Status(http.StatusOK).Send(r.Body)
+}
+
+// notModified checks the conditional headers, If-None-Match takes precedence over If-Modified-Since, RFC 7232
+func (r *cachedReport) notModified(ctx *fiber.Ctx) bool {
+	if match := ctx.Get(fiber.HeaderIfNoneMatch); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == r.ETag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(ctx.Get(fiber.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(r.LastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
@@ -0,0 +1,98 @@
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"zssn/cache"
+	"zssn/domains/entities"
+	"zssn/domains/reports"
+	"zssn/requests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockedReportCache(t *testing.T) {
+	var calls int
+	reportSvc := reports.New(&reports.MockReportRepository{
+		SurvivorsFunc: func(ctx context.Context) (*entities.Survivor, error) {
+			calls++
+			return &entities.Survivor{Total: 4, Clean: 3, Percentage: 75}, nil
+		},
+	})
+	svr := newMockServer(t, WithReportService(reportSvc), WithReportCache(cache.NewMemory(0), time.Minute))
+
+	get := func(svr *Server, header, value string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/reports/survivors", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		res, err := svr.Router.Test(req)
+		require.NoError(t, err)
+		return res
+	}
+
+	res := get(svr, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	etag, modified := res.Header.Get("ETag"), res.Header.Get("Last-Modified")
+	require.NotEmpty(t, etag)
+	require.NotEmpty(t, modified)
+	assert.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+	// served from the cache while nothing changes
+	res = get(svr, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, etag, res.Header.Get("ETag"))
+	var result entities.Survivor
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	assert.Equal(t, uint32(3), result.Clean)
+	assert.Equal(t, 1, calls)
+
+	res = get(svr, "If-None-Match", `"stale", `+etag)
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Empty(t, body)
+	res = get(svr, "If-None-Match", `"stale"`)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	res = get(svr, "If-Modified-Since", modified)
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	assert.Equal(t, 1, calls)
+
+	// registrations invalidate the cached reports
+	survivor := createMockUser(t, svr)
+	res = get(svr, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 2, calls)
+
+	// and so do location and visibility updates, the heatmap and zone reports are built from them
+	b, err := json.Marshal(requests.UpdateLocation{Latitude: 52.52, Longitude: 13.405})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/location", survivor.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	res = get(svr, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 3, calls)
+	b, err = json.Marshal(requests.UpdateVisibility{Visibility: "hidden"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/me/visibility", survivor.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	res = get(svr, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 4, calls)
+
+	// conditional GETs still work without the cache
+	svr = newMockServer(t, WithReportService(reportSvc))
+	res = get(svr, "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	res = get(svr, "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	assert.Equal(t, 6, calls)
+}
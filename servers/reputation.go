@@ -0,0 +1,64 @@
+package servers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func (s *Server) userProfile(ctx *fiber.Ctx) error {
+	res, err := s.userService.Profile(ctx.Context(), ctx.Params("id"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		return ctx.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) tradePartners(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	var (
+		minReputation float64
+		limit         int
+		err           error
+	)
+	if v := ctx.Query("min_reputation"); v != "" {
+		if minReputation, err = strconv.ParseFloat(v, 64); err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "invalid min_reputation",
+			})
+		}
+	}
+	if v := ctx.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "invalid limit",
+			})
+		}
+	}
+	res, err := s.userService.FindPartners(ctx.Context(), userID, minReputation, limit)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
@@ -0,0 +1,58 @@
+package servers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"zssn/domains/entities"
+	"zssn/domains/reputation"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockedReputation(t *testing.T) {
+	svr := newMockServer(t)
+	survivor, requester := createMockUser(t, svr), createMockUser(t, svr)
+
+	res := handleServerRequest(t, svr, http.MethodGet, "/users/"+survivor.ID+"/profile", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var profile map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&profile))
+	assert.Equal(t, survivor.ID, profile["id"])
+	assert.Equal(t, reputation.DefaultWeights().Base, profile["reputation"])
+	// the profile is public, the email and the location stay private
+	assert.NotContains(t, profile, "email")
+	assert.NotContains(t, profile, "latitude")
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/"+uuid.NewString()+"/profile", "", nil)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/trades/partners?min_reputation=50&limit=100", requester.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var partners []entities.Profile
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&partners))
+	require.NotEmpty(t, partners)
+	for i, v := range partners {
+		assert.NotEqual(t, requester.ID, v.ID)
+		assert.GreaterOrEqual(t, v.Reputation, float64(50))
+		if i > 0 {
+			assert.GreaterOrEqual(t, partners[i-1].Reputation, v.Reputation)
+		}
+	}
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/trades/partners?min_reputation=101", requester.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	partners = nil
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&partners))
+	assert.Empty(t, partners)
+
+	for _, query := range []string{"min_reputation=high", "limit=-1", "limit=many"} {
+		res = handleServerRequest(t, svr, http.MethodGet, "/trades/partners?"+query, requester.Token, nil)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+	}
+	res = handleServerRequest(t, svr, http.MethodGet, "/trades/partners", "", nil)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
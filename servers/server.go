@@ -1,6 +1,13 @@
 package servers
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"zssn/domains/export"
 	"zssn/domains/inventory"
 	iinv "zssn/domains/inventory/store"
 	"zssn/domains/reports"
@@ -9,6 +16,7 @@ import (
 	itr "zssn/domains/trade/store"
 	"zssn/domains/users"
 	iusr "zssn/domains/users/store"
+	"zssn/jobs"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -17,20 +25,172 @@ import (
 	"gorm.io/gorm"
 )
 
-var (
-	inventoryService inventory.IInventoryService
-	tradeService     trade.ITradeService
-	reportService    reports.IReportService
-)
-
 // Server contains the server properties that can be propagated across different services.
 type Server struct {
 	DB     *gorm.DB
 	Router *fiber.App
+
+	userService      users.IUserService
+	inventoryService inventory.IInventoryService
+	tradeService     trade.ITradeService
+	reportService    reports.IReportService
+	exportService    export.IExportService
+
+	corsOrigins     []string
+	infectionPolicy users.InfectionPolicy
+	userOptions     []users.Option
+	exportOptions   []export.Option
+	reportOptions   []reports.Option
+
+	restoreInventoryOnRecovery bool
+	collusionInterval          time.Duration
+	locationPruneInterval      time.Duration
+	reportSnapshotInterval     time.Duration
+	statusEffectsInterval      time.Duration
+	reportStorage              fiber.Storage
+	reportCacheTTL             time.Duration
+	reportGeneration           atomic.Uint64
+	contactTracing             bool
+	safeZoneTrades             bool
 }
 
-// New creates a new instance of the server
-func New(db *gorm.DB) (*Server, error) {
+// Option configures the server before the routes are registered
+type Option func(*Server)
+
+// WithUserService overrides the user service used by the handlers
+func WithUserService(svc users.IUserService) Option {
+	return func(s *Server) {
+		s.userService = svc
+	}
+}
+
+// WithInventoryService overrides the inventory service used by the handlers
+func WithInventoryService(svc inventory.IInventoryService) Option {
+	return func(s *Server) {
+		s.inventoryService = svc
+	}
+}
+
+// WithTradeService overrides the trade service used by the handlers
+func WithTradeService(svc trade.ITradeService) Option {
+	return func(s *Server) {
+		s.tradeService = svc
+	}
+}
+
+// WithReportService overrides the report service used by the handlers
+func WithReportService(svc reports.IReportService) Option {
+	return func(s *Server) {
+		s.reportService = svc
+	}
+}
+
+// WithExportService overrides the export service used by the handlers
+func WithExportService(svc export.IExportService) Option {
+	return func(s *Server) {
+		s.exportService = svc
+	}
+}
+
+// WithCORSOrigins restricts the origins allowed to call the API, every origin is allowed by default
+func WithCORSOrigins(origins ...string) Option {
+	return func(s *Server) {
+		s.corsOrigins = origins
+	}
+}
+
+// WithInfectionPolicy sets the policy the default user service uses to decide when a survivor is infected
+func WithInfectionPolicy(p users.InfectionPolicy) Option {
+	return func(s *Server) {
+		s.infectionPolicy = p
+	}
+}
+
+// WithUserOptions passes additional options, like the appeal admins, to the default user service
+func WithUserOptions(opts ...users.Option) Option {
+	return func(s *Server) {
+		s.userOptions = append(s.userOptions, opts...)
+	}
+}
+
+// WithExportOptions passes additional options, like the approximate precision, to the default export service
+func WithExportOptions(opts ...export.Option) Option {
+	return func(s *Server) {
+		s.exportOptions = append(s.exportOptions, opts...)
+	}
+}
+
+// WithReportOptions passes additional options, like the forecast rules, to the default report service
+func WithReportOptions(opts ...reports.Option) Option {
+	return func(s *Server) {
+		s.reportOptions = append(s.reportOptions, opts...)
+	}
+}
+
+// WithInventoryRestoredOnRecovery decides if recovered survivors get their blocked inventory back, they do by default
+func WithInventoryRestoredOnRecovery(restore bool) Option {
+	return func(s *Server) {
+		s.restoreInventoryOnRecovery = restore
+	}
+}
+
+// WithCollusionDetection runs the collusion detection every interval once the jobs are started, it doesn't run by default
+func WithCollusionDetection(interval time.Duration) Option {
+	return func(s *Server) {
+		s.collusionInterval = interval
+	}
+}
+
+// WithLocationPruning applies the location history retention every interval once the jobs are started, it doesn't run by default
+func WithLocationPruning(interval time.Duration) Option {
+	return func(s *Server) {
+		s.locationPruneInterval = interval
+	}
+}
+
+// WithReportSnapshots records the reports for the history series every interval once the jobs are started, it doesn't run by default
+func WithReportSnapshots(interval time.Duration) Option {
+	return func(s *Server) {
+		s.reportSnapshotInterval = interval
+	}
+}
+
+// WithReportCache keeps the report responses in storage for ttl, they are dropped earlier once something they are built from changes.
+// The storage is reset on every change so it shouldn't be shared, the reports aren't cached by default or when ttl isn't positive.
+func WithReportCache(storage fiber.Storage, ttl time.Duration) Option {
+	return func(s *Server) {
+		s.reportStorage, s.reportCacheTTL = nil, 0
+		if ttl > 0 {
+			s.reportStorage, s.reportCacheTTL = storage, ttl
+		}
+	}
+}
+
+// WithStatusEffectsRetry applies the status changes whose effects failed every interval once the jobs are started.
+// They are retried with the next change of the same survivor otherwise, the job doesn't run by default.
+func WithStatusEffectsRetry(interval time.Duration) Option {
+	return func(s *Server) {
+		s.statusEffectsInterval = interval
+	}
+}
+
+// WithContactTracing traces and warns the contacts of survivors once they are found infected, it's disabled by default
+func WithContactTracing(enabled bool) Option {
+	return func(s *Server) {
+		s.contactTracing = enabled
+	}
+}
+
+// WithSafeZoneTrades only lets survivors trade while both of them are inside a safe zone, they can trade anywhere by default
+func WithSafeZoneTrades(enabled bool) Option {
+	return func(s *Server) {
+		s.safeZoneTrades = enabled
+	}
+}
+
+// New creates a new instance of the server.
+// Services that are not provided through the options are built on top of the given db connection.
+func New(db *gorm.DB, opts ...Option) (*Server, error) {
 	router := fiber.New(fiber.Config{
 		EnablePrintRoutes: true,
 	})
@@ -38,48 +198,163 @@ func New(db *gorm.DB) (*Server, error) {
 		return c.SendString("Welcome to Zombie Survival Social Network API")
 	})
 
-	router.Use(requestid.New())
-	router.Use(cors.New())
-	router.Use(logger.New())
 	svr := &Server{
 		DB:     db,
 		Router: router,
+
+		restoreInventoryOnRecovery: true,
+	}
+	for _, opt := range opts {
+		opt(svr)
 	}
+
+	router.Use(requestid.New())
+	router.Use(svr.cors())
+	router.Use(logger.New())
 	if err := svr.setupServices(); err != nil {
 		return nil, err
 	}
 	svr.userRoutes()
 	svr.tradeRoutes()
 	svr.reportRoutes()
+	svr.appealRoutes()
+	svr.collusionRoutes()
+	svr.zoneRoutes()
+	svr.exportRoutes()
 
 	return svr, nil
 }
 
+// setupServices creates the default implementation of every service that wasn't provided as an option
 func (s *Server) setupServices() error {
-	st, err := iusr.New(s.DB)
-	if err != nil {
-		return err
+	if s.DB == nil && (s.userService == nil || s.inventoryService == nil ||
+		s.tradeService == nil || s.reportService == nil || s.exportService == nil) {
+		return fmt.Errorf("invalid db provided")
 	}
-	usrSvc, err := users.New(st)
-	if err != nil {
-		return err
+
+	if s.userService == nil {
+		st, err := iusr.New(s.DB)
+		if err != nil {
+			return err
+		}
+		opts := append([]users.Option{
+			users.WithInfectionPolicy(s.infectionPolicy),
+			users.WithTradeActivity(s.tradeCounts),
+			users.WithTradePartners(s.tradedWith),
+		}, s.statusEffects()...)
+		opts = append(opts, s.userOptions...)
+		usrSvc, err := users.New(st, opts...)
+		if err != nil {
+			return err
+		}
+		s.userService = usrSvc
 	}
-	userService = usrSvc
 
-	invStore, err := iinv.New(s.DB)
-	if err != nil {
-		return err
+	if s.inventoryService == nil {
+		invStore, err := iinv.New(s.DB)
+		if err != nil {
+			return err
+		}
+		s.inventoryService = inventory.New(invStore)
 	}
-	inventoryService = inventory.New(invStore)
 
-	trStore, err := itr.New(s.DB)
-	if err != nil {
-		return err
+	if s.tradeService == nil {
+		trStore, err := itr.New(s.DB)
+		if err != nil {
+			return err
+		}
+		s.tradeService = trade.New(trStore, s.userService, s.inventoryService, trade.WithSafeZonesOnly(s.safeZoneTrades))
+	}
+
+	if s.reportService == nil {
+		s.reportService = reports.New(repo.New(s.DB), s.reportOptions...)
 	}
-	tradeService = trade.New(trStore, userService, inventoryService)
 
-	rpRepo := repo.New(s.DB)
-	reportService = reports.New(rpRepo)
+	if s.exportService == nil {
+		usrStore, err := iusr.New(s.DB)
+		if err != nil {
+			return err
+		}
+		trStore, err := itr.New(s.DB)
+		if err != nil {
+			return err
+		}
+		opts := append([]export.Option{export.WithAdmins(s.userService.IsAdmin)}, s.exportOptions...)
+		s.exportService = export.New(usrStore, trStore, opts...)
+	}
 
 	return nil
 }
+
+// StartJobs runs the background jobs until ctx is done
+func (s *Server) StartJobs(ctx context.Context) {
+	go jobs.Every(ctx, "collusion detection", s.collusionInterval, func(ctx context.Context) error {
+		_, err := s.userService.DetectCollusion(ctx)
+		return err
+	})
+	go jobs.Every(ctx, "location pruning", s.locationPruneInterval, func(ctx context.Context) error {
+		_, err := s.userService.PruneLocations(ctx)
+		return err
+	})
+	go jobs.Every(ctx, "status effects", s.statusEffectsInterval, func(ctx context.Context) error {
+		_, err := s.userService.ApplyPendingTransitions(ctx)
+		return err
+	})
+	go jobs.Every(ctx, "report snapshots", s.reportSnapshotInterval, func(ctx context.Context) error {
+		if _, err := s.reportService.Snapshot(ctx); err != nil {
+			return err
+		}
+		s.invalidateReports()
+		return nil
+	})
+}
+
+// tradeCounts looks the trades up through the trade service, which is only created after the user service
+func (s *Server) tradeCounts(ctx context.Context, ids ...string) (map[string]int, error) {
+	return s.tradeService.TradeCounts(ctx, ids...)
+}
+
+// tradedWith returns everyone the survivor traded with since the given time
+func (s *Server) tradedWith(ctx context.Context, id string, since time.Time) ([]string, error) {
+	history, err := s.tradeService.History(ctx, id, since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var res []string
+	for _, v := range history {
+		for _, p := range []string{v.SellerID, v.BuyerID} {
+			if p != id && !seen[p] {
+				seen[p] = true
+				res = append(res, p)
+			}
+		}
+	}
+	return res, nil
+}
+
+// setInventoryAccess blocks and unblocks inventories through the inventory service, which is only created after the user service
+func (s *Server) setInventoryAccess(ctx context.Context, id string, blocked bool) error {
+	if blocked {
+		return s.inventoryService.BlockUserInventory(ctx, id)
+	}
+	return s.inventoryService.UnblockUserInventory(ctx, id)
+}
+
+// statusEffects configures what the user service does once the status of a survivor changes
+func (s *Server) statusEffects() []users.Option {
+	return []users.Option{
+		users.WithInventoryAccess(s.setInventoryAccess),
+		users.WithInventoryRestoredOnRecovery(s.restoreInventoryOnRecovery),
+		users.WithContactTracing(s.contactTracing),
+	}
+}
+
+func (s *Server) cors() fiber.Handler {
+	if len(s.corsOrigins) == 0 {
+		return cors.New()
+	}
+	return cors.New(cors.Config{
+		AllowOrigins: strings.Join(s.corsOrigins, ","),
+	})
+}
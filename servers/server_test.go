@@ -8,13 +8,13 @@ import (
 	"os"
 	"testing"
 
+	"zssn/database"
 	"zssn/domains/core"
 	"zssn/requests"
 
 	"github.com/brianvoe/gofakeit"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
@@ -66,6 +66,12 @@ func TestNewServer(t *testing.T) {
 	require.NotNil(t, s.Router)
 }
 
+func TestNewServerWithoutDB(t *testing.T) {
+	s, err := New(nil)
+	require.EqualError(t, err, "invalid db provided")
+	require.Nil(t, s)
+}
+
 func newSurvivor(t *testing.T) *requests.Survivor {
 	t.Helper()
 	return &requests.Survivor{
@@ -97,6 +103,11 @@ func newSurvivor(t *testing.T) *requests.Survivor {
 }
 
 func handleReqest(t *testing.T, method, path, token string, body []byte) *http.Response {
+	t.Helper()
+	return handleServerRequest(t, server, method, path, token, body)
+}
+
+func handleServerRequest(t *testing.T, svr *Server, method, path, token string, body []byte) *http.Response {
 	t.Helper()
 	var req *http.Request
 	if len(body) == 0 {
@@ -109,7 +120,7 @@ func handleReqest(t *testing.T, method, path, token string, body []byte) *http.R
 		req.Header.Add("Authorization", "Bearer "+token)
 	}
 
-	res, err := server.Router.Test(req)
+	res, err := svr.Router.Test(req)
 	require.NoError(t, err)
 	return res
 }
@@ -118,14 +129,35 @@ func cleanup() {
 	db.Exec("DELETE FROM transactions")
 	db.Exec("DELETE FROM inventories")
 	db.Exec("DELETE FROM flag_monitors")
+	db.Exec("DELETE FROM appeal_votes")
+	db.Exec("DELETE FROM appeals")
+	db.Exec("DELETE FROM infection_audits")
+	db.Exec("DELETE FROM status_transitions")
+	db.Exec("DELETE FROM collusion_reviews")
+	db.Exec("DELETE FROM location_history")
+	db.Exec("DELETE FROM notifications")
+	db.Exec("DELETE FROM zone_events")
+	db.Exec("DELETE FROM zone_members")
+	db.Exec("DELETE FROM zones")
 	db.Exec("DELETE FROM users")
 }
 
 func setupTestDB() (*gorm.DB, error) {
-	env := os.Getenv("ENVIRONMENT")
-	dsn := "root:@tcp(127.0.0.1:3306)/zssn?charset=utf8mb4&parseTime=True&loc=Local"
-	if env == "cicd" {
-		dsn = "zssn_user:password@tcp(127.0.0.1:33306)/zssn?charset=utf8mb4&parseTime=True&loc=Local"
-	}
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	return database.OpenTest()
+}
+
+func TestMockedCORSOrigins(t *testing.T) {
+	svr := newMockServer(t, WithCORSOrigins("https://zssn.io"))
+
+	req := httptest.NewRequest(http.MethodGet, "/reports/survivors", nil)
+	req.Header.Set("Origin", "https://zssn.io")
+	res, err := svr.Router.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, "https://zssn.io", res.Header.Get("Access-Control-Allow-Origin"))
+
+	req = httptest.NewRequest(http.MethodGet, "/reports/survivors", nil)
+	req.Header.Set("Origin", "https://elsewhere.io")
+	res, err = svr.Router.Test(req)
+	require.NoError(t, err)
+	assert.Empty(t, res.Header.Get("Access-Control-Allow-Origin"))
 }
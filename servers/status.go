@@ -0,0 +1,88 @@
+package servers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"zssn/domains/users"
+	iusr "zssn/domains/users/store"
+	"zssn/requests"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func (s *Server) statusHistory(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	res, err := s.userService.StatusHistory(ctx.Context(), userID, ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(statusErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) updateStatus(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	var req *requests.UpdateStatus
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	status, err := iusr.ParseStatus(req.Status)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	res, err := s.userService.UpdateStatus(ctx.Context(), userID, ctx.Params("id"), status, req.Reason)
+	if statusChanged(err) {
+		defer s.invalidateReports()
+	}
+	if err != nil {
+		return ctx.Status(statusErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+// statusChanged reports whether the request changed the survivor's status, its effects can still be pending
+func statusChanged(err error) bool {
+	return err == nil || errors.Is(err, users.ErrEffectsPending)
+}
+
+func statusErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, users.ErrInvalidTransition):
+		return http.StatusConflict
+	case errors.Is(err, users.ErrNotAdmin):
+		return http.StatusForbidden
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, users.ErrEffectsPending):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
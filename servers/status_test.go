@@ -0,0 +1,151 @@
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"zssn/domains/entities"
+	"zssn/domains/inventory"
+	"zssn/domains/users"
+	"zssn/requests"
+	"zssn/responses"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockedStatusEffectsPending(t *testing.T) {
+	invStore := inventory.NewMockStore()
+	invStore.UpdateUserInventoryAccessibilityFunc = func(ctx context.Context, userID string) error {
+		return fmt.Errorf("inventory unavailable")
+	}
+	svr := newMockServer(t,
+		WithUserOptions(users.WithInfectionThreshold(1)),
+		WithInventoryService(inventory.New(invStore)),
+	)
+	target := createMockUser(t, svr)
+
+	b, err := json.Marshal(requests.FlagUser{InfectedUserID: target.ID})
+	require.NoError(t, err)
+	res := handleServerRequest(t, svr, http.MethodPost, "/users/flag", createMockUser(t, svr).Token, b)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	// the flag and the infection are saved, only blocking the inventory is left to retry
+	infected, err := svr.userService.IsInfected(context.Background(), target.ID)
+	require.NoError(t, err)
+	assert.True(t, infected)
+}
+
+func TestMockedUnblockFollowsStatus(t *testing.T) {
+	s := newMockSetup(t,
+		WithUserOptions(users.WithInfectionThreshold(2)),
+		WithInventoryRestoredOnRecovery(false),
+	)
+	svr := s.svr
+
+	infect := func(target responses.User) []responses.User {
+		b, err := json.Marshal(requests.FlagUser{InfectedUserID: target.ID})
+		require.NoError(t, err)
+		flaggers := []responses.User{createMockUser(t, svr), createMockUser(t, svr)}
+		for _, u := range flaggers {
+			res := handleServerRequest(t, svr, http.MethodPost, "/users/flag", u.Token, b)
+			require.Equal(t, http.StatusOK, res.StatusCode)
+		}
+		return flaggers
+	}
+	setStatus := func(target responses.User, status string) {
+		b, err := json.Marshal(requests.UpdateStatus{Status: status})
+		require.NoError(t, err)
+		res := handleServerRequest(t, svr, http.MethodPatch, "/users/"+target.ID+"/status", s.admin.Token, b)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	// retracting a flag against a deceased survivor doesn't give the inventory back
+	deceased := createMockUser(t, svr)
+	flaggers := infect(deceased)
+	setStatus(deceased, "deceased")
+	res := handleServerRequest(t, svr, http.MethodDelete, "/users/flag/"+deceased.ID, flaggers[0].Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// neither does approving the appeal of a survivor who recovered in the meantime
+	recovered := createMockUser(t, svr)
+	infect(recovered)
+	b, err := json.Marshal(requests.Appeal{Reason: "It's ketchup"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/appeals", recovered.Token, b)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	var appeal entities.Appeal
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&appeal))
+	setStatus(recovered, "recovered")
+	b, err = json.Marshal(requests.AppealVote{Approve: true})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/appeals/"+appeal.ID+"/resolve", s.admin.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Empty(t, s.restored)
+}
+
+func TestMockedStatusTransitions(t *testing.T) {
+	s := newMockSetup(t, WithInventoryRestoredOnRecovery(false))
+	svr, adminUser := s.svr, s.admin
+	target := createMockUser(t, svr)
+
+	b, err := json.Marshal(requests.UpdateStatus{Status: "quarantined", Reason: "bitten"})
+	require.NoError(t, err)
+	res := handleServerRequest(t, svr, http.MethodPatch, "/users/"+target.ID+"/status", target.Token, b)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/"+target.ID+"/status", adminUser.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// quarantined survivors can't move around
+	b, err = json.Marshal(requests.UpdateLocation{Latitude: 6.5, Longitude: 3.5})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/location", target.Token, b)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	b, err = json.Marshal(requests.UpdateStatus{Status: "healthy"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/"+target.ID+"/status", adminUser.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{target.ID}, s.restored)
+
+	// recovered survivors keep their inventory blocked with this policy
+	b, err = json.Marshal(requests.UpdateStatus{Status: "infected"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/"+target.ID+"/status", adminUser.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	b, err = json.Marshal(requests.UpdateStatus{Status: "recovered", Reason: "cured"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/"+target.ID+"/status", adminUser.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{target.ID}, s.restored)
+
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/"+target.ID+"/status", adminUser.Token, b)
+	require.Equal(t, http.StatusConflict, res.StatusCode)
+	b, err = json.Marshal(requests.UpdateStatus{Status: "undead"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/"+target.ID+"/status", adminUser.Token, b)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/"+target.ID+"/status", "", nil)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/"+target.ID+"/status", adminUser.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var history []entities.StatusTransition
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&history))
+	require.Len(t, history, 4)
+	assert.Equal(t, "recovered", history[3].To)
+	assert.Equal(t, "cured", history[3].Reason)
+	assert.Equal(t, adminUser.ID, history[3].ActorID)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/"+target.ID+"/status", target.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var own []entities.StatusTransition
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&own))
+	require.Len(t, own, 4)
+	assert.Empty(t, own[3].ActorID)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/unknown/status", adminUser.Token, nil)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
@@ -11,12 +11,13 @@ import (
 )
 
 func (s *Server) tradeRoutes() {
-	tsr := s.Router.Group("/trades", authMiddleware())
+	tsr := s.Router.Group("/trades", s.authMiddleware())
 
-	tsr.Post("", newTrade)
+	tsr.Post("", s.newTrade)
+	tsr.Get("/partners", s.tradePartners)
 }
 
-func newTrade(ctx *fiber.Ctx) error {
+func (s *Server) newTrade(ctx *fiber.Ctx) error {
 	userID := ctx.Locals("user_id").(string)
 	if userID == "" {
 		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
@@ -44,14 +45,15 @@ func newTrade(ctx *fiber.Ctx) error {
 		})
 	}
 
-	err := tradeService.Execute(ctx.Context(), buyer, seller)
+	err := s.tradeService.Execute(ctx.Context(), buyer, seller)
 	if err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
 	}
-	balance, err := inventoryService.FindUserInventory(ctx.Context(), userID)
+	s.invalidateReports()
+	balance, err := s.inventoryService.FindUserInventory(ctx.Context(), userID)
 	if err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
@@ -61,7 +61,7 @@ func TestNewTrade(t *testing.T) {
 	err = json.NewDecoder(res.Body).Decode(&result)
 	require.NoError(t, err)
 
-	participantsInventory, err := inventoryService.FindMultipleInventory(ctx, tr1.UserID, tr2.UserID)
+	participantsInventory, err := server.inventoryService.FindMultipleInventory(ctx, tr1.UserID, tr2.UserID)
 	require.NoError(t, err)
 	require.NotNil(t, participantsInventory)
 
@@ -171,3 +171,33 @@ func TestExecuteTradeInvalidJSON(t *testing.T) {
 	res := handleReqest(t, http.MethodPost, "/trades", user2.Token, b)
 	require.Equal(t, http.StatusBadRequest, res.StatusCode)
 }
+
+func TestMockedTrade(t *testing.T) {
+	svr := newMockServer(t)
+	user1 := createMockUser(t, svr)
+	user2 := createMockUser(t, svr)
+
+	tr := &requests.TradeRequest{
+		Owner: &requests.TradeItems{
+			Items: []requests.TradeItem{
+				{Item: core.ItemWater, Quantity: 1},
+				{Item: core.ItemMedication, Quantity: 1},
+			},
+		},
+		SecondParty: &requests.TradeItems{
+			UserID: user2.ID,
+			Items: []requests.TradeItem{
+				{Item: core.ItemAmmunition, Quantity: 6},
+			},
+		},
+	}
+	b, err := json.Marshal(tr)
+	require.NoError(t, err)
+
+	res := handleServerRequest(t, svr, http.MethodPost, "/trades", user1.Token, b)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var result responses.Trade
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	assert.NotEmpty(t, result.Reference)
+}
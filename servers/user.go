@@ -3,12 +3,14 @@ package servers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"zssn/domains/core"
 	"zssn/domains/entities"
 	"zssn/domains/users"
+	iusr "zssn/domains/users/store"
 	"zssn/requests"
 	"zssn/responses"
 
@@ -16,23 +18,33 @@ import (
 	"gorm.io/gorm"
 )
 
-var userService users.IUserService
-
 func (s *Server) userRoutes() {
 	usr := s.Router.Group("/users")
-	usr.Post("", newUser)
-	usr.Post("/new-token", newToken)
+	usr.Post("", s.newUser)
+	usr.Post("/new-token", s.newToken)
 
-	usr.Use("/me", authMiddleware())
-	usr.Get("/me", userDetails)
-	usr.Use("/flag", authMiddleware())
-	usr.Post("/flag", flagInfectedUser)
-	usr.Use("/location", authMiddleware())
-	usr.Patch("/location", updateLocation)
+	usr.Use("/me", s.authMiddleware())
+	usr.Get("/me", s.userDetails)
+	usr.Get("/me/locations", s.locationHistory)
+	usr.Get("/me/notifications", s.notifications)
+	usr.Post("/me/notifications/:id/read", s.readNotification)
+	usr.Get("/me/zones", s.userZones)
+	usr.Patch("/me/visibility", s.updateVisibility)
+	usr.Get("/me/location-audits", s.locationAudits)
+	usr.Use("/flag", s.authMiddleware())
+	usr.Post("/flag", s.flagInfectedUser)
+	usr.Delete("/flag/:id", s.retractFlag)
+	usr.Use("/location", s.authMiddleware())
+	usr.Patch("/location", s.updateLocation)
+	usr.Get("/nearby", s.authMiddleware(), s.nearbySurvivors)
+	usr.Get("/:id/flags", s.authMiddleware(), s.userFlags)
+	usr.Get("/:id/status", s.authMiddleware(), s.statusHistory)
+	usr.Get("/:id/profile", s.userProfile)
+	usr.Patch("/:id/status", s.authMiddleware(), s.updateStatus)
 
 }
 
-func updateLocation(ctx *fiber.Ctx) error {
+func (s *Server) updateLocation(ctx *fiber.Ctx) error {
 	userID := ctx.Locals("user_id").(string)
 	if userID == "" {
 		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
@@ -49,27 +61,28 @@ func updateLocation(ctx *fiber.Ctx) error {
 		})
 	}
 
-	user, err := userService.Find(ctx.Context(), userID)
+	user, err := s.userService.Find(ctx.Context(), userID)
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
 	}
-	if user.Infected {
+	if !user.Clean() {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
-			"error":   "user is infected and thus you cannot perform this operation",
+			"error":   fmt.Sprintf("user is %s and thus you cannot perform this operation", user.Status),
 		})
 	}
 
-	err = userService.UpdateLocation(ctx.Context(), userID, req.Latitude, req.Longitude)
+	err = s.userService.UpdateLocation(ctx.Context(), userID, req.Latitude, req.Longitude)
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
 	}
+	defer s.invalidateReports()
 
 	return ctx.JSON(fiber.Map{
 		"success": true,
@@ -77,7 +90,7 @@ func updateLocation(ctx *fiber.Ctx) error {
 	})
 }
 
-func newUser(ctx *fiber.Ctx) error {
+func (s *Server) newUser(ctx *fiber.Ctx) error {
 	var u *requests.Survivor
 	if err := json.Unmarshal(ctx.Body(), &u); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -99,12 +112,13 @@ func newUser(ctx *fiber.Ctx) error {
 		Latitude:  u.Latitude,
 		Longitude: u.Longitude,
 	}
-	if err := userService.Create(ctx.Context(), user); err != nil {
+	if err := s.userService.Create(ctx.Context(), user); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
 	}
+	defer s.invalidateReports()
 
 	var invItems []*entities.Inventory
 	for _, v := range u.Inventory {
@@ -115,7 +129,7 @@ func newUser(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := inventoryService.Create(ctx.Context(), invItems); err != nil {
+	if err := s.inventoryService.Create(ctx.Context(), invItems); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
@@ -135,7 +149,7 @@ func newUser(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusCreated).JSON(responses.FromUserEntity(user, token))
 }
 
-func userDetails(ctx *fiber.Ctx) error {
+func (s *Server) userDetails(ctx *fiber.Ctx) error {
 	userID := ctx.Locals("user_id").(string)
 	if userID == "" {
 		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
@@ -144,7 +158,7 @@ func userDetails(ctx *fiber.Ctx) error {
 		})
 	}
 	// get the user
-	user, err := userService.Find(ctx.Context(), userID)
+	user, err := s.userService.Find(ctx.Context(), userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
@@ -154,7 +168,7 @@ func userDetails(ctx *fiber.Ctx) error {
 		}
 	}
 
-	balance, err := inventoryService.FindUserInventory(ctx.Context(), user.ID)
+	balance, err := s.inventoryService.FindUserInventory(ctx.Context(), user.ID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
@@ -184,7 +198,7 @@ func userDetails(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(resp)
 }
 
-func newToken(ctx *fiber.Ctx) error {
+func (s *Server) newToken(ctx *fiber.Ctx) error {
 	var f *requests.NewToken
 	if err := json.Unmarshal(ctx.Body(), &f); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -193,7 +207,7 @@ func newToken(ctx *fiber.Ctx) error {
 		})
 	}
 
-	user, err := userService.FindByEmail(ctx.Context(), f.Email)
+	user, err := s.userService.FindByEmail(ctx.Context(), f.Email)
 	if err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
@@ -201,6 +215,21 @@ func newToken(ctx *fiber.Ctx) error {
 		})
 	}
 
+	// anyone can ask for a token with just an email, so admins have to get theirs from the command line
+	admin, err := s.userService.IsAdmin(ctx.Context(), user.ID)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if admin {
+		return ctx.Status(http.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   errAdminToken.Error(),
+		})
+	}
+
 	td := &core.TokenData{
 		UserID: user.ID,
 		Email:  user.Email,
@@ -216,7 +245,7 @@ func newToken(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(responses.FromUserEntity(user, token))
 }
 
-func flagInfectedUser(ctx *fiber.Ctx) error {
+func (s *Server) flagInfectedUser(ctx *fiber.Ctx) error {
 	userID := ctx.Locals("user_id").(string)
 	if userID == "" {
 		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
@@ -231,31 +260,82 @@ func flagInfectedUser(ctx *fiber.Ctx) error {
 			"error":   err.Error(),
 		})
 	}
-	err := userService.FlagUser(ctx.Context(), userID, f.InfectedUserID)
-	if err != nil {
+	if err := f.Validate(); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
 	}
-	details, err := userService.Find(ctx.Context(), f.InfectedUserID)
+	_, err := s.userService.FlagUser(ctx.Context(), userID, f.InfectedUserID, f.Evidence())
+	if statusChanged(err) {
+		defer s.invalidateReports()
+	}
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+		return ctx.Status(flagErrorStatus(err)).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
 	}
-	// if the user is infected, we want to make all inventory items inaccessible
-	if details.Infected {
-		if err := inventoryService.BlockUserInventory(ctx.Context(), details.ID); err != nil {
-			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-				"success": false,
-				"error":   err.Error(),
-			})
-		}
-	}
 	return ctx.Status(http.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "user flagged successfully",
 	})
 }
+
+func (s *Server) retractFlag(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	infectedUserID := ctx.Params("id")
+	_, err := s.userService.RetractFlag(ctx.Context(), userID, infectedUserID)
+	if statusChanged(err) {
+		defer s.invalidateReports()
+	}
+	if err != nil {
+		return ctx.Status(flagErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "flag retracted successfully",
+	})
+}
+
+func (s *Server) userFlags(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	res, err := s.userService.FindFlags(ctx.Context(), userID, ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(flagErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func flagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, iusr.ErrDuplicateFlag):
+		return http.StatusConflict
+	case errors.Is(err, users.ErrInfectedFlagger), errors.Is(err, users.ErrFlagsHidden):
+		return http.StatusForbidden
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, users.ErrEffectsPending):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -3,12 +3,16 @@ package servers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"testing"
 
 	"zssn/domains/core"
 	"zssn/domains/entities"
+	tmocks "zssn/domains/trade/mocks"
+	"zssn/domains/users"
+	"zssn/domains/users/store"
 	"zssn/requests"
 	"zssn/responses"
 
@@ -60,7 +64,7 @@ func TestUserUpdatesLocation(t *testing.T) {
 	res := handleReqest(t, http.MethodPatch, "/users/location", user.Token, b)
 	require.Equal(t, http.StatusOK, res.StatusCode)
 
-	data, err := userService.Find(ctx, user.ID)
+	data, err := server.userService.Find(ctx, user.ID)
 	require.NoError(t, err)
 	require.NotNil(t, data)
 
@@ -184,11 +188,11 @@ func TestFlagUserAsInfected(t *testing.T) {
 	res = handleReqest(t, http.MethodPost, "/users/flag", user3.Token, b)
 	require.Equal(t, http.StatusOK, res.StatusCode)
 
-	data, err := userService.Find(ctx, infectedUser.ID)
+	data, err := server.userService.Find(ctx, infectedUser.ID)
 	require.NoError(t, err)
 	require.True(t, data.Infected)
 
-	invData, err := inventoryService.FindUserInventory(ctx, flag.InfectedUserID)
+	invData, err := server.inventoryService.FindUserInventory(ctx, flag.InfectedUserID)
 	require.NoError(t, err)
 	for _, v := range invData {
 		require.False(t, v.Accessible)
@@ -246,3 +250,154 @@ func createDemoUser(t *testing.T) responses.User {
 
 	return *result
 }
+
+func TestMockedUserDetails(t *testing.T) {
+	svr := newMockServer(t)
+	user := createMockUser(t, svr)
+
+	res := handleServerRequest(t, svr, http.MethodGet, "/users/me", user.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var data responses.User
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&data))
+	assert.Equal(t, user.ID, data.ID)
+	assert.Len(t, data.Inventory, 4)
+}
+
+func TestMockedAdminTokens(t *testing.T) {
+	svr := newMockServer(t)
+	user := createMockUser(t, svr)
+	adminToken := grantAdmin(t, user.ID)
+
+	// the token handed out at signup, or for the email, doesn't carry the admin rights
+	res := handleServerRequest(t, svr, http.MethodGet, "/users/me", user.Token, nil)
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	b, err := json.Marshal(requests.NewToken{Email: user.Email})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/users/new-token", "", b)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/me", adminToken, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// once revoked the admin is a survivor again and can get a token like everyone else
+	require.NoError(t, users.NewMockStore().UpdateRole(context.Background(), user.ID, store.RoleSurvivor))
+	res = handleServerRequest(t, svr, http.MethodPost, "/users/new-token", "", b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestMockedFlagUserFailure(t *testing.T) {
+	usrSvc := &tmocks.MockUserService{
+		FlagUserFunc: func(ctx context.Context, id, infectedUser string, evidence *entities.FlagEvidence) (*entities.StatusTransition, error) {
+			return nil, fmt.Errorf("cannot flag user right now")
+		},
+		IsAdminFunc: func(ctx context.Context, id string) (bool, error) {
+			return false, nil
+		},
+	}
+	svr := newMockServer(t, WithUserService(usrSvc))
+
+	td := core.TokenData{UserID: "flagger", Email: "flagger@zssn.io"}
+	token, err := td.Generate()
+	require.NoError(t, err)
+
+	b, err := json.Marshal(requests.FlagUser{InfectedUserID: "infected"})
+	require.NoError(t, err)
+
+	res := handleServerRequest(t, svr, http.MethodPost, "/users/flag", token, b)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestMockedFlagBlocksOnce(t *testing.T) {
+	s := newMockSetup(t, WithUserOptions(users.WithInfectionThreshold(2)))
+	svr := s.svr
+	target := createMockUser(t, svr)
+
+	b, err := json.Marshal(requests.FlagUser{InfectedUserID: target.ID})
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		res := handleServerRequest(t, svr, http.MethodPost, "/users/flag", createMockUser(t, svr).Token, b)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+	// only the flag that infected the target blocked the inventory, the later ones didn't do it again
+	assert.Equal(t, []string{target.ID}, s.blocked)
+}
+
+func TestMockedFlagUserConflicts(t *testing.T) {
+	svr := newMockServer(t)
+	flagger := createMockUser(t, svr)
+	target := createMockUser(t, svr)
+
+	b, err := json.Marshal(requests.FlagUser{InfectedUserID: target.ID})
+	require.NoError(t, err)
+
+	res := handleServerRequest(t, svr, http.MethodPost, "/users/flag", flagger.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodPost, "/users/flag", flagger.Token, b)
+	require.Equal(t, http.StatusConflict, res.StatusCode)
+
+	// infected survivors can't flag anyone
+	b, err = json.Marshal(requests.FlagUser{InfectedUserID: flagger.ID})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		u := createMockUser(t, svr)
+		res = handleServerRequest(t, svr, http.MethodPost, "/users/flag", u.Token, b)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+	b, err = json.Marshal(requests.FlagUser{InfectedUserID: createMockUser(t, svr).ID})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/users/flag", flagger.Token, b)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	b, err = json.Marshal(requests.FlagUser{InfectedUserID: "unknown"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/users/flag", target.Token, b)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestMockedFlagEvidence(t *testing.T) {
+	s := newMockSetup(t)
+	svr, adminUser := s.svr, s.admin
+	target, flagger := createMockUser(t, svr), createMockUser(t, svr)
+
+	lat := 6.5
+	b, err := json.Marshal(requests.FlagUser{InfectedUserID: target.ID, Latitude: &lat})
+	require.NoError(t, err)
+	res := handleServerRequest(t, svr, http.MethodPost, "/users/flag", flagger.Token, b)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	b, err = json.Marshal(requests.FlagUser{InfectedUserID: target.ID, Severity: "extreme"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/users/flag", flagger.Token, b)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	long := 3.5
+	b, err = json.Marshal(requests.FlagUser{InfectedUserID: target.ID, Note: "bitten", Latitude: &lat, Longitude: &long, Severity: "high"})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/users/flag", flagger.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/"+target.ID+"/flags", adminUser.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var flags []entities.Flag
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&flags))
+	require.Len(t, flags, 1)
+	assert.Equal(t, flagger.ID, flags[0].FlaggerID)
+	assert.Equal(t, "bitten", flags[0].Note)
+	assert.Equal(t, "high", flags[0].Severity)
+	require.NotNil(t, flags[0].Latitude)
+	assert.Equal(t, lat, *flags[0].Latitude)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/"+target.ID+"/flags", target.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	flags = nil
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&flags))
+	require.Len(t, flags, 1)
+	assert.Empty(t, flags[0].FlaggerID)
+	assert.Nil(t, flags[0].Latitude)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/"+target.ID+"/flags", flagger.Token, nil)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/"+target.ID+"/flags", "", nil)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
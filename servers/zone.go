@@ -0,0 +1,145 @@
+package servers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"zssn/domains/entities"
+	"zssn/domains/users"
+	"zssn/requests"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func (s *Server) zoneRoutes() {
+	zn := s.Router.Group("/zones")
+	zn.Use(s.authMiddleware())
+	zn.Get("", s.zones)
+	zn.Post("", s.createZone)
+	zn.Delete("/:id", s.deleteZone)
+	zn.Get("/:id/events", s.zoneEvents)
+}
+
+func (s *Server) zones(ctx *fiber.Ctx) error {
+	res, err := s.userService.Zones(ctx.Context())
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) createZone(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	var req *requests.Zone
+	if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	res, err := s.userService.CreateZone(ctx.Context(), userID, req.Zone())
+	if err != nil {
+		return ctx.Status(zoneErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	s.invalidateReports()
+	return ctx.Status(http.StatusCreated).JSON(res)
+}
+
+func (s *Server) deleteZone(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	if err := s.userService.DeleteZone(ctx.Context(), userID, ctx.Params("id")); err != nil {
+		return ctx.Status(zoneErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	s.invalidateReports()
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+func (s *Server) zoneEvents(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	from, to, err := timeRange(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	res, err := s.userService.ZoneEvents(ctx.Context(), userID, ctx.Params("id"), from, to)
+	if err != nil {
+		return ctx.Status(zoneErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (s *Server) userZones(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid user ID",
+		})
+	}
+	res, err := s.userService.UserZones(ctx.Context(), userID)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	zones := res[userID]
+	if zones == nil {
+		zones = []*entities.Zone{}
+	}
+	return ctx.Status(http.StatusOK).JSON(zones)
+}
+
+func zoneErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, users.ErrNotAdmin):
+		return http.StatusForbidden
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
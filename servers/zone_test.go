@@ -0,0 +1,82 @@
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"zssn/domains/entities"
+	"zssn/domains/geo"
+	"zssn/domains/reports"
+	"zssn/requests"
+
+	"github.com/brianvoe/gofakeit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockedZones(t *testing.T) {
+	s := newMockSetup(t,
+		WithReportService(reports.New(&reports.MockReportRepository{
+			ZonesFunc: func(ctx context.Context) ([]*entities.ZoneReport, error) {
+				return []*entities.ZoneReport{{ID: "camp", Total: 1}}, nil
+			},
+		})),
+	)
+	svr, adminUser := s.svr, s.admin
+	survivor := createMockUser(t, svr)
+
+	center := geo.Point{Latitude: gofakeit.Float64Range(-60, 60), Longitude: gofakeit.Float64Range(-170, 170)}
+	b, err := json.Marshal(requests.Zone{Name: "camp", Kind: "safe", Center: &center, RadiusKm: 1})
+	require.NoError(t, err)
+	res := handleServerRequest(t, svr, http.MethodPost, "/zones", survivor.Token, b)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodPost, "/zones", adminUser.Token, b)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	var zone entities.Zone
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&zone))
+	assert.Equal(t, "camp", zone.Name)
+
+	invalid, err := json.Marshal(requests.Zone{Name: "far", Kind: "safe", Center: &geo.Point{Latitude: 100}, RadiusKm: 1})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPost, "/zones", adminUser.Token, invalid)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	b, err = json.Marshal(requests.UpdateLocation{Latitude: center.Latitude, Longitude: center.Longitude})
+	require.NoError(t, err)
+	res = handleServerRequest(t, svr, http.MethodPatch, "/users/location", survivor.Token, b)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/me/zones", survivor.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var zones []entities.Zone
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&zones))
+	require.Len(t, zones, 1)
+	assert.Equal(t, zone.ID, zones[0].ID)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/zones/"+zone.ID+"/events", survivor.Token, nil)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodGet, "/zones/"+zone.ID+"/events", adminUser.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var events []entities.ZoneEvent
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&events))
+	require.Len(t, events, 1)
+	assert.Equal(t, "enter", events[0].Kind)
+	assert.Equal(t, survivor.ID, events[0].UserID)
+
+	res = handleServerRequest(t, svr, http.MethodGet, "/reports/zones", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var report []entities.ZoneReport
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&report))
+	require.Len(t, report, 1)
+
+	res = handleServerRequest(t, svr, http.MethodDelete, "/zones/"+zone.ID, adminUser.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodDelete, "/zones/"+zone.ID, adminUser.Token, nil)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+	res = handleServerRequest(t, svr, http.MethodGet, "/users/me/zones", survivor.Token, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	zones = nil
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&zones))
+	assert.Empty(t, zones)
+}